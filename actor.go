@@ -0,0 +1,186 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Actor drives an event driven machine. Each message sent to it is given as
+// the cargo to the state the actor is in, and the state returns the state to
+// be in for the next message, or nil to finish. Messages are processed one at
+// a time from a mailbox, so states never execute concurrently and need no
+// locking of their own. Each message is handled like a run in a single state,
+// so invariants, rate and concurrency limits, retries, timings and observers
+// apply as they do to runs, and MaxTransitions bounds the transitions the
+// actor takes over its life.
+type Actor struct {
+	sm      *StateMachine
+	mailbox chan envelope
+	stopped chan struct{}
+
+	// sendLock is held for reading while sending to the mailbox, and for
+	// writing when closing it
+	sendLock *sync.RWMutex
+	closed   bool // no more messages are accepted
+
+	lock        *sync.Mutex
+	state       State
+	transitions int  // taken so far, bounded by MaxTransitions
+	finished    bool // a state returned no next state
+}
+
+type envelope struct {
+	ctx   context.Context
+	msg   interface{}
+	reply chan actorReply // nil for Tell
+}
+
+type actorReply struct {
+	cargo interface{}
+	err   error
+}
+
+// NewActor starts an actor in the start state, with a mailbox holding up to
+// size messages before senders block
+func NewActor(sm *StateMachine, startState State, size int) (*Actor, error) {
+	if startState == nil {
+		return nil, ErrNoStartState
+	}
+	if !sm.isRegistered(startState) {
+		return nil, fmt.Errorf("%w %v", ErrUnknownStartState, startState)
+	}
+
+	a := &Actor{
+		sm:       sm,
+		mailbox:  make(chan envelope, size),
+		stopped:  make(chan struct{}),
+		sendLock: &sync.RWMutex{},
+		lock:     &sync.Mutex{},
+		state:    startState,
+	}
+	sm.NotifyState(nil, startState)
+	go a.loop()
+	return a, nil
+}
+
+// Tell sends the message without waiting for it to be processed. It blocks
+// while the mailbox is full, until ctx is done. It fails with ErrActorStopped
+// once the actor is stopped or finished.
+func (a *Actor) Tell(ctx context.Context, msg interface{}) error {
+	return a.send(ctx, envelope{ctx: ctx, msg: msg})
+}
+
+// Ask sends the message and waits for it to be processed, returning the cargo
+// the state returned, or the message if it failed, and the error. A state
+// failing doesn't change the actor's state.
+func (a *Actor) Ask(ctx context.Context, msg interface{}) (interface{}, error) {
+	reply := make(chan actorReply, 1)
+	if err := a.send(ctx, envelope{ctx: ctx, msg: msg, reply: reply}); err != nil {
+		return nil, err
+	}
+	select {
+	case r := <-reply:
+		return r.cargo, r.err
+	case <-a.stopped:
+		// the actor may have replied right before stopping
+		select {
+		case r := <-reply:
+			return r.cargo, r.err
+		default:
+			return nil, ErrActorStopped
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (a *Actor) send(ctx context.Context, env envelope) error {
+	a.sendLock.RLock()
+	defer a.sendLock.RUnlock()
+	if a.closed || a.isFinished() {
+		return ErrActorStopped
+	}
+
+	select {
+	case a.mailbox <- env:
+		return nil
+	case <-a.stopped:
+		return ErrActorStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// State returns the name of the state the actor is in
+func (a *Actor) State() string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.sm.StateName(a.state)
+}
+
+func (a *Actor) isFinished() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.finished
+}
+
+// Stop stops accepting messages and waits for those in the mailbox to be
+// processed, or for the actor to finish
+func (a *Actor) Stop() {
+	a.sendLock.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.mailbox)
+	}
+	a.sendLock.Unlock()
+
+	<-a.stopped
+}
+
+// Done is closed once the actor stopped, with Stop or because a state returned
+// no next state. Messages left in the mailbox of a finished actor aren't
+// processed, asking them fails with ErrActorStopped.
+func (a *Actor) Done() <-chan struct{} {
+	return a.stopped
+}
+
+func (a *Actor) loop() {
+	defer close(a.stopped)
+	for env := range a.mailbox {
+		var reply actorReply
+		reply.cargo, reply.err = a.handle(env.ctx, env.msg)
+		if env.reply != nil {
+			env.reply <- reply
+		}
+		if a.isFinished() {
+			return
+		}
+	}
+}
+
+// handle executes the current state with the message, as a run would, and
+// moves the actor to the next state
+func (a *Actor) handle(ctx context.Context, msg interface{}) (interface{}, error) {
+	sm := a.sm
+	state := a.state
+	r := sm.startRun(ctx)
+	defer sm.endRun(r)
+
+	nextState, cargo, err := sm.step(r, nil, state, msg, a.transitions, false)
+	if err != nil {
+		return cargo, err
+	}
+	a.lock.Lock()
+	if nextState == nil {
+		a.finished = true
+	} else {
+		a.state = nextState
+		a.transitions++
+	}
+	a.lock.Unlock()
+	if nextState != nil {
+		sm.NotifyState(state, nextState)
+	}
+	return cargo, nil
+}
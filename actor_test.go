@@ -0,0 +1,184 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTurnstile is locked until a coin is inserted, and locks again once
+// pushed through. coins counts the coins without locking.
+func newTurnstile(coins *int) (*StateMachine, State, State) {
+	m := NewStateMachine()
+	var locked, unlocked State
+	handle := func(msg interface{}) (State, interface{}, error) {
+		switch msg {
+		case "coin":
+			*coins++
+			return unlocked, *coins, nil
+		case "push":
+			return locked, *coins, nil
+		case "break":
+			return nil, *coins, nil
+		}
+		return nil, nil, fmt.Errorf("unknown message %v", msg)
+	}
+	locked = NewFuncState("locked", handle)
+	unlocked = NewFuncState("unlocked", handle)
+	m.AddStates(locked, unlocked)
+	return m, locked, unlocked
+}
+
+func TestActor_AskMovesBetweenStates(t *testing.T) {
+	coins := 0
+	m, locked, _ := newTurnstile(&coins)
+	o := NewObserverImpl()
+	m.RegisterObservers(o)
+
+	a, err := NewActor(m, locked, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer a.Stop()
+	assert.Equal(t, "locked", a.State())
+
+	cargo, err := a.Ask(context.Background(), "coin")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cargo)
+	assert.Equal(t, "unlocked", a.State())
+
+	_, err = a.Ask(context.Background(), "kick")
+	assert.EqualError(t, err, "state unlocked failed: unknown message kick (path: unlocked)")
+	assert.Equal(t, "unlocked", a.State()) // rejected messages don't move it
+
+	_, err = a.Ask(context.Background(), "push")
+	assert.Nil(t, err)
+	assert.Equal(t, "locked", a.State())
+	assert.Equal(t, [][]string{{"", "locked"}, {"locked", "unlocked"}, {"unlocked", "locked"}}, o.states)
+}
+
+func TestActor_ConcurrentTells_ProcessedSerially(t *testing.T) {
+	coins := 0
+	m, locked, _ := newTurnstile(&coins)
+	a, _ := NewActor(m, locked, 10)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, a.Tell(context.Background(), "coin"))
+		}()
+	}
+	wg.Wait()
+	a.Stop()
+
+	assert.Equal(t, 20, coins)
+	assert.True(t, errors.Is(a.Tell(context.Background(), "coin"), ErrActorStopped))
+	<-a.Done()
+}
+
+func TestActor_Finished_RejectsMessages(t *testing.T) {
+	coins := 0
+	m, locked, _ := newTurnstile(&coins)
+	a, _ := NewActor(m, locked, 1)
+	defer a.Stop()
+
+	_, err := a.Ask(context.Background(), "break")
+	assert.Nil(t, err)
+	_, err = a.Ask(context.Background(), "coin")
+	assert.True(t, errors.Is(err, ErrActorStopped))
+}
+
+func TestActor_InvalidTransition_Error(t *testing.T) {
+	coins := 0
+	m, locked, unlocked := newTurnstile(&coins)
+	m.AddTransition(locked, unlocked)
+	m.AddTransition(unlocked, locked)
+	a, _ := NewActor(m, locked, 0)
+	defer a.Stop()
+
+	_, err := a.Ask(context.Background(), "push") // locked -> locked isn't declared
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+	assert.Equal(t, "locked", a.State())
+}
+
+func TestNewActor_UnknownStart_Error(t *testing.T) {
+	_, err := NewActor(NewStateMachine(), &StateImpl{}, 0)
+	assert.True(t, errors.Is(err, ErrUnknownStartState))
+}
+
+func TestActor_InvariantViolated_Error(t *testing.T) {
+	coins := 0
+	m, locked, _ := newTurnstile(&coins)
+	m.AddInvariant(func(cargo interface{}, state string) error {
+		if state == "unlocked" && cargo == "coin" {
+			return errors.New("already paid")
+		}
+		return nil
+	})
+	a, _ := NewActor(m, locked, 0)
+	defer a.Stop()
+
+	_, err := a.Ask(context.Background(), "coin")
+	assert.Nil(t, err)
+	_, err = a.Ask(context.Background(), "coin")
+
+	assert.True(t, errors.Is(err, ErrInvariantViolated))
+	assert.Equal(t, 1, coins)
+	assert.Equal(t, "unlocked", a.State())
+}
+
+func TestActor_RateLimited(t *testing.T) {
+	coins := 0
+	m, locked, _ := newTurnstile(&coins)
+	m.SetStateRateLimit(locked, RateLimit{Rate: 0.001, Burst: 1})
+	a, _ := NewActor(m, locked, 0)
+	defer a.Stop()
+
+	_, err := a.Ask(context.Background(), "coin")
+	assert.Nil(t, err)
+	_, err = a.Ask(context.Background(), "push")
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = a.Ask(ctx, "coin")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, coins)
+}
+
+func TestActor_MaxTransitions(t *testing.T) {
+	coins := 0
+	m, locked, _ := newTurnstile(&coins)
+	m.MaxTransitions = 1
+	a, _ := NewActor(m, locked, 0)
+	defer a.Stop()
+
+	_, err := a.Ask(context.Background(), "coin")
+	assert.Nil(t, err)
+	_, err = a.Ask(context.Background(), "push")
+
+	assert.True(t, errors.Is(err, ErrMaxTransitions))
+	assert.Equal(t, "unlocked", a.State())
+}
+
+func TestActor_Finished_DoneClosed(t *testing.T) {
+	coins := 0
+	m, locked, _ := newTurnstile(&coins)
+	a, _ := NewActor(m, locked, 1)
+
+	assert.Nil(t, a.Tell(context.Background(), "break"))
+	select {
+	case <-a.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done not closed once the actor finished")
+	}
+	assert.True(t, errors.Is(a.Tell(context.Background(), "coin"), ErrActorStopped))
+	a.Stop() // returns at once
+}
@@ -0,0 +1,217 @@
+package gust
+
+import "sort"
+
+// Paths enumerates every simple path, one where no state repeats, from the
+// start state to a state without transitions, following the declared
+// transitions in declaration order. Paths are given as state names. At most
+// limit paths are returned, 0 meaning no limit, and complete is false if the
+// limit cut the enumeration short.
+func (sm *StateMachine) Paths(startState State, limit int) (paths [][]string, complete bool) {
+	paths = make([][]string, 0)
+	onPath := make(map[stateKey]bool)
+	path := make([]string, 0)
+
+	var visit func(state State) bool
+	visit = func(state State) bool {
+		onPath[keyOf(state)] = true
+		path = append(path, sm.StateName(state))
+		defer func() {
+			onPath[keyOf(state)] = false
+			path = path[:len(path)-1]
+		}()
+
+		next := sm.AvailableTransitions(state)
+		if len(next) == 0 {
+			if limit > 0 && len(paths) >= limit {
+				return false
+			}
+			paths = append(paths, append([]string{}, path...))
+			return true
+		}
+		for _, n := range next {
+			if onPath[keyOf(n)] {
+				continue
+			}
+			if !visit(n) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if startState == nil || !sm.hasTransitions() {
+		return paths, true
+	}
+	complete = visit(startState)
+	return paths, complete
+}
+
+// successors returns the states the declared transitions lead to from the state
+func (sm *StateMachine) successors(from State) []State {
+	next := make([]State, 0, len(sm.transitions[keyOf(from)]))
+	for _, t := range sm.transitions[keyOf(from)] {
+		next = append(next, t.To)
+	}
+	return next
+}
+
+// ShortestPath returns the fewest transitions leading from one state to
+// another over the declared transitions, as state names from the from state to
+// the to state. ok is false if the to state can't be reached.
+func (sm *StateMachine) ShortestPath(from, to State) (path []string, ok bool) {
+	return sm.shortestPath(from, func(s State) bool { return sameState(s, to) }, nil)
+}
+
+// shortestPath searches breadth first for a state satisfying found, without
+// going through the avoided state
+func (sm *StateMachine) shortestPath(from State, found func(State) bool, avoid State) ([]string, bool) {
+	if from == nil || (avoid != nil && sameState(from, avoid)) {
+		return nil, false
+	}
+
+	parent := map[stateKey]State{keyOf(from): nil}
+	queue := []State{from}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		if found(state) {
+			path := make([]string, 0)
+			for s := state; s != nil; s = parent[keyOf(s)] {
+				path = append([]string{sm.StateName(s)}, path...)
+			}
+			return path, true
+		}
+		for _, next := range sm.successors(state) {
+			if _, seen := parent[keyOf(next)]; seen || (avoid != nil && sameState(next, avoid)) {
+				continue
+			}
+			parent[keyOf(next)] = state
+			queue = append(queue, next)
+		}
+	}
+	return nil, false
+}
+
+// MustPassThrough tells whether every run from the start state that ends, by
+// reaching a state without declared transitions, passes through the given
+// state. That is whether a mandatory step, a compliance check say, can be
+// bypassed. If it can, bypass is the shortest path around it.
+func (sm *StateMachine) MustPassThrough(startState, state State) (ok bool, bypass []string) {
+	if sameState(startState, state) {
+		return true, nil
+	}
+	terminal := func(s State) bool {
+		return len(sm.transitions[keyOf(s)]) == 0
+	}
+	bypass, found := sm.shortestPath(startState, terminal, state)
+	return !found, bypass
+}
+
+// Dominators returns, for every state reachable from the start state over the
+// declared transitions, the states every path from the start state to it goes
+// through, itself excluded. They're listed in the order they're passed.
+func (sm *StateMachine) Dominators(startState State) map[string][]string {
+	if startState == nil {
+		return map[string][]string{}
+	}
+
+	// reachable states in breadth first order, and their predecessors
+	order := []State{startState}
+	index := map[stateKey]int{keyOf(startState): 0}
+	preds := map[stateKey][]int{}
+	for i := 0; i < len(order); i++ {
+		for _, next := range sm.successors(order[i]) {
+			if _, ok := index[keyOf(next)]; !ok {
+				index[keyOf(next)] = len(order)
+				order = append(order, next)
+			}
+			preds[keyOf(next)] = append(preds[keyOf(next)], i)
+		}
+	}
+
+	// dom[i] holds the dominators of order[i] including itself, computed by
+	// narrowing down from all states until nothing changes
+	n := len(order)
+	dom := make([]map[int]bool, n)
+	dom[0] = map[int]bool{0: true}
+	for i := 1; i < n; i++ {
+		dom[i] = make(map[int]bool, n)
+		for j := 0; j < n; j++ {
+			dom[i][j] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := 1; i < n; i++ {
+			next := map[int]bool{i: true}
+			for j := range dom[i] {
+				inAll := true
+				for _, p := range preds[keyOf(order[i])] {
+					if !dom[p][j] {
+						inAll = false
+						break
+					}
+				}
+				if inAll {
+					next[j] = true
+				}
+			}
+			if len(next) != len(dom[i]) {
+				dom[i] = next
+				changed = true
+			}
+		}
+	}
+
+	doms := make(map[string][]string, n)
+	for i, state := range order {
+		ds := make([]int, 0, len(dom[i])-1)
+		for j := range dom[i] {
+			if j != i {
+				ds = append(ds, j)
+			}
+		}
+		// a state's dominators dominate each other in turn, the fewer
+		// dominators one has the earlier it's passed
+		sort.Slice(ds, func(a, b int) bool {
+			return len(dom[ds[a]]) < len(dom[ds[b]])
+		})
+		names := make([]string, len(ds))
+		for k, j := range ds {
+			names[k] = sm.StateName(order[j])
+		}
+		doms[sm.StateName(state)] = names
+	}
+	return doms
+}
+
+// Degree is the number of declared transitions into and out of a state
+type Degree struct {
+	Name string
+	In   int
+	Out  int
+}
+
+// Degrees reports the in and out degree of every registered state, in the
+// order registered. States with In 0 can only be started from, states with
+// Out 0 end runs.
+func (sm *StateMachine) Degrees() []Degree {
+	in := make(map[stateKey]int)
+	for _, ts := range sm.transitions {
+		for _, t := range ts {
+			in[keyOf(t.To)]++
+		}
+	}
+
+	degrees := make([]Degree, 0, len(sm.States))
+	for _, s := range sm.States {
+		degrees = append(degrees, Degree{
+			Name: sm.StateName(s),
+			In:   in[keyOf(s)],
+			Out:  len(sm.transitions[keyOf(s)]),
+		})
+	}
+	return degrees
+}
@@ -0,0 +1,155 @@
+package gust
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaths_Diamond_BothPaths(t *testing.T) {
+	m, a, _, _, _ := newDiamond()
+
+	paths, complete := m.Paths(a, 0)
+	assert.True(t, complete)
+	assert.Equal(t, [][]string{
+		{"stateA", "stateB", "stateD"},
+		{"stateA", "stateC", "stateD"},
+	}, paths)
+}
+
+func TestPaths_Cycle_OnlySimplePaths(t *testing.T) {
+	// A -> B -> C, B -> A, B -> B
+	c := &StateImpl{name: "stateC"}
+	b := &StateImpl{name: "stateB"}
+	a := &StateImpl{name: "stateA"}
+
+	m := NewStateMachine()
+	m.AddStates(a, b, c)
+	m.AddTransition(a, b)
+	m.AddTransition(b, a)
+	m.AddTransition(b, b)
+	m.AddTransition(b, c)
+
+	paths, complete := m.Paths(a, 0)
+	assert.True(t, complete)
+	assert.Equal(t, [][]string{{"stateA", "stateB", "stateC"}}, paths)
+}
+
+func TestPaths_Limit_Incomplete(t *testing.T) {
+	m, a, _, _, _ := newDiamond()
+
+	paths, complete := m.Paths(a, 1)
+	assert.False(t, complete)
+	assert.Equal(t, [][]string{{"stateA", "stateB", "stateD"}}, paths)
+}
+
+func TestPaths_NoTransitionsDeclared_Empty(t *testing.T) {
+	a := &StateImpl{}
+
+	m := NewStateMachine()
+	m.AddState(a)
+
+	paths, complete := m.Paths(a, 0)
+	assert.True(t, complete)
+	assert.Len(t, paths, 0)
+}
+
+// newCompliance returns A -> check -> B -> D, with A -> C -> D bypassing check
+// until the bypass is left out
+func newCompliance(bypass bool) (*StateMachine, State, State) {
+	d := &StateImpl{name: "stateD"}
+	c := &StateImpl{name: "stateC"}
+	b := &StateImpl{name: "stateB"}
+	check := &StateImpl{name: "check"}
+	a := &StateImpl{name: "stateA"}
+
+	m := NewStateMachine()
+	m.AddStates(a, check, b, c, d)
+	m.AddTransition(a, check)
+	m.AddTransition(check, b)
+	m.AddTransition(b, d)
+	m.AddTransition(check, c)
+	if bypass {
+		m.AddTransition(a, c)
+	}
+	m.AddTransition(c, d)
+	return m, a, check
+}
+
+func TestShortestPath_Diamond(t *testing.T) {
+	m, a, _, _, d := newDiamond()
+
+	path, ok := m.ShortestPath(a, d)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"stateA", "stateB", "stateD"}, path)
+
+	path, ok = m.ShortestPath(a, a)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"stateA"}, path)
+}
+
+func TestShortestPath_Unreachable_NotOk(t *testing.T) {
+	m, a, _, _, d := newDiamond()
+
+	_, ok := m.ShortestPath(d, a)
+	assert.False(t, ok)
+}
+
+func TestMustPassThrough_Bypassed_ReturnsBypass(t *testing.T) {
+	m, a, check := newCompliance(true)
+
+	ok, bypass := m.MustPassThrough(a, check)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"stateA", "stateC", "stateD"}, bypass)
+}
+
+func TestMustPassThrough_Mandatory_Ok(t *testing.T) {
+	m, a, check := newCompliance(false)
+
+	ok, bypass := m.MustPassThrough(a, check)
+	assert.True(t, ok)
+	assert.Nil(t, bypass)
+}
+
+func TestDominators_Compliance(t *testing.T) {
+	m, a, _ := newCompliance(false)
+
+	assert.Equal(t, map[string][]string{
+		"stateA": {},
+		"check":  {"stateA"},
+		"stateB": {"stateA", "check"},
+		"stateC": {"stateA", "check"},
+		"stateD": {"stateA", "check"},
+	}, m.Dominators(a))
+
+	m, a, _ = newCompliance(true)
+	assert.Equal(t, []string{"stateA"}, m.Dominators(a)["stateD"])
+}
+
+func TestDominators_Cycle(t *testing.T) {
+	// A -> B -> C -> B, C -> D
+	d := &StateImpl{name: "stateD"}
+	c := &StateImpl{name: "stateC"}
+	b := &StateImpl{name: "stateB"}
+	a := &StateImpl{name: "stateA"}
+
+	m := NewStateMachine()
+	m.AddStates(a, b, c, d)
+	m.AddTransition(a, b)
+	m.AddTransition(b, c)
+	m.AddTransition(c, b)
+	m.AddTransition(c, d)
+
+	assert.Equal(t, []string{"stateA", "stateB", "stateC"}, m.Dominators(a)["stateD"])
+}
+
+func TestDegrees_Diamond(t *testing.T) {
+	m, _, _, _, _ := newDiamond()
+
+	assert.Equal(t, []Degree{
+		{Name: "stateA", In: 0, Out: 2},
+		{Name: "stateB", In: 1, Out: 1},
+		{Name: "stateC", In: 1, Out: 1},
+		{Name: "stateD", In: 2, Out: 0},
+	}, m.Degrees())
+}
@@ -0,0 +1,174 @@
+package gust
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Handler serves a REST API controlling the manager's instances, e.g.
+//
+//	http.Handle("/workflows/", http.StripPrefix("/workflows", mgr.Handler()))
+//
+// Cargo and event values are JSON, given to the states as generic JSON values
+// (map[string]interface{}, float64 and so on):
+//
+//	POST   /runs               start an instance: {"machine": "order", "cargo": {...}}
+//	GET    /runs               list the instances
+//	GET    /runs/{id}          status and history of an instance
+//	POST   /runs/{id}/events   signal an instance: {"name": "approve", "value": ...}
+//	DELETE /runs/{id}          cancel an instance
+//
+// The cargo of finished instances is served redacted, see SetRedactor. Starting
+// an instance once the manager is shutting down answers 503 Service
+// Unavailable.
+func (m *Manager) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+		if parts[0] != "runs" || len(parts) > 3 || (len(parts) == 3 && parts[2] != "events") {
+			http.NotFound(w, req)
+			return
+		}
+
+		switch {
+		case len(parts) == 1 && req.Method == http.MethodGet:
+			runs := make([]apiRun, 0)
+			for _, inst := range m.List() {
+				runs = append(runs, newAPIRun(inst))
+			}
+			writeJSON(w, http.StatusOK, runs)
+		case len(parts) == 1 && req.Method == http.MethodPost:
+			m.apiStart(w, req)
+		case len(parts) == 2 && req.Method == http.MethodGet:
+			inst, ok := m.Get(parts[1])
+			if !ok {
+				writeAPIError(w, ErrUnknownInstance)
+				return
+			}
+			writeJSON(w, http.StatusOK, newAPIRun(inst))
+		case len(parts) == 2 && req.Method == http.MethodDelete:
+			if err := m.Cancel(parts[1]); err != nil {
+				writeAPIError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		case len(parts) == 3 && req.Method == http.MethodPost:
+			m.apiSignal(w, req, parts[1])
+		default:
+			w.Header().Set("Allow", apiMethods[len(parts)])
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// apiMethods are the methods allowed on the resources, by number of path parts
+var apiMethods = map[int]string{
+	1: "GET, POST",
+	2: "GET, DELETE",
+	3: "POST",
+}
+
+func (m *Manager) apiStart(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Machine string          `json:"machine"`
+		Cargo   json.RawMessage `json:"cargo"`
+	}
+	cargo, err := decodeAPIBody(req, &body, &body.Cargo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the instance outlives the request
+	inst, err := m.Start(context.Background(), body.Machine, cargo)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newAPIRun(inst))
+}
+
+func (m *Manager) apiSignal(w http.ResponseWriter, req *http.Request, id string) {
+	var body struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	value, err := decodeAPIBody(req, &body, &body.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := m.Signal(id, body.Name, value); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// decodeAPIBody decodes the request body into body and returns the generic
+// JSON value of its raw field
+func decodeAPIBody(req *http.Request, body interface{}, raw *json.RawMessage) (interface{}, error) {
+	if err := json.NewDecoder(req.Body).Decode(body); err != nil {
+		return nil, err
+	}
+	if len(*raw) == 0 {
+		return nil, nil
+	}
+	return decodeJSON(*raw)
+}
+
+type apiRun struct {
+	ID      string      `json:"id"`
+	Machine string      `json:"machine"`
+	Status  string      `json:"status"`
+	State   string      `json:"state,omitempty"` // the state executing
+	Started time.Time   `json:"started"`
+	History []Step      `json:"history"`
+	Cargo   interface{} `json:"cargo,omitempty"` // the last cargo, once finished
+	Error   string      `json:"error,omitempty"`
+}
+
+func newAPIRun(inst *Instance) apiRun {
+	run := apiRun{
+		ID:      inst.ID,
+		Machine: inst.Machine,
+		Status:  inst.Status().String(),
+		Started: inst.Started,
+		History: inst.History(),
+	}
+	sm := inst.machine()
+	if info, ok := inst.CurrentState(); ok {
+		run.State = sm.StateName(info.State)
+	}
+	if result, ok := inst.Result(); ok {
+		run.State = ""
+		run.Cargo = sm.Redact(result.Cargo)
+		if result.Err != nil {
+			run.Error = result.Err.Error()
+		}
+	}
+	return run
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeAPIError responds with the status matching a Manager error
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrUnknownMachine), errors.Is(err, ErrUnknownInstance):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNotRunning):
+		status = http.StatusConflict
+	case errors.Is(err, ErrShutdown):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, err.Error(), status)
+}
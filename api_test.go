@@ -0,0 +1,162 @@
+package gust
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerHandler_StartSignalGet(t *testing.T) {
+	sm, start := newApprovalMachine()
+	mgr := NewManager()
+	mgr.Register("approval", sm, start)
+	srv := httptest.NewServer(http.StripPrefix("/workflows", mgr.Handler()))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/workflows/runs", "application/json", strings.NewReader(`{"machine":"approval","cargo":{"id":"o1"}}`))
+	if !assert.Nil(t, err) {
+		return
+	}
+	var run apiRun
+	json.NewDecoder(resp.Body).Decode(&run)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "approval-1", run.ID)
+	assert.Equal(t, "running", run.Status)
+
+	resp, err = http.Post(srv.URL+"/workflows/runs/approval-1/events", "application/json", strings.NewReader(`{"name":"approve","value":"alice"}`))
+	if !assert.Nil(t, err) {
+		return
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	inst, _ := mgr.Get("approval-1")
+	<-inst.Done()
+	resp, err = http.Get(srv.URL + "/workflows/runs/approval-1")
+	if !assert.Nil(t, err) {
+		return
+	}
+	json.NewDecoder(resp.Body).Decode(&run)
+	resp.Body.Close()
+	assert.Equal(t, "succeeded", run.Status)
+	assert.Equal(t, "alice", run.Cargo)
+	assert.Len(t, run.History, 2)
+}
+
+func TestManagerHandler_Cancel(t *testing.T) {
+	sm, start := newApprovalMachine()
+	mgr := NewManager()
+	mgr.Register("approval", sm, start)
+	inst, _ := mgr.Start(context.Background(), "approval", nil)
+	h := mgr.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/runs/"+inst.ID, nil))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	<-inst.Done()
+	assert.Equal(t, InstanceCancelled, inst.Status())
+
+	// cancelling again conflicts
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/runs/"+inst.ID, nil))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestManagerHandler_Errors(t *testing.T) {
+	h := NewManager().Handler()
+
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, "/runs", `{"machine":"order"}`, http.StatusNotFound},
+		{http.MethodPost, "/runs", `{`, http.StatusBadRequest},
+		{http.MethodGet, "/runs/order-1", "", http.StatusNotFound},
+		{http.MethodPut, "/runs", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/machines", "", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, strings.NewReader(c.body)))
+		assert.Equal(t, c.status, rec.Code, c.method+" "+c.path)
+	}
+}
+
+func TestManagerHandler_MethodNotAllowed_Allow(t *testing.T) {
+	h := NewManager().Handler()
+
+	cases := []struct {
+		method, path, allow string
+	}{
+		{http.MethodPut, "/runs", "GET, POST"},
+		{http.MethodPost, "/runs/order-1", "GET, DELETE"},
+		{http.MethodGet, "/runs/order-1/events", "POST"},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code, c.method+" "+c.path)
+		assert.Equal(t, c.allow, rec.Header().Get("Allow"), c.method+" "+c.path)
+	}
+}
+
+func TestManagerHandler_Shutdown_Unavailable(t *testing.T) {
+	sm, start := newApprovalMachine()
+	mgr := NewManager()
+	mgr.Register("approval", sm, start)
+	assert.Nil(t, mgr.Shutdown(context.Background()))
+
+	rec := httptest.NewRecorder()
+	mgr.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/runs", strings.NewReader(`{"machine":"approval"}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+// unnamedSignalState waits for the signal and has no name
+type unnamedSignalState struct{}
+
+func (s *unnamedSignalState) Exec(cargo interface{}) (State, interface{}, error) {
+	panic("ExecContext should be called instead")
+}
+
+func (s *unnamedSignalState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	if _, err := ReceiveSignal(ctx, "approve"); err != nil {
+		return nil, cargo, err
+	}
+	return nil, cargo, nil
+}
+
+func TestManagerHandler_UnnamedStateRedactedCargo(t *testing.T) {
+	sm := NewStateMachine()
+	waiting := &unnamedSignalState{}
+	sm.AddState(waiting)
+	sm.SetRedactor(func(cargo interface{}) interface{} {
+		return "redacted"
+	})
+	mgr := NewManager()
+	mgr.Register("approval", sm, waiting)
+	inst, _ := mgr.Start(context.Background(), "approval", "secret")
+	h := mgr.Handler()
+
+	var run apiRun
+	assert.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/runs/"+inst.ID, nil))
+		json.NewDecoder(rec.Body).Decode(&run)
+		return run.State != ""
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, sm.StateName(waiting), run.State)
+
+	assert.Nil(t, mgr.Signal(inst.ID, "approve", nil))
+	<-inst.Done()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/runs/"+inst.ID, nil))
+	json.NewDecoder(rec.Body).Decode(&run)
+	assert.Equal(t, "redacted", run.Cargo)
+}
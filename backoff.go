@@ -0,0 +1,87 @@
+package gust
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff tells how long to wait before a retry. attempt is 1 for the first
+// retry, 2 for the second and so on.
+type Backoff interface {
+	Delay(attempt int) time.Duration
+}
+
+// BackoffFunc adapts an ordinary function to a Backoff
+type BackoffFunc func(attempt int) time.Duration
+
+// Delay calls f(attempt)
+func (f BackoffFunc) Delay(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// ConstantBackoff waits the same duration before every retry
+type ConstantBackoff time.Duration
+
+// Delay returns the constant duration
+func (b ConstantBackoff) Delay(attempt int) time.Duration {
+	return time.Duration(b)
+}
+
+// ExponentialBackoff waits Initial before the first retry, multiplying the
+// delay by Multiplier (2 if not set) for every retry after, up to Max if set
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// Delay returns Initial * Multiplier^(attempt-1), capped at Max
+func (b ExponentialBackoff) Delay(attempt int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		return b.Max
+	}
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
+
+// JitterBackoff randomizes the delay of another backoff by up to Fraction in
+// both directions, so a delay d becomes something in [d*(1-Fraction), d*(1+Fraction)].
+// This spreads out retries of many runs failing at the same time.
+type JitterBackoff struct {
+	Backoff  Backoff
+	Fraction float64
+	Rand     *rand.Rand // source of randomness, math/rand's default one if nil, see NewRand
+}
+
+// Delay returns the jittered delay of the wrapped backoff
+func (b JitterBackoff) Delay(attempt int) time.Duration {
+	d := float64(b.Backoff.Delay(attempt))
+	f := math.Max(0, math.Min(b.Fraction, 1))
+	var x float64
+	if b.Rand != nil {
+		x = b.Rand.Float64()
+	} else {
+		x = rand.Float64()
+	}
+	return time.Duration(d * (1 - f + 2*f*x))
+}
+
+// ExponentialJitterBackoff is an exponential backoff with 50% jitter
+func ExponentialJitterBackoff(initial, max time.Duration) Backoff {
+	return JitterBackoff{
+		Backoff:  ExponentialBackoff{Initial: initial, Max: max},
+		Fraction: 0.5,
+	}
+}
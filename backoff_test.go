@@ -0,0 +1,109 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantBackoff_SameDelay(t *testing.T) {
+	b := ConstantBackoff(time.Second)
+
+	assert.Equal(t, time.Second, b.Delay(1))
+	assert.Equal(t, time.Second, b.Delay(10))
+}
+
+func TestExponentialBackoff_DoublesUpToMax(t *testing.T) {
+	b := ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, b.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, b.Delay(2))
+	assert.Equal(t, 400*time.Millisecond, b.Delay(3))
+	assert.Equal(t, 800*time.Millisecond, b.Delay(4))
+	assert.Equal(t, time.Second, b.Delay(5))
+	assert.Equal(t, time.Second, b.Delay(500))
+}
+
+func TestExponentialBackoff_CustomMultiplier(t *testing.T) {
+	b := ExponentialBackoff{Initial: time.Second, Multiplier: 3}
+
+	assert.Equal(t, time.Second, b.Delay(1))
+	assert.Equal(t, 3*time.Second, b.Delay(2))
+	assert.Equal(t, 9*time.Second, b.Delay(3))
+}
+
+func TestJitterBackoff_WithinFraction(t *testing.T) {
+	b := JitterBackoff{Backoff: ConstantBackoff(time.Second), Fraction: 0.5}
+
+	for i := 0; i < 100; i++ {
+		d := b.Delay(1)
+		assert.True(t, d >= 500*time.Millisecond && d <= 1500*time.Millisecond, "delay %v out of range", d)
+	}
+}
+
+func TestJitterBackoff_Rand_Reproducible(t *testing.T) {
+	a := JitterBackoff{Backoff: ConstantBackoff(time.Second), Fraction: 0.5, Rand: NewRand(42)}
+	b := JitterBackoff{Backoff: ConstantBackoff(time.Second), Fraction: 0.5, Rand: NewRand(42)}
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, a.Delay(1), b.Delay(1))
+	}
+}
+
+func TestExponentialJitterBackoff_WithinRange(t *testing.T) {
+	b := ExponentialJitterBackoff(100*time.Millisecond, time.Second)
+
+	for i := 0; i < 100; i++ {
+		d := b.Delay(3) // 400ms +- 50%
+		assert.True(t, d >= 200*time.Millisecond && d <= 600*time.Millisecond, "delay %v out of range", d)
+	}
+}
+
+func TestBackoffFunc_Custom(t *testing.T) {
+	b := BackoffFunc(func(attempt int) time.Duration {
+		return time.Duration(attempt) * time.Minute
+	})
+
+	assert.Equal(t, 3*time.Minute, b.Delay(3))
+}
+
+func TestRetry_WithBackoff_WaitsBetweenAttempts(t *testing.T) {
+	a := &FlakyState{
+		errs: []error{Retryable(errors.New("e1")), Retryable(errors.New("e2"))},
+	}
+
+	delays := make([]int, 0)
+	m := NewStateMachine()
+	m.AddState(a)
+	m.SetRetryPolicy(a, RetryPolicy{
+		MaxAttempts: 3,
+		Backoff: BackoffFunc(func(attempt int) time.Duration {
+			delays = append(delays, attempt)
+			return time.Millisecond
+		}),
+	})
+
+	assert.Nil(t, m.Run(nil, a))
+	assert.Equal(t, []int{1, 2}, delays)
+}
+
+func TestRetry_AbortedDuringBackoff_ReturnsAbortedError(t *testing.T) {
+	a := &FlakyState{
+		errs: []error{Retryable(errors.New("e1"))},
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.SetRetryPolicy(a, RetryPolicy{MaxAttempts: 2, Backoff: ConstantBackoff(time.Hour)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := m.RunContext(ctx, nil, a)
+	assert.True(t, errors.Is(err, ErrAborted))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 1, a.calls)
+}
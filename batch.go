@@ -0,0 +1,147 @@
+package gust
+
+import (
+	"sync"
+	"time"
+)
+
+// TransitionEvent is a transition taken by a run, as delivered by the batched
+// and channel observers
+type TransitionEvent struct {
+	From  string    `json:"from,omitempty"` // empty for the start state
+	To    string    `json:"to"`
+	Label string    `json:"label,omitempty"` // see AddLabeledTransition
+	At    time.Time `json:"at"`
+}
+
+// BatchObserver collects transitions and hands them to a flush function in
+// batches, once Size of them are pending or every Interval, for observers
+// writing to slow sinks like data warehouses. Flushing happens in its own
+// goroutine so runs never wait for the sink, batches are flushed one at a
+// time and in order. Close flushes what's left.
+type BatchObserver struct {
+	flush    func(events []TransitionEvent) error
+	size     int
+	interval time.Duration
+
+	// OnError if not nil is called with the errors of flush, the batch is dropped
+	OnError func(err error)
+	// Now timestamps the transitions, time.Now if nil
+	Now func() time.Time
+
+	lock    *sync.Mutex
+	pending []TransitionEvent
+	closed  bool
+	full    chan struct{} // signals the flusher that size is reached
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewBatchObserver is a constructor for BatchObserver. size is the number of
+// transitions a batch holds at most, and interval if larger than 0 how often
+// pending transitions are flushed even if fewer.
+func NewBatchObserver(size int, interval time.Duration, flush func(events []TransitionEvent) error) *BatchObserver {
+	if size < 1 {
+		size = 1
+	}
+	o := &BatchObserver{
+		flush:    flush,
+		size:     size,
+		interval: interval,
+		lock:     &sync.Mutex{},
+		pending:  make([]TransitionEvent, 0, size),
+		full:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go o.loop()
+	return o
+}
+
+// StateChanged adds the transition to the pending batch
+func (o *BatchObserver) StateChanged(prior, next string) {
+	o.TransitionTaken(prior, next, "")
+}
+
+// TransitionTaken adds the transition with its label to the pending batch
+func (o *BatchObserver) TransitionTaken(prior, next, label string) {
+	now := time.Now
+	if o.Now != nil {
+		now = o.Now
+	}
+	event := TransitionEvent{From: prior, To: next, Label: label, At: now()}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.closed {
+		return
+	}
+	o.pending = append(o.pending, event)
+	if len(o.pending) >= o.size {
+		select {
+		case o.full <- struct{}{}:
+		default: // the flusher already knows
+		}
+	}
+}
+
+// Close stops the observer once the pending transitions are flushed,
+// transitions observed afterwards are dropped
+func (o *BatchObserver) Close() {
+	o.lock.Lock()
+	if o.closed {
+		o.lock.Unlock()
+		<-o.done
+		return
+	}
+	o.closed = true
+	o.lock.Unlock()
+
+	close(o.stop)
+	<-o.done
+}
+
+func (o *BatchObserver) loop() {
+	defer close(o.done)
+
+	var tick <-chan time.Time
+	if o.interval > 0 {
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-o.full:
+			o.flushPending(false)
+		case <-tick:
+			o.flushPending(true)
+		case <-o.stop:
+			o.flushPending(true)
+			return
+		}
+	}
+}
+
+// flushPending flushes full batches, and the partial one left if all
+func (o *BatchObserver) flushPending(all bool) {
+	for {
+		o.lock.Lock()
+		n := len(o.pending)
+		if n > o.size {
+			n = o.size
+		}
+		if n == 0 || (n < o.size && !all) {
+			o.lock.Unlock()
+			return
+		}
+		batch := make([]TransitionEvent, n)
+		copy(batch, o.pending)
+		o.pending = append(o.pending[:0], o.pending[n:]...)
+		o.lock.Unlock()
+
+		if err := o.flush(batch); err != nil && o.OnError != nil {
+			o.OnError(err)
+		}
+	}
+}
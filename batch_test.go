@@ -0,0 +1,81 @@
+package gust
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// batches records the batches flushed
+type batches struct {
+	lock    sync.Mutex
+	flushed [][]TransitionEvent
+}
+
+func (b *batches) flush(events []TransitionEvent) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.flushed = append(b.flushed, events)
+	return nil
+}
+
+func (b *batches) sizes() []int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	sizes := make([]int, 0)
+	for _, batch := range b.flushed {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func TestBatchObserver_FlushBySizeThenClose(t *testing.T) {
+	b := &batches{}
+	o := NewBatchObserver(2, 0, b.flush)
+	m := NewStateMachine()
+	c := &StateImpl{name: "c"}
+	bb := &StateImpl{name: "b", nextState: c}
+	a := &StateImpl{name: "a", nextState: bb}
+	m.AddStates(a, bb, c)
+	m.AddLabeledTransition(a, bb, "go")
+	m.AddTransition(bb, c)
+	m.RegisterObservers(o)
+
+	assert.Nil(t, m.Run(nil, a))
+	o.Close()
+
+	assert.Equal(t, []int{2, 1}, b.sizes())
+	assert.Equal(t, "go", b.flushed[0][1].Label)
+	assert.Equal(t, "c", b.flushed[1][0].To)
+}
+
+func TestBatchObserver_FlushByInterval(t *testing.T) {
+	b := &batches{}
+	o := NewBatchObserver(100, 5*time.Millisecond, b.flush)
+	defer o.Close()
+
+	o.StateChanged("", "a")
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if len(b.sizes()) > 0 {
+			break
+		}
+	}
+	assert.Equal(t, []int{1}, b.sizes())
+}
+
+func TestBatchObserver_FlushFails_OnError(t *testing.T) {
+	var got error
+	o := NewBatchObserver(1, 0, func(events []TransitionEvent) error {
+		return errors.New("warehouse down")
+	})
+	o.OnError = func(err error) { got = err }
+
+	o.StateChanged("", "a")
+	o.Close()
+	o.StateChanged("a", "b") // dropped once closed
+
+	assert.EqualError(t, got, "warehouse down")
+}
@@ -0,0 +1,118 @@
+package gust
+
+import (
+	"sync"
+	"time"
+)
+
+// OverflowPolicy is what a ChannelObserver does with a transition when its
+// channel's buffer is full
+type OverflowPolicy int
+
+const (
+	// Block waits for the consumer, holding up the run
+	Block OverflowPolicy = iota
+	// DropNewest drops the transition
+	DropNewest
+	// DropOldest drops the oldest buffered transition to make room
+	DropOldest
+)
+
+// ChannelObserver exposes the transitions taken on a channel, so consumers
+// can range over them instead of implementing Observer:
+//
+//	o := gust.NewChannelObserver(64, gust.DropOldest)
+//	sm.RegisterObservers(o)
+//	for e := range o.Events() {
+//		...
+//	}
+type ChannelObserver struct {
+	events  chan TransitionEvent
+	policy  OverflowPolicy
+	closing chan struct{}
+	once    *sync.Once
+
+	lock    *sync.Mutex // serializes sends and closing
+	closed  bool
+	dropped int
+}
+
+// NewChannelObserver is a constructor for ChannelObserver, buffer is the
+// capacity of the channel
+func NewChannelObserver(buffer int, policy OverflowPolicy) *ChannelObserver {
+	return &ChannelObserver{
+		events:  make(chan TransitionEvent, buffer),
+		policy:  policy,
+		closing: make(chan struct{}),
+		once:    &sync.Once{},
+		lock:    &sync.Mutex{},
+	}
+}
+
+// Events returns the channel of transitions, it's closed by Close
+func (o *ChannelObserver) Events() <-chan TransitionEvent {
+	return o.events
+}
+
+// StateChanged sends the transition
+func (o *ChannelObserver) StateChanged(prior, next string) {
+	o.TransitionTaken(prior, next, "")
+}
+
+// TransitionTaken sends the transition with its label
+func (o *ChannelObserver) TransitionTaken(prior, next, label string) {
+	event := TransitionEvent{From: prior, To: next, Label: label, At: time.Now()}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.closed {
+		return
+	}
+
+	switch o.policy {
+	case Block:
+		select {
+		case o.events <- event:
+		case <-o.closing:
+		}
+	case DropNewest:
+		select {
+		case o.events <- event:
+		default:
+			o.dropped++
+		}
+	case DropOldest:
+		for {
+			select {
+			case o.events <- event:
+				return
+			default:
+			}
+			select {
+			case <-o.events:
+				o.dropped++
+			default: // the consumer made room meanwhile
+			}
+		}
+	}
+}
+
+// Dropped returns the number of transitions dropped because the buffer was full
+func (o *ChannelObserver) Dropped() int {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.dropped
+}
+
+// Close closes the channel, transitions observed afterwards are dropped.
+// Remove the observer from the machine first.
+func (o *ChannelObserver) Close() {
+	o.once.Do(func() {
+		close(o.closing) // unblocks a blocked send
+
+		o.lock.Lock()
+		defer o.lock.Unlock()
+		o.closed = true
+		close(o.events)
+	})
+}
@@ -0,0 +1,59 @@
+package gust
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelObserver_RangeOverEvents(t *testing.T) {
+	o := NewChannelObserver(10, Block)
+	m := NewStateMachine()
+	b := &StateImpl{name: "b"}
+	a := &StateImpl{name: "a", nextState: b}
+	m.AddStates(a, b)
+	m.RegisterObservers(o)
+
+	assert.Nil(t, m.Run(nil, a))
+	o.Close()
+
+	path := make([]string, 0)
+	for e := range o.Events() {
+		path = append(path, e.From+">"+e.To)
+	}
+	assert.Equal(t, []string{">a", "a>b"}, path)
+}
+
+func TestChannelObserver_DropNewest(t *testing.T) {
+	o := NewChannelObserver(1, DropNewest)
+	o.StateChanged("", "a")
+	o.StateChanged("a", "b")
+	o.Close()
+
+	assert.Equal(t, 1, o.Dropped())
+	assert.Equal(t, "a", (<-o.Events()).To)
+}
+
+func TestChannelObserver_DropOldest(t *testing.T) {
+	o := NewChannelObserver(1, DropOldest)
+	o.StateChanged("", "a")
+	o.StateChanged("a", "b")
+	o.Close()
+
+	assert.Equal(t, 1, o.Dropped())
+	assert.Equal(t, "b", (<-o.Events()).To)
+}
+
+func TestChannelObserver_Block_CloseUnblocks(t *testing.T) {
+	o := NewChannelObserver(0, Block)
+	sent := make(chan struct{})
+	go func() {
+		o.StateChanged("", "a") // nobody receives
+		close(sent)
+	}()
+
+	o.Close()
+	<-sent
+	_, ok := <-o.Events()
+	assert.False(t, ok)
+}
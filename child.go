@@ -0,0 +1,131 @@
+package gust
+
+import (
+	"context"
+)
+
+// Child is a run spawned by a state of another run, its parent, see Spawn
+type Child struct {
+	machine *StateMachine
+	start   State
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	result *Result
+	err    error
+}
+
+// Spawn starts a child run of the machine, which may be another one than the
+// parent's, from the start state with the cargo, without waiting for it. ctx
+// must be the one given to ExecContext. The child is tracked by the parent
+// run, so any of its later states can await, cancel or collect it with
+// Children, AwaitChildren and CancelChildren. Children share the parent's
+// correlation ID, see WithCorrelationID, and are cancelled when the parent
+// ends, so parents should await the children they need.
+func Spawn(ctx context.Context, sm *StateMachine, cargo interface{}, start State) (*Child, error) {
+	parent, ok := ctx.Value(runKey{}).(*run)
+	if !ok {
+		return nil, ErrNoRun
+	}
+
+	// the parent run's context, the state's could end with the state
+	childCtx, cancel := context.WithCancel(parent.ctx)
+	c := &Child{machine: sm, start: start, cancel: cancel, done: make(chan struct{})}
+	parent.sm.runsLock.Lock()
+	parent.children = append(parent.children, c)
+	parent.sm.runsLock.Unlock()
+
+	go func() {
+		defer cancel()
+		c.result, c.err = sm.Execute(childCtx, cargo, start)
+		close(c.done)
+	}()
+	return c, nil
+}
+
+// Machine returns the machine the child runs on
+func (c *Child) Machine() *StateMachine {
+	return c.machine
+}
+
+// Done is closed once the child run finished
+func (c *Child) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait waits for the child run to finish and returns its result, or until ctx
+// is done
+func (c *Child) Wait(ctx context.Context) (*Result, error) {
+	select {
+	case <-c.done:
+		return c.result, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Result returns the result of the child run, false if it's still running
+func (c *Child) Result() (*Result, bool) {
+	select {
+	case <-c.done:
+		return c.result, true
+	default:
+		return nil, false
+	}
+}
+
+// Cancel aborts the child run, it fails with an *AbortedError
+func (c *Child) Cancel() {
+	c.cancel()
+}
+
+// Children returns the children spawned by the run so far in the order
+// spawned, ctx must be the one given to ExecContext
+func Children(ctx context.Context) []*Child {
+	r, ok := ctx.Value(runKey{}).(*run)
+	if !ok {
+		return nil
+	}
+	r.sm.runsLock.RLock()
+	defer r.sm.runsLock.RUnlock()
+	return append([]*Child{}, r.children...)
+}
+
+// ChildrenError is returned by AwaitChildren when children failed, holding the
+// error of each failed child in the order they were spawned. errors.Is and
+// errors.As match a child's error.
+type ChildrenError struct {
+	joinedError
+}
+
+// AwaitChildren waits for all the children spawned by the run so far and
+// gathers their results, in the order spawned, each with its Err. It fails
+// with a *ChildrenError if any child failed, or with ctx's error if ctx is
+// done first.
+func AwaitChildren(ctx context.Context) ([]*Result, error) {
+	children := Children(ctx)
+	results := make([]*Result, len(children))
+	var errs []error
+	for i, c := range children {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		results[i] = c.result
+		if c.err != nil {
+			errs = append(errs, c.err)
+		}
+	}
+	if len(errs) > 0 {
+		return results, &ChildrenError{joinedError{errs}}
+	}
+	return results, nil
+}
+
+// CancelChildren cancels all the children spawned by the run so far
+func CancelChildren(ctx context.Context) {
+	for _, c := range Children(ctx) {
+		c.Cancel()
+	}
+}
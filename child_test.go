@@ -0,0 +1,114 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// squareMachine squares its int cargo, failing on negative numbers
+func squareMachine() (m *StateMachine, square State) {
+	m = NewStateMachine()
+	square = NewFuncState("square", func(cargo interface{}) (State, interface{}, error) {
+		n := cargo.(int)
+		if n < 0 {
+			return nil, cargo, errors.New("negative")
+		}
+		return nil, n * n, nil
+	})
+	m.AddStates(square)
+	return m, square
+}
+
+func TestSpawn_ScatterGather(t *testing.T) {
+	squares, square := squareMachine()
+	m := NewStateMachine()
+	var gather State
+	scatter := &ctxFuncState{name: "scatter", f: func(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+		for _, n := range cargo.([]int) {
+			if _, err := Spawn(ctx, squares, n, square); err != nil {
+				return nil, cargo, err
+			}
+		}
+		return gather, cargo, nil
+	}}
+	gather = &ctxFuncState{name: "gather", f: func(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+		results, err := AwaitChildren(ctx)
+		if err != nil {
+			return nil, cargo, err
+		}
+		sum := 0
+		for _, r := range results {
+			sum += r.Cargo.(int)
+		}
+		return nil, sum, nil
+	}}
+	m.AddStates(scatter, gather)
+
+	result, err := m.Execute(context.Background(), []int{1, 2, 3}, scatter)
+	assert.Nil(t, err)
+	assert.Equal(t, 14, result.Cargo)
+
+	_, err = m.Execute(context.Background(), []int{1, -2, -3}, scatter)
+	var children *ChildrenError
+	if assert.True(t, errors.As(err, &children)) {
+		assert.Len(t, children.Errors, 2)
+	}
+}
+
+func TestSpawn_CancelChildren(t *testing.T) {
+	m := NewStateMachine()
+	wait := NewChannelWait("wait", make(chan interface{}))
+	m.AddStates(wait)
+	var child *Child
+	parent := &ctxFuncState{name: "parent", f: func(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+		var err error
+		if child, err = Spawn(ctx, m, nil, wait); err != nil {
+			return nil, cargo, err
+		}
+		_, running := child.Result()
+		assert.False(t, running)
+		assert.Len(t, Children(ctx), 1)
+
+		CancelChildren(ctx)
+		<-child.Done()
+		return nil, cargo, nil
+	}}
+	m.AddStates(parent)
+
+	_, err := m.Execute(context.Background(), nil, parent)
+	assert.Nil(t, err)
+	result, done := child.Result()
+	assert.True(t, done)
+	assert.True(t, errors.Is(result.Err, ErrAborted))
+	assert.Equal(t, m, child.Machine())
+}
+
+func TestSpawn_CancelledWithParent(t *testing.T) {
+	m := NewStateMachine()
+	wait := NewChannelWait("wait", make(chan interface{}))
+	var child *Child
+	parent := &ctxFuncState{name: "parent", f: func(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+		var err error
+		child, err = Spawn(ctx, m, nil, wait)
+		return nil, cargo, err
+	}}
+	m.AddStates(parent, wait)
+
+	_, err := m.Execute(context.Background(), nil, parent)
+	assert.Nil(t, err)
+
+	_, err = child.Wait(context.Background())
+	assert.True(t, errors.Is(err, ErrAborted))
+}
+
+func TestSpawn_NotInRun(t *testing.T) {
+	m, square := squareMachine()
+
+	_, err := Spawn(context.Background(), m, 1, square)
+
+	assert.Equal(t, ErrNoRun, err)
+	assert.Nil(t, Children(context.Background()))
+}
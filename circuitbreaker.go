@@ -0,0 +1,167 @@
+package gust
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a CircuitBreaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets executions through to the wrapped state
+	BreakerClosed BreakerState = iota
+	// BreakerOpen short-circuits executions to the fallback state
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial execution through after the cooldown
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker wraps a state calling a flaky dependency. After Threshold
+// consecutive failures the breaker opens, and for Cooldown it doesn't execute
+// the wrapped state but transitions straight to Fallback with the cargo
+// untouched. Once the cooldown passes a single trial execution is let
+// through (half-open), closing the breaker on success and opening it again on
+// failure. Errors of executions that are let through are returned as usual.
+// The cooldown is timed by the Clock of the machine executing the breaker.
+//
+// Register the CircuitBreaker with the machine in place of the wrapped state.
+type CircuitBreaker struct {
+	state     State
+	fallback  State
+	threshold int
+	cooldown  time.Duration
+
+	lock     *sync.Mutex
+	clock    Clock // of the machine last executing the breaker
+	status   BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool // a half-open trial is in flight
+}
+
+// NewCircuitBreaker wraps state with a circuit breaker which opens after
+// threshold consecutive failures and routes to fallback for cooldown
+func NewCircuitBreaker(state State, fallback State, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		state:     state,
+		fallback:  fallback,
+		threshold: threshold,
+		cooldown:  cooldown,
+		lock:      &sync.Mutex{},
+		clock:     realClock{},
+	}
+}
+
+// Exec executes the wrapped state unless the breaker is open
+func (cb *CircuitBreaker) Exec(cargo interface{}) (State, interface{}, error) {
+	return cb.ExecContext(context.Background(), cargo)
+}
+
+// ExecContext executes the wrapped state unless the breaker is open, the
+// context is passed on if the wrapped state is a ContextState
+func (cb *CircuitBreaker) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	if r, ok := ctx.Value(runKey{}).(*run); ok {
+		cb.lock.Lock()
+		cb.clock = r.sm.clock
+		cb.lock.Unlock()
+	}
+	if !cb.allow() {
+		return cb.fallback, cargo, nil
+	}
+
+	var nextState State
+	var nextCargo interface{}
+	var err error
+	if cs, ok := cb.state.(ContextState); ok {
+		nextState, nextCargo, err = cs.ExecContext(ctx, cargo)
+	} else {
+		nextState, nextCargo, err = cb.state.Exec(cargo)
+	}
+
+	cb.record(err)
+	return nextState, nextCargo, err
+}
+
+// Name is the name of the wrapped state
+func (cb *CircuitBreaker) Name() string {
+	return stateName(cb.state)
+}
+
+// State returns the current state of the breaker
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if cb.status == BreakerOpen && cb.clock.Now().Sub(cb.openedAt) >= cb.cooldown {
+		return BreakerHalfOpen
+	}
+	return cb.status
+}
+
+// allow tells whether an execution may go through to the wrapped state
+func (cb *CircuitBreaker) allow() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	switch cb.status {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if cb.clock.Now().Sub(cb.openedAt) < cb.cooldown {
+			return false
+		}
+		cb.status = BreakerHalfOpen
+		cb.trial = true
+		return true
+	default: // half-open, only one trial at a time
+		if cb.trial {
+			return false
+		}
+		cb.trial = true
+		return true
+	}
+}
+
+func (cb *CircuitBreaker) record(err error) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if cb.status == BreakerHalfOpen {
+		cb.trial = false
+		if err != nil {
+			cb.status = BreakerOpen
+			cb.openedAt = cb.clock.Now()
+		} else {
+			cb.status = BreakerClosed
+			cb.failures = 0
+		}
+		return
+	}
+
+	if err == nil {
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		cb.status = BreakerOpen
+		cb.openedAt = cb.clock.Now()
+	}
+}
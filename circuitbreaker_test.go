@@ -0,0 +1,100 @@
+package gust
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker_FailuresUnderThreshold_StaysClosed(t *testing.T) {
+	fallback := &StateImpl{}
+	a := &FlakyState{
+		errs: []error{errors.New("e1")},
+	}
+	cb := NewCircuitBreaker(a, fallback, 2, time.Hour)
+
+	m := NewStateMachine()
+	m.AddState(cb)
+	m.AddState(fallback)
+
+	assert.Error(t, m.Run(nil, cb))
+	assert.Equal(t, BreakerClosed, cb.State())
+
+	assert.Nil(t, m.Run(nil, cb)) // success resets the failure count
+	assert.Equal(t, BreakerClosed, cb.State())
+	assert.False(t, fallback.run)
+}
+
+func TestCircuitBreaker_ThresholdReached_OpensAndRoutesToFallback(t *testing.T) {
+	fallback := &StateImpl{}
+	a := &FlakyState{
+		errs: []error{errors.New("e1"), errors.New("e2")},
+	}
+	cb := NewCircuitBreaker(a, fallback, 2, time.Hour)
+
+	m := NewStateMachine()
+	m.AddState(cb)
+	m.AddState(fallback)
+
+	assert.Error(t, m.Run(nil, cb))
+	assert.Error(t, m.Run(nil, cb))
+	assert.Equal(t, BreakerOpen, cb.State())
+
+	assert.Nil(t, m.Run(1, cb))
+	assert.Equal(t, 2, a.calls) // short-circuited
+	assert.True(t, fallback.run)
+	assert.Equal(t, 1, fallback.cargoReceived)
+}
+
+func TestCircuitBreaker_AfterCooldown_TrialClosesOnSuccess(t *testing.T) {
+	fallback := &StateImpl{}
+	a := &FlakyState{
+		errs: []error{errors.New("e1")},
+	}
+	cb := NewCircuitBreaker(a, fallback, 1, time.Minute)
+
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := NewStateMachine(WithClock(steppedClock{now: &now}))
+	m.AddState(cb)
+	m.AddState(fallback)
+
+	assert.Error(t, m.Run(nil, cb))
+	assert.Equal(t, BreakerOpen, cb.State())
+
+	now = now.Add(time.Minute - time.Nanosecond)
+	assert.Equal(t, BreakerOpen, cb.State())
+	now = now.Add(time.Nanosecond)
+	assert.Equal(t, BreakerHalfOpen, cb.State())
+
+	assert.Nil(t, m.Run(nil, cb))
+	assert.Equal(t, BreakerClosed, cb.State())
+	assert.Equal(t, 2, a.calls)
+	assert.False(t, fallback.run)
+}
+
+func TestCircuitBreaker_AfterCooldown_TrialReopensOnFailure(t *testing.T) {
+	fallback := &StateImpl{}
+	a := &FlakyState{
+		errs: []error{errors.New("e1"), errors.New("e2")},
+	}
+	cb := NewCircuitBreaker(a, fallback, 1, time.Minute)
+
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := NewStateMachine(WithClock(steppedClock{now: &now}))
+	m.AddState(cb)
+	m.AddState(fallback)
+
+	assert.Error(t, m.Run(nil, cb))
+	now = now.Add(time.Minute)
+
+	assert.Error(t, m.Run(nil, cb))
+	assert.Equal(t, BreakerOpen, cb.State())
+}
+
+func TestCircuitBreaker_Name_IsWrappedStateName(t *testing.T) {
+	cb := NewCircuitBreaker(&StateImpl{name: "callApi"}, nil, 1, time.Second)
+
+	assert.Equal(t, "callApi", cb.Name())
+}
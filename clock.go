@@ -0,0 +1,57 @@
+package gust
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the machine's source of time, it can be replaced with WithClock so
+// tests don't have to wait for real timeouts, backoffs and watchdogs
+type Clock interface {
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc calls f in its own goroutine once the duration elapsed
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer created by Clock.AfterFunc
+type Timer interface {
+	// Stop prevents the timer from firing, it returns false if it already fired or was stopped
+	Stop() bool
+}
+
+// realClock is the Clock backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// sleep waits for d or until ctx is done, it returns false if ctx is done
+func (sm *StateMachine) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case <-sm.clock.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Clock returns the machine's clock, as set with WithClock
+func (sm *StateMachine) Clock() Clock {
+	return sm.clock
+}
@@ -0,0 +1,231 @@
+// Command gustctl works with gust machine definitions written in YAML, JSON or
+// Graphviz DOT, without writing any Go:
+//
+//	gustctl validate order.yaml          check the definition, warn of deprecations
+//	gustctl render -format dot order.yaml  print a diagram (dot or mermaid)
+//	gustctl doc order.yaml > ORDER.md    write Markdown documentation
+//	gustctl paths order.yaml             list every path from start to end
+//	gustctl simulate -walks 5 order.yaml take random walks through the machine
+//	gustctl diff old.yaml new.yaml       show the states and transitions changed
+//
+// The format is taken from the file extension (.yaml, .yml, .json, .dot, .gv)
+// unless given with -input.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/t2wu/gust"
+	"github.com/t2wu/gust/internal/deffile"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+const usage = `usage: gustctl <command> [flags] <definition file>
+
+commands:
+  validate  check the definition, warning about deprecated states
+  render    print a diagram of the definition
+  doc       print Markdown documentation of the definition
+  paths     list every simple path from the start state to an end state
+  simulate  take random walks from the start state
+  diff      compare two definitions: gustctl diff <old file> <new file>
+`
+
+// run executes the command line and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var cmd func(d *gust.Definition, fs *flag.FlagSet, stdout io.Writer) error
+	fs := flag.NewFlagSet("gustctl "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "definition format: yaml, json or dot (default from the file extension)")
+
+	switch args[0] {
+	case "validate":
+		cmd = validate
+	case "render":
+		format := fs.String("format", "dot", "diagram format: dot or mermaid")
+		cmd = func(d *gust.Definition, fs *flag.FlagSet, stdout io.Writer) error {
+			return render(d, *format, stdout)
+		}
+	case "doc":
+		cmd = func(d *gust.Definition, fs *flag.FlagSet, stdout io.Writer) error {
+			fmt.Fprint(stdout, d.Markdown())
+			return nil
+		}
+	case "paths":
+		limit := fs.Int("limit", 1000, "maximum number of paths to list")
+		cmd = func(d *gust.Definition, fs *flag.FlagSet, stdout io.Writer) error {
+			return paths(d, *limit, stdout)
+		}
+	case "simulate":
+		walks := fs.Int("walks", 1, "number of walks")
+		steps := fs.Int("steps", 100, "maximum transitions per walk")
+		seed := fs.Int64("seed", 1, "random seed")
+		cmd = func(d *gust.Definition, fs *flag.FlagSet, stdout io.Writer) error {
+			return simulate(d, *walks, *steps, *seed, stdout)
+		}
+	case "diff":
+		return diff(args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	d, err := deffile.Load(fs.Arg(0), *input)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if err := cmd(d, fs, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// diff runs the diff command, it takes two files and so doesn't fit the others
+func diff(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gustctl diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "definition format: yaml, json or dot (default from the file extension)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	defs := make([]*gust.Definition, 2)
+	for i := range defs {
+		d, err := deffile.Load(fs.Arg(i), *input)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defs[i] = d
+	}
+	fmt.Fprint(stdout, gust.Diff(defs[0], defs[1]))
+	return 0
+}
+
+func validate(d *gust.Definition, fs *flag.FlagSet, stdout io.Writer) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	for _, w := range d.Warnings() {
+		fmt.Fprintf(stdout, "warning: %s\n", w)
+	}
+	fmt.Fprintf(stdout, "ok: %d states, %d transitions\n", len(d.States), len(d.Transitions))
+	return nil
+}
+
+func render(d *gust.Definition, format string, stdout io.Writer) error {
+	switch format {
+	case "dot":
+		fmt.Fprint(stdout, d.DOT())
+	case "mermaid":
+		fmt.Fprint(stdout, d.Mermaid())
+	default:
+		return fmt.Errorf("unknown diagram format %q", format)
+	}
+	return nil
+}
+
+func paths(d *gust.Definition, limit int, stdout io.Writer) error {
+	m, start, err := build(d)
+	if err != nil {
+		return err
+	}
+
+	paths, complete := m.Paths(start, limit)
+	for _, p := range paths {
+		fmt.Fprintln(stdout, strings.Join(p, " -> "))
+	}
+	if !complete {
+		fmt.Fprintf(stdout, "(stopped after %d paths)\n", limit)
+	}
+	return nil
+}
+
+func simulate(d *gust.Definition, walks, steps int, seed int64, stdout io.Writer) error {
+	m, start, err := build(d)
+	if err != nil {
+		return err
+	}
+	m.MaxTransitions = steps
+
+	// every state goes to one of its successors at random
+	rnd := rand.New(rand.NewSource(seed))
+	stubs := make(gust.Stubs, len(m.States))
+	for _, s := range m.States {
+		next := m.AvailableTransitions(s)
+		stubs[s] = func(cargo interface{}) (gust.State, interface{}, error) {
+			if len(next) == 0 {
+				return nil, cargo, nil
+			}
+			return next[rnd.Intn(len(next))], cargo, nil
+		}
+	}
+
+	for i := 0; i < walks; i++ {
+		result, err := m.Simulate(nil, start, stubs)
+		fmt.Fprintln(stdout, strings.Join(result.Path, " -> "))
+		if err != nil {
+			fmt.Fprintf(stdout, "  %v\n", err)
+		}
+	}
+	return nil
+}
+
+// node is a stand-in state for a defined state, it has no behavior
+type node struct {
+	name string
+}
+
+func (n *node) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	return nil, cargo, nil
+}
+
+func (n *node) Name() string {
+	return n.name
+}
+
+// build makes a machine of stand-in states for the definition
+func build(d *gust.Definition) (*gust.StateMachine, gust.State, error) {
+	if d.Start == "" {
+		return nil, nil, fmt.Errorf("the definition has no start state")
+	}
+
+	m := gust.NewStateMachine()
+	for _, s := range d.States {
+		if err := m.AddState(&node{name: s.Name}); err != nil {
+			return nil, nil, err
+		}
+	}
+	start, err := m.ApplyDefinition(d)
+	return m, start, err
+}
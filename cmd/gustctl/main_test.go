@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const diamondYAML = `name: diamond
+start: a
+states:
+  - name: a
+  - name: b
+  - name: c
+  - name: d
+transitions:
+  - {from: a, to: b}
+  - {from: a, to: c}
+  - {from: b, to: d}
+  - {from: c, to: d}
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "gustctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func runArgs(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Validate_OK(t *testing.T) {
+	path := writeFile(t, "diamond.yaml", diamondYAML)
+
+	code, out, _ := runArgs("validate", path)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "ok: 4 states, 4 transitions\n", out)
+}
+
+func TestRun_Validate_Invalid(t *testing.T) {
+	path := writeFile(t, "bad.json", `{"start":"x","states":[{"name":"a"}]}`)
+
+	code, _, errOut := runArgs("validate", path)
+	assert.Equal(t, 1, code)
+	assert.NotEmpty(t, errOut)
+}
+
+func TestRun_Render_Mermaid(t *testing.T) {
+	path := writeFile(t, "diamond.yml", diamondYAML)
+
+	code, out, _ := runArgs("render", "-format", "mermaid", path)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, "stateDiagram-v2\n"))
+	assert.Contains(t, out, "s0 --> s1")
+}
+
+func TestRun_Render_DOTFromDOT(t *testing.T) {
+	path := writeFile(t, "diamond.gv", `digraph { a -> b -> d; a -> c -> d }`)
+
+	code, out, _ := runArgs("render", path)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "digraph")
+}
+
+func TestRun_Paths(t *testing.T) {
+	path := writeFile(t, "diamond.yaml", diamondYAML)
+
+	code, out, _ := runArgs("paths", path)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "a -> b -> d\na -> c -> d\n", out)
+}
+
+func TestRun_Simulate_SeededWalksAreRepeatable(t *testing.T) {
+	path := writeFile(t, "diamond.yaml", diamondYAML)
+
+	code, first, _ := runArgs("simulate", "-walks", "5", "-seed", "7", path)
+	assert.Equal(t, 0, code)
+	_, second, _ := runArgs("simulate", "-walks", "5", "-seed", "7", path)
+	assert.Equal(t, first, second)
+
+	lines := strings.Split(strings.TrimSpace(first), "\n")
+	assert.Len(t, lines, 5)
+	for _, l := range lines {
+		assert.Contains(t, []string{"a -> b -> d", "a -> c -> d"}, l)
+	}
+}
+
+func TestRun_InputOverride(t *testing.T) {
+	path := writeFile(t, "diamond.txt", diamondYAML)
+
+	code, _, errOut := runArgs("validate", path)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "unknown definition format")
+
+	code, _, _ = runArgs("validate", "-input", "yaml", path)
+	assert.Equal(t, 0, code)
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, _, _ := runArgs()
+	assert.Equal(t, 2, code)
+
+	code, _, errOut := runArgs("nope", "x")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, errOut, "unknown command")
+}
+
+func TestRun_Doc(t *testing.T) {
+	path := writeFile(t, "diamond.yaml", diamondYAML)
+
+	code, out, _ := runArgs("doc", path)
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, "# diamond\n"))
+	assert.Contains(t, out, "| a (start) |  | b, c |\n")
+}
+
+func TestRun_Diff(t *testing.T) {
+	old := writeFile(t, "old.yaml", diamondYAML)
+	new := writeFile(t, "new.gv", `digraph { __start -> a; a -> b -> d; a -> d }`)
+
+	code, out, _ := runArgs("diff", old, new)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "- state c\n- transition a -> c\n- transition c -> d\n+ transition a -> d\n", out)
+
+	code, _, _ = runArgs("diff", old)
+	assert.Equal(t, 2, code)
+}
+
+func TestRun_Validate_WarnsOfDeprecatedStates(t *testing.T) {
+	path := writeFile(t, "diamond.yaml", strings.Replace(diamondYAML, "  - name: c\n", "  - name: c\n    deprecated: replaced by b\n", 1))
+
+	code, out, _ := runArgs("validate", path)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "warning: transition a -> c into deprecated state c: replaced by b\nok: 4 states, 4 transitions\n", out)
+}
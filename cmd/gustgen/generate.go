@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/t2wu/gust"
+)
+
+type genState struct {
+	Name        string // as in the definition
+	Description string // on a single line
+	Ident       string // Go identifier, e.g. PaymentFailed for payment-failed
+	Next        []*genState
+}
+
+type genData struct {
+	Source string
+	Pkg    string
+	Name   string // the machine's name, for comments
+	Start  *genState
+	States []*genState
+	Def    *gust.Definition
+}
+
+// generate returns the formatted Go source for the definition
+func generate(d *gust.Definition, pkg, source string) ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+
+	data := genData{Source: source, Pkg: pkg, Name: d.Name, Def: d}
+	if data.Name == "" {
+		data.Name = pkg
+	}
+
+	byName := make(map[string]*genState, len(d.States))
+	byIdent := make(map[string]string, len(d.States))
+	for _, s := range d.States {
+		ident := identifier(s.Name)
+		if ident == "" {
+			return nil, fmt.Errorf("state %q has no letters or digits to name it in Go", s.Name)
+		}
+		if ident == "Name" {
+			return nil, fmt.Errorf("state %q would clash with the StateName type", s.Name)
+		}
+		if other, ok := byIdent[ident]; ok {
+			return nil, fmt.Errorf("states %q and %q are both named %s in Go", other, s.Name, ident)
+		}
+		byIdent[ident] = s.Name
+
+		gs := &genState{Name: s.Name, Ident: ident, Description: strings.Join(strings.Fields(s.Description), " ")}
+		byName[s.Name] = gs
+		data.States = append(data.States, gs)
+	}
+	for _, t := range d.Transitions {
+		from := byName[t.From]
+		from.Next = append(from.Next, byName[t.To])
+	}
+	if d.Start != "" {
+		data.Start = byName[d.Start]
+	}
+
+	var buf bytes.Buffer
+	if err := genTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// identifier turns a state name into an exported Go identifier, "payment
+// failed", "payment-failed" and "payment_failed" all become PaymentFailed
+func identifier(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteByte('S')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var genTemplate = template.Must(template.New("gen").Parse(`// Code generated by gustgen from {{.Source}}. DO NOT EDIT.
+
+package {{.Pkg}}
+
+import (
+	"fmt"
+
+	"github.com/t2wu/gust"
+)
+
+// StateName is the name of a state of the {{.Name}} machine
+type StateName string
+
+// States of the {{.Name}} machine
+const (
+{{- range .States}}
+	State{{.Ident}} StateName = {{printf "%q" .Name}}
+{{- end}}
+)
+{{if .Start}}
+// StartState is the state the {{.Name}} machine starts in
+const StartState = State{{.Start.Ident}}
+{{end}}
+// Transitions lists the states each state may transition to
+var Transitions = map[StateName][]StateName{
+{{- range .States}}
+	State{{.Ident}}: { {{- range $i, $n := .Next}}{{if $i}}, {{end}}State{{$n.Ident}}{{end -}} },
+{{- end}}
+}
+
+// Handlers executes the states of the {{.Name}} machine. Each method returns
+// the name of the state to go to next, or an empty name to end the run.
+type Handlers interface {
+{{- range .States}}
+	{{- if .Description}}
+	// {{.Ident}}: {{.Description}}
+	{{- end}}
+	{{.Ident}}(cargo interface{}) (next StateName, nextCargo interface{}, err error)
+{{- end}}
+}
+
+// Definition returns the definition the code was generated from
+func Definition() *gust.Definition {
+	return &gust.Definition{
+		Name:    {{printf "%q" .Def.Name}},
+		Version: {{printf "%q" .Def.Version}},
+		Start:   {{printf "%q" .Def.Start}},
+		{{- if .Def.Description}}
+		Description: {{printf "%q" .Def.Description}},
+		{{- end}}
+		{{- if .Def.EntryPoints}}
+		EntryPoints: {{printf "%#v" .Def.EntryPoints}},
+		{{- end}}
+		States: []gust.StateDefinition{
+		{{- range .Def.States}}
+			{Name: {{printf "%q" .Name}}{{if .Description}}, Description: {{printf "%q" .Description}}{{end}}{{if .Terminal}}, Terminal: true{{end}}{{if .Outcome}}, Outcome: {{printf "%q" .Outcome}}{{end}}{{if .Deprecated}}, Deprecated: {{printf "%q" .Deprecated}}{{end}}},
+		{{- end}}
+		},
+		Transitions: []gust.TransitionDefinition{
+		{{- range .Def.Transitions}}
+			{From: {{printf "%q" .From}}, To: {{printf "%q" .To}}{{if .Label}}, Label: {{printf "%q" .Label}}{{end}}{{if .Description}}, Description: {{printf "%q" .Description}}{{end}}{{if .Tags}}, Tags: {{printf "%#v" .Tags}}{{end}}{{if .Guarded}}, Guarded: true{{end}}{{if .Priority}}, Priority: {{.Priority}}{{end}}{{if .Weight}}, Weight: {{.Weight}}{{end}}{{if .Degraded}}, Degraded: true{{end}}},
+		{{- end}}
+		},
+	}
+}
+
+// state is a state of a machine made by NewMachine
+type state struct {
+	*gust.FuncState
+	description string
+}
+
+// Description describes the state as in the definition
+func (s *state) Description() string {
+	return s.description
+}
+
+// NewMachine returns a machine with a state per handler method and the
+// definition applied with ApplyDefinition, declaring the transitions with
+// their labels, priorities, weights and degraded transitions, the terminal
+// and deprecated states and the entry points. Guards can't be generated,
+// attach them with AddGuardedTransition. Use State to find the state to run
+// from.
+func NewMachine(h Handlers, opts ...gust.Option) (*gust.StateMachine, error) {
+	sm := gust.NewStateMachine(opts...)
+	states := make(map[StateName]gust.State, {{len .States}})
+	add := func(name StateName, description string, exec func(cargo interface{}) (StateName, interface{}, error)) error {
+		s := &state{description: description}
+		s.FuncState = gust.NewFuncState(string(name), func(cargo interface{}) (gust.State, interface{}, error) {
+			next, nextCargo, err := exec(cargo)
+			if err != nil || next == "" {
+				return nil, nextCargo, err
+			}
+			state, ok := states[next]
+			if !ok {
+				return nil, nextCargo, fmt.Errorf("%w: %s", gust.ErrUnknownState, next)
+			}
+			return state, nextCargo, nil
+		})
+		states[name] = s
+		return sm.AddState(s)
+	}
+{{range .States}}
+	if err := add(State{{.Ident}}, {{printf "%q" .Description}}, h.{{.Ident}}); err != nil {
+		return nil, err
+	}
+{{- end}}
+
+	if _, err := sm.ApplyDefinition(Definition()); err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
+
+// State returns the state of a machine made by NewMachine with the given name
+func State(sm *gust.StateMachine, name StateName) (gust.State, bool) {
+	return sm.StateByName(string(name))
+}
+`))
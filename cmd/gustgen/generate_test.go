@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+func TestGenerate_MatchesCheckedInExample(t *testing.T) {
+	// internal/order is regenerated with go generate, a difference means the
+	// example is stale or the generator changed by accident
+	want, err := ioutil.ReadFile("internal/order/order_gust.go")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-pkg", "order", "internal/order/order.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, string(want), stdout.String())
+}
+
+func TestGenerate_InvalidDefinition_Error(t *testing.T) {
+	d := &gust.Definition{States: []gust.StateDefinition{{Name: "a"}, {Name: "a"}}}
+
+	_, err := generate(d, "p", "p.yaml")
+	assert.True(t, errors.Is(err, gust.ErrInvalidDefinition))
+}
+
+func TestGenerate_ClashingIdentifiers_Error(t *testing.T) {
+	d := &gust.Definition{States: []gust.StateDefinition{{Name: "payment-failed"}, {Name: "payment failed"}}}
+
+	_, err := generate(d, "p", "p.yaml")
+	assert.EqualError(t, err, `states "payment-failed" and "payment failed" are both named PaymentFailed in Go`)
+}
+
+func TestGenerate_InvalidPackage_Error(t *testing.T) {
+	_, err := generate(&gust.Definition{}, "my-pkg", "p.yaml")
+	assert.EqualError(t, err, `invalid package name "my-pkg"`)
+}
+
+func TestIdentifier(t *testing.T) {
+	assert.Equal(t, "PaymentFailed", identifier("payment_failed"))
+	assert.Equal(t, "S2fa", identifier("2fa"))
+	assert.Equal(t, "ÉtéSale", identifier("été sale"))
+	assert.Equal(t, "", identifier("--"))
+}
+
+func TestRun_NoPackage_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-pkg", "", "x.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr.String(), "no package name")
+}
+
+func TestGenerate_RoutingAndEntryPoints_Kept(t *testing.T) {
+	d := &gust.Definition{
+		Start:       "a",
+		EntryPoints: map[string]string{"retry": "b"},
+		States:      []gust.StateDefinition{{Name: "a"}, {Name: "b"}, {Name: "c", Terminal: true}},
+		Transitions: []gust.TransitionDefinition{
+			{From: "a", To: "b", Weight: 0.5},
+			{From: "a", To: "c", Weight: 1.5},
+			{From: "b", To: "c", Degraded: true},
+		},
+	}
+
+	src, err := generate(d, "p", "p.yaml")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Contains(t, string(src), `EntryPoints: map[string]string{"retry": "b"},`)
+	assert.Contains(t, string(src), `{From: "a", To: "b", Weight: 0.5},`)
+	assert.Contains(t, string(src), `{From: "b", To: "c", Degraded: true},`)
+	assert.Contains(t, string(src), "sm.ApplyDefinition(Definition())")
+}
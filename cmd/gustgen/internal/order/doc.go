@@ -0,0 +1,5 @@
+// Package order is a machine generated by gustgen from order.yaml, it keeps
+// the generator's output compiling and working
+package order
+
+//go:generate go run github.com/t2wu/gust/cmd/gustgen -o order_gust.go order.yaml
@@ -0,0 +1,77 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+// handlers fails the payment once, then pays
+type handlers struct {
+	attempts int
+}
+
+func (h *handlers) Created(cargo interface{}) (StateName, interface{}, error) {
+	h.attempts++
+	if h.attempts == 1 {
+		return StatePaymentFailed, cargo, nil
+	}
+	return StatePaid, cargo, nil
+}
+
+func (h *handlers) PaymentFailed(cargo interface{}) (StateName, interface{}, error) {
+	return StateCreated, cargo, nil
+}
+
+func (h *handlers) Paid(cargo interface{}) (StateName, interface{}, error) {
+	return "", cargo, nil
+}
+
+func (h *handlers) Abandoned(cargo interface{}) (StateName, interface{}, error) {
+	return "", cargo, nil
+}
+
+func TestNewMachine_RunsHandlers(t *testing.T) {
+	sm, err := NewMachine(&handlers{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	start, ok := State(sm, StartState)
+	if assert.True(t, ok) {
+		result, err := sm.Execute(context.Background(), nil, start)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"created", "payment-failed", "created", "paid"}, result.Path)
+		assert.Equal(t, gust.OutcomeSuccess, result.Outcome)
+	}
+}
+
+func TestNewMachine_MatchesDefinition(t *testing.T) {
+	sm, err := NewMachine(&handlers{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	d := sm.Definition()
+	assert.Equal(t, Definition().States, d.States)
+	assert.Equal(t, Definition().Transitions, d.Transitions)
+}
+
+type undeclared struct {
+	handlers
+}
+
+func (h *undeclared) Created(cargo interface{}) (StateName, interface{}, error) {
+	return "shipped", cargo, nil
+}
+
+func TestNewMachine_UnknownNextState_Error(t *testing.T) {
+	sm, _ := NewMachine(&undeclared{})
+	start, _ := State(sm, StateCreated)
+
+	err := sm.Run(nil, start)
+	assert.True(t, errors.Is(err, gust.ErrUnknownState))
+}
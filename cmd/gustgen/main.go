@@ -0,0 +1,72 @@
+// Command gustgen generates Go code from a gust machine definition file, so
+// the states a program implements can't drift from the definition. Use it
+// with go:generate:
+//
+//	//go:generate go run github.com/t2wu/gust/cmd/gustgen -o order_gust.go order.yaml
+//
+// The generated file declares a StateName constant for each state, the
+// Transitions table, a Handlers interface with a method per state and
+// NewMachine building a machine from a Handlers implementation.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/t2wu/gust/internal/deffile"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gustgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "definition format: yaml, json or dot (default from the file extension)")
+	out := fs.String("o", "", "output file (default standard output)")
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "package name (default $GOPACKAGE, set by go generate)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gustgen [flags] <definition file>")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if *pkg == "" {
+		fmt.Fprintln(stderr, "no package name, use -pkg")
+		return 2
+	}
+
+	path := fs.Arg(0)
+	d, err := deffile.Load(path, *input)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	src, err := generate(d, *pkg, filepath.Base(path))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *out == "" {
+		stdout.Write(src)
+		return 0
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package gust
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec serializes cargo and snapshots for persistence. The machine's codec
+// is JSONCodec unless replaced with SetCodec or WithCodec.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes with encoding/json
+type JSONCodec struct{}
+
+// Marshal encodes v as JSON
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON into v
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec encodes with encoding/gob. Cargo decoded into an interface{}, as
+// when Resume isn't given a decode function, must be registered with
+// gob.Register.
+type GobCodec struct{}
+
+// Marshal encodes v with gob
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes gob data into v
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// ProtoMessage is a protocol buffer message able to encode itself, as
+// generated by gogo/protobuf and vtprotobuf. Messages of the official
+// google.golang.org/protobuf can be wrapped calling proto.Marshal and
+// proto.Unmarshal.
+type ProtoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// ProtoCodec encodes cargo that is a ProtoMessage in the protocol buffer wire
+// format. Other values, snapshots among them, are encoded with gob.
+type ProtoCodec struct{}
+
+// Marshal encodes v
+func (ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	if m, ok := v.(ProtoMessage); ok {
+		return m.Marshal()
+	}
+	return GobCodec{}.Marshal(v)
+}
+
+// Unmarshal decodes into v, which must be a ProtoMessage if the data is a
+// message
+func (ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	if m, ok := v.(ProtoMessage); ok {
+		return m.Unmarshal(data)
+	}
+	return GobCodec{}.Unmarshal(data, v)
+}
+
+// SetCodec replaces the codec used for snapshots
+func (sm *StateMachine) SetCodec(codec Codec) {
+	sm.codec = codec
+}
+
+// Codec returns the codec used for snapshots, decode functions given to
+// Resume can use it to decode cargo into its type
+func (sm *StateMachine) Codec() Codec {
+	return sm.codec
+}
+
+// EncodeSnapshot serializes the snapshot with the machine's codec
+func (sm *StateMachine) EncodeSnapshot(snap *Snapshot) ([]byte, error) {
+	data, err := sm.codec.Marshal(snap)
+	if err != nil {
+		return nil, fmt.Errorf("encoding snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// DecodeSnapshot deserializes a snapshot encoded by EncodeSnapshot
+func (sm *StateMachine) DecodeSnapshot(data []byte) (*Snapshot, error) {
+	snap := &Snapshot{}
+	if err := sm.codec.Unmarshal(data, snap); err != nil {
+		return nil, fmt.Errorf("decoding snapshot: %w", err)
+	}
+	return snap, nil
+}
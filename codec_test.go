@@ -0,0 +1,95 @@
+package gust
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// counter is a hand written ProtoMessage, a single varint field
+type counter struct {
+	N uint64
+}
+
+func (c *counter) Marshal() ([]byte, error) {
+	buf := make([]byte, binary.MaxVarintLen64+1)
+	buf[0] = 0x08 // field 1, varint
+	n := binary.PutUvarint(buf[1:], c.N)
+	return buf[:n+1], nil
+}
+
+func (c *counter) Unmarshal(data []byte) error {
+	if len(data) == 0 || data[0] != 0x08 {
+		return errors.New("not a counter")
+	}
+	n, size := binary.Uvarint(data[1:])
+	if size <= 0 {
+		return errors.New("bad varint")
+	}
+	c.N = n
+	return nil
+}
+
+func TestCodecs_SnapshotRoundTrip(t *testing.T) {
+	snap := &Snapshot{
+		Version: "2",
+		State:   "charge",
+		Cargo:   []byte{1, 2, 3},
+		Path:    []string{"validate"},
+		Taken:   time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	for _, codec := range []Codec{JSONCodec{}, GobCodec{}, ProtoCodec{}} {
+		m := NewStateMachine(WithCodec(codec))
+		data, err := m.EncodeSnapshot(snap)
+		if !assert.Nil(t, err, "%T", codec) {
+			continue
+		}
+		decoded, err := m.DecodeSnapshot(data)
+		assert.Nil(t, err, "%T", codec)
+		assert.Equal(t, snap, decoded, "%T", codec)
+	}
+}
+
+func TestProtoCodec_Message_WireFormat(t *testing.T) {
+	data, err := ProtoCodec{}.Marshal(&counter{N: 300})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x08, 0xac, 0x02}, data)
+
+	var c counter
+	assert.Nil(t, ProtoCodec{}.Unmarshal(data, &c))
+	assert.Equal(t, uint64(300), c.N)
+}
+
+func TestSnapshotRun_GobCodec_Resume(t *testing.T) {
+	calls := 0
+	m, start := newOrderMachine(&calls)
+	m.SetCodec(GobCodec{})
+
+	var last *Snapshot
+	_, err := m.SnapshotRun(context.Background(), order{ID: "o1", Total: 10}, start, func(snap *Snapshot) error {
+		last = snap
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	result, err := m.Resume(context.Background(), last, func(data []byte) (interface{}, error) {
+		var o order
+		err := m.Codec().Unmarshal(data, &o)
+		return o, err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ship"}, result.Path)
+	assert.Equal(t, order{ID: "o1", Total: 15}, result.Cargo)
+}
+
+func TestCodec_DefaultJSON(t *testing.T) {
+	m := NewStateMachine()
+	assert.Equal(t, JSONCodec{}, m.Codec())
+}
@@ -0,0 +1,45 @@
+package gust
+
+// HaveMaxConcurrency when implemented by a state limits how many executions of
+// it may be in flight at once, across all runs of the machine and the branches
+// of parallel states. Runs entering it beyond the limit wait for a slot. The
+// limit is read when the state is added, 0 or less means no limit.
+type HaveMaxConcurrency interface {
+	MaxConcurrency() int
+}
+
+// SetStateConcurrency limits how many executions of the state may be in flight
+// at once, like HaveMaxConcurrency, overriding what the state declares. 0 or
+// less removes the limit. Set it before running the machine.
+func (sm *StateMachine) SetStateConcurrency(state State, n int) {
+	if sm.stateSlots == nil {
+		sm.stateSlots = make(map[stateKey]chan struct{})
+	}
+	if n > 0 {
+		sm.stateSlots[keyOf(state)] = make(chan struct{}, n)
+	} else {
+		delete(sm.stateSlots, keyOf(state))
+	}
+}
+
+// acquireSlot waits for a free execution slot of the state, it returns false
+// if the run was interrupted while waiting
+func (sm *StateMachine) acquireSlot(r *run, state State) bool {
+	slots, ok := sm.stateSlots[keyOf(state)]
+	if !ok {
+		return true
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-r.ctx.Done():
+		return false
+	}
+}
+
+// releaseSlot frees the slot taken by acquireSlot
+func (sm *StateMachine) releaseSlot(state State) {
+	if slots, ok := sm.stateSlots[keyOf(state)]; ok {
+		<-slots
+	}
+}
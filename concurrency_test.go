@@ -0,0 +1,96 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// limitedState records how many of its executions overlap
+type limitedState struct {
+	max     int
+	lock    *sync.Mutex
+	running int
+	peak    int
+}
+
+func (s *limitedState) Exec(cargo interface{}) (State, interface{}, error) {
+	s.lock.Lock()
+	s.running++
+	if s.running > s.peak {
+		s.peak = s.running
+	}
+	s.lock.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	s.lock.Lock()
+	s.running--
+	s.lock.Unlock()
+	return nil, cargo, nil
+}
+
+func (s *limitedState) Name() string {
+	return "limited"
+}
+
+func (s *limitedState) MaxConcurrency() int {
+	return s.max
+}
+
+func TestStateConcurrency_DeclaredByState(t *testing.T) {
+	m := NewStateMachine()
+	s := &limitedState{max: 2, lock: &sync.Mutex{}}
+	m.AddState(s)
+
+	_, err := m.RunEach(make([]interface{}, 8), s, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, s.peak)
+}
+
+func TestStateConcurrency_SetAcrossParallelBranches(t *testing.T) {
+	m := NewStateMachine()
+	s := &limitedState{lock: &sync.Mutex{}}
+	m.AddState(s)
+	fork := NewParallel("fork", s, s, s, s)
+	m.AddState(fork)
+	m.SetStateConcurrency(s, 1)
+
+	_, err := m.Execute(context.Background(), nil, fork)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, s.peak)
+}
+
+func TestStateConcurrency_Removed(t *testing.T) {
+	m := NewStateMachine()
+	s := &limitedState{max: 1, lock: &sync.Mutex{}}
+	m.AddState(s)
+	m.SetStateConcurrency(s, 0)
+
+	_, err := m.RunEach(make([]interface{}, 4), s, 0)
+	assert.Nil(t, err)
+	assert.True(t, s.peak > 1)
+}
+
+func TestStateConcurrency_WaitingRunAborted(t *testing.T) {
+	m := NewStateMachine()
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	s := NewFuncState("s", func(cargo interface{}) (State, interface{}, error) {
+		close(entered)
+		<-release
+		return nil, cargo, nil
+	})
+	m.AddState(s)
+	m.SetStateConcurrency(s, 1)
+
+	go m.Run(nil, s)
+	<-entered
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := m.Execute(ctx, nil, s)
+	close(release)
+	assert.True(t, errors.Is(err, ErrAborted))
+}
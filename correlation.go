@@ -0,0 +1,39 @@
+package gust
+
+import "context"
+
+type correlationKey struct{}
+
+// WithCorrelationID attaches a correlation ID, e.g. that of the request that
+// started the work, to the runs executed with the returned context, so their
+// activity can be joined with upstream logs. It's given to observers in
+// RunStatus and to CorrelationObservers, printed in traces, and kept in
+// snapshots, resume tokens, dead letters, event logs and recordings, so
+// resumed runs keep it. Runs the states start, e.g. parallel branches, share
+// it.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID returns the correlation ID attached with WithCorrelationID, so
+// states can pass it on to the services they call
+func CorrelationID(ctx context.Context) (id string, ok bool) {
+	id, ok = ctx.Value(correlationKey{}).(string)
+	return id, ok && id != ""
+}
+
+// CorrelationObserver when implemented by a RunObserver is also given the
+// correlation ID of runs that have one, see WithCorrelationID, right before
+// RunStarted
+type CorrelationObserver interface {
+	RunCorrelated(runID, correlationID string)
+}
+
+// correlated attaches the correlation ID of a persisted run to ctx, unless ctx
+// has one or there's none
+func correlated(ctx context.Context, id string) context.Context {
+	if _, ok := CorrelationID(ctx); ok || id == "" {
+		return ctx
+	}
+	return WithCorrelationID(ctx, id)
+}
@@ -0,0 +1,151 @@
+package gust
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// correlatedState remembers the correlation ID it executed with
+type correlatedState struct {
+	next State
+	seen string
+}
+
+func (s *correlatedState) Exec(cargo interface{}) (State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+func (s *correlatedState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	s.seen, _ = CorrelationID(ctx)
+	return s.next, cargo, nil
+}
+
+func (s *correlatedState) Name() string {
+	return "a"
+}
+
+func TestCorrelationID_GivenToStatesAndObservers(t *testing.T) {
+	m := NewStateMachine()
+	b := &StateImpl{name: "b"}
+	a := &correlatedState{next: b}
+	m.AddStates(a, b)
+	o := &statusObserver{}
+	m.RegisterObservers(o)
+
+	_, err := m.Execute(WithCorrelationID(context.Background(), "req-42"), nil, a)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "req-42", a.seen)
+	if assert.Len(t, o.statuses, 2) {
+		assert.Equal(t, "req-42", o.statuses[0].CorrelationID)
+		assert.Equal(t, "req-42", o.statuses[1].CorrelationID)
+	}
+
+	_, ok := CorrelationID(context.Background())
+	assert.False(t, ok)
+}
+
+func TestCorrelationID_Trace(t *testing.T) {
+	m := NewStateMachine()
+	a := &StateImpl{name: "a", err: errors.New("boom")}
+	m.AddStates(a)
+	var out bytes.Buffer
+	m.Trace(&out)
+
+	m.Execute(WithCorrelationID(context.Background(), "req-42"), nil, a)
+	m.Execute(context.Background(), nil, a)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(t, lines, 6) {
+		assert.Contains(t, lines[0], "run 1 [req-42] started")
+		assert.Contains(t, lines[1], "run 1 [req-42] -> a")
+		assert.Contains(t, lines[2], "run 1 [req-42] failed")
+		assert.Contains(t, lines[3], "run 2 started")
+	}
+}
+
+func TestCorrelationID_KeptBySnapshotsAndTokens(t *testing.T) {
+	m := NewStateMachine()
+	b := &StateImpl{name: "b"}
+	a := &StateImpl{name: "a", nextState: b, cargo: "x"}
+	m.AddStates(a, b)
+	var snaps []*Snapshot
+	ctx := WithCorrelationID(context.Background(), "req-42")
+
+	_, err := m.SnapshotRun(ctx, "x", a, func(snap *Snapshot) error {
+		snaps = append(snaps, snap)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "req-42", snaps[1].CorrelationID)
+
+	o := &statusObserver{}
+	m.RegisterObservers(o)
+	_, err = m.Resume(context.Background(), snaps[1], nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "req-42", o.statuses[0].CorrelationID)
+
+	_, err = m.Resume(WithCorrelationID(context.Background(), "req-43"), snaps[1], nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "req-43", o.statuses[1].CorrelationID)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = m.Execute(ctx, "x", a)
+	token, ok := ResumeTokenOf(err)
+	if assert.True(t, ok) {
+		snap, err := m.Checkpoint(token)
+		assert.Nil(t, err)
+		assert.Equal(t, "req-42", snap.CorrelationID)
+	}
+}
+
+func TestCorrelationID_EventLogAndDeadLetters(t *testing.T) {
+	sink := &memDeadLetters{}
+	m := NewStateMachine(WithDeadLetterSink(sink))
+	a := &StateImpl{name: "a", err: errors.New("boom")}
+	m.AddStates(a)
+	log := NewMemoryEventLog()
+
+	m.EventSourcedRun(WithCorrelationID(context.Background(), "req-42"), log, "x", a, nil)
+
+	events, _ := log.Events()
+	for _, e := range events {
+		assert.Equal(t, "req-42", e.CorrelationID)
+	}
+	pos, _ := Fold(events)
+	assert.Equal(t, "req-42", pos.Snapshot().CorrelationID)
+	if assert.Len(t, sink.letters, 1) {
+		assert.Equal(t, "req-42", sink.letters[0].CorrelationID)
+		assert.Equal(t, "req-42", sink.letters[0].Snapshot().CorrelationID)
+	}
+}
+
+func TestCorrelationID_Recording(t *testing.T) {
+	m := NewStateMachine()
+	a := &StateImpl{name: "a", cargo: "y"}
+	m.AddStates(a)
+	var rec bytes.Buffer
+
+	m.RecordRun(WithCorrelationID(context.Background(), "req-42"), &rec, "x", a)
+	assert.Contains(t, rec.String(), `"correlationId":"req-42"`)
+
+	o := &statusObserver{}
+	m.RegisterObservers(o)
+	_, err := m.Replay(context.Background(), &rec, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "req-42", o.statuses[0].CorrelationID)
+}
+
+func TestTraceObserver_ForgetsEndedRuns(t *testing.T) {
+	o := NewTraceObserver(&bytes.Buffer{})
+	o.RunCorrelated("1", "req-42")
+	o.RunCompleted("1", time.Second)
+	assert.Empty(t, o.correlations)
+}
@@ -0,0 +1,110 @@
+package gust
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TransitionCoverage is how many times a declared transition was taken
+type TransitionCoverage struct {
+	From  string
+	To    string
+	Count int
+}
+
+// CoverageReport tells which declared transitions runs have taken since
+// coverage tracking was enabled
+type CoverageReport struct {
+	// Transitions are all declared transitions, ordered by the registration of
+	// their from state and then by declaration
+	Transitions []TransitionCoverage
+}
+
+// Percent is the percentage of declared transitions taken at least once, 100
+// if there are none
+func (c *CoverageReport) Percent() float64 {
+	if len(c.Transitions) == 0 {
+		return 100
+	}
+	return float64(len(c.Transitions)-len(c.Uncovered())) / float64(len(c.Transitions)) * 100
+}
+
+// Uncovered returns the declared transitions never taken
+func (c *CoverageReport) Uncovered() []TransitionCoverage {
+	uncovered := make([]TransitionCoverage, 0)
+	for _, t := range c.Transitions {
+		if t.Count == 0 {
+			uncovered = append(uncovered, t)
+		}
+	}
+	return uncovered
+}
+
+// String formats the report as a table, one transition per line
+func (c *CoverageReport) String() string {
+	var b strings.Builder
+	for _, t := range c.Transitions {
+		fmt.Fprintf(&b, "%s -> %s\t%d\n", t.From, t.To, t.Count)
+	}
+	fmt.Fprintf(&b, "coverage: %.1f%% of transitions\n", c.Percent())
+	return b.String()
+}
+
+type transitionKey struct {
+	from, to stateKey
+}
+
+type coverage struct {
+	lock   *sync.Mutex
+	counts map[transitionKey]int
+}
+
+// TrackCoverage starts or stops counting the transitions taken by runs, see
+// Coverage. Starting again resets the counts.
+func (sm *StateMachine) TrackCoverage(enabled bool) {
+	if !enabled {
+		sm.coverage = nil
+		return
+	}
+	sm.coverage = &coverage{
+		lock:   &sync.Mutex{},
+		counts: make(map[transitionKey]int),
+	}
+}
+
+// Coverage reports which declared transitions were taken since TrackCoverage
+// was enabled
+func (sm *StateMachine) Coverage() *CoverageReport {
+	report := &CoverageReport{Transitions: make([]TransitionCoverage, 0)}
+
+	var counts map[transitionKey]int
+	if c := sm.coverage; c != nil {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		counts = c.counts
+	}
+
+	for _, from := range sm.States {
+		for _, t := range sm.transitions[keyOf(from)] {
+			report.Transitions = append(report.Transitions, TransitionCoverage{
+				From:  sm.StateName(t.From),
+				To:    sm.StateName(t.To),
+				Count: counts[transitionKey{from: keyOf(t.From), to: keyOf(t.To)}],
+			})
+		}
+	}
+	return report
+}
+
+func (sm *StateMachine) recordTransition(from, to State) {
+	sm.stats.transitionTaken()
+	c := sm.coverage
+	if c == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.counts[transitionKey{from: keyOf(from), to: keyOf(to)}]++
+}
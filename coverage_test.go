@@ -0,0 +1,48 @@
+package gust
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverage_OneBranchRun_HalfCovered(t *testing.T) {
+	m, a, _, _, _ := newDiamond()
+	m.TrackCoverage(true)
+
+	assert.Nil(t, m.Run(nil, a)) // A -> C -> D
+	assert.Nil(t, m.Run(nil, a))
+
+	report := m.Coverage()
+	assert.Equal(t, []TransitionCoverage{
+		{From: "stateA", To: "stateB", Count: 0},
+		{From: "stateA", To: "stateC", Count: 2},
+		{From: "stateB", To: "stateD", Count: 0},
+		{From: "stateC", To: "stateD", Count: 2},
+	}, report.Transitions)
+	assert.Equal(t, 50.0, report.Percent())
+	assert.Equal(t, []TransitionCoverage{
+		{From: "stateA", To: "stateB"},
+		{From: "stateB", To: "stateD"},
+	}, report.Uncovered())
+	assert.Equal(t, "stateA -> stateB\t0\nstateA -> stateC\t2\nstateB -> stateD\t0\nstateC -> stateD\t2\ncoverage: 50.0% of transitions\n", report.String())
+}
+
+func TestCoverage_NotTracking_NothingCounted(t *testing.T) {
+	m, a, _, _, _ := newDiamond()
+
+	assert.Nil(t, m.Run(nil, a))
+	assert.Equal(t, 0.0, m.Coverage().Percent())
+}
+
+func TestCoverage_WithCoverageOption_Tracks(t *testing.T) {
+	b := &StateImpl{name: "stateB"}
+	a := &StateImpl{name: "stateA", nextState: b}
+
+	m := NewStateMachine(WithCoverage())
+	m.AddStates(a, b)
+	m.AddTransition(a, b)
+
+	assert.Nil(t, m.Run(nil, a))
+	assert.Equal(t, 100.0, m.Coverage().Percent())
+}
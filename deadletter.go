@@ -0,0 +1,203 @@
+package gust
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DeadLetter is a run that failed, kept so the work item can be inspected and
+// requeued later with Requeue
+type DeadLetter struct {
+	Machine string    `json:"machine,omitempty"` // the machine's Name
+	Version string    `json:"version,omitempty"` // the machine's Version
+	Run     string    `json:"run"`               // the run's idempotency key base, see IdempotencyKey
+	State   string    `json:"state"`             // the state that failed
+	Cargo   []byte    `json:"cargo"`             // the cargo it was given, encoded with the machine's Codec
+	Error   string    `json:"error"`
+	Path    []string  `json:"path,omitempty"` // the states entered, ending with the failing one
+	Steps   int       `json:"steps,omitempty"`
+	Failed  time.Time `json:"failed"`
+
+	CorrelationID string `json:"correlationId,omitempty"` // see WithCorrelationID
+}
+
+// Snapshot returns the snapshot to resume the run from the state that failed
+func (d *DeadLetter) Snapshot() *Snapshot {
+	path := append([]string{}, d.Path...)
+	if len(path) > 0 && path[len(path)-1] == d.State {
+		path = path[:len(path)-1]
+	}
+	return &Snapshot{
+		Version: d.Version,
+		State:   d.State,
+		Cargo:   append([]byte{}, d.Cargo...),
+		Path:    path,
+		Taken:   d.Failed,
+		Run:     d.Run,
+		Steps:   d.Steps,
+
+		CorrelationID: d.CorrelationID,
+	}
+}
+
+// DeadLetterSink keeps the dead letters of failed runs, see SetDeadLetterSink.
+// FileDeadLetterSink keeps them in a directory, gustsql has one keeping them in
+// a table.
+type DeadLetterSink interface {
+	Put(ctx context.Context, letter *DeadLetter) error
+}
+
+// SetDeadLetterSink hands the final cargo, error and history of every failed
+// run to the sink. Aborted runs, see Abort, aren't dead letters, nor are runs
+// moved to a reloaded machine, see Manager.Reload, which go on there. The cargo
+// is redacted if SetRedactor is set to redact persisted cargo. Failures
+// encoding the cargo or putting the letter are reported to the OnObserverError
+// callback, they don't change the run's error. nil removes the sink.
+func (sm *StateMachine) SetDeadLetterSink(sink DeadLetterSink) {
+	sm.deadLetters = sink
+}
+
+// WithDeadLetterSink hands failed runs to the sink, like SetDeadLetterSink
+func WithDeadLetterSink(sink DeadLetterSink) Option {
+	return func(sm *StateMachine) {
+		sm.SetDeadLetterSink(sink)
+	}
+}
+
+// Requeue executes the dead letter's run again from the state that failed, as
+// Resume does with the letter's Snapshot
+func (sm *StateMachine) Requeue(ctx context.Context, letter *DeadLetter, decode func(data []byte) (interface{}, error)) (*Result, error) {
+	return sm.Resume(ctx, letter.Snapshot(), decode)
+}
+
+// deadLetter hands the failed run to the sink, cargo being what the failing
+// state was given
+func (sm *StateMachine) deadLetter(r *run, startState State, cargo interface{}, err error) {
+	if sm.deadLetters == nil || err == nil || errors.Is(err, ErrAborted) || errors.Is(err, ErrReloaded) {
+		return
+	}
+
+	letter := &DeadLetter{
+		Machine: sm.Name,
+		Version: sm.Version,
+		Run:     r.idempotencyBase(),
+		State:   sm.StateName(startState),
+		Error:   err.Error(),
+		Path:    r.path.list(),
+		Steps:   r.resumedSteps + r.path.entered - 1,
+		Failed:  sm.clock.Now(),
+
+		CorrelationID: r.correlation,
+	}
+	var runErr *RunError
+	if errors.As(err, &runErr) {
+		letter.State = runErr.State
+	}
+	if letter.Steps < 0 {
+		letter.Steps = 0
+	}
+
+	if letter.Cargo, err = sm.codec.Marshal(sm.persisted(cargo)); err != nil {
+		sm.deadLetterError(fmt.Errorf("encoding dead letter cargo: %w", err))
+		return
+	}
+	if err := sm.deadLetters.Put(r.ctx, letter); err != nil {
+		sm.deadLetterError(fmt.Errorf("putting dead letter: %w", err))
+	}
+}
+
+func (sm *StateMachine) deadLetterError(err error) {
+	if sm.observerErrorHandler != nil {
+		sm.observerErrorHandler(err)
+	}
+}
+
+// FileDeadLetterSink keeps dead letters as JSON files in a directory, one per
+// run, named after the run
+type FileDeadLetterSink struct {
+	dir string
+}
+
+// NewFileDeadLetterSink is a constructor for FileDeadLetterSink, the directory
+// is created on the first letter if it doesn't exist
+func NewFileDeadLetterSink(dir string) *FileDeadLetterSink {
+	return &FileDeadLetterSink{dir: dir}
+}
+
+// Put writes the letter, replacing that of the same run
+func (s *FileDeadLetterSink) Put(ctx context.Context, letter *DeadLetter) error {
+	data, err := json.MarshalIndent(letter, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	// written aside then renamed, so List never reads half a letter
+	tmp, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(letter.Run))
+}
+
+// List returns the letters, oldest first
+func (s *FileDeadLetterSink) List(ctx context.Context) ([]*DeadLetter, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var letters []*DeadLetter
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(s.dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		letter := &DeadLetter{}
+		if err := json.Unmarshal(data, letter); err != nil {
+			return nil, fmt.Errorf("decoding dead letter %s: %w", f.Name(), err)
+		}
+		letters = append(letters, letter)
+	}
+	sort.SliceStable(letters, func(i, j int) bool {
+		return letters[i].Failed.Before(letters[j].Failed)
+	})
+	return letters, nil
+}
+
+// Delete removes the letter of the run, e.g. once requeued
+func (s *FileDeadLetterSink) Delete(ctx context.Context, run string) error {
+	err := os.Remove(s.path(run))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileDeadLetterSink) path(run string) string {
+	return filepath.Join(s.dir, filepath.Base(run)+".json")
+}
@@ -0,0 +1,121 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memDeadLetters struct {
+	letters []*DeadLetter
+}
+
+func (s *memDeadLetters) Put(ctx context.Context, letter *DeadLetter) error {
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+func deadLetterMachine(sink DeadLetterSink, fail *bool) (m *StateMachine, validate State) {
+	m = NewStateMachine(WithDeadLetterSink(sink))
+	m.Name = "order"
+	var charge, ship State
+	ship = NewFuncState("ship", func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo, nil
+	})
+	charge = NewFuncState("charge", func(cargo interface{}) (State, interface{}, error) {
+		if *fail {
+			return nil, "changed", errors.New("card declined")
+		}
+		return ship, cargo, nil
+	})
+	validate = NewFuncState("validate", func(cargo interface{}) (State, interface{}, error) {
+		return charge, cargo.(string) + "!", nil
+	})
+	m.AddStates(validate, charge, ship)
+	return m, validate
+}
+
+func TestSetDeadLetterSink_FailedRun(t *testing.T) {
+	sink := &memDeadLetters{}
+	fail := true
+	m, validate := deadLetterMachine(sink, &fail)
+
+	_, err := m.Execute(context.Background(), "o1", validate)
+	assert.NotNil(t, err)
+
+	if assert.Len(t, sink.letters, 1) {
+		letter := sink.letters[0]
+		assert.Equal(t, "order", letter.Machine)
+		assert.Equal(t, "charge", letter.State)
+		assert.Equal(t, `"o1!"`, string(letter.Cargo))
+		assert.Equal(t, err.Error(), letter.Error)
+		assert.Equal(t, []string{"validate", "charge"}, letter.Path)
+		assert.Equal(t, 1, letter.Steps)
+		assert.NotEmpty(t, letter.Run)
+	}
+}
+
+func TestSetDeadLetterSink_SucceededOrAbortedRun(t *testing.T) {
+	sink := &memDeadLetters{}
+	fail := false
+	m, validate := deadLetterMachine(sink, &fail)
+
+	_, err := m.Execute(context.Background(), "o1", validate)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = m.Execute(ctx, "o1", validate)
+	assert.True(t, errors.Is(err, ErrAborted))
+	assert.Empty(t, sink.letters)
+}
+
+func TestRequeue(t *testing.T) {
+	sink := &memDeadLetters{}
+	fail := true
+	m, validate := deadLetterMachine(sink, &fail)
+	m.Execute(context.Background(), "o1", validate)
+
+	fail = false
+	result, err := m.Requeue(context.Background(), sink.letters[0], nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"charge", "ship"}, result.Path)
+	assert.Equal(t, "o1!", result.Cargo)
+}
+
+func TestFileDeadLetterSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gust-dead-letters")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	sink := NewFileDeadLetterSink(dir + "/letters")
+	fail := true
+	m, validate := deadLetterMachine(sink, &fail)
+
+	m.Execute(context.Background(), "o1", validate)
+	m.Execute(context.Background(), "o2", validate)
+
+	letters, err := sink.List(context.Background())
+	assert.Nil(t, err)
+	if assert.Len(t, letters, 2) {
+		assert.Equal(t, `"o1!"`, string(letters[0].Cargo))
+		assert.Equal(t, "charge", letters[0].State)
+
+		assert.Nil(t, sink.Delete(context.Background(), letters[0].Run))
+		assert.Nil(t, sink.Delete(context.Background(), letters[0].Run))
+		letters, err = sink.List(context.Background())
+		assert.Nil(t, err)
+		assert.Len(t, letters, 1)
+	}
+}
+
+func TestFileDeadLetterSink_NoDirectory(t *testing.T) {
+	letters, err := NewFileDeadLetterSink("does-not-exist").List(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, letters)
+}
@@ -0,0 +1,339 @@
+package gust
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Definition is a serializable description of a machine's topology: its
+// states and the transitions between them. It carries no behavior, so it can
+// be stored, diffed, rendered and validated outside of Go.
+type Definition struct {
+	Name        string                 `json:"name,omitempty" yaml:"name,omitempty"`
+	Version     string                 `json:"version,omitempty" yaml:"version,omitempty"`
+	Start       string                 `json:"start,omitempty" yaml:"start,omitempty"`
+	EntryPoints map[string]string      `json:"entryPoints,omitempty" yaml:"entryPoints,omitempty"` // entry point names to their state
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	States      []StateDefinition      `json:"states" yaml:"states"`
+	Transitions []TransitionDefinition `json:"transitions" yaml:"transitions"`
+}
+
+// StateDefinition describes a state
+type StateDefinition struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Terminal    bool   `json:"terminal,omitempty" yaml:"terminal,omitempty"`
+	// Outcome of a terminal state, "success" or "failure", empty for success
+	Outcome string `json:"outcome,omitempty" yaml:"outcome,omitempty"`
+	// Deprecated is why the state is deprecated, empty if it isn't, see MarkDeprecated
+	Deprecated string `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// TransitionDefinition describes a transition between two states by name
+type TransitionDefinition struct {
+	From        string   `json:"from" yaml:"from"`
+	To          string   `json:"to" yaml:"to"`
+	Label       string   `json:"label,omitempty" yaml:"label,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Guarded transitions are taken on a guard, attached in Go with
+	// AddGuardedTransition, and chosen between by Priority
+	Guarded  bool    `json:"guarded,omitempty" yaml:"guarded,omitempty"`
+	Priority int     `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Weight   float64 `json:"weight,omitempty" yaml:"weight,omitempty"`     // see SetTransitionWeight
+	Degraded bool    `json:"degraded,omitempty" yaml:"degraded,omitempty"` // see AddDegradedTransition
+}
+
+// Definition describes the machine's registered states and declared
+// transitions. Unnamed states are named as by StateName, states implementing
+// HaveDescription are described.
+func (sm *StateMachine) Definition() *Definition {
+	d := &Definition{
+		Name:        sm.Name,
+		Version:     sm.Version,
+		States:      make([]StateDefinition, 0, len(sm.States)),
+		Transitions: make([]TransitionDefinition, 0),
+	}
+	for _, s := range sm.States {
+		sd := StateDefinition{Name: sm.StateName(s), Terminal: sm.IsTerminal(s)}
+		if outcome := sm.OutcomeOf(s); outcome == OutcomeFailure {
+			sd.Outcome = outcome.String()
+		}
+		if desc, ok := s.(HaveDescription); ok {
+			sd.Description = desc.Description()
+		}
+		sd.Deprecated, _ = sm.DeprecationOf(s)
+		d.States = append(d.States, sd)
+		for _, t := range sm.transitions[keyOf(s)] {
+			td := TransitionDefinition{
+				From: sm.StateName(t.From), To: sm.StateName(t.To), Label: t.Label, Description: t.Description,
+				Guarded: t.Guard != nil, Priority: t.Priority, Weight: t.Weight, Degraded: t.Degraded,
+			}
+			if len(t.Tags) > 0 {
+				td.Tags = append([]string{}, t.Tags...)
+			}
+			d.Transitions = append(d.Transitions, td)
+		}
+	}
+	if len(sm.entryPoints) > 0 {
+		d.EntryPoints = make(map[string]string, len(sm.entryPoints))
+		for name, ep := range sm.entryPoints {
+			d.EntryPoints[name] = sm.StateName(ep.state)
+		}
+	}
+	return d
+}
+
+// ValidationError lists everything wrong with a definition
+type ValidationError struct {
+	Problems []string
+	// Warnings are what's valid but should be looked at, see Warnings
+	Warnings []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid definition: " + strings.Join(e.Problems, "; ")
+}
+
+// Is reports ErrInvalidDefinition as a match
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidDefinition
+}
+
+// Validate checks that states are named and unique, that transitions, the
+// start state and the entry points refer to defined states, and that every
+// state can be reached from the start state or an entry point. If any state is
+// terminal, it also checks that states without transitions are terminal and
+// terminal states have none, telling a forgotten transition from an end, and
+// that no two guarded transitions from a state share a priority, which would
+// be ambiguous if both guards held. It returns a *ValidationError listing all
+// problems, along with the warnings about deprecated states. Warnings alone
+// aren't problems, a definition whose only issues are warnings is valid and
+// they're listed by Warnings.
+func (d *Definition) Validate() error {
+	problems := make([]string, 0)
+
+	defined := make(map[string]bool, len(d.States))
+	for i, s := range d.States {
+		if s.Name == "" {
+			problems = append(problems, fmt.Sprintf("state #%d has no name", i+1))
+		} else if defined[s.Name] {
+			problems = append(problems, fmt.Sprintf("state %s defined twice", s.Name))
+		}
+		defined[s.Name] = true
+	}
+
+	if d.Start != "" && !defined[d.Start] {
+		problems = append(problems, fmt.Sprintf("start state %s not defined", d.Start))
+	}
+	entries := make([]string, 0, len(d.EntryPoints))
+	for name := range d.EntryPoints {
+		entries = append(entries, name)
+	}
+	sort.Strings(entries)
+	for _, name := range entries {
+		if !defined[d.EntryPoints[name]] {
+			problems = append(problems, fmt.Sprintf("entry point %s state %s not defined", name, d.EntryPoints[name]))
+		}
+	}
+
+	seen := make(map[[2]string]bool, len(d.Transitions))
+	for _, t := range d.Transitions {
+		if !defined[t.From] {
+			problems = append(problems, fmt.Sprintf("transition %s -> %s from undefined state %s", t.From, t.To, t.From))
+		}
+		if !defined[t.To] {
+			problems = append(problems, fmt.Sprintf("transition %s -> %s to undefined state %s", t.From, t.To, t.To))
+		}
+		if seen[[2]string{t.From, t.To}] {
+			problems = append(problems, fmt.Sprintf("transition %s -> %s defined twice", t.From, t.To))
+		}
+		seen[[2]string{t.From, t.To}] = true
+	}
+
+	problems = append(problems, d.terminalProblems()...)
+	problems = append(problems, d.priorityProblems()...)
+	problems = append(problems, d.degradedProblems()...)
+
+	starts := make([]string, 0, len(entries)+1)
+	if d.Start != "" && defined[d.Start] {
+		starts = append(starts, d.Start)
+	}
+	for _, name := range entries {
+		if state := d.EntryPoints[name]; defined[state] && state != d.Start {
+			starts = append(starts, state)
+		}
+	}
+	if len(starts) > 0 {
+		reachable := make(map[string]bool, len(d.States))
+		for _, start := range starts {
+			for name := range d.reachable(start) {
+				reachable[name] = true
+			}
+		}
+		for _, s := range d.States {
+			if s.Name != "" && !reachable[s.Name] {
+				problems = append(problems, fmt.Sprintf("state %s unreachable from %s", s.Name, strings.Join(starts, ", ")))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems, Warnings: d.Warnings()}
+	}
+	return nil
+}
+
+// terminalProblems checks the states without transitions are the terminal ones
+func (d *Definition) terminalProblems() []string {
+	problems := make([]string, 0)
+	hasTerminals := false
+	for _, s := range d.States {
+		hasTerminals = hasTerminals || s.Terminal
+	}
+	if !hasTerminals {
+		return problems
+	}
+	for _, s := range d.States {
+		if s.Outcome != "" && s.Outcome != OutcomeSuccess.String() && s.Outcome != OutcomeFailure.String() {
+			problems = append(problems, fmt.Sprintf("state %s has unknown outcome %s", s.Name, s.Outcome))
+		}
+		if s.Outcome != "" && !s.Terminal {
+			problems = append(problems, fmt.Sprintf("state %s has an outcome but isn't terminal", s.Name))
+		}
+		next := len(d.Successors(s.Name))
+		if s.Terminal && next > 0 {
+			problems = append(problems, fmt.Sprintf("terminal state %s has transitions", s.Name))
+		} else if !s.Terminal && next == 0 && s.Name != "" {
+			problems = append(problems, fmt.Sprintf("state %s has no transitions and isn't terminal", s.Name))
+		}
+	}
+	return problems
+}
+
+// priorityProblems checks the guarded transitions from each state have
+// distinct priorities
+func (d *Definition) priorityProblems() []string {
+	problems := make([]string, 0)
+	first := make(map[string]map[int]string) // from state to priority to the first transition's to state
+	for _, t := range d.Transitions {
+		if !t.Guarded {
+			continue
+		}
+		if first[t.From] == nil {
+			first[t.From] = make(map[int]string)
+		}
+		if to, ok := first[t.From][t.Priority]; ok {
+			problems = append(problems, fmt.Sprintf("state %s has guarded transitions to %s and %s with priority %d", t.From, to, t.To, t.Priority))
+			continue
+		}
+		first[t.From][t.Priority] = t.To
+	}
+	return problems
+}
+
+// degradedProblems checks no state has several degraded transitions
+func (d *Definition) degradedProblems() []string {
+	problems := make([]string, 0)
+	first := make(map[string]string) // from state to the first degraded transition's to state
+	for _, t := range d.Transitions {
+		if !t.Degraded {
+			continue
+		}
+		if to, ok := first[t.From]; ok {
+			problems = append(problems, fmt.Sprintf("state %s has degraded transitions to %s and %s", t.From, to, t.To))
+			continue
+		}
+		first[t.From] = t.To
+	}
+	return problems
+}
+
+// Successors returns the states the named state transitions to, in order
+func (d *Definition) Successors(name string) []string {
+	next := make([]string, 0)
+	for _, t := range d.Transitions {
+		if t.From == name {
+			next = append(next, t.To)
+		}
+	}
+	return next
+}
+
+// reachable returns the states reachable from the given one, including itself
+func (d *Definition) reachable(from string) map[string]bool {
+	reachable := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, next := range d.Successors(name) {
+			if !reachable[next] {
+				reachable[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return reachable
+}
+
+// ApplyDefinition declares the definition's transitions on the machine, binding
+// each defined state to the registered state of the same name, so a definition
+// kept outside of Go is the source of truth for the topology. Every defined
+// state must be registered. Terminal and deprecated states are marked, entry
+// points not declared yet are declared, without cargo validation, and
+// priorities, weights and degraded transitions are set. Guards can't be
+// defined outside of Go, attach them with AddGuardedTransition. It returns the
+// start state, nil if the definition has none.
+func (sm *StateMachine) ApplyDefinition(d *Definition) (startState State, err error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	// every state is bound before anything is applied, so a definition that
+	// doesn't match the machine leaves it untouched
+	states := make(map[string]State, len(d.States))
+	missing := make([]string, 0)
+	for _, s := range d.States {
+		state, ok := sm.StateByName(s.Name)
+		if !ok {
+			missing = append(missing, s.Name)
+			continue
+		}
+		states[s.Name] = state
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: no registered state named %s", ErrUnknownState, strings.Join(missing, ", "))
+	}
+
+	for _, s := range d.States {
+		state := states[s.Name]
+		if s.Outcome == OutcomeFailure.String() {
+			sm.MarkOutcome(OutcomeFailure, state)
+		} else if s.Terminal {
+			sm.MarkTerminal(state)
+		}
+		if s.Deprecated != "" {
+			sm.MarkDeprecated(s.Deprecated, state)
+		}
+	}
+	for _, t := range d.Transitions {
+		sm.AddDescribedTransition(states[t.From], states[t.To], t.Label, t.Description, t.Tags...)
+		if t.Priority != 0 {
+			sm.SetTransitionPriority(states[t.From], states[t.To], t.Priority)
+		}
+		if t.Weight != 0 {
+			sm.SetTransitionWeight(states[t.From], states[t.To], t.Weight)
+		}
+		if t.Degraded {
+			sm.AddDegradedTransition(states[t.From], states[t.To])
+		}
+	}
+	for name, state := range d.EntryPoints {
+		if _, ok := sm.entryPoints[name]; !ok {
+			sm.AddEntryPoint(name, states[state], nil)
+		}
+	}
+	return states[d.Start], nil
+}
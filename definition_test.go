@@ -0,0 +1,77 @@
+package gust
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefinition_FromMachine(t *testing.T) {
+	m, _, _, _, _ := newDiamond()
+
+	d := m.Definition()
+	assert.Equal(t, []StateDefinition{{Name: "stateA"}, {Name: "stateB"}, {Name: "stateC"}, {Name: "stateD"}}, d.States)
+	assert.Equal(t, []TransitionDefinition{
+		{From: "stateA", To: "stateB"},
+		{From: "stateA", To: "stateC"},
+		{From: "stateB", To: "stateD"},
+		{From: "stateC", To: "stateD"},
+	}, d.Transitions)
+	assert.Equal(t, []string{"stateB", "stateC"}, d.Successors("stateA"))
+}
+
+func TestDefinition_JSONRoundTrip(t *testing.T) {
+	src := `{"name":"order","start":"a","states":[{"name":"a"},{"name":"b"}],"transitions":[{"from":"a","to":"b"}]}`
+
+	var d Definition
+	if !assert.Nil(t, json.Unmarshal([]byte(src), &d)) {
+		return
+	}
+	assert.Equal(t, "order", d.Name)
+	assert.Equal(t, "a", d.Start)
+	assert.Nil(t, d.Validate())
+
+	out, err := json.Marshal(&d)
+	assert.Nil(t, err)
+	assert.JSONEq(t, src, string(out))
+}
+
+func TestDefinition_Validate_ReportsAllProblems(t *testing.T) {
+	d := &Definition{
+		Start: "a",
+		States: []StateDefinition{
+			{Name: "a"}, {Name: "b"}, {Name: "b"}, {Name: ""}, {Name: "island"},
+		},
+		Transitions: []TransitionDefinition{
+			{From: "a", To: "b"},
+			{From: "a", To: "b"},
+			{From: "b", To: "nowhere"},
+		},
+	}
+
+	err := d.Validate()
+	assert.True(t, errors.Is(err, ErrInvalidDefinition))
+
+	var verr *ValidationError
+	if !assert.True(t, errors.As(err, &verr)) {
+		return
+	}
+	assert.Equal(t, []string{
+		"state b defined twice",
+		"state #4 has no name",
+		"transition a -> b defined twice",
+		"transition b -> nowhere to undefined state nowhere",
+		"state island unreachable from a",
+	}, verr.Problems)
+}
+
+func TestDefinition_Validate_UnknownStart(t *testing.T) {
+	d := &Definition{Start: "x", States: []StateDefinition{{Name: "a"}}}
+
+	var verr *ValidationError
+	if assert.True(t, errors.As(d.Validate(), &verr)) {
+		assert.Equal(t, []string{"start state x not defined"}, verr.Problems)
+	}
+}
@@ -0,0 +1,73 @@
+package gust
+
+// DegradedError is returned by runs that went on along degraded transitions
+// after states failed, see SetContinueOnError. It's ErrDegraded for errors.Is,
+// which also finds the errors of the states that failed in it, as does
+// errors.As.
+type DegradedError struct {
+	joinedError // in the order they happened, the run's own error last if it failed
+}
+
+// Is reports ErrDegraded, or any of the errors matching target
+func (e *DegradedError) Is(target error) bool {
+	return target == ErrDegraded || e.joinedError.Is(target)
+}
+
+// AddDegradedTransition declares the transition a run takes when the from
+// state fails and continue on error is enabled, see SetContinueOnError, e.g.
+// going on with the next cleanup step even though this one failed. A state has
+// one degraded transition at most, declaring another one from the same state
+// turns the earlier one into an ordinary transition. Definitions declaring
+// several fail Validate.
+func (sm *StateMachine) AddDegradedTransition(from, to State) {
+	sm.AddTransition(from, to)
+	ts := sm.transitions[keyOf(from)]
+	for i := range ts {
+		ts[i].Degraded = sameState(ts[i].To, to)
+	}
+}
+
+// SetContinueOnError has runs go on when a state with a degraded transition
+// fails, see AddDegradedTransition, for best effort workflows like cleanups.
+// The next state is given the cargo the failed state was given. The errors
+// are collected and the run returns them all in a *DegradedError once it
+// ends. Fatal errors, see Fatal, and failures of states without a degraded
+// transition still fail the run. Disabled by default.
+func (sm *StateMachine) SetContinueOnError(enabled bool) {
+	sm.continueOnError = enabled
+}
+
+// WithContinueOnError has runs go on along degraded transitions, like
+// SetContinueOnError(true)
+func WithContinueOnError() Option {
+	return func(sm *StateMachine) {
+		sm.SetContinueOnError(true)
+	}
+}
+
+// degradedNext returns where the run goes after the state failed with err,
+// ok is false if the run fails
+func (sm *StateMachine) degradedNext(state State, err error) (next State, ok bool) {
+	if !sm.continueOnError || IsFatal(err) {
+		return nil, false
+	}
+	for _, t := range sm.transitions[keyOf(state)] {
+		if t.Degraded {
+			return t.To, true
+		}
+	}
+	return nil, false
+}
+
+// degradedError returns the run's error with the errors of the states it went
+// on after, if any
+func (r *run) degradedError(err error) error {
+	if len(r.degraded) == 0 {
+		return err
+	}
+	errs := append([]error{}, r.degraded...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return &DegradedError{joinedError{errs}}
+}
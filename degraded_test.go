@@ -0,0 +1,91 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cleanupMachine(opts ...Option) (m *StateMachine, volumes, network *StateImpl, done State) {
+	m = NewStateMachine(opts...)
+	done = &StateImpl{name: "done"}
+	network = &StateImpl{name: "network", nextState: done}
+	volumes = &StateImpl{name: "volumes", nextState: network}
+	m.AddStates(volumes, network, done)
+	m.AddDegradedTransition(volumes, network)
+	m.AddDegradedTransition(network, done)
+	return m, volumes, network, done
+}
+
+func TestSetContinueOnError_CollectsErrors(t *testing.T) {
+	m, volumes, network, _ := cleanupMachine(WithContinueOnError())
+	errVolumes, errNetwork := errors.New("volume busy"), errors.New("network in use")
+	volumes.err = errVolumes
+	network.err = errNetwork
+
+	_, err := m.Execute(context.Background(), nil, volumes)
+
+	var degraded *DegradedError
+	if assert.True(t, errors.As(err, &degraded)) {
+		assert.Len(t, degraded.Errors, 2)
+	}
+	assert.True(t, errors.Is(err, ErrDegraded))
+	assert.True(t, errors.Is(err, errVolumes))
+	assert.True(t, errors.Is(err, errNetwork))
+	var runErr *RunError
+	assert.True(t, errors.As(err, &runErr))
+	assert.Equal(t, "volumes", runErr.State)
+}
+
+func TestSetContinueOnError_NoErrors(t *testing.T) {
+	m, volumes, _, _ := cleanupMachine(WithContinueOnError())
+
+	_, err := m.Execute(context.Background(), nil, volumes)
+
+	assert.Nil(t, err)
+}
+
+func TestSetContinueOnError_Disabled(t *testing.T) {
+	m, volumes, network, _ := cleanupMachine()
+	volumes.err = errors.New("volume busy")
+
+	_, err := m.Execute(context.Background(), nil, volumes)
+
+	assert.False(t, errors.Is(err, ErrDegraded))
+	assert.False(t, network.run)
+}
+
+func TestSetContinueOnError_Fatal(t *testing.T) {
+	m, volumes, _, _ := cleanupMachine(WithContinueOnError())
+	volumes.err = Fatal(errors.New("no credentials"))
+
+	_, err := m.Execute(context.Background(), nil, volumes)
+
+	assert.False(t, errors.Is(err, ErrDegraded))
+}
+
+func TestAddDegradedTransition_Definition(t *testing.T) {
+	m, _, _, _ := cleanupMachine()
+
+	d := m.Definition()
+
+	assert.True(t, d.Transitions[0].Degraded)
+	assert.True(t, d.Transitions[1].Degraded)
+}
+
+func TestDefinition_Validate_SeveralDegradedTransitions(t *testing.T) {
+	d := &Definition{
+		Start:  "volumes",
+		States: []StateDefinition{{Name: "volumes"}, {Name: "network"}, {Name: "dns"}},
+		Transitions: []TransitionDefinition{
+			{From: "volumes", To: "network", Degraded: true},
+			{From: "volumes", To: "dns", Degraded: true},
+		},
+	}
+
+	err := d.Validate()
+
+	assert.Contains(t, err.Error(), "state volumes has degraded transitions to network and dns")
+}
@@ -0,0 +1,104 @@
+package gust
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Deprecated when implemented by a state declares it deprecated, like
+// MarkDeprecated, with the reason returned. An empty reason declares nothing.
+type Deprecated interface {
+	Deprecation() string
+}
+
+// DeprecationObserver when implemented by an observer is also notified when a
+// run enters a deprecated state, to find the runs still taking a branch of the
+// workflow being retired
+type DeprecationObserver interface {
+	DeprecatedStateEntered(runID, prior, state, reason string)
+}
+
+// MarkDeprecated declares states deprecated, e.g. "replaced by
+// manual-review", the reason being given to DeprecationObservers when a run
+// enters them. Runs still go through deprecated states, and
+// Definition.Warnings lists the transitions into them.
+func (sm *StateMachine) MarkDeprecated(reason string, states ...State) {
+	if reason == "" {
+		reason = "deprecated"
+	}
+	if sm.deprecated == nil {
+		sm.deprecated = make(map[stateKey]string)
+	}
+	for _, s := range states {
+		sm.deprecated[keyOf(s)] = reason
+	}
+}
+
+// DeprecationOf returns why the state is deprecated, ok is false if it isn't
+func (sm *StateMachine) DeprecationOf(state State) (reason string, ok bool) {
+	if reason, ok := sm.deprecated[keyOf(state)]; ok {
+		return reason, true
+	}
+	if d, ok := state.(Deprecated); ok && d.Deprecation() != "" {
+		return d.Deprecation(), true
+	}
+	return "", false
+}
+
+// notifyDeprecated notifies DeprecationObservers if the state entered is deprecated
+func (sm *StateMachine) notifyDeprecated(r *run, prior, state State) {
+	observers := sm.loadObserverSet().deprecations
+	if len(observers) == 0 {
+		return
+	}
+	reason, ok := sm.DeprecationOf(state)
+	if !ok {
+		return
+	}
+	priorName := ""
+	if prior != nil {
+		priorName = sm.StateName(prior)
+	}
+	for _, observer := range observers {
+		do := observer.(DeprecationObserver)
+		sm.notify(observer, func() {
+			do.DeprecatedStateEntered(r.id, priorName, sm.StateName(state), reason)
+		})
+	}
+}
+
+// Warnings lists what's valid but should be looked at: transitions, the start
+// state and entry points leading into deprecated states. Unlike Validate's
+// problems, warnings don't keep a definition from being applied.
+func (d *Definition) Warnings() []string {
+	warnings := make([]string, 0)
+	deprecated := make(map[string]string)
+	for _, s := range d.States {
+		if s.Deprecated != "" {
+			deprecated[s.Name] = s.Deprecated
+		}
+	}
+	if len(deprecated) == 0 {
+		return warnings
+	}
+
+	if reason, ok := deprecated[d.Start]; ok {
+		warnings = append(warnings, fmt.Sprintf("start state %s is deprecated: %s", d.Start, reason))
+	}
+	entries := make([]string, 0, len(d.EntryPoints))
+	for name := range d.EntryPoints {
+		entries = append(entries, name)
+	}
+	sort.Strings(entries)
+	for _, name := range entries {
+		if reason, ok := deprecated[d.EntryPoints[name]]; ok {
+			warnings = append(warnings, fmt.Sprintf("entry point %s state %s is deprecated: %s", name, d.EntryPoints[name], reason))
+		}
+	}
+	for _, t := range d.Transitions {
+		if reason, ok := deprecated[t.To]; ok {
+			warnings = append(warnings, fmt.Sprintf("transition %s -> %s into deprecated state %s: %s", t.From, t.To, t.To, reason))
+		}
+	}
+	return warnings
+}
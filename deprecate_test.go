@@ -0,0 +1,103 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type deprecationObserver struct {
+	ObserverImpl
+	notices []string
+}
+
+func (o *deprecationObserver) DeprecatedStateEntered(runID, prior, state, reason string) {
+	o.notices = append(o.notices, prior+" -> "+state+": "+reason)
+}
+
+type oldState struct { // interface State, HaveName and Deprecated
+	StateImpl
+}
+
+func (s *oldState) Deprecation() string {
+	return "use manual"
+}
+
+func TestMarkDeprecated_ObserversNotified(t *testing.T) {
+	m := NewStateMachine()
+	c := &StateImpl{name: "c"}
+	b := &StateImpl{name: "b", nextState: c}
+	a := &StateImpl{name: "a", nextState: b}
+	m.AddStates(a, b, c)
+	m.MarkDeprecated("replaced by d", b)
+	o := &deprecationObserver{ObserverImpl: *NewObserverImpl()}
+	m.RegisterObservers(o)
+
+	_, err := m.Execute(context.Background(), nil, a)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a -> b: replaced by d"}, o.notices)
+	assert.True(t, c.run) // runs still go through
+}
+
+func TestDeprecated_DeclaredByState(t *testing.T) {
+	m := NewStateMachine()
+	old := &oldState{StateImpl{name: "old"}}
+	m.AddState(old)
+
+	reason, ok := m.DeprecationOf(old)
+	assert.True(t, ok)
+	assert.Equal(t, "use manual", reason)
+
+	_, ok = m.DeprecationOf(&StateImpl{name: "new"})
+	assert.False(t, ok)
+
+	m.MarkDeprecated("", old)
+	reason, _ = m.DeprecationOf(old)
+	assert.Equal(t, "deprecated", reason)
+}
+
+func TestDefinition_Warnings(t *testing.T) {
+	m := NewStateMachine()
+	a, b, c := &StateImpl{name: "a"}, &StateImpl{name: "b"}, &StateImpl{name: "c"}
+	m.AddStates(a, b, c)
+	m.AddTransition(a, b)
+	m.AddTransition(a, c)
+	m.AddEntryPoint("legacy", b, nil)
+	m.MarkDeprecated("replaced by c", b)
+
+	d := m.Definition()
+	d.Start = "a"
+	assert.Equal(t, "replaced by c", d.States[1].Deprecated)
+	assert.Nil(t, d.Validate())
+	assert.Equal(t, []string{
+		"entry point legacy state b is deprecated: replaced by c",
+		"transition a -> b into deprecated state b: replaced by c",
+	}, d.Warnings())
+
+	other := NewStateMachine()
+	other.AddStates(a, b, c)
+	_, err := other.ApplyDefinition(d)
+	assert.Nil(t, err)
+	reason, _ := other.DeprecationOf(b)
+	assert.Equal(t, "replaced by c", reason)
+}
+
+func TestDefinition_Validate_InvalidListsWarnings(t *testing.T) {
+	d := &Definition{
+		Start:       "a",
+		States:      []StateDefinition{{Name: "a"}, {Name: "b", Deprecated: "gone"}},
+		Transitions: []TransitionDefinition{{From: "a", To: "b"}, {From: "a", To: "x"}},
+	}
+
+	var verr *ValidationError
+	if assert.True(t, errors.As(d.Validate(), &verr)) {
+		assert.Equal(t, []string{"transition a -> b into deprecated state b: gone"}, verr.Warnings)
+	}
+}
+
+func TestDefinition_Warnings_NoneDeprecated(t *testing.T) {
+	d := &Definition{States: []StateDefinition{{Name: "a"}}}
+	assert.Empty(t, d.Warnings())
+}
@@ -0,0 +1,176 @@
+package gust
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefinitionDiff is how a definition changed from one version to the next
+type DefinitionDiff struct {
+	AddedStates        []string
+	RemovedStates      []string
+	AddedTransitions   []TransitionDefinition
+	RemovedTransitions []TransitionDefinition
+	ChangedTransitions []TransitionChange // between the same states, with other attributes
+
+	// OldStart and NewStart are the start states when the start state changed
+	OldStart string
+	NewStart string
+}
+
+// TransitionChange is a transition whose label, description, tags, priority,
+// weight or kind changed
+type TransitionChange struct {
+	Old, New TransitionDefinition
+}
+
+// Diff compares two versions of a definition. Added states and transitions
+// are listed in the order of the new definition, removed and changed ones in
+// the order of the old. A transition removed and added back between the same
+// states with other attributes is listed as changed.
+func Diff(old, new *Definition) *DefinitionDiff {
+	diff := &DefinitionDiff{
+		AddedStates:        stateNamesMissing(new.States, old.States),
+		RemovedStates:      stateNamesMissing(old.States, new.States),
+		AddedTransitions:   transitionsMissing(new.Transitions, old.Transitions),
+		RemovedTransitions: transitionsMissing(old.Transitions, new.Transitions),
+		ChangedTransitions: make([]TransitionChange, 0),
+	}
+	diff.pairChangedTransitions()
+
+	if old.Start != new.Start {
+		diff.OldStart = old.Start
+		diff.NewStart = new.Start
+	}
+	return diff
+}
+
+// stateNamesMissing returns the names of the states in a missing from b
+func stateNamesMissing(a, b []StateDefinition) []string {
+	in := make(map[string]bool, len(b))
+	for _, s := range b {
+		in[s.Name] = true
+	}
+	missing := make([]string, 0)
+	for _, s := range a {
+		if !in[s.Name] {
+			missing = append(missing, s.Name)
+		}
+	}
+	return missing
+}
+
+// transitionsMissing returns the transitions in a missing from b
+func transitionsMissing(a, b []TransitionDefinition) []TransitionDefinition {
+	in := make(map[transitionDefKey]bool, len(b))
+	for _, t := range b {
+		in[keyOfTransition(t)] = true
+	}
+	missing := make([]TransitionDefinition, 0)
+	for _, t := range a {
+		if !in[keyOfTransition(t)] {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
+
+// pairChangedTransitions moves the removed transitions added back between the
+// same states to the changed ones
+func (d *DefinitionDiff) pairChangedTransitions() {
+	removed := make([]TransitionDefinition, 0, len(d.RemovedTransitions))
+	for _, old := range d.RemovedTransitions {
+		paired := false
+		for i, new := range d.AddedTransitions {
+			if new.From == old.From && new.To == old.To {
+				d.ChangedTransitions = append(d.ChangedTransitions, TransitionChange{Old: old, New: new})
+				d.AddedTransitions = append(d.AddedTransitions[:i:i], d.AddedTransitions[i+1:]...)
+				paired = true
+				break
+			}
+		}
+		if !paired {
+			removed = append(removed, old)
+		}
+	}
+	d.RemovedTransitions = removed
+}
+
+// transitionDefKey is a TransitionDefinition made comparable
+type transitionDefKey struct {
+	from, to, label, description, tags string
+	guarded                            bool
+	priority                           int
+	weight                             float64
+	degraded                           bool
+}
+
+func keyOfTransition(t TransitionDefinition) transitionDefKey {
+	return transitionDefKey{from: t.From, to: t.To, label: t.Label, description: t.Description, tags: strings.Join(t.Tags, "\x00"),
+		guarded: t.Guarded, priority: t.Priority, weight: t.Weight, degraded: t.Degraded}
+}
+
+// Empty tells whether nothing changed
+func (d *DefinitionDiff) Empty() bool {
+	return len(d.AddedStates) == 0 && len(d.RemovedStates) == 0 &&
+		len(d.AddedTransitions) == 0 && len(d.RemovedTransitions) == 0 && len(d.ChangedTransitions) == 0 &&
+		d.OldStart == d.NewStart
+}
+
+// String lists the changes a line each, additions prefixed with +, removals
+// with - and changes with ~ followed by the attributes changed, e.g.
+//
+//	start: new -> created
+//	- state new
+//	+ state created
+//	+ transition created -> paid
+//	~ transition paid -> shipped: label "ship" -> "dispatch"
+func (d *DefinitionDiff) String() string {
+	var b strings.Builder
+	if d.OldStart != d.NewStart {
+		fmt.Fprintf(&b, "start: %s -> %s\n", orNone(d.OldStart), orNone(d.NewStart))
+	}
+	for _, s := range d.RemovedStates {
+		fmt.Fprintf(&b, "- state %s\n", s)
+	}
+	for _, s := range d.AddedStates {
+		fmt.Fprintf(&b, "+ state %s\n", s)
+	}
+	for _, t := range d.RemovedTransitions {
+		fmt.Fprintf(&b, "- transition %s -> %s\n", t.From, t.To)
+	}
+	for _, t := range d.AddedTransitions {
+		fmt.Fprintf(&b, "+ transition %s -> %s\n", t.From, t.To)
+	}
+	for _, c := range d.ChangedTransitions {
+		fmt.Fprintf(&b, "~ transition %s -> %s: %s\n", c.Old.From, c.Old.To, strings.Join(c.Attributes(), ", "))
+	}
+	return b.String()
+}
+
+// Attributes describes the attributes changed, one each, as in
+// label "ship" -> "dispatch"
+func (c TransitionChange) Attributes() []string {
+	changes := make([]string, 0)
+	add := func(name, old, new string) {
+		if old != new {
+			changes = append(changes, fmt.Sprintf("%s %s -> %s", name, old, new))
+		}
+	}
+	add("label", strconv.Quote(c.Old.Label), strconv.Quote(c.New.Label))
+	add("description", strconv.Quote(c.Old.Description), strconv.Quote(c.New.Description))
+	add("tags", fmt.Sprint(c.Old.Tags), fmt.Sprint(c.New.Tags))
+	add("guarded", strconv.FormatBool(c.Old.Guarded), strconv.FormatBool(c.New.Guarded))
+	add("priority", strconv.Itoa(c.Old.Priority), strconv.Itoa(c.New.Priority))
+	add("weight", strconv.FormatFloat(c.Old.Weight, 'g', -1, 64), strconv.FormatFloat(c.New.Weight, 'g', -1, 64))
+	add("degraded", strconv.FormatBool(c.Old.Degraded), strconv.FormatBool(c.New.Degraded))
+	return changes
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
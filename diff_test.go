@@ -0,0 +1,71 @@
+package gust
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff_Changes(t *testing.T) {
+	old := &Definition{
+		Start:  "new",
+		States: []StateDefinition{{Name: "new"}, {Name: "paid"}},
+		Transitions: []TransitionDefinition{
+			{From: "new", To: "paid"},
+		},
+	}
+	new := &Definition{
+		Start:  "created",
+		States: []StateDefinition{{Name: "created"}, {Name: "paid"}, {Name: "shipped"}},
+		Transitions: []TransitionDefinition{
+			{From: "created", To: "paid"},
+			{From: "paid", To: "shipped"},
+		},
+	}
+
+	diff := Diff(old, new)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []string{"created", "shipped"}, diff.AddedStates)
+	assert.Equal(t, []string{"new"}, diff.RemovedStates)
+	assert.Equal(t, []TransitionDefinition{{From: "created", To: "paid"}, {From: "paid", To: "shipped"}}, diff.AddedTransitions)
+	assert.Equal(t, []TransitionDefinition{{From: "new", To: "paid"}}, diff.RemovedTransitions)
+	assert.Equal(t, "start: new -> created\n"+
+		"- state new\n"+
+		"+ state created\n"+
+		"+ state shipped\n"+
+		"- transition new -> paid\n"+
+		"+ transition created -> paid\n"+
+		"+ transition paid -> shipped\n", diff.String())
+}
+
+func TestDiff_Same_Empty(t *testing.T) {
+	m, _, _, _, _ := newDiamond()
+
+	diff := Diff(m.Definition(), m.Definition())
+	assert.True(t, diff.Empty())
+	assert.Equal(t, "", diff.String())
+}
+
+func TestDiff_StartRemoved(t *testing.T) {
+	diff := Diff(&Definition{Start: "a"}, &Definition{})
+	assert.False(t, diff.Empty())
+	assert.Equal(t, "start: a -> (none)\n", diff.String())
+}
+
+func TestDiff_TransitionAttributesChanged(t *testing.T) {
+	old := &Definition{
+		States:      []StateDefinition{{Name: "paid"}, {Name: "shipped"}},
+		Transitions: []TransitionDefinition{{From: "paid", To: "shipped", Label: "ship", Priority: 1}},
+	}
+	new := &Definition{
+		States:      []StateDefinition{{Name: "paid"}, {Name: "shipped"}},
+		Transitions: []TransitionDefinition{{From: "paid", To: "shipped", Label: "dispatch", Tags: []string{"audit"}, Priority: 1}},
+	}
+
+	diff := Diff(old, new)
+	assert.False(t, diff.Empty())
+	assert.Empty(t, diff.AddedTransitions)
+	assert.Empty(t, diff.RemovedTransitions)
+	assert.Equal(t, []TransitionChange{{Old: old.Transitions[0], New: new.Transitions[0]}}, diff.ChangedTransitions)
+	assert.Equal(t, `~ transition paid -> shipped: label "ship" -> "dispatch", tags [] -> [audit]`+"\n", diff.String())
+}
@@ -0,0 +1,390 @@
+package gust
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// dotStartNode is the invisible node pointing at the start state in DOT output
+const dotStartNode = "__start"
+
+// DOT renders the definition as a Graphviz digraph. The start state, if any,
+// is pointed at by an edge from a point shaped node named __start. Terminal
+// states are double circles, with an outcome attribute if they fail, and
+// deprecated states dashed, with the reason in a deprecated attribute. State
+// and transition descriptions are tooltips, transition tags a comma separated
+// tags attribute. Guarded transitions have guarded and priority attributes,
+// weighted ones a routing_weight, and degraded ones are dashed with a degraded
+// attribute. The version is a graph attribute. Entry points aren't rendered.
+func (d *Definition) DOT() string {
+	var b strings.Builder
+
+	name := d.Name
+	if name == "" {
+		name = "gust"
+	}
+	fmt.Fprintf(&b, "digraph %s {\n", dotID(name))
+	if d.Version != "" {
+		fmt.Fprintf(&b, "\tversion=%q;\n", d.Version)
+	}
+	if d.Description != "" {
+		fmt.Fprintf(&b, "\ttooltip=%q;\n", d.Description)
+	}
+	if d.Start != "" {
+		fmt.Fprintf(&b, "\t%s [shape=point];\n", dotStartNode)
+	}
+	for _, s := range d.States {
+		writeDOTStatement(&b, dotID(s.Name), dotStateAttributes(s))
+	}
+	if d.Start != "" {
+		fmt.Fprintf(&b, "\t%s -> %s;\n", dotStartNode, dotID(d.Start))
+	}
+	for _, t := range d.Transitions {
+		writeDOTStatement(&b, dotID(t.From)+" -> "+dotID(t.To), dotTransitionAttributes(t))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// writeDOTStatement writes a node or edge statement with its attributes
+func writeDOTStatement(b *strings.Builder, stmt string, attrs []string) {
+	if len(attrs) > 0 {
+		fmt.Fprintf(b, "\t%s [%s];\n", stmt, strings.Join(attrs, ", "))
+	} else {
+		fmt.Fprintf(b, "\t%s;\n", stmt)
+	}
+}
+
+func dotStateAttributes(s StateDefinition) []string {
+	attrs := make([]string, 0)
+	if s.Terminal {
+		attrs = append(attrs, "shape=doublecircle")
+	}
+	if s.Outcome != "" {
+		attrs = append(attrs, fmt.Sprintf("outcome=%q", s.Outcome))
+	}
+	if s.Deprecated != "" {
+		attrs = append(attrs, "style=dashed", fmt.Sprintf("deprecated=%q", s.Deprecated))
+	}
+	if s.Description != "" {
+		attrs = append(attrs, fmt.Sprintf("tooltip=%q", s.Description))
+	}
+	return attrs
+}
+
+func dotTransitionAttributes(t TransitionDefinition) []string {
+	attrs := make([]string, 0)
+	if t.Label != "" {
+		attrs = append(attrs, fmt.Sprintf("label=%q", t.Label))
+	}
+	if t.Description != "" {
+		attrs = append(attrs, fmt.Sprintf("tooltip=%q", t.Description))
+	}
+	if len(t.Tags) > 0 {
+		attrs = append(attrs, fmt.Sprintf("tags=%q", strings.Join(t.Tags, ",")))
+	}
+	if t.Guarded {
+		attrs = append(attrs, "guarded=true")
+	}
+	if t.Priority != 0 {
+		attrs = append(attrs, fmt.Sprintf("priority=%d", t.Priority))
+	}
+	if t.Weight != 0 {
+		attrs = append(attrs, fmt.Sprintf("routing_weight=%s", strconv.FormatFloat(t.Weight, 'g', -1, 64)))
+	}
+	if t.Degraded {
+		attrs = append(attrs, "style=dashed", "degraded=true")
+	}
+	return attrs
+}
+
+// DOT renders the machine's registered states and declared transitions as a
+// Graphviz digraph, see Definition.DOT
+func (sm *StateMachine) DOT() string {
+	return sm.Definition().DOT()
+}
+
+// dotID quotes the identifier if needed
+func dotID(id string) string {
+	if id == "" {
+		return `""`
+	}
+	for i, r := range id {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return strconv.Quote(id)
+		}
+	}
+	return id
+}
+
+// ParseDOT reads a definition from a Graphviz digraph. Every node becomes a
+// state and every edge a transition, edge chains like a -> b -> c included.
+// An edge from a node named __start marks the start state, as written by
+// Definition.DOT. The attributes written by Definition.DOT are read back, a
+// doublecircle shape marking a terminal state, other attributes are read past.
+// Subgraphs aren't supported.
+func ParseDOT(r io.Reader) (*Definition, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotParser{tokens: dotTokenize(string(data))}
+	return p.parse()
+}
+
+type dotParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *dotParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *dotParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *dotParser) expect(token string) error {
+	if t := p.next(); t != token {
+		return fmt.Errorf("%w: expected %q, found %q", ErrInvalidDOT, token, t)
+	}
+	return nil
+}
+
+func (p *dotParser) parse() (*Definition, error) {
+	d := &Definition{
+		States:      make([]StateDefinition, 0),
+		Transitions: make([]TransitionDefinition, 0),
+	}
+	defined := make(map[string]int) // indexes in d.States
+	define := func(name string) {
+		if _, ok := defined[name]; name != dotStartNode && !ok {
+			defined[name] = len(d.States)
+			d.States = append(d.States, StateDefinition{Name: name})
+		}
+	}
+
+	if strings.EqualFold(p.peek(), "strict") {
+		p.next()
+	}
+	if t := p.next(); !strings.EqualFold(t, "digraph") {
+		return nil, fmt.Errorf("%w: expected digraph, found %q", ErrInvalidDOT, t)
+	}
+	if p.peek() != "{" {
+		d.Name = dotUnquote(p.next())
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.next()
+		switch {
+		case t == "":
+			return nil, fmt.Errorf("%w: missing }", ErrInvalidDOT)
+		case t == "}":
+			return d, nil
+		case t == ";":
+			continue
+		case t == "{" || strings.EqualFold(t, "subgraph"):
+			return nil, fmt.Errorf("%w: subgraphs aren't supported", ErrInvalidDOT)
+		case strings.EqualFold(t, "graph") || strings.EqualFold(t, "node") || strings.EqualFold(t, "edge"):
+			if err := p.skipAttributes(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if p.peek() == "=" { // graph attribute
+			p.next()
+			switch value := dotUnquote(p.next()); t {
+			case "version":
+				d.Version = value
+			case "tooltip":
+				d.Description = value
+			}
+			continue
+		}
+
+		nodes := []string{dotUnquote(t)}
+		for p.peek() == "->" {
+			p.next()
+			n := p.next()
+			if n == "" || strings.ContainsAny(n, "{}[];=") {
+				return nil, fmt.Errorf("%w: expected node after ->, found %q", ErrInvalidDOT, n)
+			}
+			nodes = append(nodes, dotUnquote(n))
+		}
+		if p.peek() == "--" {
+			return nil, fmt.Errorf("%w: undirected edges aren't supported", ErrInvalidDOT)
+		}
+		attrs, err := p.attributes()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, n := range nodes {
+			define(n)
+		}
+		if i, ok := defined[nodes[0]]; ok && len(nodes) == 1 {
+			s := &d.States[i]
+			for name, value := range attrs {
+				switch name {
+				case "shape":
+					s.Terminal = value == "doublecircle"
+				case "outcome":
+					s.Outcome = value
+				case "deprecated":
+					s.Deprecated = value
+				case "tooltip":
+					s.Description = value
+				}
+			}
+		}
+		for i := 1; i < len(nodes); i++ {
+			if nodes[i-1] == dotStartNode {
+				d.Start = nodes[i]
+				continue
+			}
+			t, err := dotTransition(nodes[i-1], nodes[i], attrs)
+			if err != nil {
+				return nil, err
+			}
+			d.Transitions = append(d.Transitions, t)
+		}
+	}
+}
+
+// dotTransition is the transition of an edge with the given attributes
+func dotTransition(from, to string, attrs map[string]string) (TransitionDefinition, error) {
+	t := TransitionDefinition{From: from, To: to, Label: attrs["label"], Description: attrs["tooltip"],
+		Guarded: attrs["guarded"] == "true", Degraded: attrs["degraded"] == "true"}
+	if tags := attrs["tags"]; tags != "" {
+		t.Tags = strings.Split(tags, ",")
+	}
+	var err error
+	if priority := attrs["priority"]; priority != "" {
+		if t.Priority, err = strconv.Atoi(priority); err != nil {
+			return t, fmt.Errorf("%w: priority of %s -> %s: %v", ErrInvalidDOT, from, to, err)
+		}
+	}
+	if weight := attrs["routing_weight"]; weight != "" {
+		if t.Weight, err = strconv.ParseFloat(weight, 64); err != nil {
+			return t, fmt.Errorf("%w: routing_weight of %s -> %s: %v", ErrInvalidDOT, from, to, err)
+		}
+	}
+	return t, nil
+}
+
+// skipAttributes reads past an attribute list like [shape=point, label="x"] if there is one
+func (p *dotParser) skipAttributes() error {
+	_, err := p.attributes()
+	return err
+}
+
+// attributes reads an attribute list like [shape=point, label="x"] if there
+// is one, returning the attributes by name
+func (p *dotParser) attributes() (map[string]string, error) {
+	attrs := make(map[string]string)
+	for p.peek() == "[" {
+		p.next()
+		for {
+			t := p.next()
+			if t == "" {
+				return nil, fmt.Errorf("%w: missing ]", ErrInvalidDOT)
+			}
+			if t == "]" {
+				break
+			}
+			if p.peek() == "=" {
+				p.next()
+				attrs[dotUnquote(t)] = dotUnquote(p.next())
+			}
+		}
+	}
+	return attrs, nil
+}
+
+// dotTokenize splits DOT source into identifiers, quoted strings and
+// punctuation, dropping comments
+func dotTokenize(src string) []string {
+	tokens := make([]string, 0)
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '#' || (r == '/' && i+1 < len(rs) && rs[i+1] == '/'):
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			i += 2
+			for i+1 < len(rs) && !(rs[i] == '*' && rs[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '"':
+			j := i + 1
+			for j < len(rs) && rs[j] != '"' {
+				if rs[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(rs) {
+				j = len(rs) - 1
+			}
+			tokens = append(tokens, string(rs[i:j+1]))
+			i = j + 1
+		case r == '-' && i+1 < len(rs) && (rs[i+1] == '>' || rs[i+1] == '-'):
+			tokens = append(tokens, string(rs[i:i+2]))
+			i += 2
+		case strings.ContainsRune("{}[];=,", r):
+			tokens = append(tokens, string(r))
+			i++
+		default:
+			j := i
+			for j < len(rs) && !unicode.IsSpace(rs[j]) && !strings.ContainsRune("{}[];=,\"#", rs[j]) &&
+				!(rs[j] == '-' && j+1 < len(rs) && (rs[j+1] == '>' || rs[j+1] == '-')) {
+				j++
+			}
+			tokens = append(tokens, string(rs[i:j]))
+			i = j
+		}
+	}
+	return tokens
+}
+
+// dotUnquote removes the quotes of a quoted DOT identifier
+func dotUnquote(id string) string {
+	if len(id) >= 2 && id[0] == '"' && id[len(id)-1] == '"' {
+		if s, err := strconv.Unquote(id); err == nil {
+			return s
+		}
+		return id[1 : len(id)-1]
+	}
+	return id
+}
+
+// ImportDOT reads a Graphviz digraph with ParseDOT and applies it to the
+// machine with ApplyDefinition, binding nodes to the registered states of the
+// same name. It returns the start state marked with __start, if any.
+func (sm *StateMachine) ImportDOT(r io.Reader) (startState State, err error) {
+	d, err := ParseDOT(r)
+	if err != nil {
+		return nil, err
+	}
+	return sm.ApplyDefinition(d)
+}
@@ -0,0 +1,230 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefinition_DOT(t *testing.T) {
+	d := &Definition{
+		Name:        "order",
+		Start:       "new",
+		States:      []StateDefinition{{Name: "new"}, {Name: "in review"}},
+		Transitions: []TransitionDefinition{{From: "new", To: "in review"}},
+	}
+
+	assert.Equal(t, `digraph order {
+	__start [shape=point];
+	new;
+	"in review";
+	__start -> new;
+	new -> "in review";
+}
+`, d.DOT())
+}
+
+func TestStateMachine_DOT(t *testing.T) {
+	b := &StateImpl{name: "stateB"}
+	a := &StateImpl{name: "stateA"}
+
+	m := NewStateMachine()
+	m.AddStates(a, b)
+	m.AddTransition(a, b)
+
+	assert.Equal(t, "digraph gust {\n\tstateA;\n\tstateB;\n\tstateA -> stateB;\n}\n", m.DOT())
+}
+
+func TestParseDOT_RoundTrip(t *testing.T) {
+	d := &Definition{
+		Name:   "order",
+		Start:  "new",
+		States: []StateDefinition{{Name: "new"}, {Name: "in review"}, {Name: "done"}},
+		Transitions: []TransitionDefinition{
+			{From: "new", To: "in review"},
+			{From: "in review", To: "done"},
+			{From: "in review", To: "new"},
+		},
+	}
+
+	parsed, err := ParseDOT(strings.NewReader(d.DOT()))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, d, parsed)
+}
+
+func TestParseDOT_HandWritten(t *testing.T) {
+	src := `
+// an order workflow
+strict digraph "order flow" {
+	rankdir=LR;
+	node [shape=box];
+	a -> b -> c [label="go", color=red]
+	a -> d; /* a comment */
+	# another comment
+	e
+}
+`
+	d, err := ParseDOT(strings.NewReader(src))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "order flow", d.Name)
+	assert.Equal(t, "", d.Start)
+	assert.Equal(t, []StateDefinition{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}, d.States)
+	assert.Equal(t, []TransitionDefinition{
+		{From: "a", To: "b", Label: "go"}, // edge attributes apply to every edge of the statement
+		{From: "b", To: "c", Label: "go"},
+		{From: "a", To: "d"},
+	}, d.Transitions)
+}
+
+func TestParseDOT_Invalid(t *testing.T) {
+	for _, src := range []string{
+		"graph g { a -- b }",
+		"digraph g { a -> b ",
+		"digraph g { a -- b }",
+		"digraph g { subgraph s { a } }",
+		"digraph g { a -> ; }",
+	} {
+		_, err := ParseDOT(strings.NewReader(src))
+		assert.True(t, errors.Is(err, ErrInvalidDOT), src)
+	}
+}
+
+func TestImportDOT_BindsRegisteredStates(t *testing.T) {
+	d := &StateImpl{name: "stateD"}
+	c := &StateImpl{name: "stateC", nextState: d}
+	b := &StateImpl{name: "stateB", nextState: d}
+	a := &StateImpl{name: "stateA", nextState: c}
+
+	m := NewStateMachine()
+	m.AddStates(a, b, c, d)
+
+	start, err := m.ImportDOT(strings.NewReader(`digraph {
+		__start [shape=point];
+		__start -> stateA;
+		stateA -> stateB -> stateD;
+		stateA -> stateC -> stateD;
+	}`))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, a, start)
+	assert.Equal(t, []State{b, c}, m.AvailableTransitions(a))
+
+	result, err := m.Execute(context.Background(), nil, start)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stateA", "stateC", "stateD"}, result.Path)
+}
+
+func TestImportDOT_UnregisteredNode_Error(t *testing.T) {
+	m := NewStateMachine()
+	m.AddState(&StateImpl{name: "stateA"})
+
+	_, err := m.ImportDOT(strings.NewReader(`digraph { stateA -> stateB -> stateC }`))
+	assert.True(t, errors.Is(err, ErrUnknownState))
+	assert.EqualError(t, err, "invalid target state: no registered state named stateB, stateC")
+	assert.Len(t, m.AvailableTransitions(&StateImpl{name: "stateA"}), 1) // nothing declared
+}
+
+func TestApplyDefinition_UnregisteredState_MachineUntouched(t *testing.T) {
+	m := NewStateMachine()
+	a := &StateImpl{name: "a"}
+	m.AddState(a)
+
+	_, err := m.ApplyDefinition(&Definition{
+		States:      []StateDefinition{{Name: "a", Terminal: true, Deprecated: "gone"}, {Name: "b"}},
+		Transitions: []TransitionDefinition{{From: "b", To: "a"}},
+	})
+	assert.True(t, errors.Is(err, ErrUnknownState))
+	assert.False(t, m.IsTerminal(a))
+	_, deprecated := m.DeprecationOf(a)
+	assert.False(t, deprecated)
+}
+
+func TestApplyDefinition_Invalid_Error(t *testing.T) {
+	m := NewStateMachine()
+
+	_, err := m.ApplyDefinition(&Definition{Start: "x"})
+	assert.True(t, errors.Is(err, ErrInvalidDefinition))
+}
+
+func TestDOT_Labels_RoundTrip(t *testing.T) {
+	d := &Definition{
+		Start:       "review",
+		States:      []StateDefinition{{Name: "review"}, {Name: "fulfilled"}},
+		Transitions: []TransitionDefinition{{From: "review", To: "fulfilled", Label: "approved"}},
+	}
+	assert.Contains(t, d.DOT(), `review -> fulfilled [label="approved"];`)
+	assert.Contains(t, d.Mermaid(), "s0 --> s1: approved")
+
+	parsed, err := ParseDOT(strings.NewReader(d.DOT()))
+	if assert.Nil(t, err) {
+		assert.Equal(t, d.Transitions, parsed.Transitions)
+	}
+}
+
+func TestDOT_DescriptionsAndTags_RoundTrip(t *testing.T) {
+	d := &Definition{
+		States: []StateDefinition{{Name: "review"}, {Name: "fulfilled"}},
+		Transitions: []TransitionDefinition{{
+			From: "review", To: "fulfilled", Label: "approved",
+			Description: "A reviewer approved", Tags: []string{"manual", "audit"},
+		}},
+	}
+	assert.Contains(t, d.DOT(), `review -> fulfilled [label="approved", tooltip="A reviewer approved", tags="manual,audit"];`)
+
+	parsed, err := ParseDOT(strings.NewReader(d.DOT()))
+	if assert.Nil(t, err) {
+		assert.Equal(t, d.Transitions, parsed.Transitions)
+	}
+}
+
+func TestDOT_TerminalsDeprecationAndRouting_RoundTrip(t *testing.T) {
+	d := &Definition{
+		Name:        "order",
+		Version:     "2",
+		Description: "takes orders",
+		Start:       "new",
+		States: []StateDefinition{
+			{Name: "new", Description: "just placed"},
+			{Name: "legacy", Deprecated: "use new"},
+			{Name: "paid", Terminal: true},
+			{Name: "lost", Terminal: true, Outcome: "failure"},
+		},
+		Transitions: []TransitionDefinition{
+			{From: "new", To: "paid", Guarded: true, Priority: 2},
+			{From: "new", To: "legacy", Weight: 0.25},
+			{From: "new", To: "lost", Degraded: true},
+			{From: "legacy", To: "paid"},
+		},
+	}
+
+	dot := d.DOT()
+	assert.Contains(t, dot, "\tpaid [shape=doublecircle];\n")
+	assert.Contains(t, dot, "\tnew -> lost [style=dashed, degraded=true];\n")
+	parsed, err := ParseDOT(strings.NewReader(dot))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, d, parsed)
+	assert.Nil(t, parsed.Validate())
+}
+
+func TestParseDOT_TerminalShape_Validated(t *testing.T) {
+	d, err := ParseDOT(strings.NewReader(`digraph {
+		__start -> a -> b;
+		a -> c;
+		b [shape=doublecircle];
+	}`))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, d.States[1].Terminal)
+	assert.True(t, errors.Is(d.Validate(), ErrInvalidDefinition)) // c is a dead end
+}
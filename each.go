@@ -0,0 +1,99 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EachError is returned by RunEach when some of the runs failed
+type EachError struct {
+	Runs   int           // the number of runs
+	Errors map[int]error // the errors of the failed runs, by index of their cargo
+}
+
+func (e *EachError) Error() string {
+	indexes := e.failed()
+	msgs := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		msgs = append(msgs, fmt.Sprintf("run %d: %v", i, e.Errors[i]))
+	}
+	return fmt.Sprintf("%d of %d runs failed: %s", len(indexes), e.Runs, strings.Join(msgs, "; "))
+}
+
+// Is reports whether any of the runs failed with target
+func (e *EachError) Is(target error) bool {
+	return e.joined().Is(target)
+}
+
+// As finds the error of the first failed run, by index, matching target
+func (e *EachError) As(target interface{}) bool {
+	return e.joined().As(target)
+}
+
+// joined returns the errors of the failed runs in order
+func (e *EachError) joined() joinedError {
+	indexes := e.failed()
+	errs := make([]error, 0, len(indexes))
+	for _, i := range indexes {
+		errs = append(errs, e.Errors[i])
+	}
+	return joinedError{errs}
+}
+
+// failed returns the indexes of the failed runs in order
+func (e *EachError) failed() []int {
+	indexes := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// RunEach runs the machine from the start state once for every cargo, at most
+// concurrency runs at a time, all at once if concurrency is 0 or less. The
+// runs are independent, one failing doesn't stop the others. The results are
+// in the order of the cargos. If runs failed the error is an *EachError.
+func (sm *StateMachine) RunEach(cargos []interface{}, startState State, concurrency int) ([]*Result, error) {
+	return sm.RunEachContext(context.Background(), cargos, startState, concurrency)
+}
+
+// RunEachContext is like RunEach, runs not started yet once ctx is done fail
+// with an *AbortedError like running ones
+func (sm *StateMachine) RunEachContext(ctx context.Context, cargos []interface{}, startState State, concurrency int) ([]*Result, error) {
+	if concurrency <= 0 || concurrency > len(cargos) {
+		concurrency = len(cargos)
+	}
+
+	results := make([]*Result, len(cargos))
+	indexes := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], _ = sm.Execute(ctx, cargos[i], startState)
+			}
+		}()
+	}
+	for i := range cargos {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	errs := make(map[int]error)
+	for i, result := range results {
+		if result.Err != nil {
+			errs[i] = result.Err
+		}
+	}
+	if len(errs) > 0 {
+		return results, &EachError{Runs: len(cargos), Errors: errs}
+	}
+	return results, nil
+}
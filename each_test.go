@@ -0,0 +1,74 @@
+package gust
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunEach_ResultsInOrder_ConcurrencyBounded(t *testing.T) {
+	m := NewStateMachine()
+	lock := &sync.Mutex{}
+	running, maxRunning := 0, 0
+	double := NewFuncState("double", func(cargo interface{}) (State, interface{}, error) {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return nil, cargo.(int) * 2, nil
+	})
+	m.AddState(double)
+
+	results, err := m.RunEach([]interface{}{1, 2, 3, 4, 5, 6}, double, 2)
+	assert.Nil(t, err)
+	for i, result := range results {
+		assert.Equal(t, (i+1)*2, result.Cargo)
+	}
+	assert.True(t, maxRunning <= 2)
+}
+
+func TestRunEach_SomeFail_EachError(t *testing.T) {
+	errOdd := errors.New("odd")
+	m := NewStateMachine()
+	even := NewFuncState("even", func(cargo interface{}) (State, interface{}, error) {
+		if cargo.(int)%2 == 1 {
+			return nil, cargo, errOdd
+		}
+		return nil, cargo, nil
+	})
+	m.AddState(even)
+
+	results, err := m.RunEach([]interface{}{1, 2, 3}, even, 0)
+	assert.Len(t, results, 3)
+	var eachErr *EachError
+	if assert.True(t, errors.As(err, &eachErr)) {
+		assert.Equal(t, 3, eachErr.Runs)
+		assert.Len(t, eachErr.Errors, 2)
+		assert.Contains(t, err.Error(), "2 of 3 runs failed: run 0: ")
+	}
+	assert.True(t, errors.Is(err, errOdd))
+	var runErr *RunError
+	if assert.True(t, errors.As(err, &runErr)) {
+		assert.Equal(t, "even", runErr.State)
+	}
+	assert.Nil(t, results[1].Err)
+}
+
+func TestRunEach_NoCargos(t *testing.T) {
+	m := NewStateMachine()
+	s := &StateImpl{name: "s"}
+	m.AddState(s)
+
+	results, err := m.RunEach(nil, s, 4)
+	assert.Nil(t, err)
+	assert.Len(t, results, 0)
+}
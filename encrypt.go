@@ -0,0 +1,74 @@
+package gust
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// EncryptedCodec wraps a codec with AES-GCM authenticated encryption, so
+// persisted cargo and snapshots can't be read or tampered with without the
+// key. Create it with NewEncryptedCodec.
+type EncryptedCodec struct {
+	codec   Codec
+	aead    cipher.AEAD
+	oldAEAD []cipher.AEAD
+}
+
+// NewEncryptedCodec returns a codec encrypting what codec encodes with key,
+// which must be 16, 24 or 32 bytes long for AES-128, AES-192 or AES-256.
+// Data encrypted with any of the old keys can still be decrypted, so keys can
+// be rotated.
+func NewEncryptedCodec(codec Codec, key []byte, oldKeys ...[]byte) (*EncryptedCodec, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	c := &EncryptedCodec{codec: codec, aead: aead, oldAEAD: make([]cipher.AEAD, 0, len(oldKeys))}
+	for _, k := range oldKeys {
+		aead, err := newGCM(k)
+		if err != nil {
+			return nil, err
+		}
+		c.oldAEAD = append(c.oldAEAD, aead)
+	}
+	return c, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Marshal encodes v and encrypts it, a random nonce is put in front
+func (c *EncryptedCodec) Marshal(v interface{}) ([]byte, error) {
+	plain, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plain)+c.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+// Unmarshal decrypts data and decodes it into v. It fails with ErrDecryption
+// if no key decrypts it, it was encrypted with another key or altered.
+func (c *EncryptedCodec) Unmarshal(data []byte, v interface{}) error {
+	for _, aead := range append([]cipher.AEAD{c.aead}, c.oldAEAD...) {
+		if len(data) < aead.NonceSize() {
+			break
+		}
+		nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if plain, err := aead.Open(nil, nonce, sealed, nil); err == nil {
+			return c.codec.Unmarshal(plain, v)
+		}
+	}
+	return fmt.Errorf("%w: wrong key or altered data", ErrDecryption)
+}
@@ -0,0 +1,100 @@
+package gust
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	encryptionKey    = bytes.Repeat([]byte{1}, 32)
+	oldEncryptionKey = bytes.Repeat([]byte{2}, 16)
+)
+
+func TestEncryptedCodec_RoundTrip_NoPlaintext(t *testing.T) {
+	codec, err := NewEncryptedCodec(JSONCodec{}, encryptionKey)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	data, err := codec.Marshal(order{ID: "alice@example.com", Total: 10})
+	assert.Nil(t, err)
+	assert.False(t, bytes.Contains(data, []byte("alice")))
+
+	again, _ := codec.Marshal(order{ID: "alice@example.com", Total: 10})
+	assert.NotEqual(t, data, again) // random nonce
+
+	var o order
+	assert.Nil(t, codec.Unmarshal(data, &o))
+	assert.Equal(t, order{ID: "alice@example.com", Total: 10}, o)
+}
+
+func TestEncryptedCodec_Tampered_Error(t *testing.T) {
+	codec, _ := NewEncryptedCodec(JSONCodec{}, encryptionKey)
+	data, _ := codec.Marshal(order{ID: "o1"})
+	data[len(data)-1] ^= 1
+
+	var o order
+	err := codec.Unmarshal(data, &o)
+	assert.True(t, errors.Is(err, ErrDecryption))
+
+	assert.True(t, errors.Is(codec.Unmarshal([]byte{1}, &o), ErrDecryption))
+}
+
+func TestEncryptedCodec_KeyRotation(t *testing.T) {
+	old, _ := NewEncryptedCodec(JSONCodec{}, oldEncryptionKey)
+	data, _ := old.Marshal(order{ID: "o1"})
+
+	rotated, err := NewEncryptedCodec(JSONCodec{}, encryptionKey, oldEncryptionKey)
+	if !assert.Nil(t, err) {
+		return
+	}
+	var o order
+	assert.Nil(t, rotated.Unmarshal(data, &o))
+	assert.Equal(t, "o1", o.ID)
+
+	other, _ := NewEncryptedCodec(JSONCodec{}, encryptionKey)
+	assert.True(t, errors.Is(other.Unmarshal(data, &o), ErrDecryption))
+}
+
+func TestEncryptedCodec_BadKey_Error(t *testing.T) {
+	_, err := NewEncryptedCodec(JSONCodec{}, []byte("short"))
+	assert.NotNil(t, err)
+
+	_, err = NewEncryptedCodec(JSONCodec{}, encryptionKey, []byte("short"))
+	assert.NotNil(t, err)
+}
+
+func TestSnapshotRun_EncryptedCodec(t *testing.T) {
+	calls := 0
+	m, start := newOrderMachine(&calls)
+	codec, _ := NewEncryptedCodec(JSONCodec{}, encryptionKey)
+	m.SetCodec(codec)
+
+	var saved []byte
+	_, err := m.SnapshotRun(context.Background(), order{ID: "alice@example.com", Total: 10}, start, func(snap *Snapshot) error {
+		var err error
+		saved, err = m.EncodeSnapshot(snap)
+		return err
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.False(t, bytes.Contains(saved, []byte("alice")))
+	assert.False(t, bytes.Contains(saved, []byte("ship")))
+
+	snap, err := m.DecodeSnapshot(saved)
+	if !assert.Nil(t, err) {
+		return
+	}
+	result, err := m.Resume(context.Background(), snap, func(data []byte) (interface{}, error) {
+		var o order
+		err := m.Codec().Unmarshal(data, &o)
+		return o, err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, order{ID: "alice@example.com", Total: 15}, result.Cargo)
+}
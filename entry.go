@@ -0,0 +1,72 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// entryPoint is a start state declared with AddEntryPoint
+type entryPoint struct {
+	state    State
+	validate func(cargo interface{}) error
+}
+
+// AddEntryPoint declares a named way into the machine, e.g. "new-order" and
+// "imported-order" starting in different states, run with RunEntry or
+// ExecuteEntry. The state must be registered. validate if not nil checks the
+// cargo runs are started with, a run given a cargo it rejects fails with an
+// error wrapping ErrInvalidCargo without entering the state.
+func (sm *StateMachine) AddEntryPoint(name string, state State, validate func(cargo interface{}) error) error {
+	if !sm.isRegistered(state) {
+		return fmt.Errorf("%w %v", ErrUnknownStartState, state)
+	}
+	if _, ok := sm.entryPoints[name]; ok {
+		return fmt.Errorf("%w %s", ErrDuplicateEntryPoint, name)
+	}
+	if sm.entryPoints == nil {
+		sm.entryPoints = make(map[string]entryPoint)
+	}
+	sm.entryPoints[name] = entryPoint{state: state, validate: validate}
+	return nil
+}
+
+// EntryPoint returns the start state of the named entry point
+func (sm *StateMachine) EntryPoint(name string) (State, bool) {
+	ep, ok := sm.entryPoints[name]
+	return ep.state, ok
+}
+
+// EntryPoints returns the names of the entry points, sorted
+func (sm *StateMachine) EntryPoints() []string {
+	names := make([]string, 0, len(sm.entryPoints))
+	for name := range sm.entryPoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunEntry is like Run but starts from the named entry point
+func (sm *StateMachine) RunEntry(entry string, cargo interface{}) error {
+	_, err := sm.ExecuteEntry(context.Background(), entry, cargo)
+	return err
+}
+
+// ExecuteEntry is like Execute but starts from the named entry point, once its
+// cargo is validated. It fails with ErrUnknownEntryPoint if there's no entry
+// point with that name.
+func (sm *StateMachine) ExecuteEntry(ctx context.Context, entry string, cargo interface{}) (*Result, error) {
+	ep, ok := sm.entryPoints[entry]
+	if !ok {
+		err := fmt.Errorf("%w %s", ErrUnknownEntryPoint, entry)
+		return &Result{Cargo: cargo, Err: err}, err
+	}
+	if ep.validate != nil {
+		if err := ep.validate(cargo); err != nil {
+			err = fmt.Errorf("%w for entry point %s: %v", ErrInvalidCargo, entry, err)
+			return &Result{Cargo: cargo, Err: err}, err
+		}
+	}
+	return sm.Execute(ctx, cargo, ep.state)
+}
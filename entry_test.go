@@ -0,0 +1,84 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newEntryMachine() (*StateMachine, State, State) {
+	m := NewStateMachine()
+	ship := NewFuncState("ship", func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo.(string) + " shipped", nil
+	})
+	create := NewFuncState("create", func(cargo interface{}) (State, interface{}, error) {
+		return ship, cargo.(string) + " created", nil
+	})
+	imported := NewFuncState("import", func(cargo interface{}) (State, interface{}, error) {
+		return ship, cargo.(string) + " imported", nil
+	})
+	m.AddStates(create, imported, ship)
+	m.AddTransition(create, ship)
+	m.AddTransition(imported, ship)
+	return m, create, imported
+}
+
+func TestEntryPoints_RunByName(t *testing.T) {
+	m, create, imported := newEntryMachine()
+	assert.Nil(t, m.AddEntryPoint("new-order", create, nil))
+	assert.Nil(t, m.AddEntryPoint("imported-order", imported, func(cargo interface{}) error {
+		if cargo.(string) == "" {
+			return errors.New("no order number")
+		}
+		return nil
+	}))
+
+	result, err := m.ExecuteEntry(context.Background(), "imported-order", "o1")
+	assert.Nil(t, err)
+	assert.Equal(t, "o1 imported shipped", result.Cargo)
+	assert.Nil(t, m.RunEntry("new-order", "o2"))
+	assert.Equal(t, []string{"imported-order", "new-order"}, m.EntryPoints())
+	state, ok := m.EntryPoint("new-order")
+	assert.True(t, ok)
+	assert.Equal(t, create, state)
+}
+
+func TestEntryPoints_Errors(t *testing.T) {
+	m, create, imported := newEntryMachine()
+	m.AddEntryPoint("imported-order", imported, func(cargo interface{}) error {
+		return errors.New("no order number")
+	})
+
+	err := m.AddEntryPoint("imported-order", create, nil)
+	assert.True(t, errors.Is(err, ErrDuplicateEntryPoint))
+	err = m.AddEntryPoint("other", &StateImpl{name: "other"}, nil)
+	assert.True(t, errors.Is(err, ErrUnknownStartState))
+
+	result, err := m.ExecuteEntry(context.Background(), "imported-order", "")
+	assert.True(t, errors.Is(err, ErrInvalidCargo))
+	assert.EqualError(t, err, "invalid cargo for entry point imported-order: no order number")
+	assert.Len(t, result.Path, 0)
+	err = m.RunEntry("nope", nil)
+	assert.True(t, errors.Is(err, ErrUnknownEntryPoint))
+}
+
+func TestEntryPoints_Definition(t *testing.T) {
+	m, create, imported := newEntryMachine()
+	m.AddEntryPoint("new-order", create, nil)
+	m.AddEntryPoint("imported-order", imported, nil)
+
+	d := m.Definition()
+	assert.Equal(t, map[string]string{"new-order": "create", "imported-order": "import"}, d.EntryPoints)
+	assert.Nil(t, d.Validate(), "import unreachable without entry points")
+
+	d.EntryPoints["broken"] = "missing"
+	assert.EqualError(t, d.Validate(), "invalid definition: entry point broken state missing not defined")
+
+	delete(d.EntryPoints, "broken")
+	other, _, _ := newEntryMachine()
+	_, err := other.ApplyDefinition(d)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"imported-order", "new-order"}, other.EntryPoints())
+}
@@ -0,0 +1,175 @@
+package gust
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnknownState is returned when a state transitions to a state that isn't registered
+	ErrUnknownState = errors.New("invalid target state")
+	// ErrInvalidTransition is returned when a state takes a transition that isn't declared
+	ErrInvalidTransition = errors.New("invalid transition")
+	// ErrAmbiguousTransition is returned when the next state can't be decided among several candidates
+	ErrAmbiguousTransition = errors.New("ambiguous transition")
+	// ErrReplayMismatch is returned when a recording doesn't match the machine replaying it
+	ErrReplayMismatch = errors.New("replay mismatch")
+	// ErrNoMigration is returned when a snapshot can't be migrated to the machine's version
+	ErrNoMigration = errors.New("no migration")
+	// ErrDecryption is returned when encrypted data can't be decrypted
+	ErrDecryption = errors.New("decryption failed")
+	// ErrInvalidEventLog is returned when a run's events can't be folded into a position
+	ErrInvalidEventLog = errors.New("invalid event log")
+	// ErrInvalidDefinition matches any *ValidationError with errors.Is
+	ErrInvalidDefinition = errors.New("invalid definition")
+	// ErrInvalidDOT is returned when a Graphviz file can't be read as a definition
+	ErrInvalidDOT = errors.New("invalid DOT")
+	// ErrDeadEnd is reported when a state that isn't terminal has nowhere to go
+	ErrDeadEnd = errors.New("dead end")
+	// ErrNoStartState is returned when Run is given a nil start state
+	ErrNoStartState = errors.New("no start state")
+	// ErrUnknownStartState is returned when Run is given a start state that isn't registered
+	ErrUnknownStartState = errors.New("invalid start state")
+	// ErrUnknownEntryPoint is returned when running an entry point that isn't declared
+	ErrUnknownEntryPoint = errors.New("unknown entry point")
+	// ErrDuplicateEntryPoint is returned when declaring an entry point name twice
+	ErrDuplicateEntryPoint = errors.New("duplicate entry point")
+	// ErrInvalidCargo is returned when an entry point's validation rejects the cargo
+	ErrInvalidCargo = errors.New("invalid cargo")
+	// ErrDuplicateState is returned when registering a state twice, or two states with the same name
+	ErrDuplicateState = errors.New("duplicate state")
+	// ErrInvariantViolated matches any *InvariantError with errors.Is
+	ErrInvariantViolated = errors.New("invariant violated")
+	// ErrMaxTransitions is returned when a run takes more transitions than MaxTransitions
+	ErrMaxTransitions = errors.New("max transitions exceeded")
+	// ErrNoRun is returned when a context given to a function doesn't belong to a run
+	ErrNoRun = errors.New("not in a run")
+	// ErrUnknownMachine is returned when a Manager has no machine with the given name
+	ErrUnknownMachine = errors.New("unknown machine")
+	// ErrDuplicateMachine is returned when registering a machine name twice with a Manager
+	ErrDuplicateMachine = errors.New("duplicate machine")
+	// ErrUnknownInstance is returned when a Manager has no instance with the given ID
+	ErrUnknownInstance = errors.New("unknown instance")
+	// ErrNotRunning is returned when an instance that finished is signalled or cancelled
+	ErrNotRunning = errors.New("not running")
+	// ErrPoolClosed is returned when submitting a job to a closed Pool
+	ErrPoolClosed = errors.New("pool closed")
+	// ErrActorStopped is returned when sending to an Actor that stopped or finished
+	ErrActorStopped = errors.New("actor stopped")
+	// ErrWaitTimeout is returned when a WaitState with no OnTimeout state times out
+	ErrWaitTimeout = errors.New("wait timed out")
+	// ErrWaitClosed is returned when the channel of a WaitState with no OnClose state is closed
+	ErrWaitClosed = errors.New("wait channel closed")
+	// ErrUnknownRun is returned when a machine has no in-flight run with the given ID
+	ErrUnknownRun = errors.New("unknown run")
+	// ErrSignalled is the reason of runs aborted because the process received a signal
+	ErrSignalled = errors.New("received signal")
+	// ErrLocked is returned when a run's lock is held by another process, see SetLocker
+	ErrLocked = errors.New("run locked")
+	// ErrLockLost is the reason of runs aborted because their lock was lost
+	ErrLockLost = errors.New("run lock lost")
+	// ErrReloaded is the error runs moved to a reloaded machine end with on the
+	// old one, see Manager.Reload
+	ErrReloaded = errors.New("machine reloaded")
+	// ErrShutdown is returned when starting an instance on a Manager shutting
+	// down, and is the reason of the runs it stopped, see Manager.Shutdown
+	ErrShutdown = errors.New("shutting down")
+	// ErrDegraded matches any *DegradedError with errors.Is
+	ErrDegraded = errors.New("run degraded")
+	// ErrAborted matches any *AbortedError with errors.Is, and is the reason
+	// used when Abort is given nil
+	ErrAborted = errors.New("aborted")
+)
+
+// joinedError holds several errors, as the error of errors.Join does, for
+// Go versions without it. It matches whatever any of them matches.
+type joinedError struct {
+	Errors []error
+}
+
+func (e joinedError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As of Go 1.20 on
+func (e joinedError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is reports whether any of the errors matches target
+func (e joinedError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors matching target
+func (e joinedError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunError is returned by Run when the run fails in a state, either because the
+// state returned an error or because it took an invalid transition
+type RunError struct {
+	Err   error    // the underlying error
+	State string   // name of the failing state, as given by StateName
+	Path  []string // states visited so far, ending with the failing state
+}
+
+func newRunError(r *run, state State, err error) *RunError {
+	return &RunError{Err: err, State: r.sm.StateName(state), Path: r.path.list()}
+}
+
+func (e *RunError) Error() string {
+	return fmt.Sprintf("state %s failed: %v (path: %s)", e.State, e.Err, strings.Join(e.Path, " -> "))
+}
+
+// Unwrap returns the underlying error
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// AbortedError is returned by Run when the run was interrupted by Abort or by
+// its context being done
+type AbortedError struct {
+	Reason error  // the reason given to Abort, or the context's error
+	State  string // name of the state the run was in when interrupted
+
+	// Token resumes the run with Continue, nil if it can't be resumed
+	Token *ResumeToken
+}
+
+func (e *AbortedError) Error() string {
+	msg := "run aborted"
+	if e.State != "" {
+		msg += " in state " + e.State
+	}
+	if e.Reason != nil && e.Reason != ErrAborted {
+		msg = fmt.Sprintf("%s: %v", msg, e.Reason)
+	}
+	return msg
+}
+
+// Unwrap returns the abort reason, so errors.Is(err, context.Canceled) and the
+// like work
+func (e *AbortedError) Unwrap() error {
+	return e.Reason
+}
+
+// Is reports ErrAborted as a match
+func (e *AbortedError) Is(target error) bool {
+	return target == ErrAborted
+}
@@ -0,0 +1,152 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors_UnregisteredTarget_IsErrUnknownState(t *testing.T) {
+	b := &StateImpl{}
+	a := &StateImpl{
+		nextState: b,
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+
+	err := m.Run(nil, a)
+	assert.True(t, errors.Is(err, ErrUnknownState))
+}
+
+func TestErrors_UndeclaredTransition_IsErrInvalidTransition(t *testing.T) {
+	c := &StateImpl{}
+	b := &StateImpl{}
+	a := &StateImpl{
+		nextState: c,
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.AddState(c)
+	m.AddTransition(a, b)
+
+	err := m.Run(nil, a)
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+}
+
+func TestErrors_NilStartState_IsErrNoStartState(t *testing.T) {
+	m := NewStateMachine()
+
+	err := m.Run(nil, nil)
+	assert.True(t, errors.Is(err, ErrNoStartState))
+}
+
+func TestErrors_LoopOverMaxTransitions_IsErrMaxTransitions(t *testing.T) {
+	// A -> A -> A ...
+	a := &StateImpl{}
+	a.nextState = a
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.MaxTransitions = 10
+
+	err := m.Run(nil, a)
+	assert.True(t, errors.Is(err, ErrMaxTransitions))
+}
+
+func TestErrors_Aborted_IsErrAborted(t *testing.T) {
+	a := &StateImpl{}
+
+	m := NewStateMachine()
+	m.AddState(a)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := m.RunContext(ctx, nil, a)
+	assert.True(t, errors.Is(err, ErrAborted))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, "run aborted in state *gust.StateImpl: context canceled", err.Error()) // named by StateName
+}
+
+func TestRunError_StateFails_CarriesStateAndPath(t *testing.T) {
+	// A -> B -> C, where C fails
+	c := &StateImpl{
+		name: "stateC",
+		err:  errors.New("some error"),
+	}
+	b := &StateImpl{
+		nextState: c,
+		name:      "stateB",
+	}
+	a := &StateImpl{
+		nextState: b,
+		name:      "stateA",
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.AddState(c)
+
+	err := m.Run(nil, a)
+	var runErr *RunError
+	if !assert.True(t, errors.As(err, &runErr)) {
+		return
+	}
+	assert.Equal(t, c.err, runErr.Err)
+	assert.Equal(t, "stateC", runErr.State)
+	assert.Equal(t, []string{"stateA", "stateB", "stateC"}, runErr.Path)
+	assert.True(t, errors.Is(err, c.err))
+	assert.Equal(t, "state stateC failed: some error (path: stateA -> stateB -> stateC)", err.Error())
+}
+
+func TestRunError_UnnamedState_UsesType(t *testing.T) {
+	b := &StateImpl{}
+	a := &StateNoName{
+		nextState: b,
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+
+	err := m.Run(nil, a)
+	var runErr *RunError
+	if !assert.True(t, errors.As(err, &runErr)) {
+		return
+	}
+	assert.Equal(t, "*gust.StateNoName", runErr.State)
+	assert.True(t, errors.Is(err, ErrUnknownState))
+}
+
+func TestErrors_UnregisteredStartState_IsErrUnknownStartState(t *testing.T) {
+	a := &StateImpl{}
+	b := &StateImpl{}
+
+	m := NewStateMachine()
+	m.AddState(b)
+
+	err := m.Run(nil, a)
+	assert.True(t, errors.Is(err, ErrUnknownStartState))
+	assert.False(t, a.run)
+}
+
+func TestJoinedError_MatchesAny(t *testing.T) {
+	errA := errors.New("a")
+	aborted := &AbortedError{Reason: ErrSignalled}
+	err := &ChildrenError{joinedError{[]error{errA, fmt.Errorf("wrapped: %w", aborted)}}}
+
+	assert.Equal(t, "a\nwrapped: run aborted: received signal", err.Error())
+	assert.True(t, errors.Is(err, errA))
+	assert.True(t, errors.Is(err, ErrSignalled))
+	assert.False(t, errors.Is(err, ErrDegraded))
+	var found *AbortedError
+	if assert.True(t, errors.As(err, &found)) {
+		assert.Equal(t, aborted, found)
+	}
+}
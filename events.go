@@ -0,0 +1,246 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of an Event
+type EventType string
+
+// The events of a run, in the order they happen
+const (
+	EventStarted EventType = "started" // the run started in State
+	EventResumed EventType = "resumed" // the run was resumed in State
+	EventEntered EventType = "entered" // the run moved from From to State
+	EventEnded   EventType = "ended"   // the run ended, Cargo is the final cargo
+	EventFailed  EventType = "failed"  // the run failed in State with Error
+	EventAborted EventType = "aborted" // the run was aborted in State, it can be resumed
+)
+
+// Event is an entry of a run's event log, see EventSourcedRun
+type Event struct {
+	Seq     int       `json:"seq"` // 1 for the first event of the log
+	Type    EventType `json:"type"`
+	From    string    `json:"from,omitempty"`
+	State   string    `json:"state,omitempty"`
+	Cargo   []byte    `json:"cargo,omitempty"` // encoded with the machine's Codec
+	Error   string    `json:"error,omitempty"`
+	Version string    `json:"version,omitempty"` // the machine's Version
+	At      time.Time `json:"at"`
+
+	CorrelationID string `json:"correlationId,omitempty"` // the run's, see WithCorrelationID
+	// RunID is that of the run appending the event, runs resuming a log append
+	// events with their own
+	RunID string `json:"runId,omitempty"`
+}
+
+// EventLog is an append only log of a single run's events
+type EventLog interface {
+	// Append adds the event at the end of the log
+	Append(e Event) error
+	// Events returns all events in the order appended
+	Events() ([]Event, error)
+}
+
+// MemoryEventLog is an EventLog kept in memory
+type MemoryEventLog struct {
+	lock   *sync.Mutex
+	events []Event
+}
+
+// NewMemoryEventLog is a constructor for MemoryEventLog
+func NewMemoryEventLog() *MemoryEventLog {
+	return &MemoryEventLog{lock: &sync.Mutex{}, events: make([]Event, 0)}
+}
+
+// Append adds the event
+func (l *MemoryEventLog) Append(e Event) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, e)
+	return nil
+}
+
+// Events returns a copy of the events
+func (l *MemoryEventLog) Events() ([]Event, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]Event{}, l.events...), nil
+}
+
+// Position is where a run is according to its event log, see Fold
+type Position struct {
+	Seq     int      // of the last event folded
+	Version string   // of the machine that wrote the last event
+	State   string   // the state the run is in, empty once ended
+	Cargo   []byte   // the cargo given to State, or the final cargo once ended
+	Path    []string // the states entered, State included
+	Ended   bool
+	Failed  bool
+	Aborted bool   // until resumed
+	Error   string // why it failed or was aborted
+
+	CorrelationID string // of the last event having one
+}
+
+// Fold rebuilds the position of a run from its events. It fails with
+// ErrInvalidEventLog if the events are out of order or don't follow from one
+// another.
+func Fold(events []Event) (*Position, error) {
+	p := &Position{Path: make([]string, 0)}
+	for i, e := range events {
+		if e.Seq != i+1 {
+			return p, fmt.Errorf("%w: event %d has sequence number %d", ErrInvalidEventLog, i+1, e.Seq)
+		}
+		if p.Ended || p.Failed {
+			return p, fmt.Errorf("%w: event %d after the run finished", ErrInvalidEventLog, e.Seq)
+		}
+
+		switch e.Type {
+		case EventStarted:
+			if i != 0 {
+				return p, fmt.Errorf("%w: run started again at event %d", ErrInvalidEventLog, e.Seq)
+			}
+			p.State, p.Cargo = e.State, e.Cargo
+			p.Path = append(p.Path, e.State)
+		case EventResumed:
+			if i == 0 || e.State != p.State {
+				return p, fmt.Errorf("%w: resumed in %s at event %d but was in %s", ErrInvalidEventLog, e.State, e.Seq, p.State)
+			}
+			p.Cargo = e.Cargo
+			p.Aborted, p.Error = false, ""
+		case EventEntered:
+			if i == 0 || e.From != p.State {
+				return p, fmt.Errorf("%w: moved from %s at event %d but was in %s", ErrInvalidEventLog, e.From, e.Seq, p.State)
+			}
+			p.State, p.Cargo = e.State, e.Cargo
+			p.Path = append(p.Path, e.State)
+		case EventEnded:
+			p.Ended = true
+			p.State, p.Cargo = "", e.Cargo
+		case EventFailed:
+			p.Failed = true
+			p.Error = e.Error
+		case EventAborted:
+			p.Aborted = true
+			p.Error = e.Error
+		default:
+			return p, fmt.Errorf("%w: unknown event type %q", ErrInvalidEventLog, e.Type)
+		}
+		p.Seq = e.Seq
+		p.Version = e.Version
+		if e.CorrelationID != "" {
+			p.CorrelationID = e.CorrelationID
+		}
+	}
+	return p, nil
+}
+
+// Snapshot turns the position into a snapshot Resume can continue from
+func (p *Position) Snapshot() *Snapshot {
+	path := make([]string, 0, len(p.Path))
+	if len(p.Path) > 0 {
+		path = append(path, p.Path[:len(p.Path)-1]...)
+	}
+	return &Snapshot{Version: p.Version, State: p.State, Cargo: p.Cargo, Path: path, CorrelationID: p.CorrelationID}
+}
+
+// EventSourcedRun is like Execute but appends every transition of the run to
+// the log as an event, along with how the run started and finished. Folding
+// the log with Fold rebuilds where the run is at any point. If the log already
+// has events the run is resumed where they leave it, like Resume, and decode
+// turns the cargo back into a value. An aborted run can be resumed this way,
+// a failed or ended one can't. If appending fails, so does the run.
+func (sm *StateMachine) EventSourcedRun(ctx context.Context, log EventLog, cargo interface{}, startState State, decode func(data []byte) (interface{}, error)) (*Result, error) {
+	events, err := log.Events()
+	if err != nil {
+		return &Result{Cargo: cargo, Err: err}, err
+	}
+	pos, err := Fold(events)
+	if err != nil {
+		return &Result{Cargo: cargo, Err: err}, err
+	}
+
+	first := EventStarted
+	if len(events) > 0 {
+		if pos.Ended || pos.Failed {
+			err := fmt.Errorf("%w: the run already finished", ErrInvalidEventLog)
+			return &Result{Err: err}, err
+		}
+		first = EventResumed
+		snap, err := sm.Migrate(pos.Snapshot())
+		if err != nil {
+			return &Result{Err: err}, err
+		}
+		var ok bool
+		if startState, ok = sm.StateByName(snap.State); !ok {
+			err := fmt.Errorf("%w %s", ErrUnknownStartState, snap.State)
+			return &Result{Err: err}, err
+		}
+		if decode == nil {
+			decode = sm.decodeCargo
+		}
+		if cargo, err = decode(snap.Cargo); err != nil {
+			err = fmt.Errorf("decoding cargo: %w", err)
+			return &Result{Err: err}, err
+		}
+		ctx = correlated(ctx, snap.CorrelationID)
+	}
+	correlation, _ := CorrelationID(ctx)
+
+	seq := pos.Seq
+	var runID string
+	appendEvent := func(e Event, cargo interface{}) error {
+		if cargo != nil {
+			data, err := sm.codec.Marshal(sm.persisted(cargo))
+			if err != nil {
+				return fmt.Errorf("encoding cargo: %w", err)
+			}
+			e.Cargo = data
+		}
+		seq++
+		e.Seq, e.Version, e.At, e.CorrelationID, e.RunID = seq, sm.Version, sm.clock.Now(), correlation, runID
+		if err := log.Append(e); err != nil {
+			return fmt.Errorf("appending event: %w", err)
+		}
+		return nil
+	}
+
+	entered := 0 // states entered when last appended, retries don't append again
+	var prior string
+	result, err := sm.executeWith(ctx, cargo, startState, func(r *run, state State, cargo interface{}) (State, interface{}, error) {
+		runID = r.id
+		if r.path.entered != entered {
+			entered = r.path.entered
+			e := Event{Type: EventEntered, From: prior, State: sm.StateName(state)}
+			if entered == 1 {
+				e.Type, e.From = first, ""
+			}
+			if err := appendEvent(e, cargo); err != nil {
+				return nil, nil, err
+			}
+			prior = sm.StateName(state)
+		}
+		return sm.execState(r, state, cargo)
+	})
+
+	if entered == 0 {
+		return result, err // never got to append the start
+	}
+	var end error
+	if errors.Is(err, ErrAborted) {
+		end = appendEvent(Event{Type: EventAborted, State: prior, Error: err.Error()}, nil)
+	} else if err != nil {
+		end = appendEvent(Event{Type: EventFailed, State: prior, Error: err.Error()}, nil)
+	} else {
+		end = appendEvent(Event{Type: EventEnded}, result.Cargo)
+	}
+	if end != nil && err == nil {
+		result.Err, err = end, end
+	}
+	return result, err
+}
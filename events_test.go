@@ -0,0 +1,119 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventTypes(events []Event) []EventType {
+	types := make([]EventType, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestEventSourcedRun_LogFoldsToEnd(t *testing.T) {
+	calls := 0
+	m, start := newOrderMachine(&calls)
+	log := NewMemoryEventLog()
+
+	_, err := m.EventSourcedRun(context.Background(), log, order{ID: "o1", Total: 10}, start, decodeOrder)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	events, _ := log.Events()
+	assert.Equal(t, []EventType{EventStarted, EventEntered, EventEntered, EventEnded}, eventTypes(events))
+	assert.Equal(t, "charge", events[2].From)
+	assert.Equal(t, "ship", events[2].State)
+	assert.JSONEq(t, `{"id":"o1","total":15}`, string(events[2].Cargo))
+
+	pos, err := Fold(events)
+	assert.Nil(t, err)
+	assert.True(t, pos.Ended)
+	assert.Equal(t, 4, pos.Seq)
+	assert.Equal(t, []string{"validate", "charge", "ship"}, pos.Path)
+	assert.JSONEq(t, `{"id":"o1","total":15}`, string(pos.Cargo))
+
+	// every prefix of the log folds to where the run was
+	pos, _ = Fold(events[:2])
+	assert.Equal(t, "charge", pos.State)
+	assert.JSONEq(t, `{"id":"o1","total":10}`, string(pos.Cargo))
+
+	_, err = m.EventSourcedRun(context.Background(), log, nil, start, decodeOrder)
+	assert.True(t, errors.Is(err, ErrInvalidEventLog))
+}
+
+func TestEventSourcedRun_AbortedThenResumed(t *testing.T) {
+	m := NewStateMachine()
+	waits := 0
+	states, _ := m.RegisterAll(map[string]ExecFunc{
+		"prepare": func(cargo interface{}) (State, interface{}, error) {
+			next, _ := m.StateByName("wait")
+			return next, cargo.(float64) + 1, nil
+		},
+		"wait": func(cargo interface{}) (State, interface{}, error) {
+			waits++
+			if waits == 1 {
+				m.Abort(nil)
+			}
+			return nil, cargo.(float64) * 10, nil
+		},
+	})
+	log := NewMemoryEventLog()
+
+	_, err := m.EventSourcedRun(context.Background(), log, 1.0, states["prepare"], nil)
+	assert.True(t, errors.Is(err, ErrAborted))
+	events, _ := log.Events()
+	pos, _ := Fold(events)
+	assert.True(t, pos.Aborted)
+	assert.Equal(t, "wait", pos.State)
+
+	result, err := m.EventSourcedRun(context.Background(), log, nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 20.0, result.Cargo)
+	assert.Equal(t, []string{"wait"}, result.Path)
+
+	events, _ = log.Events()
+	assert.Equal(t, []EventType{EventStarted, EventEntered, EventAborted, EventResumed, EventEnded}, eventTypes(events))
+	pos, err = Fold(events)
+	assert.Nil(t, err)
+	assert.True(t, pos.Ended)
+	assert.False(t, pos.Aborted)
+	assert.Equal(t, []string{"prepare", "wait"}, pos.Path)
+}
+
+func TestEventSourcedRun_Failed(t *testing.T) {
+	a := NewFuncState("a", func(cargo interface{}) (State, interface{}, error) {
+		return nil, nil, errors.New("boom")
+	})
+	m := NewStateMachine()
+	m.AddState(a)
+	log := NewMemoryEventLog()
+
+	_, err := m.EventSourcedRun(context.Background(), log, nil, a, nil)
+	assert.NotNil(t, err)
+
+	events, _ := log.Events()
+	pos, _ := Fold(events)
+	assert.True(t, pos.Failed)
+	assert.Equal(t, "state a failed: boom (path: a)", pos.Error)
+}
+
+func TestFold_Invalid(t *testing.T) {
+	logs := [][]Event{
+		{{Seq: 2, Type: EventStarted, State: "a"}},
+		{{Seq: 1, Type: EventStarted, State: "a"}, {Seq: 2, Type: EventEntered, From: "b", State: "c"}},
+		{{Seq: 1, Type: EventStarted, State: "a"}, {Seq: 2, Type: EventStarted, State: "a"}},
+		{{Seq: 1, Type: EventStarted, State: "a"}, {Seq: 2, Type: EventEnded}, {Seq: 3, Type: EventResumed, State: "a"}},
+		{{Seq: 1, Type: "jumped"}},
+	}
+	for _, events := range logs {
+		_, err := Fold(events)
+		assert.True(t, errors.Is(err, ErrInvalidEventLog), "%v", events)
+	}
+}
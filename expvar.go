@@ -0,0 +1,57 @@
+package gust
+
+import (
+	"expvar"
+	"time"
+)
+
+// PublishExpvar publishes the machine's statistics with expvar under the
+// given name, so they're served at /debug/vars with the process's others:
+//
+//	runs_active     runs in flight
+//	runs_started    runs started
+//	runs_completed  runs that ended without error
+//	runs_failed     runs that failed or were aborted
+//	transitions     transitions taken
+//	visits          states entered, by state name
+//
+// Like expvar.Publish it panics if the name is already published.
+func (sm *StateMachine) PublishExpvar(name string) *expvar.Map {
+	o := &expvarObserver{vars: expvar.NewMap(name), visits: new(expvar.Map).Init()}
+	o.vars.Set("runs_active", expvar.Func(func() interface{} {
+		sm.runsLock.RLock()
+		defer sm.runsLock.RUnlock()
+		return len(sm.runs)
+	}))
+	o.vars.Set("visits", o.visits)
+	for _, key := range []string{"runs_started", "runs_completed", "runs_failed", "transitions"} {
+		o.vars.Add(key, 0)
+	}
+	sm.RegisterObservers(o)
+	return o.vars
+}
+
+// expvarObserver counts the notifications into the vars
+type expvarObserver struct {
+	vars   *expvar.Map
+	visits *expvar.Map
+}
+
+func (o *expvarObserver) StateChanged(prior, next string) {
+	if prior != "" {
+		o.vars.Add("transitions", 1)
+	}
+	o.visits.Add(next, 1)
+}
+
+func (o *expvarObserver) RunStarted(runID string) {
+	o.vars.Add("runs_started", 1)
+}
+
+func (o *expvarObserver) RunCompleted(runID string, duration time.Duration) {
+	o.vars.Add("runs_completed", 1)
+}
+
+func (o *expvarObserver) RunFailed(runID string, duration time.Duration, err error) {
+	o.vars.Add("runs_failed", 1)
+}
@@ -0,0 +1,33 @@
+package gust
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishExpvar_Counts(t *testing.T) {
+	m := NewStateMachine()
+	failing := &StateImpl{name: "failing", err: errors.New("boom")}
+	b := &StateImpl{name: "b"}
+	a := &StateImpl{name: "a", nextState: b}
+	m.AddStates(a, b, failing)
+	vars := m.PublishExpvar("gust_test_machine")
+
+	m.Run(nil, a)
+	m.Run(nil, a)
+	m.Run(nil, failing)
+
+	var got map[string]interface{}
+	if !assert.Nil(t, json.Unmarshal([]byte(vars.String()), &got)) {
+		return
+	}
+	assert.Equal(t, 0.0, got["runs_active"])
+	assert.Equal(t, 3.0, got["runs_started"])
+	assert.Equal(t, 2.0, got["runs_completed"])
+	assert.Equal(t, 1.0, got["runs_failed"])
+	assert.Equal(t, 2.0, got["transitions"])
+	assert.Equal(t, map[string]interface{}{"a": 2.0, "b": 2.0, "failing": 1.0}, got["visits"])
+}
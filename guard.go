@@ -0,0 +1,75 @@
+package gust
+
+import "fmt"
+
+// Guard tells from the cargo whether a guarded transition is taken, see
+// AddGuardedTransition
+type Guard func(cargo interface{}) bool
+
+// AddGuardedTransition declares a transition like AddLabeledTransition, the
+// label may be empty, that the machine takes by itself when the from state
+// returns no next state and the guard holds for the cargo it returned. This
+// moves routing out of Exec into the table:
+//
+//	sm.AddGuardedTransition(review, approved, "approved", func(cargo interface{}) bool {
+//		return cargo.(*Order).Total < 100
+//	})
+//	sm.AddGuardedTransition(review, escalated, "escalated", func(cargo interface{}) bool {
+//		return true // otherwise
+//	})
+//	sm.SetTransitionPriority(review, approved, 1)
+//
+// If the guards of several transitions from a state hold, the one with the
+// highest priority is taken, see SetTransitionPriority, and the run fails with
+// ErrAmbiguousTransition if there's a tie. If none holds the run ends in the
+// state. States may still move along a guarded transition by returning its to
+// state. Guarding a declared transition again replaces its label and guard.
+func (sm *StateMachine) AddGuardedTransition(from, to State, label string, guard Guard) {
+	sm.AddLabeledTransition(from, to, label)
+	ts := sm.transitions[keyOf(from)]
+	for i := range ts {
+		if sameState(ts[i].To, to) {
+			ts[i].Guard = guard
+		}
+	}
+}
+
+// SetTransitionPriority sets the priority of a declared transition, deciding
+// between guarded transitions whose guards hold: the highest is taken. The
+// default is 0. Definition.Validate reports guarded transitions from a state
+// sharing a priority, since which is taken would depend on the cargo.
+func (sm *StateMachine) SetTransitionPriority(from, to State, priority int) {
+	ts := sm.transitions[keyOf(from)]
+	for i := range ts {
+		if sameState(ts[i].To, to) {
+			ts[i].Priority = priority
+		}
+	}
+}
+
+// guardedNext returns the state the guarded transition from the state with
+// the highest priority whose guard holds for the cargo leads to, nil if
+// there's none
+func (sm *StateMachine) guardedNext(state State, cargo interface{}) (State, error) {
+	var next, tie *Transition
+	ts := sm.transitions[keyOf(state)]
+	for i := range ts {
+		t := &ts[i]
+		if t.Guard == nil || (next != nil && t.Priority < next.Priority) || !t.Guard(cargo) {
+			continue
+		}
+		if next != nil && t.Priority == next.Priority {
+			tie = t
+			continue
+		}
+		next, tie = t, nil
+	}
+	if tie != nil {
+		return nil, fmt.Errorf("%w from %s: guards to %s and %s hold with priority %d",
+			ErrAmbiguousTransition, sm.StateName(state), sm.StateName(next.To), sm.StateName(tie.To), tie.Priority)
+	}
+	if next == nil {
+		return nil, nil
+	}
+	return next.To, nil
+}
@@ -0,0 +1,126 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// guardedMachine routes review to small or large by the cargo, review
+// returning next if set
+func guardedMachine(next *State) (m *StateMachine, review, small, large State) {
+	m = NewStateMachine()
+	review = NewFuncState("review", func(cargo interface{}) (State, interface{}, error) {
+		return *next, cargo, nil
+	})
+	small = &StateImpl{name: "small"}
+	large = &StateImpl{name: "large"}
+	m.AddStates(review, small, large)
+	m.AddGuardedTransition(review, small, "small", func(cargo interface{}) bool {
+		return cargo.(int) < 100
+	})
+	m.AddGuardedTransition(review, large, "", func(cargo interface{}) bool {
+		return true
+	})
+	m.SetTransitionPriority(review, small, 1)
+	return m, review, small, large
+}
+
+func TestAddGuardedTransition_HighestPriorityTaken(t *testing.T) {
+	m, review, _, _ := guardedMachine(new(State))
+
+	result, err := m.Execute(context.Background(), 5, review)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"review", "small"}, result.Path)
+
+	result, err = m.Execute(context.Background(), 500, review)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"review", "large"}, result.Path)
+}
+
+func TestAddGuardedTransition_NoGuardHolds_RunEnds(t *testing.T) {
+	m := NewStateMachine()
+	a, b := &StateImpl{name: "a"}, &StateImpl{name: "b"}
+	m.AddStates(a, b)
+	m.AddGuardedTransition(a, b, "", func(cargo interface{}) bool { return false })
+
+	result, err := m.Execute(context.Background(), nil, a)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a"}, result.Path)
+}
+
+func TestAddGuardedTransition_ExplicitNextStateWins(t *testing.T) {
+	next := new(State)
+	m, review, small, large := guardedMachine(next)
+	*next = small
+
+	result, err := m.Execute(context.Background(), 500, review)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"review", "small"}, result.Path)
+	assert.Equal(t, "small", m.TransitionLabel(review, small))
+	assert.True(t, m.CanTransition(review, large))
+}
+
+func TestAddGuardedTransition_Simulate(t *testing.T) {
+	m, review, _, _ := guardedMachine(new(State))
+
+	result, err := m.Simulate(500, review, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"review", "large"}, result.Path)
+}
+
+func TestAddGuardedTransition_TiedGuardsHold_Ambiguous(t *testing.T) {
+	m, review, small, large := guardedMachine(new(State))
+	m.SetTransitionPriority(review, large, 1)
+
+	result, err := m.Execute(context.Background(), 5, review)
+	assert.True(t, errors.Is(err, ErrAmbiguousTransition))
+	assert.Contains(t, err.Error(), "guards to small and large hold with priority 1")
+	assert.Equal(t, []string{"review"}, result.Path)
+
+	_, err = m.Simulate(5, review, nil)
+	assert.True(t, errors.Is(err, ErrAmbiguousTransition))
+
+	// only one holds
+	result, err = m.Execute(context.Background(), 500, review)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"review", "large"}, result.Path)
+
+	// a higher priority breaks the tie
+	m.SetTransitionPriority(review, small, 2)
+	result, err = m.Execute(context.Background(), 5, review)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"review", "small"}, result.Path)
+}
+
+func TestSetTransitionPriority_Definition(t *testing.T) {
+	m, _, _, _ := guardedMachine(new(State))
+
+	d := m.Definition()
+	assert.Equal(t, []TransitionDefinition{
+		{From: "review", To: "small", Label: "small", Guarded: true, Priority: 1},
+		{From: "review", To: "large", Guarded: true},
+	}, d.Transitions)
+	assert.Nil(t, d.Validate())
+
+	d.Transitions[0].Priority = 0
+	err := d.Validate()
+	assert.True(t, errors.Is(err, ErrInvalidDefinition))
+	assert.Equal(t, []string{"state review has guarded transitions to small and large with priority 0"}, err.(*ValidationError).Problems)
+}
+
+func TestApplyDefinition_SetsPriorities(t *testing.T) {
+	m, review, small, _ := guardedMachine(new(State))
+	d := m.Definition()
+	d.Transitions[0].Priority = 7
+
+	other := NewStateMachine()
+	other.AddStates(review, small, &StateImpl{name: "large"})
+	_, err := other.ApplyDefinition(d)
+	assert.Nil(t, err)
+	tr, _ := other.TransitionBetween(review, small)
+	assert.Equal(t, 7, tr.Priority)
+	assert.Nil(t, tr.Guard)
+}
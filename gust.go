@@ -1,8 +1,16 @@
 package gust
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
+	"runtime/pprof"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Inspired by David Mertz's state machine in Python
@@ -16,11 +24,24 @@ type State interface {
 	Exec(cargo interface{}) (nextState State, nextCargo interface{}, err error)
 }
 
+// ContextState when implemented by a state is executed in place of Exec. The
+// context is cancelled when the run is aborted or the context given to
+// RunContext is done, so long running states can bail out early.
+type ContextState interface {
+	ExecContext(ctx context.Context, cargo interface{}) (nextState State, nextCargo interface{}, err error)
+}
+
 // HaveName when implemented allows state to be reported during transition change
 type HaveName interface {
 	Name() string // state name, used in state change notification if needed
 }
 
+// HaveDescription when implemented by a state describes what it does, for
+// the machine's Definition and the documentation generated from it
+type HaveDescription interface {
+	Description() string
+}
+
 // Observer interface for observing any state change, if needed
 type Observer interface {
 	// StateChanged notifies the prior and the next string name, if the next
@@ -28,21 +49,145 @@ type Observer interface {
 	StateChanged(priorState string, nextState string)
 }
 
+// TransitionObserver when implemented by an observer is notified with the
+// label of the transition taken, see AddLabeledTransition, instead of with
+// StateChanged. The label is empty for the start state and for transitions
+// that aren't labelled.
+type TransitionObserver interface {
+	TransitionTaken(priorState, nextState, label string)
+}
+
+// StateInfo describes the state an in-flight run is currently executing
+type StateInfo struct {
+	Name    string    // state name, as given by StateName
+	State   State     // the state itself
+	Entered time.Time // when the run entered the state
+
+	Progress Progress // last progress reported by the state, if any
+}
+
 // NewStateMachine is a constructor for StateMachine
-func NewStateMachine() *StateMachine {
-	return &StateMachine{
+func NewStateMachine(opts ...Option) *StateMachine {
+	sm := &StateMachine{
 		States:        make([]State, 0),
-		observers:     make([]Observer, 0),
-		observersLock: &sync.RWMutex{},
+		index:         make(map[stateKey]struct{}),
+		names:         make(map[string]State),
+		auto:          make(map[stateKey]string),
+		observersLock: &sync.Mutex{},
+		runs:          make(map[*run]struct{}),
+		runsLock:      &sync.RWMutex{},
+		clock:         realClock{},
+		codec:         JSONCodec{},
+	}
+	for _, opt := range opts {
+		opt(sm)
 	}
+	sm.stats = newStats(sm.clock.Now())
+	return sm
 }
 
 // StateMachine is a handler for joggling between the states
 type StateMachine struct {
+	// States are the registered states in the order added. Register states
+	// with AddState rather than appending here, so they get indexed.
 	States []State
+	index  map[stateKey]struct{} // registered states for constant time lookup
+	names  map[string]State      // registered states by name
+	auto   map[stateKey]string   // names made up for unnamed states, see StateName
+
+	unknownStatePolicy UnknownStatePolicy
+	dynamic            *sync.Map // states registered by runs, see UnknownStateRegister
+	quarantine         State
+
+	// Name identifies the machine in its Definition and in profiles
+	Name string
+
+	// Version identifies the machine's definition, it's stamped on snapshots
+	// and reported in the Definition
+	Version string
+
+	// MaxTransitions if larger than 0 limits the number of transitions a single
+	// run may take, Run fails with ErrMaxTransitions once exceeded
+	MaxTransitions int
+
+	transitions   map[stateKey][]Transition // declared transitions, keyed by the from state
+	entryPoints   map[string]entryPoint
+	terminals     map[stateKey]Outcome // end states, see MarkTerminal
+	deprecated    map[stateKey]string  // reasons, see MarkDeprecated
+	retryPolicies map[stateKey]RetryPolicy
+	invariants    []Invariant // see AddInvariant
+
+	rateLimit       *tokenBucket
+	stateRateLimits map[stateKey]*tokenBucket
+	stateSlots      map[stateKey]chan struct{} // semaphores of states with limited concurrency
+
+	locker            Locker
+	store             SnapshotStore // see SetStore
+	weightedRouting   bool
+	rand              *rand.Rand // see SetRand
+	redactor          Redactor
+	redactPersisted   bool
+	continueOnError   bool
+	deadLetters       DeadLetterSink
+	profilerLabels    bool
+	historyLimit      int
+	watchdogThreshold time.Duration
+	coverage          *coverage
+	stats             *stats
+	migrations        []migration
+	codec             Codec
+	clock             Clock
+
+	// observers holds an *observerSet which is never modified once stored,
+	// changes store a new one. Notifying thus only needs an atomic load, and
+	// costs nothing without observers.
+	observers     atomic.Value
+	observersLock *sync.Mutex // serializes changes to observers
+
+	observerErrorHandler func(err error)
+	tracer               *TraceObserver // registered by Trace
+
+	runs        map[*run]struct{} // in-flight runs
+	runsStarted int               // runs started, for run IDs
+	runIDs      RunIDGenerator
+	runsLock    *sync.RWMutex
 
-	observers     []Observer
-	observersLock *sync.RWMutex
+	runStartHooks []func(ctx context.Context, cargo interface{}) error
+	runEndHooks   []func(cargo interface{}, err error)
+}
+
+// run is the bookkeeping of a single in-flight Run
+type run struct {
+	sm      *StateMachine
+	id      string
+	started time.Time
+	ctx     context.Context
+	cancel  context.CancelFunc
+	reason  error // set by Abort
+
+	correlation string   // see WithCorrelationID
+	children    []*Child // see Spawn
+	drain       *drainer // stops the run before the next state, see Manager.Shutdown
+
+	state     State
+	prior     State // the state before state, nil for the first
+	entered   time.Time
+	executing bool    // whether state is executing or done, rather than about to be entered
+	path      history // display names of the states entered so far
+	retries   int     // total number of retries taken
+	timings   []StateTiming
+	degraded  []error // of the states the run went on after, see SetContinueOnError
+	attempt   int     // of the state executing, from 1
+
+	keyBase      string // idempotency key base, made up on first use
+	resumedSteps int    // states entered by the run this one resumed
+	lease        Lease  // the run's lock, see SetLocker
+
+	progress Progress
+	signals  *signals // mailbox for ReceiveSignal
+
+	watchdog     Timer
+	execOverride execFunc // replaces executing the states, for replays
 }
 
 // RegisterObserver for any notification of state change event in between state change. When a state
@@ -51,7 +196,11 @@ func (sm *StateMachine) RegisterObservers(os ...Observer) {
 	sm.observersLock.Lock()
 	defer sm.observersLock.Unlock()
 
-	sm.observers = append(sm.observers, os...)
+	current := sm.loadObservers()
+	observers := make([]Observer, 0, len(current)+len(os))
+	observers = append(observers, current...)
+	observers = append(observers, os...)
+	sm.storeObservers(observers)
 }
 
 // RemoveObserver removes the observer from the observer list
@@ -59,80 +208,470 @@ func (sm *StateMachine) RemoveObserver(o Observer) {
 	sm.observersLock.Lock()
 	defer sm.observersLock.Unlock()
 
+	observers := append([]Observer{}, sm.loadObservers()...)
 	indexToRemove := -1
-	for i, observer := range sm.observers {
+	for i, observer := range observers {
 		if observer == o {
 			indexToRemove = i
 		}
 	}
 	if indexToRemove != -1 {
-		sm.observers[indexToRemove] = sm.observers[len(sm.observers)-1] // move the last one over
-		sm.observers[len(sm.observers)-1] = nil
-		sm.observers = sm.observers[:len(sm.observers)-1] // truncate
+		observers[indexToRemove] = observers[len(observers)-1] // move the last one over
+		observers = observers[:len(observers)-1]               // truncate
+		sm.storeObservers(observers)
+	}
+}
+
+// observerSet is the registered observers, and those among them taking the
+// optional notifications, sorted once when registering so notifying doesn't
+// need type assertions
+type observerSet struct {
+	all      []Observer
+	runs     []Observer // implementing RunObserver
+	statuses []Observer // implementing StatusObserver
+
+	deprecations []Observer // implementing DeprecationObserver
+}
+
+// storeObservers replaces the observers, the caller holds observersLock
+func (sm *StateMachine) storeObservers(observers []Observer) {
+	set := &observerSet{all: observers}
+	for _, o := range observers {
+		if _, ok := o.(RunObserver); ok {
+			set.runs = append(set.runs, o)
+		}
+		if _, ok := o.(StatusObserver); ok {
+			set.statuses = append(set.statuses, o)
+		}
+		if _, ok := o.(DeprecationObserver); ok {
+			set.deprecations = append(set.deprecations, o)
+		}
+	}
+	sm.observers.Store(set)
+}
+
+// loadObserverSet returns the current observers, which must not be modified
+func (sm *StateMachine) loadObserverSet() *observerSet {
+	if set, ok := sm.observers.Load().(*observerSet); ok {
+		return set
 	}
+	return &observerSet{}
 }
 
-// AddState adds a state state
-func (sm *StateMachine) AddState(state State) {
+// loadObservers returns the current observers, the slice must not be modified
+func (sm *StateMachine) loadObservers() []Observer {
+	return sm.loadObserverSet().all
+}
+
+// AddState adds a state state. It returns ErrDuplicateState if the same state,
+// or another state with the same name, is already registered.
+func (sm *StateMachine) AddState(state State) error {
+	if sm.isRegistered(state) {
+		return fmt.Errorf("%w %s", ErrDuplicateState, sm.StateName(state))
+	}
+	name := stateName(state)
+	if _, ok := sm.names[name]; ok && name != "" {
+		return fmt.Errorf("%w: name %s already taken", ErrDuplicateState, name)
+	}
+
 	sm.States = append(sm.States, state)
+	sm.index[keyOf(state)] = struct{}{}
+	if name == "" {
+		name = sm.autoName(state)
+	}
+	sm.names[name] = state
+	sm.markDeclaredTerminal(state)
+	if mc, ok := state.(HaveMaxConcurrency); ok && mc.MaxConcurrency() > 0 {
+		sm.SetStateConcurrency(state, mc.MaxConcurrency())
+	}
+	return nil
+}
+
+// MustAddState is like AddState but panics on error
+func (sm *StateMachine) MustAddState(state State) {
+	if err := sm.AddState(state); err != nil {
+		panic(err)
+	}
+}
+
+// isRegistered tells whether the state was added with AddState
+func (sm *StateMachine) isRegistered(state State) bool {
+	if _, ok := sm.index[keyOf(state)]; ok {
+		return true
+	}
+	if sm.dynamic != nil {
+		_, ok := sm.dynamic.Load(keyOf(state))
+		return ok
+	}
+	return false
 }
 
-// Run starts the state machine from the start state
+// CurrentState returns the state being executed by an in-flight Run, ok is false
+// when nothing is running. It's safe to call from any goroutine. If several runs
+// are in flight at once, the most recently entered state is reported.
+func (sm *StateMachine) CurrentState() (info StateInfo, ok bool) {
+	sm.runsLock.RLock()
+	defer sm.runsLock.RUnlock()
+
+	for r := range sm.runs {
+		if r.state == nil {
+			continue
+		}
+		if !ok || r.entered.After(info.Entered) {
+			info = r.info()
+			ok = true
+		}
+	}
+	return info, ok
+}
+
+// Runs returns the states being executed by all in-flight runs, earliest
+// entered first. It's safe to call from any goroutine.
+func (sm *StateMachine) Runs() []StateInfo {
+	sm.runsLock.RLock()
+	infos := make([]StateInfo, 0, len(sm.runs))
+	for r := range sm.runs {
+		if r.state != nil {
+			infos = append(infos, r.info())
+		}
+	}
+	sm.runsLock.RUnlock()
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].Entered.Before(infos[j].Entered)
+	})
+	return infos
+}
+
+// Abort interrupts all in-flight runs of the machine. The runs stop before the
+// next transition, and the context of states implementing ContextState is
+// cancelled. The interrupted Run returns an *AbortedError carrying the reason.
+func (sm *StateMachine) Abort(reason error) {
+	if reason == nil {
+		reason = ErrAborted
+	}
+
+	sm.runsLock.Lock()
+	defer sm.runsLock.Unlock()
+
+	for r := range sm.runs {
+		if r.reason == nil {
+			r.reason = reason
+		}
+		r.cancel()
+	}
+}
+
+// Run starts the state machine from the start state, which must be registered
 func (sm *StateMachine) Run(cargo interface{}, startState State) error {
-	state := startState
-	var priorState State = nil
+	return sm.RunContext(context.Background(), cargo, startState)
+}
 
-	for {
-		sm.NotifyState(priorState, state)
-		nextState, nextCargo, err := state.Exec(cargo)
-		if err != nil {
-			return err
+// RunContext is like Run but stops with an *AbortedError once ctx is done
+func (sm *StateMachine) RunContext(ctx context.Context, cargo interface{}, startState State) error {
+	_, err := sm.Execute(ctx, cargo, startState)
+	return err
+}
+
+// Execute is like RunContext but also returns a Result describing the run. The
+// result is never nil, and its Err is the returned error.
+func (sm *StateMachine) Execute(ctx context.Context, cargo interface{}, startState State) (*Result, error) {
+	if _, nested := ctx.Value(runKey{}).(*run); sm.store != nil && !nested {
+		return sm.executeStored(ctx, cargo, startState)
+	}
+	return sm.executeWith(ctx, cargo, startState, nil)
+}
+
+// executeWith executes a run, if execOverride is not nil it's called instead of
+// executing the states
+func (sm *StateMachine) executeWith(ctx context.Context, cargo interface{}, startState State, execOverride execFunc) (*Result, error) {
+	if startState == nil {
+		return &Result{Cargo: cargo, Err: ErrNoStartState}, ErrNoStartState
+	}
+	if !sm.isRegistered(startState) {
+		err := fmt.Errorf("%w %v", ErrUnknownStartState, startState)
+		return &Result{Cargo: cargo, Err: err}, err
+	}
+
+	r := sm.startRun(ctx)
+	r.execOverride = execOverride
+	defer sm.endRun(r)
+	if r.lease != nil {
+		sm.watchLease(r)
+	} else if r.keyBase != "" {
+		if err := sm.lockRun(r); err != nil {
+			return &Result{Cargo: cargo, Err: err}, err
 		}
-		if nextState == nil {
-			break
+	}
+	sm.notifyRunStarted(r)
+	sm.stats.runStarted()
+
+	if err := sm.runStarted(r.ctx, cargo); err != nil {
+		sm.notifyRunEnded(r, err)
+		sm.stats.runEnded(sm.clock.Now().Sub(r.started), err)
+		return newResult(r, cargo, err), err
+	}
+
+	cargo, err := sm.execute(r, cargo, startState)
+	err = r.degradedError(err)
+	sm.deadLetter(r, startState, cargo, err)
+	sm.runEnded(cargo, err)
+	sm.notifyRunEnded(r, err)
+	sm.stats.runEnded(sm.clock.Now().Sub(r.started), err)
+	return newResult(r, cargo, err), err
+}
+
+// execute runs the states from the start state, returning the last cargo
+func (sm *StateMachine) execute(r *run, cargo interface{}, startState State) (interface{}, error) {
+	var prior State
+	for state, transitions := startState, 0; state != nil; transitions++ {
+		nextState, nextCargo, err := sm.step(r, prior, state, cargo, transitions, true)
+		cargo = nextCargo
+		if err != nil {
+			return cargo, err
 		}
+		prior, state = state, nextState
+	}
+	return cargo, nil
+}
 
-		if !contains(sm.States, nextState) {
-			return fmt.Errorf("invalid target state %v", nextState)
-		} else {
-			cargo = nextCargo
-			priorState = state
-			state = nextState
+// step is what a run does in every state, for runs and actors alike: it
+// executes the state with the cargo and takes the transition out of it,
+// returning the next state, nil once the run ends, and the cargo to go on
+// with. prior is the state the run came from, transitions the number it took
+// so far, and changed whether observers are told it entered the state.
+func (sm *StateMachine) step(r *run, prior, state State, cargo interface{}, transitions int, changed bool) (State, interface{}, error) {
+	if err := sm.interrupted(r, state, cargo); err != nil {
+		return nil, cargo, err
+	}
+	if err := r.drained(state, cargo); err != nil {
+		return nil, cargo, err
+	}
+	if err := sm.checkInvariants(state, cargo); err != nil {
+		return nil, cargo, newRunError(r, state, err)
+	}
+	queued := sm.clock.Now()
+	if !sm.throttle(r, state) || !sm.acquireSlot(r, state) {
+		return nil, cargo, sm.interrupted(r, state, cargo)
+	}
+	sm.enterState(r, state)
+	if changed {
+		sm.NotifyState(prior, state)
+	}
+	sm.notifyStatus(r, prior, cargo)
+	sm.notifyDeprecated(r, prior, state)
+	retries := r.retries
+	nextState, nextCargo, err := sm.execWithRetry(r, state, cargo)
+	sm.releaseSlot(state)
+	sm.timeState(r, state, queued, r.entered, r.retries-retries, err != nil && !errors.Is(err, ErrReloaded))
+	if aborted := sm.interrupted(r, state, cargo); aborted != nil {
+		return nil, cargo, aborted
+	}
+	r.executing = false
+	if err != nil {
+		degraded, ok := sm.degradedNext(state, err)
+		if !ok {
+			return nil, cargo, newRunError(r, state, err)
+		}
+		r.degraded = append(r.degraded, newRunError(r, state, err))
+		nextState, nextCargo = degraded, cargo
+	}
+	cargo = nextCargo
+	if nextState == nil {
+		if nextState, err = sm.guardedNext(state, cargo); err != nil {
+			return nil, cargo, newRunError(r, state, err)
+		}
+	}
+	if nextState == nil && sm.weightedRouting {
+		nextState = sm.weightedNext(state, sm.random())
+	}
+	if nextState == nil {
+		if err := sm.checkEnd(state); err != nil {
+			return nil, cargo, newRunError(r, state, err)
 		}
+		return nil, cargo, nil
 	}
 
+	var quarantined bool
+	nextState, cargo, quarantined = sm.routeUnknown(state, nextState, cargo)
+	if err := sm.checkTransition(state, nextState); err != nil && !quarantined {
+		return nil, cargo, newRunError(r, state, err)
+	} else if sm.MaxTransitions > 0 && transitions >= sm.MaxTransitions {
+		return nil, cargo, newRunError(r, state, fmt.Errorf("%w (%d)", ErrMaxTransitions, sm.MaxTransitions))
+	}
+	sm.recordTransition(state, nextState)
+	return nextState, cargo, nil
+}
+
+// checkTransition tells why the state can't move to the next state, if it can't
+func (sm *StateMachine) checkTransition(state, nextState State) error {
+	if !sm.isRegistered(nextState) {
+		return fmt.Errorf("%w %v", ErrUnknownState, nextState)
+	}
+	if sm.hasTransitions() && !sm.CanTransition(state, nextState) {
+		return fmt.Errorf("%w from %v to %v", ErrInvalidTransition, state, nextState)
+	}
 	return nil
 }
 
 // NotifyState notifies the observer about the state change
 func (sm *StateMachine) NotifyState(prior, next State) {
-	sm.observersLock.Lock()
-	defer sm.observersLock.Unlock()
-
-	for _, observer := range sm.observers {
-		priorName, nextName := "", ""
-		if n, ok := next.(HaveName); ok {
-			nextName = n.Name()
-		}
+	for _, observer := range sm.loadObservers() {
+		priorName, nextName := "", sm.StateName(next)
 		if prior != nil {
-			if p, ok := prior.(HaveName); ok {
-				priorName = p.Name()
+			priorName = sm.StateName(prior)
+		}
+		sm.notify(observer, func() {
+			if to, ok := observer.(TransitionObserver); ok {
+				to.TransitionTaken(priorName, nextName, sm.TransitionLabel(prior, next))
 			} else {
-				return
+				observer.StateChanged(priorName, nextName)
 			}
+		})
+	}
+}
+
+// execFunc executes a state on behalf of a run
+type execFunc func(r *run, state State, cargo interface{}) (State, interface{}, error)
+
+func (sm *StateMachine) exec(r *run, state State, cargo interface{}) (State, interface{}, error) {
+	if r.execOverride != nil {
+		return r.execOverride(r, state, cargo)
+	}
+	return sm.execState(r, state, cargo)
+}
+
+// execState calls the state's ExecContext or Exec, labelled for pprof if
+// enabled with SetProfilerLabels
+func (sm *StateMachine) execState(r *run, state State, cargo interface{}) (nextState State, nextCargo interface{}, err error) {
+	if !sm.profilerLabels {
+		if cs, ok := state.(ContextState); ok {
+			return cs.ExecContext(r.ctx, cargo)
 		}
+		return state.Exec(cargo)
+	}
 
-		if nextName != "" {
-			observer.StateChanged(priorName, nextName)
+	labels := []string{"gust_state", sm.StateName(state)}
+	if sm.Name != "" {
+		labels = append(labels, "gust_machine", sm.Name)
+	}
+	pprof.Do(r.ctx, pprof.Labels(labels...), func(ctx context.Context) {
+		if cs, ok := state.(ContextState); ok {
+			nextState, nextCargo, err = cs.ExecContext(ctx, cargo)
+		} else {
+			nextState, nextCargo, err = state.Exec(cargo)
 		}
+	})
+	return nextState, nextCargo, err
+}
+
+// interrupted returns an *AbortedError if the run was aborted or its context is done
+func (sm *StateMachine) interrupted(r *run, state State, cargo interface{}) error {
+	if r.ctx.Err() == nil {
+		return nil
 	}
+
+	sm.runsLock.RLock()
+	reason := r.reason
+	sm.runsLock.RUnlock()
+	if reason == nil {
+		reason = r.ctx.Err()
+	}
+	return &AbortedError{Reason: reason, State: sm.StateName(state), Token: r.resumeToken(state, cargo)}
 }
 
-func contains(s []State, e State) bool {
-	for _, a := range s {
-		if a == e {
-			return true
-		}
+// resumeToken returns the token resuming the run in the state with the cargo
+func (r *run) resumeToken(state State, cargo interface{}) *ResumeToken {
+	// the state is executed again when resumed, so isn't part of the path before it
+	path := r.path.list()
+	if r.executing {
+		path = path[:len(path)-1]
 	}
-	return false
+	steps := r.resumedSteps + r.path.entered
+	if r.executing {
+		steps--
+	}
+	return &ResumeToken{state: state, name: r.sm.StateName(state), cargo: cargo, path: path, run: r.idempotencyBase(), steps: steps, correlation: r.correlation}
+}
+
+func (sm *StateMachine) startRun(ctx context.Context) *run {
+	r := &run{sm: sm, path: newHistory(sm.historyLimit)}
+	if res, ok := ctx.Value(resumeKey{}).(resumed); ok {
+		r.keyBase, r.resumedSteps, r.lease = res.base, res.steps, res.lease
+		// runs started by the states, e.g. parallel branches, aren't resumed
+		ctx = context.WithValue(ctx, resumeKey{}, nil)
+	}
+	if drain, ok := ctx.Value(drainKey{}).(*drainer); ok {
+		r.drain = drain
+		// runs started by the states finish for the state to reach the next one
+		ctx = context.WithValue(ctx, drainKey{}, nil)
+	}
+	r.correlation, _ = CorrelationID(ctx)
+	ctx, r.signals = withSignals(ctx)
+	r.ctx, r.cancel = context.WithCancel(context.WithValue(ctx, runKey{}, r))
+
+	if sm.runIDs != nil {
+		r.id = sm.runIDs()
+	}
+
+	sm.runsLock.Lock()
+	defer sm.runsLock.Unlock()
+	sm.runsStarted++
+	if r.id == "" {
+		r.id = strconv.Itoa(sm.runsStarted)
+	}
+	r.started = sm.clock.Now()
+	sm.runs[r] = struct{}{}
+	return r
+}
+
+func (sm *StateMachine) enterState(r *run, state State) {
+	sm.runsLock.Lock()
+	defer sm.runsLock.Unlock()
+	r.prior = r.state
+	r.state = state
+	r.entered = sm.clock.Now()
+	r.executing = true
+	r.progress = Progress{}
+	r.path.add(sm.StateName(state))
+	sm.armWatchdog(r)
+}
+
+// info describes where the run is, the caller holds runsLock
+func (r *run) info() StateInfo {
+	return StateInfo{Name: r.sm.StateName(r.state), State: r.state, Entered: r.entered, Progress: r.progress}
+}
+
+func (sm *StateMachine) endRun(r *run) {
+	r.cancel()
+
+	sm.runsLock.Lock()
+	if r.watchdog != nil {
+		r.watchdog.Stop()
+	}
+	delete(sm.runs, r)
+	lease := r.lease
+	r.lease = nil
+	r.path.release()
+	sm.runsLock.Unlock()
+
+	if lease != nil {
+		sm.unlock(lease)
+	}
+}
+
+// stateName returns the name of the state if it has one
+func stateName(s State) string {
+	if n, ok := s.(HaveName); ok {
+		return n.Name()
+	}
+	return ""
+}
+
+// displayName is the state name, or its type if it has none
+func displayName(s State) string {
+	if name := stateName(s); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%T", s)
 }
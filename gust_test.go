@@ -1,8 +1,11 @@
 package gust
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -55,14 +58,15 @@ func (o *ObserverImpl) StateChanged(priorState string, nextState string) {
 	o.states = append(o.states, []string{priorState, nextState})
 }
 
-func TestContain_SameHandler_Works(t *testing.T) {
-	handlers := make([]State, 0)
+func TestIsRegistered_SameHandler_Works(t *testing.T) {
 	a := &StateImpl{}
 	b := &StateImpl{}
-	handlers = append(handlers, a)
 
-	assert.True(t, contains(handlers, a))
-	assert.False(t, contains(handlers, b))
+	m := NewStateMachine()
+	m.AddState(a)
+
+	assert.True(t, m.isRegistered(a))
+	assert.False(t, m.isRegistered(b))
 }
 
 func TestState_TraverselFromAToB_Works(t *testing.T) {
@@ -109,7 +113,7 @@ func TestStateTraversel_StateHasError_HasError(t *testing.T) {
 	if !assert.Error(t, err) {
 		return
 	}
-	assert.Equal(t, a.err, err)
+	assert.True(t, errors.Is(err, a.err))
 }
 
 func TestStateTraversel_FromAToBToDNotC_Works(t *testing.T) {
@@ -204,7 +208,7 @@ func TestObserver_TransitionFromAToBToD_ReportStateTransitions(t *testing.T) {
 	assert.Equal(t, []string{"stateC", "stateD"}, o.states[2])
 }
 
-func TestObserver_TransitionFromAToBToDToE_WithoutName_NotReportStateTransition(t *testing.T) {
+func TestObserver_TransitionFromAToBToDToE_WithoutName_ReportedByType(t *testing.T) {
 	// Construct
 	//     B
 	//   /   \
@@ -246,12 +250,12 @@ func TestObserver_TransitionFromAToBToDToE_WithoutName_NotReportStateTransition(
 		return
 	}
 
-	if !assert.Len(t, o.states, 2) {
-		return
-	}
-
-	assert.Equal(t, []string{"", "stateA"}, o.states[0])
-	assert.Equal(t, []string{"stateD", "stateE"}, o.states[1]) // A->C not reported since C has no name
+	assert.Equal(t, [][]string{
+		{"", "stateA"},
+		{"stateA", "*gust.StateNoName"}, // C has no name, it's named after its type
+		{"*gust.StateNoName", "stateD"},
+		{"stateD", "stateE"},
+	}, o.states)
 }
 
 func TestObserver_TransitionFromAToBToD_AddTwoObserver_BothReceived(t *testing.T) {
@@ -391,3 +395,271 @@ func TestObserver_GoToANextStateNotRegistered_ReturnsError(t *testing.T) {
 	err := m.Run(nil, a)
 	assert.Error(t, err)
 }
+
+type BlockingState struct { // interface State
+	name    string
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *BlockingState) Exec(cargo interface{}) (State, interface{}, error) {
+	close(s.entered)
+	<-s.release
+	return nil, cargo, nil
+}
+
+func (s *BlockingState) Name() string {
+	return s.name
+}
+
+func TestCurrentState_NotRunning_NotOk(t *testing.T) {
+	m := NewStateMachine()
+
+	_, ok := m.CurrentState()
+	assert.False(t, ok)
+}
+
+func TestCurrentState_WhileRunning_ReportsExecutingState(t *testing.T) {
+	// A -> B, where B blocks until released
+	b := &BlockingState{
+		name:    "stateB",
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	a := &StateImpl{
+		nextState: b,
+		name:      "stateA",
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+
+	before := time.Now()
+	done := make(chan error)
+	go func() {
+		done <- m.Run(nil, a)
+	}()
+
+	<-b.entered
+	info, ok := m.CurrentState()
+	if assert.True(t, ok) {
+		assert.Equal(t, "stateB", info.Name)
+		assert.Equal(t, b, info.State)
+		assert.False(t, info.Entered.Before(before))
+	}
+
+	close(b.release)
+	assert.Nil(t, <-done)
+
+	_, ok = m.CurrentState()
+	assert.False(t, ok)
+}
+
+func TestCurrentState_UnnamedStates_NamedByStateName(t *testing.T) {
+	first := &ContextStateImpl{entered: make(chan struct{})}
+	second := &ContextStateImpl{entered: make(chan struct{})}
+	m := NewStateMachine()
+	m.AddStates(first, second)
+
+	done := make(chan error)
+	go func() {
+		done <- m.Run(nil, second)
+	}()
+
+	<-second.entered
+	info, ok := m.CurrentState()
+	if assert.True(t, ok) {
+		assert.Equal(t, m.StateName(second), info.Name)
+		assert.NotEqual(t, m.StateName(first), info.Name)
+	}
+
+	m.Abort(nil)
+	var aborted *AbortedError
+	if assert.True(t, errors.As(<-done, &aborted)) {
+		assert.Equal(t, m.StateName(second), aborted.State)
+	}
+}
+
+type ContextStateImpl struct { // interface State and ContextState
+	name    string
+	entered chan struct{}
+}
+
+func (s *ContextStateImpl) Exec(cargo interface{}) (State, interface{}, error) {
+	panic("ExecContext should be called instead")
+}
+
+func (s *ContextStateImpl) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	close(s.entered)
+	<-ctx.Done()
+	return nil, cargo, ctx.Err()
+}
+
+func (s *ContextStateImpl) Name() string {
+	return s.name
+}
+
+func TestAbort_ContextStateRunning_CancelsAndReturnsAbortedError(t *testing.T) {
+	a := &ContextStateImpl{
+		name:    "stateA",
+		entered: make(chan struct{}),
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+
+	done := make(chan error)
+	go func() {
+		done <- m.Run(nil, a)
+	}()
+
+	<-a.entered
+	reason := fmt.Errorf("operator stop")
+	m.Abort(reason)
+
+	err := <-done
+	var aborted *AbortedError
+	if !assert.True(t, errors.As(err, &aborted)) {
+		return
+	}
+	assert.Equal(t, reason, aborted.Reason)
+	assert.Equal(t, "stateA", aborted.State)
+	assert.True(t, errors.Is(err, reason))
+}
+
+func TestAbort_PlainStateRunning_StopsBeforeNextTransition(t *testing.T) {
+	// A -> B -> C, aborted while in B so C never runs
+	c := &StateImpl{}
+	b := &BlockingState{
+		name:    "stateB",
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	a := &StateImpl{
+		nextState: b,
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.AddState(c)
+
+	done := make(chan error)
+	go func() {
+		done <- m.Run(nil, a)
+	}()
+
+	<-b.entered
+	m.Abort(nil)
+	close(b.release)
+
+	err := <-done
+	var aborted *AbortedError
+	assert.True(t, errors.As(err, &aborted))
+	assert.False(t, c.run)
+}
+
+func TestRunContext_ContextCancelled_ReturnsAbortedError(t *testing.T) {
+	a := &StateImpl{}
+
+	m := NewStateMachine()
+	m.AddState(a)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := m.RunContext(ctx, nil, a)
+	var aborted *AbortedError
+	assert.True(t, errors.As(err, &aborted))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, a.run)
+}
+
+func BenchmarkRun_ThousandStates(b *testing.B) {
+	// A chain of 1000 states, each going to the next one
+	states := make([]*StateImpl, 1000)
+	for i := len(states) - 1; i >= 0; i-- {
+		states[i] = &StateImpl{}
+		if i < len(states)-1 {
+			states[i].nextState = states[i+1]
+		}
+	}
+
+	m := NewStateMachine()
+	for _, s := range states {
+		m.AddState(s)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := m.Run(nil, states[0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestObserver_RegisterWhileRunning_NoRace(t *testing.T) {
+	a := &StateImpl{}
+	a.nextState = a
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.MaxTransitions = 1000
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			o := NewObserverImpl()
+			m.RegisterObservers(o)
+			m.RemoveObserver(o)
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, errors.Is(m.Run(nil, a), ErrMaxTransitions))
+	}
+	<-done
+}
+
+func TestAddState_SameStateTwice_ReturnsError(t *testing.T) {
+	a := &StateImpl{}
+
+	m := NewStateMachine()
+	assert.Nil(t, m.AddState(a))
+
+	err := m.AddState(a)
+	assert.True(t, errors.Is(err, ErrDuplicateState))
+	assert.Len(t, m.States, 1)
+}
+
+func TestAddState_SameNameTwice_ReturnsError(t *testing.T) {
+	a := &StateImpl{name: "stateA"}
+	b := &StateImpl{name: "stateA"}
+
+	m := NewStateMachine()
+	assert.Nil(t, m.AddState(a))
+
+	err := m.AddState(b)
+	assert.True(t, errors.Is(err, ErrDuplicateState))
+	assert.Len(t, m.States, 1)
+}
+
+func TestAddState_UnnamedStates_NotDuplicates(t *testing.T) {
+	m := NewStateMachine()
+	assert.Nil(t, m.AddState(&StateNoName{}))
+	assert.Nil(t, m.AddState(&StateNoName{}))
+	assert.Len(t, m.States, 2)
+}
+
+func TestMustAddState_Duplicate_Panics(t *testing.T) {
+	a := &StateImpl{}
+
+	m := NewStateMachine()
+	m.MustAddState(a)
+
+	assert.Panics(t, func() {
+		m.MustAddState(a)
+	})
+}
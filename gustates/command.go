@@ -0,0 +1,83 @@
+package gustates
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/t2wu/gust"
+)
+
+// CommandResult is the cargo an ExecCommand passes on
+type CommandResult struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+}
+
+// ExecCommand runs a program and passes its output on to Next as a
+// *CommandResult. A non-zero exit fails the state unless AllowFailure is set.
+type ExecCommand struct {
+	name    string
+	Command string
+	Args    []string
+	Dir     string
+	Env     []string // added to the environment of the process, as KEY=value
+
+	// Stdin if not nil returns what's written to the program's standard
+	// input, it can use the cargo
+	Stdin func(cargo interface{}) ([]byte, error)
+	// AllowFailure passes non-zero exits on to Next rather than failing
+	AllowFailure bool
+
+	Next gust.State
+}
+
+// NewExecCommand is a constructor for ExecCommand
+func NewExecCommand(name, command string, args ...string) *ExecCommand {
+	return &ExecCommand{name: name, Command: command, Args: args}
+}
+
+// Exec runs the program without a way to kill it, ExecContext is used in runs
+func (s *ExecCommand) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+// ExecContext runs the program, it's killed if ctx is done before it exits
+func (s *ExecCommand) ExecContext(ctx context.Context, cargo interface{}) (gust.State, interface{}, error) {
+	cmd := exec.CommandContext(ctx, s.Command, s.Args...)
+	cmd.Dir = s.Dir
+	if len(s.Env) > 0 {
+		cmd.Env = append(os.Environ(), s.Env...)
+	}
+	if s.Stdin != nil {
+		in, err := s.Stdin(cargo)
+		if err != nil {
+			return nil, cargo, gust.Fatal(err)
+		}
+		cmd.Stdin = bytes.NewReader(in)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	err := cmd.Run()
+	result := &CommandResult{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		result.ExitCode = exit.ExitCode()
+		if !s.AllowFailure {
+			return nil, cargo, fmt.Errorf("%s: %w: %s", s.Command, err, bytes.TrimSpace(result.Stderr))
+		}
+	} else if err != nil {
+		return nil, cargo, err
+	}
+	return s.Next, result, nil
+}
+
+// Name is the name given to NewExecCommand
+func (s *ExecCommand) Name() string {
+	return s.name
+}
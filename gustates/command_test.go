@@ -0,0 +1,43 @@
+package gustates
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecCommand_OutputAsCargo(t *testing.T) {
+	s := NewExecCommand("echo", "sh", "-c", "cat; echo \" $GREETING\"")
+	s.Env = []string{"GREETING=world"}
+	s.Stdin = func(cargo interface{}) ([]byte, error) {
+		return []byte(cargo.(string)), nil
+	}
+
+	next, cargo, err := s.ExecContext(context.Background(), "hello")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, next)
+	result := cargo.(*CommandResult)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "hello world\n", string(result.Stdout))
+}
+
+func TestExecCommand_NonZeroExit_Fails(t *testing.T) {
+	s := NewExecCommand("fail", "sh", "-c", "echo oops >&2; exit 3")
+
+	_, _, err := s.ExecContext(context.Background(), nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "oops")
+	}
+}
+
+func TestExecCommand_AllowFailure_ExitCodeInCargo(t *testing.T) {
+	s := NewExecCommand("fail", "sh", "-c", "exit 3")
+	s.AllowFailure = true
+
+	_, cargo, err := s.ExecContext(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, cargo.(*CommandResult).ExitCode)
+}
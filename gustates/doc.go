@@ -0,0 +1,8 @@
+// Package gustates is a library of ready made states for common steps: Noop,
+// Sleep, HTTPRequest, ExecCommand, Publish, Query and Render. Each is created with a name
+// and goes on to its Next state, nil ending the run:
+//
+//	wait := gustates.NewSleep("cool down", time.Minute)
+//	fetch := gustates.NewHTTPRequest("fetch", http.MethodGet, "https://example.com/orders")
+//	wait.Next = fetch
+package gustates
@@ -0,0 +1,107 @@
+package gustates
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/t2wu/gust"
+)
+
+// HTTPResponse is the cargo an HTTPRequest passes on
+type HTTPResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// HTTPRequest sends a request and passes the response on to Next as an
+// *HTTPResponse. Responses with a status outside 2xx fail the state, 5xx and
+// 429 ones with a retryable error so a retry policy can try again.
+type HTTPRequest struct {
+	name   string
+	Method string
+	URL    string
+	Header http.Header
+
+	// Body if not nil returns the request body for the cargo
+	Body func(cargo interface{}) (io.Reader, error)
+	// Client sends the request, http.DefaultClient if nil
+	Client *http.Client
+	// MaxBody limits how much of the response body is read, 10 MiB if 0
+	MaxBody int64
+
+	Next gust.State
+}
+
+// NewHTTPRequest is a constructor for HTTPRequest
+func NewHTTPRequest(name, method, url string) *HTTPRequest {
+	return &HTTPRequest{name: name, Method: method, URL: url, Header: make(http.Header)}
+}
+
+// JSONBody is an HTTPRequest Body sending the cargo as it is if it's a
+// []byte or string, and as JSON otherwise
+func JSONBody(cargo interface{}) (io.Reader, error) {
+	data, err := encode(cargo)
+	return bytes.NewReader(data), err
+}
+
+// Exec sends the request without a way to cancel it, ExecContext is used in runs
+func (s *HTTPRequest) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+// ExecContext sends the request
+func (s *HTTPRequest) ExecContext(ctx context.Context, cargo interface{}) (gust.State, interface{}, error) {
+	var body io.Reader
+	if s.Body != nil {
+		var err error
+		if body, err = s.Body(cargo); err != nil {
+			return nil, cargo, gust.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(s.Method, s.URL, body)
+	if err != nil {
+		return nil, cargo, gust.Fatal(err)
+	}
+	req = req.WithContext(ctx)
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, cargo, gust.Retryable(err)
+	}
+	defer resp.Body.Close()
+
+	max := s.MaxBody
+	if max == 0 {
+		max = 10 << 20
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, max))
+	if err != nil {
+		return nil, cargo, gust.Retryable(err)
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return s.Next, &HTTPResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return nil, cargo, gust.Retryable(fmt.Errorf("%s %s: %s", s.Method, s.URL, resp.Status))
+	default:
+		return nil, cargo, fmt.Errorf("%s %s: %s", s.Method, s.URL, resp.Status)
+	}
+}
+
+// Name is the name given to NewHTTPRequest
+func (s *HTTPRequest) Name() string {
+	return s.name
+}
@@ -0,0 +1,58 @@
+package gustates
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+func TestHTTPRequest_OK_ResponseAsCargo(t *testing.T) {
+	var got []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ioutil.ReadAll(r.Body)
+		assert.Equal(t, "yes", r.Header.Get("X-Test"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+
+	s := NewHTTPRequest("create", http.MethodPost, srv.URL)
+	s.Header.Set("X-Test", "yes")
+	s.Body = JSONBody
+	s.Next = NewNoop("done")
+
+	next, cargo, err := s.ExecContext(context.Background(), map[string]int{"total": 5})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, s.Next, next)
+	assert.Equal(t, `{"total":5}`, string(got))
+	resp := cargo.(*HTTPResponse)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"id":1}`, string(resp.Body))
+}
+
+func TestHTTPRequest_ServerError_Retryable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	_, _, err := NewHTTPRequest("fetch", http.MethodGet, srv.URL).ExecContext(context.Background(), nil)
+	assert.NotNil(t, err)
+	assert.True(t, gust.IsRetryable(err))
+}
+
+func TestHTTPRequest_ClientError_NotRetryable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, _, err := NewHTTPRequest("fetch", http.MethodGet, srv.URL).ExecContext(context.Background(), nil)
+	assert.NotNil(t, err)
+	assert.False(t, gust.IsRetryable(err))
+}
@@ -0,0 +1,24 @@
+package gustates
+
+import "github.com/t2wu/gust"
+
+// Noop passes the cargo on to Next, for joining branches or as a placeholder
+type Noop struct {
+	name string
+	Next gust.State
+}
+
+// NewNoop is a constructor for Noop
+func NewNoop(name string) *Noop {
+	return &Noop{name: name}
+}
+
+// Exec goes to Next
+func (s *Noop) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	return s.Next, cargo, nil
+}
+
+// Name is the name given to NewNoop
+func (s *Noop) Name() string {
+	return s.name
+}
@@ -0,0 +1,25 @@
+package gustates
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+func TestNoop_GoesToNextWithCargo(t *testing.T) {
+	m := gust.NewStateMachine()
+	first, second := NewNoop("first"), NewNoop("second")
+	first.Next = second
+	m.AddState(first)
+	m.AddState(second)
+	m.AddTransition(first, second)
+
+	result, err := m.Execute(context.Background(), "cargo", first)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"first", "second"}, result.Path)
+	assert.Equal(t, "cargo", result.Cargo)
+}
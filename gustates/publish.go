@@ -0,0 +1,51 @@
+package gustates
+
+import (
+	"encoding/json"
+
+	"github.com/t2wu/gust"
+	"github.com/t2wu/gust/gustpub"
+)
+
+// Publish sends the cargo to a subject or topic, then passes it on unchanged
+// to Next. Cargo is sent as it is if it's a []byte or string, as JSON
+// otherwise.
+type Publish struct {
+	name      string
+	Publisher gustpub.Publisher
+	Subject   string
+	Next      gust.State
+}
+
+// NewPublish is a constructor for Publish
+func NewPublish(name string, pub gustpub.Publisher, subject string) *Publish {
+	return &Publish{name: name, Publisher: pub, Subject: subject}
+}
+
+// Exec publishes the cargo, failures to publish are retryable
+func (s *Publish) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	data, err := encode(cargo)
+	if err != nil {
+		return nil, cargo, gust.Fatal(err)
+	}
+	if err := s.Publisher.Publish(s.Subject, data); err != nil {
+		return nil, cargo, gust.Retryable(err)
+	}
+	return s.Next, cargo, nil
+}
+
+// Name is the name given to NewPublish
+func (s *Publish) Name() string {
+	return s.name
+}
+
+// encode returns []byte and string cargo as it is, other cargo as JSON
+func encode(cargo interface{}) ([]byte, error) {
+	switch c := cargo.(type) {
+	case []byte:
+		return c, nil
+	case string:
+		return []byte(c), nil
+	}
+	return json.Marshal(cargo)
+}
@@ -0,0 +1,34 @@
+package gustates
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+	"github.com/t2wu/gust/gustpub"
+)
+
+func TestPublish_SendsCargoUnchanged(t *testing.T) {
+	var subject, data string
+	pub := gustpub.PublisherFunc(func(s string, d []byte) error {
+		subject, data = s, string(d)
+		return nil
+	})
+	s := NewPublish("announce", pub, "orders.shipped")
+
+	_, cargo, err := s.Exec(map[string]string{"id": "o1"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"id": "o1"}, cargo)
+	assert.Equal(t, "orders.shipped", subject)
+	assert.Equal(t, `{"id":"o1"}`, data)
+}
+
+func TestPublish_Fails_Retryable(t *testing.T) {
+	pub := gustpub.PublisherFunc(func(string, []byte) error {
+		return errors.New("broker down")
+	})
+
+	_, _, err := NewPublish("announce", pub, "orders").Exec("raw")
+	assert.True(t, gust.IsRetryable(err))
+}
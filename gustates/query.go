@@ -0,0 +1,158 @@
+package gustates
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/t2wu/gust"
+)
+
+// Queryer runs queries, *sql.DB, *sql.Tx and *sql.Conn all are
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Row is a result row, keyed by column name. Text columns are strings rather
+// than []byte.
+type Row map[string]interface{}
+
+// Query runs a parameterized query with arguments taken from the cargo and
+// merges the rows it returns back into the cargo. By default the cargo must
+// be a map[string]interface{}, and the rows are set under the Into key of a
+// copy of it, Merge changes that.
+type Query struct {
+	name  string
+	DB    Queryer
+	Query string
+
+	// Args returns the query arguments for the cargo, there are none if nil
+	Args func(cargo interface{}) ([]interface{}, error)
+	// Into is the cargo key the rows are set under by default, "rows" if empty
+	Into string
+	// Merge if not nil returns the cargo passed on, given the rows
+	Merge func(cargo interface{}, rows []Row) (interface{}, error)
+
+	Next gust.State
+}
+
+// NewQuery is a constructor for Query
+func NewQuery(name string, db Queryer, query string) *Query {
+	return &Query{name: name, DB: db, Query: query}
+}
+
+// ArgsFrom is a Query Args taking the arguments from the given keys of a
+// map[string]interface{} cargo, in order
+func ArgsFrom(keys ...string) func(cargo interface{}) ([]interface{}, error) {
+	return func(cargo interface{}) ([]interface{}, error) {
+		m, ok := cargo.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cargo is %T, not map[string]interface{}", cargo)
+		}
+		args := make([]interface{}, len(keys))
+		for i, k := range keys {
+			v, ok := m[k]
+			if !ok {
+				return nil, fmt.Errorf("cargo has no %q", k)
+			}
+			args[i] = v
+		}
+		return args, nil
+	}
+}
+
+// Exec runs the query without a way to cancel it, ExecContext is used in runs
+func (s *Query) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+// ExecContext runs the query
+func (s *Query) ExecContext(ctx context.Context, cargo interface{}) (gust.State, interface{}, error) {
+	var args []interface{}
+	if s.Args != nil {
+		var err error
+		if args, err = s.Args(cargo); err != nil {
+			return nil, cargo, gust.Fatal(err)
+		}
+	}
+
+	rows, err := s.DB.QueryContext(ctx, s.Query, args...)
+	if err != nil {
+		return nil, cargo, err
+	}
+	result, err := scanRows(rows)
+	if err != nil {
+		return nil, cargo, err
+	}
+
+	merged, err := s.merge(cargo, result)
+	if err != nil {
+		return nil, cargo, gust.Fatal(err)
+	}
+	return s.Next, merged, nil
+}
+
+// merge returns the cargo with the rows in it
+func (s *Query) merge(cargo interface{}, rows []Row) (interface{}, error) {
+	if s.Merge != nil {
+		return s.Merge(cargo, rows)
+	}
+
+	into := s.Into
+	if into == "" {
+		into = "rows"
+	}
+	return mergeInto(cargo, into, rows)
+}
+
+// Name is the name given to NewQuery
+func (s *Query) Name() string {
+	return s.name
+}
+
+// mergeInto returns a copy of the map[string]interface{} cargo with value
+// set under key
+func mergeInto(cargo interface{}, key string, value interface{}) (interface{}, error) {
+	m, ok := cargo.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cargo is %T, not map[string]interface{}", cargo)
+	}
+	merged := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		merged[k] = v
+	}
+	merged[key] = value
+	return merged, nil
+}
+
+// scanRows reads all rows and closes them
+func scanRows(rows *sql.Rows) ([]Row, error) {
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Row, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make(Row, len(cols))
+		for i, c := range cols {
+			if b, ok := values[i].([]byte); ok {
+				row[c] = string(b)
+			} else {
+				row[c] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
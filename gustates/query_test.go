@@ -0,0 +1,104 @@
+package gustates
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDriver answers every query with the customers table, recording the
+// query and arguments it was given
+type fakeDriver struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.query = query
+	return &fakeStmt{c.d}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, io.EOF }
+
+type fakeStmt struct{ d *fakeDriver }
+
+func (s *fakeStmt) Close() error                                    { return nil }
+func (s *fakeStmt) NumInput() int                                   { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) { return nil, io.EOF }
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.args = args
+	return &fakeRows{rows: [][]driver.Value{{int64(1), []byte("ada")}}}, nil
+}
+
+type fakeRows struct{ rows [][]driver.Value }
+
+func (r *fakeRows) Columns() []string { return []string{"id", "name"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openFake(t *testing.T) (*sql.DB, *fakeDriver) {
+	d := &fakeDriver{}
+	sql.Register("gustates-fake-"+t.Name(), d)
+	db, err := sql.Open("gustates-fake-"+t.Name(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func TestQuery_RowsMergedIntoCargo(t *testing.T) {
+	db, d := openFake(t)
+	s := NewQuery("lookup", db, "SELECT id, name FROM customers WHERE email = ?")
+	s.Args = ArgsFrom("email")
+	s.Into = "customers"
+
+	cargo := map[string]interface{}{"email": "ada@example.com"}
+	_, next, err := s.ExecContext(context.Background(), cargo)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "SELECT id, name FROM customers WHERE email = ?", d.query)
+	assert.Equal(t, []driver.Value{"ada@example.com"}, d.args)
+	assert.Equal(t, map[string]interface{}{
+		"email":     "ada@example.com",
+		"customers": []Row{{"id": int64(1), "name": "ada"}},
+	}, next)
+	assert.Len(t, cargo, 1) // not modified
+}
+
+func TestQuery_Merge_CustomCargo(t *testing.T) {
+	db, _ := openFake(t)
+	s := NewQuery("lookup", db, "SELECT id, name FROM customers")
+	s.Merge = func(cargo interface{}, rows []Row) (interface{}, error) {
+		return rows[0]["name"], nil
+	}
+
+	_, next, err := s.Exec(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "ada", next)
+}
+
+func TestQuery_ArgMissing_Fails(t *testing.T) {
+	db, _ := openFake(t)
+	s := NewQuery("lookup", db, "SELECT 1")
+	s.Args = ArgsFrom("email")
+
+	_, _, err := s.Exec(map[string]interface{}{})
+	assert.NotNil(t, err)
+}
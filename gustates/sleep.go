@@ -0,0 +1,44 @@
+package gustates
+
+import (
+	"context"
+	"time"
+
+	"github.com/t2wu/gust"
+)
+
+// Sleep waits for a duration then passes the cargo on to Next. Aborting the
+// run cuts the wait short.
+type Sleep struct {
+	name     string
+	Duration time.Duration
+	Next     gust.State
+}
+
+// NewSleep is a constructor for Sleep
+func NewSleep(name string, d time.Duration) *Sleep {
+	return &Sleep{name: name, Duration: d}
+}
+
+// Exec sleeps without a way to be interrupted, ExecContext is used in runs
+func (s *Sleep) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+// ExecContext sleeps until the duration elapsed or ctx is done
+func (s *Sleep) ExecContext(ctx context.Context, cargo interface{}) (gust.State, interface{}, error) {
+	t := time.NewTimer(s.Duration)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return s.Next, cargo, nil
+	case <-ctx.Done():
+		return nil, cargo, ctx.Err()
+	}
+}
+
+// Name is the name given to NewSleep
+func (s *Sleep) Name() string {
+	return s.name
+}
@@ -0,0 +1,32 @@
+package gustates
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSleep_Waits(t *testing.T) {
+	s := NewSleep("wait", 10*time.Millisecond)
+	s.Next = NewNoop("done")
+
+	start := time.Now()
+	next, cargo, err := s.ExecContext(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, s.Next, next)
+	assert.Equal(t, 1, cargo)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+}
+
+func TestSleep_ContextDone_CutShort(t *testing.T) {
+	s := NewSleep("wait", time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	next, _, err := s.ExecContext(ctx, nil)
+	assert.Nil(t, next)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
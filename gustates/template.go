@@ -0,0 +1,64 @@
+package gustates
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/t2wu/gust"
+)
+
+// Template is a parsed template, *text/template.Template and
+// *html/template.Template both are
+type Template interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// Render executes a template with the cargo as data and merges the output
+// back into the cargo. By default the cargo must be a map[string]interface{},
+// and the output is set as a string under the Into key of a copy of it, Merge
+// changes that.
+type Render struct {
+	name     string
+	Template Template
+
+	// Into is the cargo key the output is set under by default, "rendered" if empty
+	Into string
+	// Merge if not nil returns the cargo passed on, given the output
+	Merge func(cargo interface{}, rendered string) (interface{}, error)
+
+	Next gust.State
+}
+
+// NewRender is a constructor for Render
+func NewRender(name string, tmpl Template) *Render {
+	return &Render{name: name, Template: tmpl}
+}
+
+// Exec renders the template, a template failing to execute is fatal
+func (s *Render) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	var b bytes.Buffer
+	if err := s.Template.Execute(&b, cargo); err != nil {
+		return nil, cargo, gust.Fatal(err)
+	}
+
+	var merged interface{}
+	var err error
+	if s.Merge != nil {
+		merged, err = s.Merge(cargo, b.String())
+	} else {
+		into := s.Into
+		if into == "" {
+			into = "rendered"
+		}
+		merged, err = mergeInto(cargo, into, b.String())
+	}
+	if err != nil {
+		return nil, cargo, gust.Fatal(err)
+	}
+	return s.Next, merged, nil
+}
+
+// Name is the name given to NewRender
+func (s *Render) Name() string {
+	return s.name
+}
@@ -0,0 +1,39 @@
+package gustates
+
+import (
+	htmltemplate "html/template"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_OutputMergedIntoCargo(t *testing.T) {
+	tmpl := template.Must(template.New("email").Parse("Hi {{.name}}, order {{.id}} shipped"))
+	s := NewRender("email", tmpl)
+	s.Into = "body"
+
+	_, cargo, err := s.Exec(map[string]interface{}{"name": "Ada", "id": "o1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hi Ada, order o1 shipped", cargo.(map[string]interface{})["body"])
+	assert.Equal(t, "Ada", cargo.(map[string]interface{})["name"])
+}
+
+func TestRender_HTMLTemplate_Escaped(t *testing.T) {
+	tmpl := htmltemplate.Must(htmltemplate.New("page").Parse("<p>{{.}}</p>"))
+	s := NewRender("page", tmpl)
+	s.Merge = func(cargo interface{}, rendered string) (interface{}, error) {
+		return rendered, nil
+	}
+
+	_, cargo, err := s.Exec("<b>")
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>&lt;b&gt;</p>", cargo)
+}
+
+func TestRender_NotMapCargo_Fails(t *testing.T) {
+	s := NewRender("text", template.Must(template.New("t").Parse("{{.}}")))
+
+	_, _, err := s.Exec("plain")
+	assert.NotNil(t, err)
+}
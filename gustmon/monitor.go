@@ -0,0 +1,135 @@
+// Package gustmon is a live terminal monitor for gust machines. It shows the
+// in-flight runs with the state each is in and for how long, followed by the
+// most recent transitions, redrawn in place. It only writes ANSI escapes to an
+// io.Writer, so it works over SSH without anything installed.
+//
+//	mon := gustmon.New(sm)
+//	defer mon.Close()
+//	go mon.Run(ctx, os.Stdout, time.Second)
+package gustmon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/t2wu/gust"
+)
+
+// DefaultHistory is the number of recent transitions a Monitor shows
+const DefaultHistory = 10
+
+// clearScreen moves the cursor home and clears the screen
+const clearScreen = "\x1b[H\x1b[2J"
+
+// Transition is a state change seen by the monitor
+type Transition struct {
+	At    time.Time
+	Prior string // empty when Next is a start state
+	Next  string
+}
+
+// Monitor observes a machine and renders its runs, see New
+type Monitor struct {
+	sm *gust.StateMachine
+
+	// History is the number of recent transitions kept and shown
+	History int
+
+	lock   *sync.Mutex
+	recent []Transition
+}
+
+// New registers a monitor as an observer of sm, Close unregisters it
+func New(sm *gust.StateMachine) *Monitor {
+	m := &Monitor{
+		sm:      sm,
+		History: DefaultHistory,
+		lock:    &sync.Mutex{},
+		recent:  make([]Transition, 0),
+	}
+	sm.RegisterObservers(m)
+	return m
+}
+
+// Close stops observing the machine
+func (m *Monitor) Close() {
+	m.sm.RemoveObserver(m)
+}
+
+// StateChanged remembers the transition, it implements gust.Observer
+func (m *Monitor) StateChanged(priorState string, nextState string) {
+	t := Transition{At: m.sm.Clock().Now(), Prior: priorState, Next: nextState}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.recent = append(m.recent, t)
+	if over := len(m.recent) - m.History; over > 0 {
+		m.recent = append(m.recent[:0], m.recent[over:]...)
+	}
+}
+
+// Recent returns the recent transitions, oldest first
+func (m *Monitor) Recent() []Transition {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]Transition{}, m.recent...)
+}
+
+// Render writes a single frame, without clearing the screen
+func (m *Monitor) Render(w io.Writer) error {
+	now := m.sm.Clock().Now()
+	runs := m.sm.Runs()
+	recent := m.Recent()
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "gust monitor  %s\n\n", now.Format("15:04:05"))
+
+	fmt.Fprintf(tw, "ACTIVE RUNS (%d)\n", len(runs))
+	if len(runs) > 0 {
+		fmt.Fprintln(tw, "STATE\tFOR\tPROGRESS")
+	}
+	for _, r := range runs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Name, now.Sub(r.Entered).Truncate(time.Second), progress(r.Progress))
+	}
+
+	fmt.Fprintln(tw, "\nRECENT TRANSITIONS")
+	for i := len(recent) - 1; i >= 0; i-- {
+		t := recent[i]
+		prior := t.Prior
+		if prior == "" {
+			prior = "start"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t-> %s\n", t.At.Format("15:04:05"), prior, t.Next)
+	}
+	return tw.Flush()
+}
+
+// Run redraws the screen every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context, w io.Writer, interval time.Duration) error {
+	for {
+		if _, err := io.WriteString(w, clearScreen); err != nil {
+			return err
+		}
+		if err := m.Render(w); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.sm.Clock().After(interval):
+		}
+	}
+}
+
+func progress(p gust.Progress) string {
+	if p.Updated.IsZero() {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprintf("%.0f%% %s", p.Percent, p.Message))
+}
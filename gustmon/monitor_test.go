@@ -0,0 +1,132 @@
+package gustmon
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+	"github.com/t2wu/gust/gusttest"
+)
+
+type namedState struct {
+	name string
+	next gust.State
+}
+
+func (s *namedState) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	return s.next, cargo, nil
+}
+
+func (s *namedState) Name() string {
+	return s.name
+}
+
+// uploadState reports progress then blocks until released
+type uploadState struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *uploadState) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	panic("ExecContext should be called instead")
+}
+
+func (s *uploadState) ExecContext(ctx context.Context, cargo interface{}) (gust.State, interface{}, error) {
+	gust.ReportProgress(ctx, 30, "3 of 10 files")
+	close(s.entered)
+	<-s.release
+	return nil, cargo, nil
+}
+
+func (s *uploadState) Name() string {
+	return "upload"
+}
+
+var start = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestMonitor_Render_ShowsRunsAndTransitions(t *testing.T) {
+	clock := gusttest.NewFakeClock(start)
+	upload := &uploadState{entered: make(chan struct{}), release: make(chan struct{})}
+	prepare := &namedState{name: "prepare", next: upload}
+
+	sm := gust.NewStateMachine(gust.WithClock(clock))
+	sm.AddStates(prepare, upload)
+	mon := New(sm)
+	defer mon.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- sm.Run(nil, prepare)
+	}()
+	<-upload.entered
+	clock.Advance(90 * time.Second)
+
+	var buf bytes.Buffer
+	assert.Nil(t, mon.Render(&buf))
+	assert.Equal(t, strings.Join([]string{
+		"gust monitor  12:01:30",
+		"",
+		"ACTIVE RUNS (1)",
+		"STATE   FOR    PROGRESS",
+		"upload  1m30s  30% 3 of 10 files",
+		"",
+		"RECENT TRANSITIONS",
+		"12:00:00  prepare  -> upload",
+		"12:00:00  start    -> prepare",
+		"",
+	}, "\n"), buf.String())
+
+	close(upload.release)
+	assert.Nil(t, <-done)
+}
+
+func TestMonitor_History_KeepsMostRecent(t *testing.T) {
+	sm := gust.NewStateMachine()
+	mon := New(sm)
+	mon.History = 2
+
+	mon.StateChanged("", "a")
+	mon.StateChanged("a", "b")
+	mon.StateChanged("b", "c")
+
+	recent := mon.Recent()
+	if assert.Len(t, recent, 2) {
+		assert.Equal(t, "b", recent[0].Next)
+		assert.Equal(t, "c", recent[1].Next)
+	}
+}
+
+func TestMonitor_Close_StopsObserving(t *testing.T) {
+	a := &namedState{name: "a"}
+	sm := gust.NewStateMachine()
+	sm.AddState(a)
+	mon := New(sm)
+	mon.Close()
+
+	assert.Nil(t, sm.Run(nil, a))
+	assert.Len(t, mon.Recent(), 0)
+}
+
+func TestMonitor_Run_RedrawsUntilDone(t *testing.T) {
+	clock := gusttest.NewFakeClock(start)
+	sm := gust.NewStateMachine(gust.WithClock(clock))
+	mon := New(sm)
+	defer mon.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- mon.Run(ctx, &buf, time.Second)
+	}()
+
+	clock.BlockUntil(1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.True(t, strings.HasPrefix(buf.String(), clearScreen+"gust monitor  12:00:00\n"))
+	assert.Contains(t, buf.String(), "ACTIVE RUNS (0)")
+}
@@ -0,0 +1,85 @@
+// Package gustpub publishes a machine's transitions to a message broker such
+// as NATS or Kafka, or to webhooks, so other services can follow workflows
+// without polling. Events are JSON in a stable schema, see Event.
+//
+// A *nats.Conn is a Publisher as is:
+//
+//	sm.RegisterObservers(gustpub.NewObserver(nc, "orders.transitions", "order"))
+//
+// Kafka clients are adapted with PublisherFunc, e.g. with kafka-go:
+//
+//	pub := gustpub.PublisherFunc(func(topic string, data []byte) error {
+//		return w.WriteMessages(context.Background(), kafka.Message{Topic: topic, Value: data})
+//	})
+package gustpub
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Schema identifies the version of the event schema, it only changes when a
+// change would break consumers
+const Schema = "gust.transition/v1"
+
+// Event is what's published for every transition
+type Event struct {
+	Schema  string    `json:"schema"`
+	Machine string    `json:"machine,omitempty"`
+	From    string    `json:"from"` // empty when To is the start state
+	To      string    `json:"to"`
+	At      time.Time `json:"at"`
+}
+
+// Publisher sends a message to a subject or topic. *nats.Conn implements it.
+type Publisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// PublisherFunc is a function used as a Publisher
+type PublisherFunc func(subject string, data []byte) error
+
+// Publish calls the function
+func (f PublisherFunc) Publish(subject string, data []byte) error {
+	return f(subject, data)
+}
+
+// Observer is a gust.Observer publishing every state change as an Event
+type Observer struct {
+	pub     Publisher
+	subject string
+	machine string
+
+	// OnError if not nil is called when publishing fails, events aren't retried
+	OnError func(err error)
+	// Now is the source of the events' time, time.Now if nil
+	Now func() time.Time
+}
+
+// NewObserver returns an observer publishing to the subject, with events
+// naming the machine
+func NewObserver(pub Publisher, subject, machine string) *Observer {
+	return &Observer{pub: pub, subject: subject, machine: machine}
+}
+
+// StateChanged publishes the change, it implements gust.Observer
+func (o *Observer) StateChanged(priorState string, nextState string) {
+	now := time.Now
+	if o.Now != nil {
+		now = o.Now
+	}
+
+	data, err := json.Marshal(Event{
+		Schema:  Schema,
+		Machine: o.machine,
+		From:    priorState,
+		To:      nextState,
+		At:      now().UTC(),
+	})
+	if err == nil {
+		err = o.pub.Publish(o.subject, data)
+	}
+	if err != nil && o.OnError != nil {
+		o.OnError(err)
+	}
+}
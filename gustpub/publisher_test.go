@@ -0,0 +1,68 @@
+package gustpub
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+type message struct {
+	subject string
+	data    string
+}
+
+type fakeBroker struct {
+	messages []message
+}
+
+func (b *fakeBroker) Publish(subject string, data []byte) error {
+	b.messages = append(b.messages, message{subject, string(data)})
+	return nil
+}
+
+type node struct {
+	name string
+	next gust.State
+}
+
+func (n *node) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	return n.next, cargo, nil
+}
+
+func (n *node) Name() string {
+	return n.name
+}
+
+func TestObserver_PublishesStableJSON(t *testing.T) {
+	b := &node{name: "paid"}
+	a := &node{name: "created", next: b}
+	sm := gust.NewStateMachine()
+	sm.AddStates(a, b)
+
+	broker := &fakeBroker{}
+	o := NewObserver(broker, "orders.transitions", "order")
+	o.Now = func() time.Time {
+		return time.Date(2021, 6, 1, 14, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	}
+	sm.RegisterObservers(o)
+
+	assert.Nil(t, sm.Run(nil, a))
+	assert.Equal(t, []message{
+		{"orders.transitions", `{"schema":"gust.transition/v1","machine":"order","from":"","to":"created","at":"2021-06-01T12:00:00Z"}`},
+		{"orders.transitions", `{"schema":"gust.transition/v1","machine":"order","from":"created","to":"paid","at":"2021-06-01T12:00:00Z"}`},
+	}, broker.messages)
+}
+
+func TestObserver_PublishFails_OnError(t *testing.T) {
+	var got error
+	o := NewObserver(PublisherFunc(func(subject string, data []byte) error {
+		return errors.New("no connection")
+	}), "t", "")
+	o.OnError = func(err error) { got = err }
+
+	o.StateChanged("", "a")
+	assert.EqualError(t, got, "no connection")
+}
@@ -0,0 +1,185 @@
+package gustpub
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/t2wu/gust"
+)
+
+// Headers set on webhook requests
+const (
+	// HeaderTimestamp is the Unix time the request was signed at
+	HeaderTimestamp = "X-Gust-Timestamp"
+	// HeaderSignature is "sha256=" followed by the hex HMAC-SHA256 of the
+	// timestamp, a dot and the body, see VerifySignature
+	HeaderSignature = "X-Gust-Signature"
+)
+
+// ErrQueueFull is reported to OnError when an event is dropped because the
+// webhook's queue is full
+var ErrQueueFull = errors.New("webhook queue full")
+
+// Webhook is a gust.Observer POSTing every state change as an Event to URLs.
+// Events are delivered in order from a queue in the background, so slow
+// endpoints don't hold up runs; Close delivers what's queued. Failed requests,
+// on network errors and 5xx or 429 responses, are retried.
+type Webhook struct {
+	urls    []string
+	secret  []byte
+	queue   chan Event
+	stopped chan struct{}
+	once    *sync.Once
+
+	// Machine names the machine in events
+	Machine string
+	// Client sends the requests, http.DefaultClient if nil
+	Client *http.Client
+	// MaxAttempts is the number of times a request is tried
+	MaxAttempts int
+	// Backoff tells how long to wait between attempts
+	Backoff gust.Backoff
+	// OnError if not nil is called when an event couldn't be delivered to a URL
+	OnError func(err error)
+	// Now is the source of the events' and signatures' time, time.Now if nil
+	Now func() time.Time
+}
+
+// NewWebhook starts a webhook signing requests with secret, unsigned if it's
+// empty. Up to queueSize events wait to be delivered, more are dropped.
+// Options are set on the returned webhook before events are sent.
+func NewWebhook(secret []byte, queueSize int, urls ...string) *Webhook {
+	w := &Webhook{
+		urls:        urls,
+		secret:      secret,
+		queue:       make(chan Event, queueSize),
+		stopped:     make(chan struct{}),
+		once:        &sync.Once{},
+		MaxAttempts: 3,
+		Backoff:     gust.ExponentialBackoff{Initial: 100 * time.Millisecond, Max: 5 * time.Second},
+	}
+	go w.deliver()
+	return w
+}
+
+// StateChanged queues the change, it implements gust.Observer
+func (w *Webhook) StateChanged(priorState string, nextState string) {
+	e := Event{Schema: Schema, Machine: w.Machine, From: priorState, To: nextState, At: w.now().UTC()}
+	select {
+	case w.queue <- e:
+	default:
+		w.report(fmt.Errorf("%w: dropped %s -> %s", ErrQueueFull, priorState, nextState))
+	}
+}
+
+// Close delivers the queued events and stops. Events after Close panic.
+func (w *Webhook) Close() {
+	w.once.Do(func() {
+		close(w.queue)
+	})
+	<-w.stopped
+}
+
+func (w *Webhook) deliver() {
+	defer close(w.stopped)
+	for e := range w.queue {
+		body, err := json.Marshal(e)
+		if err != nil {
+			w.report(err)
+			continue
+		}
+		for _, url := range w.urls {
+			if err := w.post(url, body); err != nil {
+				w.report(fmt.Errorf("webhook %s: %w", url, err))
+			}
+		}
+	}
+}
+
+// post sends the body to url, retrying
+func (w *Webhook) post(url string, body []byte) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		if retry, err = w.try(url, body); err == nil || !retry || attempt >= w.MaxAttempts {
+			return err
+		}
+		if w.Backoff != nil {
+			time.Sleep(w.Backoff.Delay(attempt))
+		}
+	}
+}
+
+// try sends a single request, retry tells whether trying again could help
+func (w *Webhook) try(url string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.secret) > 0 {
+		timestamp := strconv.FormatInt(w.now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(w.secret, timestamp, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("status %s", resp.Status)
+	default:
+		return false, fmt.Errorf("status %s", resp.Status)
+	}
+}
+
+func (w *Webhook) now() time.Time {
+	if w.Now != nil {
+		return w.Now()
+	}
+	return time.Now()
+}
+
+func (w *Webhook) report(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
+
+// Sign returns the signature of a webhook request as put in HeaderSignature
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature tells whether a received webhook request was signed with
+// the secret, given its HeaderTimestamp, HeaderSignature and body. Receivers
+// should also reject timestamps too far in the past, against replays.
+func VerifySignature(secret []byte, timestamp, signature string, body []byte) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
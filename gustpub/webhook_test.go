@@ -0,0 +1,113 @@
+package gustpub
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+var secret = []byte("s3cret")
+
+type receiver struct {
+	lock     *sync.Mutex
+	bodies   []string
+	failures int // requests to fail with 503 first
+	calls    int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.calls++
+	if rc.calls <= rc.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	if !VerifySignature(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	rc.bodies = append(rc.bodies, string(body))
+}
+
+func fixedNow() time.Time {
+	return time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func TestWebhook_PostsSignedEventsWithRetries(t *testing.T) {
+	rc := &receiver{lock: &sync.Mutex{}, failures: 1}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	w := NewWebhook(secret, 10, server.URL)
+	w.Machine = "order"
+	w.Now = fixedNow
+	w.Backoff = gust.ConstantBackoff(time.Millisecond)
+	var errs []error
+	w.OnError = func(err error) { errs = append(errs, err) }
+
+	w.StateChanged("", "created")
+	w.StateChanged("created", "paid")
+	w.Close()
+
+	assert.Empty(t, errs)
+	assert.Equal(t, 3, rc.calls)
+	assert.Equal(t, []string{
+		`{"schema":"gust.transition/v1","machine":"order","from":"","to":"created","at":"2021-06-01T12:00:00Z"}`,
+		`{"schema":"gust.transition/v1","machine":"order","from":"created","to":"paid","at":"2021-06-01T12:00:00Z"}`,
+	}, rc.bodies)
+}
+
+func TestWebhook_WrongSecret_NotRetried(t *testing.T) {
+	rc := &receiver{lock: &sync.Mutex{}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	w := NewWebhook([]byte("other"), 10, server.URL)
+	var errs []error
+	w.OnError = func(err error) { errs = append(errs, err) }
+
+	w.StateChanged("", "created")
+	w.Close()
+
+	assert.Equal(t, 1, rc.calls)
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "401 Unauthorized")
+	}
+}
+
+func TestWebhook_QueueFull_Dropped(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+
+	w := NewWebhook(nil, 1, server.URL)
+	var dropped error
+	w.OnError = func(err error) { dropped = err }
+
+	w.StateChanged("", "a") // taken by the delivery goroutine, or queued
+	w.StateChanged("a", "b")
+	w.StateChanged("b", "c")
+	close(block)
+	w.Close()
+
+	assert.True(t, errors.Is(dropped, ErrQueueFull))
+}
+
+func TestVerifySignature(t *testing.T) {
+	sig := Sign(secret, "1622548800", []byte(`{}`))
+	assert.True(t, VerifySignature(secret, "1622548800", sig, []byte(`{}`)))
+	assert.False(t, VerifySignature(secret, "1622548801", sig, []byte(`{}`)))
+	assert.False(t, VerifySignature([]byte("x"), "1622548800", sig, []byte(`{}`)))
+}
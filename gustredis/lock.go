@@ -0,0 +1,147 @@
+// Package gustredis locks persisted runs with Redis, so only one process
+// executes a run at a time. It doesn't depend on a Redis client, any client
+// able to run a script is adapted with EvalFunc, e.g. go-redis:
+//
+//	locker := gustredis.NewLocker(gustredis.EvalFunc(func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+//		return rdb.Eval(ctx, script, keys, args...).Result()
+//	}))
+//	sm.SetLocker(locker)
+package gustredis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/t2wu/gust"
+)
+
+// DefaultTTL is how long a lock lives unless refreshed
+const DefaultTTL = 30 * time.Second
+
+// Evaler runs a Lua script, the scripts used by the locker only return integers
+type Evaler interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// EvalFunc is a function used as an Evaler
+type EvalFunc func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+
+// Eval calls the function
+func (f EvalFunc) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return f(ctx, script, keys, args...)
+}
+
+const (
+	lockScript    = `if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then return 1 else return 0 end`
+	refreshScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+	unlockScript  = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+)
+
+// Locker is a gust.Locker keeping locks as Redis keys set to a token unique to
+// the lease. The keys expire after TTL, and are refreshed while the run holds
+// the lock, so a lock outlives its process by TTL at most.
+type Locker struct {
+	redis Evaler
+
+	// Prefix is prepended to the run keys
+	Prefix string
+	// TTL is how long a lock lives unless refreshed, it's refreshed every
+	// third of it
+	TTL time.Duration
+}
+
+// NewLocker is a constructor for Locker
+func NewLocker(redis Evaler) *Locker {
+	return &Locker{redis: redis, Prefix: "gust:lock:", TTL: DefaultTTL}
+}
+
+// Lock takes the lock of the key without waiting
+func (l *Locker) Lock(ctx context.Context, key string) (gust.Lease, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	lease := &lease{
+		locker:   l,
+		key:      l.Prefix + key,
+		token:    hex.EncodeToString(b),
+		once:     &sync.Once{},
+		lost:     make(chan struct{}),
+		released: make(chan struct{}),
+	}
+
+	ok, err := lease.eval(ctx, lockScript)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", gust.ErrLocked, key)
+	}
+	go lease.refresh()
+	return lease, nil
+}
+
+type lease struct {
+	locker   *Locker
+	key      string
+	token    string // tells this lease's key from one taken after it expired
+	once     *sync.Once
+	lost     chan struct{}
+	released chan struct{}
+}
+
+// eval runs a script on the lease's key, telling whether it returned 1
+func (l *lease) eval(ctx context.Context, script string) (bool, error) {
+	v, err := l.locker.redis.Eval(ctx, script, []string{l.key}, l.token, l.locker.TTL.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected reply %T from redis", v)
+	}
+	return n == 1, nil
+}
+
+func (l *lease) Unlock() error {
+	var err error
+	l.once.Do(func() {
+		close(l.released)
+		_, err = l.eval(context.Background(), unlockScript)
+	})
+	return err
+}
+
+func (l *lease) Done() <-chan struct{} {
+	return l.lost
+}
+
+// refresh extends the key's expiry until the lease is released, the lock is
+// lost when the key no longer holds the token or it couldn't be refreshed
+// before the next refresh would be too late
+func (l *lease) refresh() {
+	interval := l.locker.TTL / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	refreshed := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			ok, err := l.eval(ctx, refreshScript)
+			cancel()
+			if err == nil && ok {
+				refreshed = time.Now()
+			} else if err == nil || time.Since(refreshed)+interval >= l.locker.TTL {
+				close(l.lost)
+				return
+			}
+		case <-l.released:
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package gustredis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+// fakeRedis runs the locker's scripts on a map, keys never expire
+type fakeRedis struct {
+	lock *sync.Mutex
+	keys map[string]string
+	fail error
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{lock: &sync.Mutex{}, keys: make(map[string]string)}
+}
+
+func (r *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.fail != nil {
+		return nil, r.fail
+	}
+
+	key, token := keys[0], args[0].(string)
+	switch script {
+	case lockScript:
+		if _, ok := r.keys[key]; ok {
+			return int64(0), nil
+		}
+		r.keys[key] = token
+		return int64(1), nil
+	case refreshScript:
+		if r.keys[key] == token {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case unlockScript:
+		if r.keys[key] == token {
+			delete(r.keys, key)
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+	return nil, errors.New("unknown script")
+}
+
+func TestLocker_Exclusive(t *testing.T) {
+	redis := newFakeRedis()
+	l := NewLocker(redis)
+
+	lease, err := l.Lock(context.Background(), "run")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Contains(t, redis.keys, "gust:lock:run")
+
+	_, err = l.Lock(context.Background(), "run")
+	assert.True(t, errors.Is(err, gust.ErrLocked))
+
+	assert.Nil(t, lease.Unlock())
+	assert.Empty(t, redis.keys)
+	lease, err = l.Lock(context.Background(), "run")
+	assert.Nil(t, err)
+	lease.Unlock()
+}
+
+func TestLocker_KeyTakenOver_Lost(t *testing.T) {
+	redis := newFakeRedis()
+	l := NewLocker(redis)
+	l.TTL = 30 * time.Millisecond
+
+	lease, err := l.Lock(context.Background(), "run")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer lease.Unlock()
+	redis.lock.Lock()
+	redis.keys["gust:lock:run"] = "another process"
+	redis.lock.Unlock()
+
+	select {
+	case <-lease.Done():
+	case <-time.After(time.Second):
+		t.Fatal("lease not lost")
+	}
+}
+
+func TestLocker_RedisDown(t *testing.T) {
+	redis := newFakeRedis()
+	redis.fail = errors.New("down")
+
+	_, err := NewLocker(redis).Lock(context.Background(), "run")
+	assert.EqualError(t, err, "down")
+}
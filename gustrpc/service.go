@@ -0,0 +1,210 @@
+// Package gustrpc serves the machines of a gust.Manager over gRPC, so other
+// languages can drive gust hosted workflows. gust.proto is the service
+// definition. To keep gust free of dependencies the generated code isn't part
+// of it: generate it in your module, and have the server's methods convert
+// between the generated messages and the ones here, which have the same
+// fields, and call Service:
+//
+//	func (s *server) GetRun(ctx context.Context, req *gustpb.GetRunRequest) (*gustpb.Run, error) {
+//		run, err := s.svc.GetRun(ctx, &gustrpc.GetRunRequest{ID: req.Id})
+//		if err != nil {
+//			return nil, status.Error(codes.Code(gustrpc.Code(err)), err.Error())
+//		}
+//		return toPB(run), nil
+//	}
+package gustrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/t2wu/gust"
+)
+
+// Codes of errors, the same as gRPC's codes.Code
+const (
+	CodeOK                 = 0
+	CodeInvalidArgument    = 3
+	CodeNotFound           = 5
+	CodeAlreadyExists      = 6
+	CodeFailedPrecondition = 9
+	CodeInternal           = 13
+)
+
+// ErrInvalidValue is returned when cargo or an event value can't be decoded
+var ErrInvalidValue = errors.New("invalid value")
+
+// StartRunRequest is the request of StartRun
+type StartRunRequest struct {
+	Machine string
+	Cargo   []byte
+}
+
+// GetRunRequest is the request of GetRun
+type GetRunRequest struct {
+	ID string
+}
+
+// SendEventRequest is the request of SendEvent
+type SendEventRequest struct {
+	ID    string
+	Name  string
+	Value []byte
+}
+
+// SendEventResponse is the response of SendEvent
+type SendEventResponse struct{}
+
+// WatchRunRequest is the request of WatchRun
+type WatchRunRequest struct {
+	ID string
+}
+
+// Run is the status of an instance
+type Run struct {
+	ID              string
+	Machine         string
+	Status          string
+	State           string
+	Path            []string
+	Cargo           []byte
+	Error           string
+	StartedUnixNano int64
+}
+
+// Step is a state an instance entered
+type Step struct {
+	From       string
+	To         string
+	AtUnixNano int64
+}
+
+// StepStream is the server side of a WatchRun stream, the generated
+// Workflow_WatchRunServer is one once its Send converts the Step
+type StepStream interface {
+	Context() context.Context
+	Send(step *Step) error
+}
+
+// Service implements the Workflow service on a Manager
+type Service struct {
+	Manager *gust.Manager
+
+	// Codec encodes and decodes cargo and event values, gust.JSONCodec if nil.
+	// Values decoded are interface{}, so with JSON generic JSON values.
+	Codec gust.Codec
+}
+
+// NewService is a constructor for Service
+func NewService(mgr *gust.Manager) *Service {
+	return &Service{Manager: mgr}
+}
+
+// StartRun starts an instance of the machine with the decoded cargo
+func (s *Service) StartRun(ctx context.Context, req *StartRunRequest) (*Run, error) {
+	var cargo interface{}
+	if len(req.Cargo) > 0 {
+		if err := s.codec().Unmarshal(req.Cargo, &cargo); err != nil {
+			return nil, fmt.Errorf("%w: cargo: %v", ErrInvalidValue, err)
+		}
+	}
+	// the instance outlives the request
+	inst, err := s.Manager.Start(context.Background(), req.Machine, cargo)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(inst)
+}
+
+// GetRun returns the status of the instance
+func (s *Service) GetRun(ctx context.Context, req *GetRunRequest) (*Run, error) {
+	inst, ok := s.Manager.Get(req.ID)
+	if !ok {
+		return nil, gust.ErrUnknownInstance
+	}
+	return s.run(inst)
+}
+
+// SendEvent delivers the decoded value as a signal to the instance
+func (s *Service) SendEvent(ctx context.Context, req *SendEventRequest) (*SendEventResponse, error) {
+	var value interface{}
+	if len(req.Value) > 0 {
+		if err := s.codec().Unmarshal(req.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidValue, req.Name, err)
+		}
+	}
+	if err := s.Manager.Signal(req.ID, req.Name, value); err != nil {
+		return nil, err
+	}
+	return &SendEventResponse{}, nil
+}
+
+// WatchRun sends the steps of the instance until it finishes or the stream's
+// context is done
+func (s *Service) WatchRun(req *WatchRunRequest, stream StepStream) error {
+	inst, ok := s.Manager.Get(req.ID)
+	if !ok {
+		return gust.ErrUnknownInstance
+	}
+	ctx := stream.Context()
+	for step := range inst.Watch(ctx) {
+		if err := stream.Send(&Step{From: step.From, To: step.To, AtUnixNano: step.At.UnixNano()}); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// Code returns the gRPC code for an error returned by Service
+func Code(err error) int {
+	switch {
+	case err == nil:
+		return CodeOK
+	case errors.Is(err, ErrInvalidValue):
+		return CodeInvalidArgument
+	case errors.Is(err, gust.ErrUnknownMachine), errors.Is(err, gust.ErrUnknownInstance):
+		return CodeNotFound
+	case errors.Is(err, gust.ErrNotRunning):
+		return CodeFailedPrecondition
+	case errors.Is(err, gust.ErrDuplicateMachine):
+		return CodeAlreadyExists
+	}
+	return CodeInternal
+}
+
+func (s *Service) codec() gust.Codec {
+	if s.Codec == nil {
+		return gust.JSONCodec{}
+	}
+	return s.Codec
+}
+
+// run describes the instance
+func (s *Service) run(inst *gust.Instance) (*Run, error) {
+	run := &Run{
+		ID:              inst.ID,
+		Machine:         inst.Machine,
+		Status:          inst.Status().String(),
+		Path:            make([]string, 0),
+		StartedUnixNano: inst.Started.UnixNano(),
+	}
+	for _, step := range inst.History() {
+		run.Path = append(run.Path, step.To)
+	}
+	if info, ok := inst.CurrentState(); ok {
+		run.State = info.Name
+	}
+	if result, ok := inst.Result(); ok {
+		run.State = ""
+		if result.Err != nil {
+			run.Error = result.Err.Error()
+		}
+		data, err := s.codec().Marshal(result.Cargo)
+		if err != nil {
+			return nil, err
+		}
+		run.Cargo = data
+	}
+	return run, nil
+}
@@ -0,0 +1,85 @@
+package gustrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+// newService serves a machine "approval" waiting in "review" for an
+// "approve" event, whose value becomes the cargo
+func newService(t *testing.T) *Service {
+	sm := gust.NewStateMachine()
+	review := gust.NewSignalWait("review", "approve")
+	review.Merge = func(cargo, value interface{}) (interface{}, error) {
+		return value, nil
+	}
+	submit := gust.NewFuncState("submit", func(cargo interface{}) (gust.State, interface{}, error) {
+		return review, cargo, nil
+	})
+	sm.AddStates(submit, review)
+	sm.AddTransition(submit, review)
+
+	mgr := gust.NewManager()
+	if err := mgr.Register("approval", sm, submit); err != nil {
+		t.Fatal(err)
+	}
+	return NewService(mgr)
+}
+
+type stepStream struct {
+	ctx   context.Context
+	steps []*Step
+}
+
+func (s *stepStream) Context() context.Context { return s.ctx }
+
+func (s *stepStream) Send(step *Step) error {
+	s.steps = append(s.steps, step)
+	return nil
+}
+
+func TestService_StartSendEventWatch(t *testing.T) {
+	svc := newService(t)
+	ctx := context.Background()
+
+	run, err := svc.StartRun(ctx, &StartRunRequest{Machine: "approval", Cargo: []byte(`{"id":"o1"}`)})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "approval-1", run.ID)
+
+	_, err = svc.SendEvent(ctx, &SendEventRequest{ID: run.ID, Name: "approve", Value: []byte(`"alice"`)})
+	assert.Nil(t, err)
+
+	stream := &stepStream{ctx: ctx}
+	assert.Nil(t, svc.WatchRun(&WatchRunRequest{ID: run.ID}, stream))
+	if assert.Len(t, stream.steps, 2) {
+		assert.Equal(t, "submit", stream.steps[1].From)
+		assert.Equal(t, "review", stream.steps[1].To)
+	}
+
+	run, err = svc.GetRun(ctx, &GetRunRequest{ID: run.ID})
+	assert.Nil(t, err)
+	assert.Equal(t, "succeeded", run.Status)
+	assert.Equal(t, []string{"submit", "review"}, run.Path)
+	assert.Equal(t, `"alice"`, string(run.Cargo))
+}
+
+func TestService_Errors_Codes(t *testing.T) {
+	svc := newService(t)
+	ctx := context.Background()
+
+	_, err := svc.StartRun(ctx, &StartRunRequest{Machine: "shipping"})
+	assert.Equal(t, CodeNotFound, Code(err))
+
+	_, err = svc.StartRun(ctx, &StartRunRequest{Machine: "approval", Cargo: []byte("{")})
+	assert.True(t, errors.Is(err, ErrInvalidValue))
+	assert.Equal(t, CodeInvalidArgument, Code(err))
+
+	_, err = svc.GetRun(ctx, &GetRunRequest{ID: "approval-9"})
+	assert.Equal(t, CodeNotFound, Code(err))
+}
@@ -0,0 +1,127 @@
+// Package gustsql writes a durable audit log of every transition to a
+// database/sql table. Create the table with Migrate, or with the migration in
+// the migrations directory if the schema is managed by a migration tool:
+//
+//	if err := gustsql.Migrate(ctx, db, gustsql.DefaultTable); err != nil {
+//		return err
+//	}
+//	sm.RegisterObservers(gustsql.NewAuditObserver(db, "order"))
+//
+// It also stores the snapshots of a gust.Manager's instances, created with
+// MigrateSnapshots, and locks persisted runs with the database's advisory
+// locks, so the instances of a node that crashed are reclaimed by another:
+//
+//	sm.SetLocker(gustsql.NewAdvisoryLocker(db, gustsql.Postgres))
+//	mgr.SetStore(gustsql.NewSnapshotStore(db))
+//	go mgr.ReclaimEvery(ctx, time.Minute)
+//
+// Failed runs can be kept as dead letters, in a table created with
+// MigrateDeadLetters, to be inspected and requeued:
+//
+//	sm.SetDeadLetterSink(gustsql.NewDeadLetterSink(db))
+package gustsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/t2wu/gust"
+)
+
+// DefaultTable is the table the audit log is written to unless changed
+const DefaultTable = "gust_audit"
+
+// Placeholders is how a database marks query parameters
+type Placeholders int
+
+const (
+	// Question is ?, as used by MySQL and SQLite
+	Question Placeholders = iota
+	// Dollar is $1, $2..., as used by PostgreSQL
+	Dollar
+)
+
+// placeholder returns the i-th query parameter, from 1
+func placeholder(p Placeholders, i int) string {
+	if p == Dollar {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
+
+// Schema returns the CREATE TABLE statement of the audit log table. step
+// orders the rows of a run, run IDs being unique within a process only.
+// correlation_id is the run's, see gust.WithCorrelationID, tables created
+// before it was added get it with the 004 migration.
+func Schema(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	machine    VARCHAR(255) NOT NULL,
+	run_id     VARCHAR(255) NOT NULL,
+	step       INTEGER NOT NULL,
+	from_state VARCHAR(255) NOT NULL,
+	to_state   VARCHAR(255) NOT NULL,
+	label      VARCHAR(255) NOT NULL,
+	cargo_type VARCHAR(255) NOT NULL,
+	entered_at TIMESTAMP NOT NULL,
+	correlation_id VARCHAR(255) NOT NULL DEFAULT ''
+)`, table)
+}
+
+// Migrate creates the audit log table if it doesn't exist
+func Migrate(ctx context.Context, db *sql.DB, table string) error {
+	_, err := db.ExecContext(ctx, Schema(table))
+	return err
+}
+
+// AuditObserver inserts a row for every state a run of the machine enters.
+// Inserts happen as the run goes, wrap the db in something batching if that's
+// too slow.
+type AuditObserver struct {
+	db      *sql.DB
+	machine string
+
+	Table        string
+	Placeholders Placeholders
+	// Timeout bounds each insert, no timeout if 0
+	Timeout time.Duration
+	// OnError if not nil is called with the errors of inserts, the row is lost
+	OnError func(err error)
+}
+
+// NewAuditObserver is a constructor for AuditObserver, machine names the
+// machine in the log
+func NewAuditObserver(db *sql.DB, machine string) *AuditObserver {
+	return &AuditObserver{db: db, machine: machine, Table: DefaultTable}
+}
+
+// StateChanged does nothing, rows are inserted by RunStatusChanged
+func (o *AuditObserver) StateChanged(prior, next string) {}
+
+// RunStatusChanged inserts the row of the state entered
+func (o *AuditObserver) RunStatusChanged(status gust.RunStatus) {
+	ctx := context.Background()
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	_, err := o.db.ExecContext(ctx, o.insert(), o.machine, status.RunID, status.Steps,
+		status.Prior, status.State, status.Label, status.CargoType, status.Entered, status.CorrelationID)
+	if err != nil && o.OnError != nil {
+		o.OnError(fmt.Errorf("writing audit log: %w", err))
+	}
+}
+
+// insert returns the INSERT statement
+func (o *AuditObserver) insert() string {
+	params := make([]string, 9)
+	for i := range params {
+		params[i] = placeholder(o.Placeholders, i+1)
+	}
+	return fmt.Sprintf("INSERT INTO %s (machine, run_id, step, from_state, to_state, label, cargo_type, entered_at, correlation_id) VALUES (%s)",
+		o.Table, strings.Join(params, ", "))
+}
@@ -0,0 +1,135 @@
+package gustsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+// fakeDriver records the statements executed and their arguments
+type fakeDriver struct {
+	stmts []string
+	args  [][]driver.Value
+	fail  error
+	// query if not nil answers queries with rows
+	query func(query string, args []driver.Value) ([][]driver.Value, error)
+	// affected if not nil is the number of rows statements affect, 1 otherwise
+	affected func(query string, args []driver.Value) int64
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c.d, query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("no transactions") }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.d.fail != nil {
+		return nil, s.d.fail
+	}
+	s.d.stmts = append(s.d.stmts, s.query)
+	s.d.args = append(s.d.args, args)
+	if s.d.affected != nil {
+		return driver.RowsAffected(s.d.affected(s.query, args)), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.d.query == nil {
+		return nil, errors.New("no queries")
+	}
+	s.d.stmts = append(s.d.stmts, s.query)
+	s.d.args = append(s.d.args, args)
+	rows, err := s.d.query(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+// fakeRows returns the rows given, all with as many columns as the first
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openFake(t *testing.T) (*sql.DB, *fakeDriver) {
+	d := &fakeDriver{}
+	sql.Register("gustsql-fake-"+t.Name(), d)
+	db, err := sql.Open("gustsql-fake-"+t.Name(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func TestAuditObserver_RowPerState(t *testing.T) {
+	db, d := openFake(t)
+	assert.Nil(t, Migrate(context.Background(), db, DefaultTable))
+
+	sm := gust.NewStateMachine()
+	shipped := gust.NewFuncState("shipped", func(cargo interface{}) (gust.State, interface{}, error) {
+		return nil, cargo, nil
+	})
+	paid := gust.NewFuncState("paid", func(cargo interface{}) (gust.State, interface{}, error) {
+		return shipped, cargo, nil
+	})
+	sm.AddStates(paid, shipped)
+	sm.AddLabeledTransition(paid, shipped, "dispatched")
+	o := NewAuditObserver(db, "order")
+	o.Placeholders = Dollar
+	sm.RegisterObservers(o)
+
+	_, err := sm.Execute(gust.WithCorrelationID(context.Background(), "req-42"), "o1", paid)
+	assert.Nil(t, err)
+	if !assert.Len(t, d.stmts, 3) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(d.stmts[0], "CREATE TABLE IF NOT EXISTS gust_audit"))
+	assert.Equal(t, "INSERT INTO gust_audit (machine, run_id, step, from_state, to_state, label, cargo_type, entered_at, correlation_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)", d.stmts[1])
+	assert.Equal(t, []driver.Value{"order", "1", int64(2), "paid", "shipped", "dispatched", "string"}, d.args[2][:7])
+	assert.Equal(t, "req-42", d.args[2][8])
+}
+
+func TestAuditObserver_InsertFails_OnError(t *testing.T) {
+	db, d := openFake(t)
+	d.fail = errors.New("disk full")
+	o := NewAuditObserver(db, "order")
+	var got error
+	o.OnError = func(err error) { got = err }
+
+	o.RunStatusChanged(gust.RunStatus{RunID: "1", State: "paid"})
+	assert.True(t, errors.Is(got, d.fail))
+}
@@ -0,0 +1,110 @@
+package gustsql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/t2wu/gust"
+)
+
+// DefaultDeadLetterTable is the table dead letters are kept in unless changed
+const DefaultDeadLetterTable = "gust_dead_letters"
+
+// DeadLetterSchema returns the CREATE TABLE statement of the dead letter table
+func DeadLetterSchema(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	run       VARCHAR(255) NOT NULL PRIMARY KEY,
+	machine   VARCHAR(255) NOT NULL,
+	state     VARCHAR(255) NOT NULL,
+	error     TEXT NOT NULL,
+	letter    TEXT NOT NULL,
+	failed_at TIMESTAMP NOT NULL
+)`, table)
+}
+
+// MigrateDeadLetters creates the dead letter table if it doesn't exist
+func MigrateDeadLetters(ctx context.Context, db *sql.DB, table string) error {
+	_, err := db.ExecContext(ctx, DeadLetterSchema(table))
+	return err
+}
+
+// DeadLetterSink is a gust.DeadLetterSink keeping a row per failed run, with
+// the letter as JSON. The machine, state and error have columns of their own
+// to be queried.
+type DeadLetterSink struct {
+	db *sql.DB
+
+	Table        string
+	Placeholders Placeholders
+}
+
+// NewDeadLetterSink is a constructor for DeadLetterSink
+func NewDeadLetterSink(db *sql.DB) *DeadLetterSink {
+	return &DeadLetterSink{db: db, Table: DefaultDeadLetterTable}
+}
+
+// Put updates the run's row, inserting it if there's none, a requeued run
+// failing again replaces its letter
+func (s *DeadLetterSink) Put(ctx context.Context, letter *gust.DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET machine = %s, state = %s, error = %s, letter = %s, failed_at = %s WHERE run = %s",
+		s.Table, s.param(1), s.param(2), s.param(3), s.param(4), s.param(5), s.param(6)),
+		letter.Machine, letter.State, letter.Error, string(data), letter.Failed, letter.Run)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (run, machine, state, error, letter, failed_at) VALUES (%s, %s, %s, %s, %s, %s)",
+		s.Table, s.param(1), s.param(2), s.param(3), s.param(4), s.param(5), s.param(6)),
+		letter.Run, letter.Machine, letter.State, letter.Error, string(data), letter.Failed)
+	return err
+}
+
+// Delete removes the run's row, e.g. once requeued
+func (s *DeadLetterSink) Delete(ctx context.Context, run string) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE run = %s", s.Table, s.param(1)), run)
+	return err
+}
+
+// List returns the letters of the machine, all of them if machine is empty,
+// oldest first
+func (s *DeadLetterSink) List(ctx context.Context, machine string) ([]*gust.DeadLetter, error) {
+	query := fmt.Sprintf("SELECT letter FROM %s ORDER BY failed_at", s.Table)
+	var args []interface{}
+	if machine != "" {
+		query = fmt.Sprintf("SELECT letter FROM %s WHERE machine = %s ORDER BY failed_at", s.Table, s.param(1))
+		args = append(args, machine)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var letters []*gust.DeadLetter
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		letter := &gust.DeadLetter{}
+		if err := json.Unmarshal([]byte(data), letter); err != nil {
+			return nil, fmt.Errorf("decoding dead letter: %w", err)
+		}
+		letters = append(letters, letter)
+	}
+	return letters, rows.Err()
+}
+
+// param returns the i-th query parameter, from 1
+func (s *DeadLetterSink) param(i int) string {
+	return placeholder(s.Placeholders, i)
+}
@@ -0,0 +1,61 @@
+package gustsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+func TestDeadLetterSink_PutInsertsThenUpdates(t *testing.T) {
+	db, d := openFake(t)
+	assert.Nil(t, MigrateDeadLetters(context.Background(), db, DefaultDeadLetterTable))
+	rows := 0
+	d.affected = func(query string, args []driver.Value) int64 {
+		if query[:6] == "INSERT" {
+			rows++
+		}
+		if query[:6] == "UPDATE" {
+			return int64(rows)
+		}
+		return 1
+	}
+	s := NewDeadLetterSink(db)
+	letter := &gust.DeadLetter{Machine: "order", Run: "r1", State: "charge", Error: "card declined", Failed: time.Unix(0, 0)}
+
+	assert.Nil(t, s.Put(context.Background(), letter))
+	assert.Nil(t, s.Put(context.Background(), letter))
+	assert.Nil(t, s.Delete(context.Background(), "r1"))
+	assert.Equal(t, []string{
+		DeadLetterSchema(DefaultDeadLetterTable),
+		"UPDATE gust_dead_letters SET machine = ?, state = ?, error = ?, letter = ?, failed_at = ? WHERE run = ?",
+		"INSERT INTO gust_dead_letters (run, machine, state, error, letter, failed_at) VALUES (?, ?, ?, ?, ?, ?)",
+		"UPDATE gust_dead_letters SET machine = ?, state = ?, error = ?, letter = ?, failed_at = ? WHERE run = ?",
+		"DELETE FROM gust_dead_letters WHERE run = ?",
+	}, d.stmts)
+	assert.Equal(t, []driver.Value{"r1", "order", "charge", "card declined"}, d.args[2][:4])
+}
+
+func TestDeadLetterSink_List(t *testing.T) {
+	db, d := openFake(t)
+	data, _ := json.Marshal(&gust.DeadLetter{Machine: "order", Run: "r1", State: "charge", Cargo: []byte(`"o1"`)})
+	d.query = func(query string, args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{string(data)}}, nil
+	}
+	s := NewDeadLetterSink(db)
+	s.Placeholders = Dollar
+
+	letters, err := s.List(context.Background(), "order")
+	assert.Nil(t, err)
+	if assert.Len(t, letters, 1) {
+		assert.Equal(t, "r1", letters[0].Run)
+		assert.Equal(t, "charge", letters[0].Snapshot().State)
+		assert.Equal(t, `"o1"`, string(letters[0].Cargo))
+	}
+	assert.Equal(t, "SELECT letter FROM gust_dead_letters WHERE machine = $1 ORDER BY failed_at", d.stmts[0])
+	assert.Equal(t, "order", d.args[0][0])
+}
@@ -0,0 +1,132 @@
+package gustsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/t2wu/gust"
+)
+
+// Dialect is the database whose advisory locks an AdvisoryLocker takes
+type Dialect int
+
+const (
+	// Postgres takes locks with pg_try_advisory_lock, keys are hashed to 64 bits
+	Postgres Dialect = iota
+	// MySQL takes locks with GET_LOCK
+	MySQL
+)
+
+// AdvisoryLocker is a gust.Locker taking session level advisory locks, so a
+// lock is held by a connection taken from the pool for as long as the run
+// executes, and is released by the database if the process dies
+type AdvisoryLocker struct {
+	db      *sql.DB
+	dialect Dialect
+
+	// CheckInterval if larger than 0 is how often the connection holding a
+	// lock is pinged, the lock is lost once pinging fails
+	CheckInterval time.Duration
+}
+
+// NewAdvisoryLocker is a constructor for AdvisoryLocker
+func NewAdvisoryLocker(db *sql.DB, dialect Dialect) *AdvisoryLocker {
+	return &AdvisoryLocker{db: db, dialect: dialect}
+}
+
+// Lock takes the lock of the key without waiting
+func (l *AdvisoryLocker) Lock(ctx context.Context, key string) (gust.Lease, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	acquired := false
+	switch l.dialect {
+	case Postgres:
+		err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", hashKey(key)).Scan(&acquired)
+	case MySQL:
+		// GET_LOCK is 1 if acquired, 0 if held elsewhere and NULL on error
+		var got sql.NullInt64
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", key).Scan(&got)
+		acquired = got.Valid && got.Int64 == 1
+	default:
+		err = fmt.Errorf("unknown dialect %d", l.dialect)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %s", gust.ErrLocked, key)
+	}
+
+	lease := &advisoryLease{locker: l, conn: conn, key: key, once: &sync.Once{}}
+	if l.CheckInterval > 0 {
+		lease.lost = make(chan struct{})
+		lease.released = make(chan struct{})
+		go lease.check(l.CheckInterval)
+	}
+	return lease, nil
+}
+
+// hashKey turns the key into the 64 bits key of a PostgreSQL advisory lock
+func hashKey(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
+
+type advisoryLease struct {
+	locker   *AdvisoryLocker
+	conn     *sql.Conn
+	key      string
+	once     *sync.Once
+	lost     chan struct{} // nil without CheckInterval
+	released chan struct{} // stops checking
+}
+
+func (l *advisoryLease) Unlock() error {
+	var err error
+	l.once.Do(func() {
+		if l.released != nil {
+			close(l.released)
+		}
+		ctx := context.Background()
+		if l.locker.dialect == Postgres {
+			_, err = l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", hashKey(l.key))
+		} else {
+			_, err = l.conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", l.key)
+		}
+		if closeErr := l.conn.Close(); err == nil {
+			err = closeErr
+		}
+	})
+	return err
+}
+
+func (l *advisoryLease) Done() <-chan struct{} {
+	return l.lost
+}
+
+// check pings the connection holding the lock until it's released
+func (l *advisoryLease) check(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.conn.PingContext(context.Background()); err != nil {
+				close(l.lost)
+				return
+			}
+		case <-l.released:
+			return
+		}
+	}
+}
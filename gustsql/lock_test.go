@@ -0,0 +1,78 @@
+package gustsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+// one is a single row of a single value
+func one(v driver.Value) [][]driver.Value {
+	return [][]driver.Value{{v}}
+}
+
+func TestAdvisoryLocker_Postgres(t *testing.T) {
+	db, d := openFake(t)
+	held := false
+	d.query = func(query string, args []driver.Value) ([][]driver.Value, error) {
+		if held {
+			return one(false), nil
+		}
+		held = true
+		return one(true), nil
+	}
+	l := NewAdvisoryLocker(db, Postgres)
+
+	lease, err := l.Lock(context.Background(), "run")
+	if !assert.Nil(t, err) {
+		return
+	}
+	_, err = l.Lock(context.Background(), "run")
+	assert.True(t, errors.Is(err, gust.ErrLocked))
+
+	assert.Nil(t, lease.Unlock())
+	assert.Nil(t, lease.Done())
+	assert.Equal(t, []string{
+		"SELECT pg_try_advisory_lock($1)",
+		"SELECT pg_try_advisory_lock($1)",
+		"SELECT pg_advisory_unlock($1)",
+	}, d.stmts)
+	assert.Equal(t, hashKey("run"), d.args[0][0])
+	assert.Equal(t, hashKey("run"), d.args[2][0])
+}
+
+func TestAdvisoryLocker_MySQL(t *testing.T) {
+	db, d := openFake(t)
+	d.query = func(query string, args []driver.Value) ([][]driver.Value, error) {
+		if args[0] == "held" {
+			return one(int64(0)), nil
+		}
+		return one(int64(1)), nil
+	}
+	l := NewAdvisoryLocker(db, MySQL)
+
+	_, err := l.Lock(context.Background(), "held")
+	assert.True(t, errors.Is(err, gust.ErrLocked))
+
+	lease, err := l.Lock(context.Background(), "run")
+	if assert.Nil(t, err) {
+		assert.Nil(t, lease.Unlock())
+		assert.Nil(t, lease.Unlock(), "unlocking twice")
+	}
+	assert.Equal(t, "SELECT RELEASE_LOCK(?)", d.stmts[len(d.stmts)-1])
+	assert.Len(t, d.stmts, 3)
+}
+
+func TestAdvisoryLocker_QueryFails(t *testing.T) {
+	db, d := openFake(t)
+	d.query = func(query string, args []driver.Value) ([][]driver.Value, error) {
+		return nil, errors.New("down")
+	}
+
+	_, err := NewAdvisoryLocker(db, Postgres).Lock(context.Background(), "run")
+	assert.EqualError(t, err, "down")
+}
@@ -0,0 +1,11 @@
+-- The audit log written by gustsql.AuditObserver, see gustsql.Schema
+CREATE TABLE IF NOT EXISTS gust_audit (
+	machine    VARCHAR(255) NOT NULL,
+	run_id     VARCHAR(255) NOT NULL,
+	step       INTEGER NOT NULL,
+	from_state VARCHAR(255) NOT NULL,
+	to_state   VARCHAR(255) NOT NULL,
+	label      VARCHAR(255) NOT NULL,
+	cargo_type VARCHAR(255) NOT NULL,
+	entered_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,7 @@
+-- The snapshots stored by gustsql.SnapshotStore, see gustsql.SnapshotSchema
+CREATE TABLE IF NOT EXISTS gust_snapshots (
+	run      VARCHAR(255) NOT NULL PRIMARY KEY,
+	machine  VARCHAR(255) NOT NULL,
+	snapshot TEXT NOT NULL,
+	taken_at TIMESTAMP NOT NULL
+);
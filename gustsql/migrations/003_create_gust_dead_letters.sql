@@ -0,0 +1,9 @@
+-- The dead letters kept by gustsql.DeadLetterSink, see gustsql.DeadLetterSchema
+CREATE TABLE IF NOT EXISTS gust_dead_letters (
+	run       VARCHAR(255) NOT NULL PRIMARY KEY,
+	machine   VARCHAR(255) NOT NULL,
+	state     VARCHAR(255) NOT NULL,
+	error     TEXT NOT NULL,
+	letter    TEXT NOT NULL,
+	failed_at TIMESTAMP NOT NULL
+);
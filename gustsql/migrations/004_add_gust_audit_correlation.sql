@@ -0,0 +1,2 @@
+-- The correlation IDs of runs in the audit log, see gust.WithCorrelationID
+ALTER TABLE gust_audit ADD COLUMN correlation_id VARCHAR(255) NOT NULL DEFAULT '';
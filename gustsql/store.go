@@ -0,0 +1,97 @@
+package gustsql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/t2wu/gust"
+)
+
+// DefaultSnapshotTable is the table snapshots are stored in unless changed
+const DefaultSnapshotTable = "gust_snapshots"
+
+// SnapshotSchema returns the CREATE TABLE statement of the snapshot table
+func SnapshotSchema(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	run      VARCHAR(255) NOT NULL PRIMARY KEY,
+	machine  VARCHAR(255) NOT NULL,
+	snapshot TEXT NOT NULL,
+	taken_at TIMESTAMP NOT NULL
+)`, table)
+}
+
+// MigrateSnapshots creates the snapshot table if it doesn't exist
+func MigrateSnapshots(ctx context.Context, db *sql.DB, table string) error {
+	_, err := db.ExecContext(ctx, SnapshotSchema(table))
+	return err
+}
+
+// SnapshotStore is a gust.SnapshotStore keeping a row per unfinished run, with
+// the snapshot as JSON
+type SnapshotStore struct {
+	db *sql.DB
+
+	Table        string
+	Placeholders Placeholders
+}
+
+// NewSnapshotStore is a constructor for SnapshotStore
+func NewSnapshotStore(db *sql.DB) *SnapshotStore {
+	return &SnapshotStore{db: db, Table: DefaultSnapshotTable}
+}
+
+// Save updates the run's row, inserting it if there's none
+func (s *SnapshotStore) Save(ctx context.Context, machine string, snap *gust.Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET machine = %s, snapshot = %s, taken_at = %s WHERE run = %s",
+		s.Table, s.param(1), s.param(2), s.param(3), s.param(4)), machine, string(data), snap.Taken, snap.Run)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (run, machine, snapshot, taken_at) VALUES (%s, %s, %s, %s)",
+		s.Table, s.param(1), s.param(2), s.param(3), s.param(4)), snap.Run, machine, string(data), snap.Taken)
+	return err
+}
+
+// Delete removes the run's row
+func (s *SnapshotStore) Delete(ctx context.Context, run string) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE run = %s", s.Table, s.param(1)), run)
+	return err
+}
+
+// List returns the snapshots of all rows
+func (s *SnapshotStore) List(ctx context.Context) ([]gust.StoredSnapshot, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT machine, snapshot FROM %s ORDER BY taken_at", s.Table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stored []gust.StoredSnapshot
+	for rows.Next() {
+		var machine, data string
+		if err := rows.Scan(&machine, &data); err != nil {
+			return nil, err
+		}
+		snap := &gust.Snapshot{}
+		if err := json.Unmarshal([]byte(data), snap); err != nil {
+			return nil, fmt.Errorf("decoding snapshot: %w", err)
+		}
+		stored = append(stored, gust.StoredSnapshot{Machine: machine, Snapshot: snap})
+	}
+	return stored, rows.Err()
+}
+
+// param returns the i-th query parameter, from 1
+func (s *SnapshotStore) param(i int) string {
+	return placeholder(s.Placeholders, i)
+}
@@ -0,0 +1,61 @@
+package gustsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+func TestSnapshotStore_SaveInsertsThenUpdates(t *testing.T) {
+	db, d := openFake(t)
+	assert.Nil(t, MigrateSnapshots(context.Background(), db, DefaultSnapshotTable))
+	rows := 0
+	d.affected = func(query string, args []driver.Value) int64 {
+		if query[:6] == "INSERT" {
+			rows++
+		}
+		if query[:6] == "UPDATE" {
+			return int64(rows)
+		}
+		return 1
+	}
+	s := NewSnapshotStore(db)
+	s.Placeholders = Dollar
+	snap := &gust.Snapshot{State: "paid", Cargo: []byte(`"o1"`), Run: "r1", Taken: time.Unix(0, 0)}
+
+	assert.Nil(t, s.Save(context.Background(), "order", snap))
+	assert.Nil(t, s.Save(context.Background(), "order", snap))
+	assert.Nil(t, s.Delete(context.Background(), "r1"))
+	assert.Equal(t, []string{
+		SnapshotSchema(DefaultSnapshotTable),
+		"UPDATE gust_snapshots SET machine = $1, snapshot = $2, taken_at = $3 WHERE run = $4",
+		"INSERT INTO gust_snapshots (run, machine, snapshot, taken_at) VALUES ($1, $2, $3, $4)",
+		"UPDATE gust_snapshots SET machine = $1, snapshot = $2, taken_at = $3 WHERE run = $4",
+		"DELETE FROM gust_snapshots WHERE run = $1",
+	}, d.stmts)
+	assert.Equal(t, "r1", d.args[2][0])
+	assert.Equal(t, "order", d.args[2][1])
+}
+
+func TestSnapshotStore_List(t *testing.T) {
+	db, d := openFake(t)
+	data, _ := json.Marshal(&gust.Snapshot{State: "paid", Cargo: []byte(`"o1"`), Run: "r1", Steps: 2})
+	d.query = func(query string, args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{"order", string(data)}}, nil
+	}
+
+	stored, err := NewSnapshotStore(db).List(context.Background())
+	assert.Nil(t, err)
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, "order", stored[0].Machine)
+		assert.Equal(t, "r1", stored[0].Snapshot.Run)
+		assert.Equal(t, 2, stored[0].Snapshot.Steps)
+		assert.Equal(t, `"o1"`, string(stored[0].Snapshot.Cargo))
+	}
+	assert.Equal(t, "SELECT machine, snapshot FROM gust_snapshots ORDER BY taken_at", d.stmts[0])
+}
@@ -0,0 +1,71 @@
+package gusttest
+
+import (
+	"strings"
+
+	"github.com/t2wu/gust"
+)
+
+// TestingT is the part of *testing.T the assertions need
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertPath checks that the run entered exactly the given states in order
+func AssertPath(t TestingT, result *gust.Result, states ...string) bool {
+	t.Helper()
+
+	if !equal(result.Path, states) {
+		t.Errorf("path mismatch\nexpected: %s\nactual:   %s", formatPath(states), formatPath(result.Path))
+		return false
+	}
+	return true
+}
+
+// AssertVisited checks that the run entered every given state
+func AssertVisited(t TestingT, result *gust.Result, states ...string) bool {
+	t.Helper()
+
+	ok := true
+	for _, state := range states {
+		if !result.Visited(state) {
+			t.Errorf("state %s not visited, path: %s", state, formatPath(result.Path))
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertNotVisited checks that the run entered none of the given states
+func AssertNotVisited(t TestingT, result *gust.Result, states ...string) bool {
+	t.Helper()
+
+	ok := true
+	for _, state := range states {
+		if result.Visited(state) {
+			t.Errorf("state %s visited, path: %s", state, formatPath(result.Path))
+			ok = false
+		}
+	}
+	return ok
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatPath(states []string) string {
+	if len(states) == 0 {
+		return "(empty)"
+	}
+	return strings.Join(states, " -> ")
+}
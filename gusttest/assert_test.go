@@ -0,0 +1,73 @@
+package gusttest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func runDiamond(t *testing.T) *gust.Result {
+	//     b
+	//   /   \
+	// a      d, going from a, c, d and b not run
+	//   \   /
+	//     c
+	d := NewScriptedState("d")
+	c := NewScriptedState("c", Step{Next: d})
+	b := NewScriptedState("b", Step{Next: d})
+	a := NewScriptedState("a", Step{Next: c})
+
+	m := gust.NewStateMachine()
+	m.AddStates(a, b, c, d)
+
+	result, err := m.Execute(context.Background(), nil, a)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return result
+}
+
+func TestAssertPath_Diamond_Works(t *testing.T) {
+	result := runDiamond(t)
+
+	AssertPath(t, result, "a", "c", "d")
+	AssertVisited(t, result, "a", "c", "d")
+	AssertNotVisited(t, result, "b")
+}
+
+func TestAssertPath_Mismatch_Fails(t *testing.T) {
+	result := runDiamond(t)
+	ft := &fakeT{}
+
+	assert.False(t, AssertPath(ft, result, "a", "b", "d"))
+	assert.Equal(t, []string{"path mismatch\nexpected: a -> b -> d\nactual:   a -> c -> d"}, ft.errors)
+}
+
+func TestAssertVisited_Missing_Fails(t *testing.T) {
+	result := runDiamond(t)
+	ft := &fakeT{}
+
+	assert.False(t, AssertVisited(ft, result, "a", "b"))
+	assert.Equal(t, []string{"state b not visited, path: a -> c -> d"}, ft.errors)
+}
+
+func TestAssertNotVisited_Visited_Fails(t *testing.T) {
+	result := runDiamond(t)
+	ft := &fakeT{}
+
+	assert.False(t, AssertNotVisited(ft, result, "c"))
+	assert.Equal(t, []string{"state c visited, path: a -> c -> d"}, ft.errors)
+}
@@ -0,0 +1,131 @@
+package gusttest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/t2wu/gust"
+)
+
+// FakeClock is a gust.Clock whose time only moves with Advance, give it to the
+// machine with gust.WithClock. Timers due after an Advance fire before
+// Advance returns, AfterFunc callbacks are called synchronously.
+type FakeClock struct {
+	lock   *sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	f     func()
+	ch    chan time.Time
+}
+
+// NewFakeClock returns a clock set to now
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{
+		lock:   &sync.Mutex{},
+		now:    now,
+		timers: make([]*fakeTimer, 0),
+	}
+	c.cond = sync.NewCond(c.lock)
+	return c
+}
+
+// Now returns the fake time
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the fake time once it's advanced by d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.add(t, d)
+	return t.ch
+}
+
+// AfterFunc calls f once the fake time is advanced by d
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) gust.Timer {
+	t := &fakeTimer{clock: c, f: f}
+	c.add(t, d)
+	return t
+}
+
+// Advance moves the fake time forward by d, firing timers that become due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+
+	due := make([]*fakeTimer, 0)
+	pending := make([]*fakeTimer, 0, len(c.timers))
+	for _, t := range c.timers {
+		if !t.at.After(now) {
+			due = append(due, t)
+		} else {
+			pending = append(pending, t)
+		}
+	}
+	c.timers = pending
+	c.cond.Broadcast()
+	c.lock.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].at.Before(due[j].at)
+	})
+	for _, t := range due {
+		if t.f != nil {
+			t.f()
+		} else {
+			t.ch <- now
+		}
+	}
+}
+
+// Pending returns the number of timers waiting to fire
+func (c *FakeClock) Pending() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil waits until at least n timers are waiting to fire. Use it to
+// make sure the machine reached a wait (a backoff, say) before advancing.
+func (c *FakeClock) BlockUntil(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) add(t *fakeTimer, d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t.at = c.now.Add(d)
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+}
+
+// Stop removes the timer, it returns false if it already fired or was stopped
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
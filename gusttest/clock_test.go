@@ -0,0 +1,71 @@
+package gusttest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+var epoch = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClock_Advance_FiresDueTimers(t *testing.T) {
+	c := NewFakeClock(epoch)
+
+	fired := make([]string, 0)
+	c.AfterFunc(2*time.Second, func() { fired = append(fired, "2s") })
+	c.AfterFunc(time.Second, func() { fired = append(fired, "1s") })
+	ch := c.After(3 * time.Second)
+	assert.Equal(t, 3, c.Pending())
+
+	c.Advance(2 * time.Second)
+	assert.Equal(t, []string{"1s", "2s"}, fired)
+	assert.Equal(t, epoch.Add(2*time.Second), c.Now())
+	select {
+	case <-ch:
+		t.Fatal("fired too early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	assert.Equal(t, epoch.Add(3*time.Second), <-ch)
+	assert.Equal(t, 0, c.Pending())
+}
+
+func TestFakeClock_Stop_PreventsFiring(t *testing.T) {
+	c := NewFakeClock(epoch)
+
+	fired := false
+	timer := c.AfterFunc(time.Second, func() { fired = true })
+	assert.True(t, timer.Stop())
+	assert.False(t, timer.Stop())
+
+	c.Advance(time.Minute)
+	assert.False(t, fired)
+}
+
+func TestFakeClock_Backoff_RunWaitsForAdvance(t *testing.T) {
+	a := NewScriptedState("a",
+		Step{Err: gust.Retryable(errors.New("timeout"))},
+		Step{},
+	)
+
+	c := NewFakeClock(epoch)
+	m := gust.NewStateMachine(gust.WithClock(c))
+	m.AddState(a)
+	m.SetRetryPolicy(a, gust.RetryPolicy{MaxAttempts: 2, Backoff: gust.ConstantBackoff(time.Hour)})
+
+	done := make(chan error)
+	go func() {
+		done <- m.Run(nil, a)
+	}()
+
+	c.BlockUntil(1)
+	assert.Equal(t, 1, a.Calls())
+
+	c.Advance(time.Hour)
+	assert.Nil(t, <-done)
+	assert.Equal(t, 2, a.Calls())
+}
@@ -0,0 +1,4 @@
+// Package gusttest provides fakes for testing machines built with gust: an
+// observer recording every transition, states following a script and a clock
+// that only moves when told to.
+package gusttest
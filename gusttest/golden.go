@@ -0,0 +1,46 @@
+package gusttest
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/t2wu/gust"
+)
+
+// update rewrites golden files instead of comparing, run with
+//
+//	go test ./... -gust.update
+var update = flag.Bool("gust.update", false, "update gust golden trace files")
+
+// AssertGolden compares the trace of the run (see gust.Result.Trace) with the
+// golden file at path, conventionally under testdata. When the tests are run
+// with -gust.update the golden file is written instead.
+func AssertGolden(t TestingT, result *gust.Result, path string) bool {
+	t.Helper()
+
+	actual := result.Trace()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Errorf("cannot create golden file directory: %v", err)
+			return false
+		}
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Errorf("cannot write golden file: %v", err)
+			return false
+		}
+		return true
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("cannot read golden file (run with -gust.update to create it): %v", err)
+		return false
+	}
+	if string(expected) != actual {
+		t.Errorf("trace differs from golden file %s\nexpected:\n%s\nactual:\n%s", path, expected, actual)
+		return false
+	}
+	return true
+}
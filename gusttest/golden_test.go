@@ -0,0 +1,55 @@
+package gusttest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertGolden_MatchingTrace_Passes(t *testing.T) {
+	result := runDiamond(t)
+
+	AssertGolden(t, result, "testdata/diamond.golden")
+}
+
+func TestAssertGolden_DifferentTrace_Fails(t *testing.T) {
+	result := runDiamond(t)
+	result.Path = []string{"a", "b", "d"}
+	ft := &fakeT{}
+
+	assert.False(t, AssertGolden(ft, result, "testdata/diamond.golden"))
+	if assert.Len(t, ft.errors, 1) {
+		assert.True(t, strings.HasPrefix(ft.errors[0], "trace differs from golden file testdata/diamond.golden"))
+	}
+}
+
+func TestAssertGolden_MissingFile_Fails(t *testing.T) {
+	result := runDiamond(t)
+	ft := &fakeT{}
+
+	assert.False(t, AssertGolden(ft, result, "testdata/missing.golden"))
+	assert.Len(t, ft.errors, 1)
+}
+
+func TestAssertGolden_Update_WritesFile(t *testing.T) {
+	*update = true
+	defer func() { *update = false }()
+
+	dir, err := ioutil.TempDir("", "gusttest")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "diamond.golden")
+
+	result := runDiamond(t)
+	assert.True(t, AssertGolden(t, result, path))
+
+	written, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, result.Trace(), string(written))
+}
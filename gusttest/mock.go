@@ -0,0 +1,66 @@
+package gusttest
+
+import "github.com/t2wu/gust"
+
+// MockBuilder scripts a state one Exec call at a time, for example a state
+// failing twice with a transient error before moving on:
+//
+//	charge := gusttest.Mock("charge").
+//		FailTimes(2, gust.Retryable(errTimeout)).
+//		GoTo(ship).
+//		Build()
+//
+// Like ScriptedState, the last scripted call is repeated once the script runs out.
+type MockBuilder struct {
+	name  string
+	steps []Step
+}
+
+// Mock starts scripting a state with the given name
+func Mock(name string) *MockBuilder {
+	return &MockBuilder{name: name, steps: make([]Step, 0)}
+}
+
+// Fail makes the next call return err
+func (b *MockBuilder) Fail(err error) *MockBuilder {
+	return b.FailTimes(1, err)
+}
+
+// FailTimes makes the next n calls return err
+func (b *MockBuilder) FailTimes(n int, err error) *MockBuilder {
+	for i := 0; i < n; i++ {
+		b.steps = append(b.steps, Step{Err: err})
+	}
+	return b
+}
+
+// GoTo makes the next call move to next, passing the cargo on
+func (b *MockBuilder) GoTo(next gust.State) *MockBuilder {
+	b.steps = append(b.steps, Step{Next: next})
+	return b
+}
+
+// GoToWith makes the next call move to next with the given cargo
+func (b *MockBuilder) GoToWith(next gust.State, cargo interface{}) *MockBuilder {
+	b.steps = append(b.steps, Step{Next: next, Cargo: cargo})
+	return b
+}
+
+// End makes the next call end the run, passing the cargo on
+func (b *MockBuilder) End() *MockBuilder {
+	b.steps = append(b.steps, Step{})
+	return b
+}
+
+// Do makes the next call run f
+func (b *MockBuilder) Do(f gust.ExecFunc) *MockBuilder {
+	b.steps = append(b.steps, Step{Do: f})
+	return b
+}
+
+// Build returns the scripted state
+func (b *MockBuilder) Build() *ScriptedState {
+	steps := make([]Step, len(b.steps))
+	copy(steps, b.steps)
+	return NewScriptedState(b.name, steps...)
+}
@@ -0,0 +1,57 @@
+package gusttest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+func TestMock_FailTwiceThenGoTo_RetriedUntilSuccess(t *testing.T) {
+	errTimeout := gust.Retryable(errors.New("timeout"))
+	ship := Mock("ship").End().Build()
+	charge := Mock("charge").
+		FailTimes(2, errTimeout).
+		GoTo(ship).
+		Build()
+
+	m := gust.NewStateMachine()
+	m.AddStates(charge, ship)
+	m.SetRetryPolicy(charge, gust.RetryPolicy{MaxAttempts: 3})
+
+	assert.Nil(t, m.Run("order", charge))
+	assert.Equal(t, 3, charge.Calls())
+	assert.Equal(t, []interface{}{"order"}, ship.Received())
+}
+
+func TestMock_FatalThenCompensate_SecondRunTakesOtherBranch(t *testing.T) {
+	refund := Mock("refund").End().Build()
+	ship := Mock("ship").End().Build()
+	charge := Mock("charge").
+		Fail(errors.New("card declined")).
+		GoToWith(refund, "refund me").
+		GoTo(ship).
+		Build()
+
+	m := gust.NewStateMachine()
+	m.AddStates(charge, refund, ship)
+
+	assert.Error(t, m.Run(nil, charge))
+
+	assert.Nil(t, m.Run(nil, charge))
+	assert.Equal(t, []interface{}{"refund me"}, refund.Received())
+
+	assert.Nil(t, m.Run(nil, charge))
+	assert.Equal(t, 1, ship.Calls())
+}
+
+func TestMock_Do_CallsFunction(t *testing.T) {
+	double := Mock("double").Do(func(cargo interface{}) (gust.State, interface{}, error) {
+		return nil, cargo.(int) * 2, nil
+	}).Build()
+
+	_, cargo, err := double.Exec(21)
+	assert.Nil(t, err)
+	assert.Equal(t, 42, cargo)
+}
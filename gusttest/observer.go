@@ -0,0 +1,57 @@
+package gusttest
+
+import "sync"
+
+// Change is a state change seen by a RecordingObserver
+type Change struct {
+	Prior string // empty when Next is the start state
+	Next  string
+}
+
+// RecordingObserver is a gust.Observer remembering every state change. It's
+// safe to read while the machine is running.
+type RecordingObserver struct {
+	lock    *sync.Mutex
+	changes []Change
+}
+
+// NewRecordingObserver is a constructor for RecordingObserver
+func NewRecordingObserver() *RecordingObserver {
+	return &RecordingObserver{
+		lock:    &sync.Mutex{},
+		changes: make([]Change, 0),
+	}
+}
+
+// StateChanged records the change
+func (o *RecordingObserver) StateChanged(priorState string, nextState string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.changes = append(o.changes, Change{Prior: priorState, Next: nextState})
+}
+
+// Changes returns the changes recorded so far in order
+func (o *RecordingObserver) Changes() []Change {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return append([]Change{}, o.changes...)
+}
+
+// States returns the names of the states entered so far in order
+func (o *RecordingObserver) States() []string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	states := make([]string, len(o.changes))
+	for i, c := range o.changes {
+		states[i] = c.Next
+	}
+	return states
+}
+
+// Reset forgets the changes recorded so far
+func (o *RecordingObserver) Reset() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.changes = o.changes[:0]
+}
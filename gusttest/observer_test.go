@@ -0,0 +1,30 @@
+package gusttest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+func TestRecordingObserver_Run_RecordsChanges(t *testing.T) {
+	c := NewScriptedState("c")
+	b := NewScriptedState("b", Step{Next: c})
+	a := NewScriptedState("a", Step{Next: b})
+
+	o := NewRecordingObserver()
+
+	m := gust.NewStateMachine(gust.WithObservers(o))
+	m.AddStates(a, b, c)
+
+	err := m.Run(nil, a)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, []Change{{"", "a"}, {"a", "b"}, {"b", "c"}}, o.Changes())
+	assert.Equal(t, []string{"a", "b", "c"}, o.States())
+
+	o.Reset()
+	assert.Len(t, o.Changes(), 0)
+}
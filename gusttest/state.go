@@ -0,0 +1,88 @@
+package gusttest
+
+import (
+	"sync"
+
+	"github.com/t2wu/gust"
+)
+
+// Step is what a ScriptedState returns from one Exec call
+type Step struct {
+	Next  gust.State  // the next state, nil to end the run
+	Cargo interface{} // the next cargo, the received cargo is passed on if nil
+	Err   error
+
+	Do gust.ExecFunc // if set it's called instead, and the fields above are ignored
+}
+
+// ScriptedState is a named state returning the configured steps in order, one
+// per Exec call. Once the script runs out the last step is repeated, and a
+// state without steps ends the run passing its cargo on.
+type ScriptedState struct {
+	name  string
+	steps []Step
+
+	lock     *sync.Mutex
+	received []interface{}
+}
+
+// NewScriptedState is a constructor for ScriptedState
+func NewScriptedState(name string, steps ...Step) *ScriptedState {
+	return &ScriptedState{
+		name:     name,
+		steps:    steps,
+		lock:     &sync.Mutex{},
+		received: make([]interface{}, 0),
+	}
+}
+
+// Exec returns the next step of the script
+func (s *ScriptedState) Exec(cargo interface{}) (gust.State, interface{}, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	call := len(s.received)
+	s.received = append(s.received, cargo)
+	if len(s.steps) == 0 {
+		return nil, cargo, nil
+	}
+	if call >= len(s.steps) {
+		call = len(s.steps) - 1
+	}
+
+	step := s.steps[call]
+	if step.Do != nil {
+		return step.Do(cargo)
+	}
+	if step.Cargo == nil {
+		return step.Next, cargo, step.Err
+	}
+	return step.Next, step.Cargo, step.Err
+}
+
+// Name is the name given to NewScriptedState
+func (s *ScriptedState) Name() string {
+	return s.name
+}
+
+// Then appends steps to the script, it returns the state so it can be chained
+func (s *ScriptedState) Then(steps ...Step) *ScriptedState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.steps = append(s.steps, steps...)
+	return s
+}
+
+// Calls is the number of times Exec was called
+func (s *ScriptedState) Calls() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.received)
+}
+
+// Received returns the cargo given to each Exec call in order
+func (s *ScriptedState) Received() []interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]interface{}{}, s.received...)
+}
@@ -0,0 +1,59 @@
+package gusttest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+func TestScriptedState_StepsInOrder_ThenRepeatsLast(t *testing.T) {
+	b := NewScriptedState("b")
+	someErr := errors.New("some error")
+	a := NewScriptedState("a",
+		Step{Err: someErr},
+		Step{Next: b, Cargo: 2},
+	)
+
+	_, _, err := a.Exec(1)
+	assert.Equal(t, someErr, err)
+
+	next, cargo, err := a.Exec(1)
+	assert.Nil(t, err)
+	assert.Equal(t, b, next)
+	assert.Equal(t, 2, cargo)
+
+	next, cargo, err = a.Exec(3)
+	assert.Nil(t, err)
+	assert.Equal(t, b, next)
+	assert.Equal(t, 2, cargo)
+
+	assert.Equal(t, 3, a.Calls())
+	assert.Equal(t, []interface{}{1, 1, 3}, a.Received())
+}
+
+func TestScriptedState_NoSteps_EndsPassingCargo(t *testing.T) {
+	a := NewScriptedState("a")
+
+	next, cargo, err := a.Exec("cargo")
+	assert.Nil(t, next)
+	assert.Equal(t, "cargo", cargo)
+	assert.Nil(t, err)
+}
+
+func TestScriptedState_InMachine_RetriedUntilSuccess(t *testing.T) {
+	b := NewScriptedState("b")
+	a := NewScriptedState("a").Then(
+		Step{Err: gust.Retryable(errors.New("timeout"))},
+		Step{Next: b},
+	)
+
+	m := gust.NewStateMachine()
+	m.AddStates(a, b)
+	m.SetRetryPolicy(a, gust.RetryPolicy{MaxAttempts: 2})
+
+	assert.Nil(t, m.Run("cargo", a))
+	assert.Equal(t, 2, a.Calls())
+	assert.Equal(t, []interface{}{"cargo"}, b.Received())
+}
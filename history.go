@@ -0,0 +1,65 @@
+package gust
+
+import "sync"
+
+// history is the names of the states a run entered, in a ring buffer of the
+// last limit of them if limit is larger than 0
+type history struct {
+	names   []string
+	start   int // index of the oldest name once the buffer wrapped
+	limit   int
+	entered int // states entered in total, including those dropped
+}
+
+// historyPool recycles the buffers of finished runs, anything outliving a run
+// copies its history
+var historyPool = sync.Pool{
+	New: func() interface{} {
+		return make([]string, 0, 16)
+	},
+}
+
+// maxPooledHistory is the largest buffer recycled, so one long run doesn't
+// pin memory
+const maxPooledHistory = 1024
+
+func newHistory(limit int) history {
+	return history{names: historyPool.Get().([]string), limit: limit}
+}
+
+// release gives the buffer back to the pool, the history must not be used
+// afterwards
+func (h *history) release() {
+	if cap(h.names) <= maxPooledHistory {
+		for i := range h.names {
+			h.names[i] = "" // not to keep the names alive
+		}
+		historyPool.Put(h.names[:0])
+	}
+	h.names = nil
+}
+
+func (h *history) add(name string) {
+	h.entered++
+	if h.limit <= 0 || len(h.names) < h.limit {
+		h.names = append(h.names, name)
+		return
+	}
+	h.names[h.start] = name
+	h.start = (h.start + 1) % len(h.names)
+}
+
+// list returns a copy of the names kept, oldest first
+func (h *history) list() []string {
+	names := make([]string, 0, len(h.names))
+	names = append(names, h.names[h.start:]...)
+	return append(names, h.names[:h.start]...)
+}
+
+// SetHistoryLimit bounds the states a run remembers to the last n, so
+// long-lived looping runs don't grow without bound. Result.Path,
+// RunError.Path and snapshot paths then hold the last n states at most. 0,
+// the default, keeps them all.
+func (sm *StateMachine) SetHistoryLimit(n int) {
+	sm.historyLimit = n
+}
@@ -0,0 +1,57 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory_Limit_KeepsLast(t *testing.T) {
+	h := history{limit: 3}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		h.add(name)
+	}
+	assert.Equal(t, []string{"c", "d", "e"}, h.list())
+	assert.Equal(t, 5, h.entered)
+
+	unbounded := history{}
+	unbounded.add("a")
+	unbounded.add("b")
+	assert.Equal(t, []string{"a", "b"}, unbounded.list())
+}
+
+func TestSetHistoryLimit_LoopingRun_LastStates(t *testing.T) {
+	m := NewStateMachine(WithHistoryLimit(4))
+	s := &loopState{n: 100}
+	fail := &StateImpl{name: "fail", err: errors.New("boom")}
+	m.AddStates(s, fail)
+
+	result, err := m.Execute(context.Background(), nil, s)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"loop", "loop", "loop", "loop"}, result.Path)
+
+	_, err = m.Execute(context.Background(), nil, fail)
+	var runErr *RunError
+	if assert.True(t, errors.As(err, &runErr)) {
+		assert.Equal(t, []string{"fail"}, runErr.Path)
+	}
+}
+
+func TestSetHistoryLimit_SnapshotsOncePerState(t *testing.T) {
+	calls := 0
+	m, start := newOrderMachine(&calls) // charge is retried
+	m.SetHistoryLimit(1)
+
+	snaps := make([]*Snapshot, 0)
+	_, err := m.SnapshotRun(context.Background(), order{ID: "o1"}, start, func(snap *Snapshot) error {
+		snaps = append(snaps, snap)
+		return nil
+	})
+	assert.Nil(t, err)
+	if assert.Len(t, snaps, 3) {
+		assert.Equal(t, "ship", snaps[2].State)
+		assert.Equal(t, []string{}, snaps[2].Path) // charge was dropped
+	}
+}
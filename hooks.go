@@ -0,0 +1,34 @@
+package gust
+
+import "context"
+
+// OnRunStart registers a callback fired when a run starts, before the start
+// state is entered. If the callback returns an error the run fails with it and
+// neither states nor OnRunEnd callbacks are executed. Callbacks are called in
+// the order registered.
+func (sm *StateMachine) OnRunStart(f func(ctx context.Context, cargo interface{}) error) {
+	sm.runStartHooks = append(sm.runStartHooks, f)
+}
+
+// OnRunEnd registers a callback fired when a run finishes. cargo is the last
+// cargo of the run and err is what Run returns, nil on success. Runs moved to a
+// reloaded machine, see Manager.Reload, end with ErrReloaded and go on there.
+// Callbacks are called in the order registered.
+func (sm *StateMachine) OnRunEnd(f func(cargo interface{}, err error)) {
+	sm.runEndHooks = append(sm.runEndHooks, f)
+}
+
+func (sm *StateMachine) runStarted(ctx context.Context, cargo interface{}) error {
+	for _, f := range sm.runStartHooks {
+		if err := f(ctx, cargo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sm *StateMachine) runEnded(cargo interface{}, err error) {
+	for _, f := range sm.runEndHooks {
+		f(cargo, err)
+	}
+}
@@ -0,0 +1,80 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHooks_SuccessfulRun_StartAndEndCalled(t *testing.T) {
+	// A -> B
+	b := &StateImpl{
+		cargo: 3,
+	}
+	a := &StateImpl{
+		nextState: b,
+		cargo:     2,
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+
+	calls := make([]string, 0)
+	m.OnRunStart(func(ctx context.Context, cargo interface{}) error {
+		calls = append(calls, fmt.Sprintf("start %v", cargo))
+		return nil
+	})
+	m.OnRunEnd(func(cargo interface{}, err error) {
+		calls = append(calls, fmt.Sprintf("end %v %v", cargo, err))
+	})
+
+	err := m.Run(1, a)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"start 1", "end 3 <nil>"}, calls)
+}
+
+func TestRunHooks_StateHasError_EndReceivesError(t *testing.T) {
+	a := &StateImpl{
+		err: fmt.Errorf("some error"),
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+
+	var endErr error
+	m.OnRunEnd(func(cargo interface{}, err error) {
+		endErr = err
+	})
+
+	err := m.Run(nil, a)
+	assert.Error(t, err)
+	assert.Equal(t, err, endErr)
+	assert.True(t, errors.Is(endErr, a.err))
+}
+
+func TestRunHooks_StartFails_NoStateRunAndEndNotCalled(t *testing.T) {
+	a := &StateImpl{}
+
+	m := NewStateMachine()
+	m.AddState(a)
+
+	leaseErr := fmt.Errorf("lease not acquired")
+	endCalled := false
+	m.OnRunStart(func(ctx context.Context, cargo interface{}) error {
+		return leaseErr
+	})
+	m.OnRunEnd(func(cargo interface{}, err error) {
+		endCalled = true
+	})
+
+	err := m.Run(nil, a)
+	assert.Equal(t, leaseErr, err)
+	assert.False(t, a.run)
+	assert.False(t, endCalled)
+}
@@ -0,0 +1,72 @@
+package gust
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// IdempotencyKey returns a key identifying the current execution of the state,
+// the ctx must be the one given to ExecContext. It's derived from the run, how
+// many states the run entered, the state and the attempt, so it changes with
+// every retry and every visit of the state, but is the same when a run resumed
+// with Resume or Continue executes the state again. Side effects passing it to
+// the systems they call, e.g. as a payment's idempotency key, can thus be
+// retried after a crash without being applied twice. ok is false if ctx
+// doesn't belong to a run.
+func IdempotencyKey(ctx context.Context) (key string, ok bool) {
+	r, ok := ctx.Value(runKey{}).(*run)
+	if !ok {
+		return "", false
+	}
+	return r.idempotencyKey(r.path.entered, r.state, r.attempt), true
+}
+
+// resumed is where a resumed run takes over from, carried by the context given
+// to executeWith
+type resumed struct {
+	base  string // the idempotency key base of the interrupted run
+	steps int    // the states it entered before the one resumed
+	lease Lease  // the run's lock if already taken
+}
+
+type resumeKey struct{}
+
+// resuming marks ctx as resuming the run with the idempotency key base, after
+// the given number of states
+func resuming(ctx context.Context, base string, steps int) context.Context {
+	if base == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, resumeKey{}, resumed{base: base, steps: steps})
+}
+
+// idempotencyBase returns the run's idempotency key base, made up on first use
+func (r *run) idempotencyBase() string {
+	r.sm.runsLock.Lock()
+	defer r.sm.runsLock.Unlock()
+	if r.keyBase == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			panic(fmt.Sprintf("gust: reading random idempotency key: %v", err))
+		}
+		r.keyBase = hex.EncodeToString(b)
+	}
+	return r.keyBase
+}
+
+// idempotencyKey is the key of the attempt of the state entered at the given step
+func (r *run) idempotencyKey(step int, state State, attempt int) string {
+	return fmt.Sprintf("%s/%d/%s/%d", r.idempotencyBase(), r.resumedSteps+step, r.sm.StateName(state), attempt)
+}
+
+// IdempotencyKey returns the key the first attempt of the snapshot's state is
+// given when resumed, see the IdempotencyKey function. It's empty for snapshots
+// taken before idempotency keys existed.
+func (s *Snapshot) IdempotencyKey() string {
+	if s.Run == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%d/%s/%d", s.Run, s.Steps+1, s.State, 1)
+}
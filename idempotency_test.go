@@ -0,0 +1,113 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// keyState records the idempotency keys it's executed with, failing while fail
+// returns true
+type keyState struct {
+	name string
+	next State
+	keys []string
+	fail func(attempt int) error
+}
+
+func (s *keyState) Exec(cargo interface{}) (State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+func (s *keyState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	key, _ := IdempotencyKey(ctx)
+	s.keys = append(s.keys, key)
+	if s.fail != nil {
+		if err := s.fail(len(s.keys)); err != nil {
+			return nil, cargo, err
+		}
+	}
+	return s.next, cargo, nil
+}
+
+func (s *keyState) Name() string {
+	return s.name
+}
+
+func TestIdempotencyKey_PerAttemptAndVisit(t *testing.T) {
+	m := NewStateMachine()
+	charge := &keyState{name: "charge", fail: func(n int) error {
+		if n == 1 {
+			return Retryable(errors.New("timeout"))
+		}
+		return nil
+	}}
+	m.AddState(charge)
+	m.SetRetryPolicy(charge, RetryPolicy{MaxAttempts: 2})
+
+	assert.Nil(t, m.Run(nil, charge))
+	assert.Nil(t, m.Run(nil, charge))
+	if assert.Len(t, charge.keys, 3) {
+		assert.Regexp(t, "^[0-9a-f]{32}/1/charge/1$", charge.keys[0])
+		assert.Regexp(t, "/1/charge/2$", charge.keys[1])
+		assert.NotEqual(t, charge.keys[0][:32], charge.keys[2][:32], "runs share a key base")
+	}
+
+	_, ok := IdempotencyKey(context.Background())
+	assert.False(t, ok)
+}
+
+func TestIdempotencyKey_SameWhenResumed(t *testing.T) {
+	m := NewStateMachine()
+	crash := errors.New("crash")
+	charge := &keyState{name: "charge", fail: func(n int) error {
+		if n == 1 {
+			return crash
+		}
+		return nil
+	}}
+	order := &keyState{name: "order", next: charge}
+	m.AddState(order)
+	m.AddState(charge)
+
+	var last *Snapshot
+	_, err := m.SnapshotRun(context.Background(), "cargo", order, func(snap *Snapshot) error {
+		last = snap
+		return nil
+	})
+	assert.True(t, errors.Is(err, crash))
+	assert.Equal(t, 1, last.Steps)
+	assert.Equal(t, charge.keys[0], last.IdempotencyKey())
+
+	_, err = m.Resume(context.Background(), last, nil)
+	assert.Nil(t, err)
+	if assert.Len(t, charge.keys, 2) {
+		assert.Equal(t, charge.keys[0], charge.keys[1])
+	}
+}
+
+func TestIdempotencyKey_SameWhenContinued(t *testing.T) {
+	m := NewStateMachine()
+	ctx, cancel := context.WithCancel(context.Background())
+	charge := &keyState{name: "charge", fail: func(n int) error {
+		if n == 1 {
+			cancel()
+		}
+		return nil
+	}}
+	m.AddState(charge)
+
+	_, err := m.Execute(ctx, nil, charge)
+	token, ok := ResumeTokenOf(err)
+	if assert.True(t, ok) {
+		_, err = m.Continue(context.Background(), token)
+		assert.Nil(t, err)
+		assert.Equal(t, charge.keys[0], charge.keys[1])
+
+		snap, err := m.Checkpoint(token)
+		assert.Nil(t, err)
+		assert.Equal(t, charge.keys[0], snap.IdempotencyKey())
+	}
+}
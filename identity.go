@@ -0,0 +1,30 @@
+package gust
+
+// Identifier when implemented by a state gives it an identity other than its
+// pointer. States with the same ID are the same state to the machine, so value
+// type states, or states reconstructed after deserialization, are recognized as
+// the registered ones.
+type Identifier interface {
+	ID() string
+}
+
+// stateKey is what states are compared and indexed by
+type stateKey interface{}
+
+type idKey struct {
+	id string
+}
+
+// keyOf returns the identity of the state, its ID if it's an Identifier or the
+// state itself otherwise
+func keyOf(s State) stateKey {
+	if i, ok := s.(Identifier); ok {
+		return idKey{id: i.ID()}
+	}
+	return s
+}
+
+// sameState tells whether two states have the same identity
+func sameState(a, b State) bool {
+	return keyOf(a) == keyOf(b)
+}
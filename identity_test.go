@@ -0,0 +1,56 @@
+package gust
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ValueState struct { // interface State and Identifier, used by value
+	id   string
+	next string // id of the next state, empty to end
+}
+
+func (s ValueState) Exec(cargo interface{}) (State, interface{}, error) {
+	if s.next == "" {
+		return nil, cargo, nil
+	}
+	// reconstruct the next state rather than returning the registered one
+	return ValueState{id: s.next}, append(cargo.([]string), s.id), nil
+}
+
+func (s ValueState) ID() string {
+	return s.id
+}
+
+func TestIdentifier_SameID_SameState(t *testing.T) {
+	assert.True(t, sameState(ValueState{id: "a"}, ValueState{id: "a", next: "b"}))
+	assert.False(t, sameState(ValueState{id: "a"}, ValueState{id: "b"}))
+
+	a, b := &StateImpl{}, &StateImpl{}
+	assert.True(t, sameState(a, a))
+	assert.False(t, sameState(a, b))
+}
+
+func TestIdentifier_ReconstructedStates_RecognizedAsRegistered(t *testing.T) {
+	m := NewStateMachine()
+	m.AddState(ValueState{id: "a", next: "b"})
+	m.AddState(ValueState{id: "b", next: "c"})
+	m.AddState(ValueState{id: "c"})
+	m.AddTransition(ValueState{id: "a"}, ValueState{id: "b"})
+	m.AddTransition(ValueState{id: "b"}, ValueState{id: "c"})
+
+	assert.True(t, m.CanTransition(ValueState{id: "a"}, ValueState{id: "b"}))
+	assert.False(t, m.CanTransition(ValueState{id: "a"}, ValueState{id: "c"}))
+
+	err := m.Run([]string{}, ValueState{id: "a", next: "b"})
+	assert.Nil(t, err)
+}
+
+func TestIdentifier_UnregisteredID_ReturnsError(t *testing.T) {
+	m := NewStateMachine()
+	m.AddState(ValueState{id: "a", next: "x"})
+
+	err := m.Run([]string{}, ValueState{id: "a", next: "x"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+// Package deffile reads machine definition files for the gust commands
+package deffile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/t2wu/gust"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads a definition in the given format, yaml, json or dot. If format
+// is empty, it's taken from the file extension.
+func Load(path, format string) (*gust.Definition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	d := &gust.Definition{}
+	switch format {
+	case "yaml", "yml":
+		err = yaml.NewDecoder(f).Decode(d)
+	case "json":
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(d)
+	case "dot", "gv":
+		d, err = gust.ParseDOT(f)
+	default:
+		return nil, fmt.Errorf("unknown definition format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return d, nil
+}
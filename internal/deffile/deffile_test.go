@@ -0,0 +1,55 @@
+package deffile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/t2wu/gust"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "deffile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_SameDefinitionInEveryFormat(t *testing.T) {
+	want := &gust.Definition{
+		Start:       "a",
+		States:      []gust.StateDefinition{{Name: "a"}, {Name: "b"}},
+		Transitions: []gust.TransitionDefinition{{From: "a", To: "b"}},
+	}
+
+	files := map[string]string{
+		"m.yaml": "start: a\nstates:\n  - name: a\n  - name: b\ntransitions:\n  - {from: a, to: b}\n",
+		"m.json": `{"start":"a","states":[{"name":"a"},{"name":"b"}],"transitions":[{"from":"a","to":"b"}]}`,
+		"m.dot":  "digraph { __start [shape=point]; __start -> a; a -> b }",
+	}
+	for name, content := range files {
+		d, err := Load(writeFile(t, name, content), "")
+		if assert.Nil(t, err, name) {
+			assert.Equal(t, want, d, name)
+		}
+	}
+}
+
+func TestLoad_JSONUnknownField_Error(t *testing.T) {
+	_, err := Load(writeFile(t, "m.json", `{"stats":[]}`), "")
+	assert.NotNil(t, err)
+}
+
+func TestLoad_UnknownFormat_Error(t *testing.T) {
+	_, err := Load(writeFile(t, "m.txt", ""), "")
+	assert.EqualError(t, err, `unknown definition format "txt"`)
+}
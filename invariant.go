@@ -0,0 +1,54 @@
+package gust
+
+import "fmt"
+
+// InvariantError is returned by runs failing because an invariant doesn't
+// hold, see AddInvariant
+type InvariantError struct {
+	State string // name of the state entered when the invariant was violated
+	Err   error  // the invariant's error, a *PanicError if it panicked
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("invariant violated entering %s: %v", e.State, e.Err)
+}
+
+// Unwrap returns the invariant's error
+func (e *InvariantError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports ErrInvariantViolated as a match
+func (e *InvariantError) Is(target error) bool {
+	return target == ErrInvariantViolated
+}
+
+// AddInvariant registers conditions that must hold whenever a run enters a
+// state, given the cargo the state is about to execute with. A run whose
+// invariant doesn't hold fails before executing the state with an
+// *InvariantError, cheap model checking always on in production. Actors check
+// them against every message, before the state they're in handles it.
+// Invariants are also checked by RandomWalk. Register them before running the
+// machine.
+func (sm *StateMachine) AddInvariant(invariants ...Invariant) {
+	sm.invariants = append(sm.invariants, invariants...)
+}
+
+// WithInvariants registers invariants, like AddInvariant
+func WithInvariants(invariants ...Invariant) Option {
+	return func(sm *StateMachine) {
+		sm.AddInvariant(invariants...)
+	}
+}
+
+// checkInvariants tells which of the machine's invariants doesn't hold
+// entering the state
+func (sm *StateMachine) checkInvariants(state State, cargo interface{}) error {
+	if len(sm.invariants) == 0 {
+		return nil
+	}
+	if err := checkInvariants(sm.invariants, cargo, sm.StateName(state)); err != nil {
+		return &InvariantError{State: sm.StateName(state), Err: err}
+	}
+	return nil
+}
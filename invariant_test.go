@@ -0,0 +1,78 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errNegative = errors.New("negative balance")
+
+func nonNegative(cargo interface{}, state string) error {
+	if n, ok := cargo.(int); ok && n < 0 {
+		return fmt.Errorf("%w %d", errNegative, n)
+	}
+	return nil
+}
+
+func TestAddInvariant_Violated_RunFails(t *testing.T) {
+	m := NewStateMachine(WithInvariants(nonNegative))
+	c := &StateImpl{name: "c"}
+	b := &StateImpl{name: "b", nextState: c, cargo: -5}
+	a := &StateImpl{name: "a", nextState: b, cargo: 3}
+	m.AddStates(a, b, c)
+
+	result, err := m.Execute(context.Background(), 1, a)
+	assert.True(t, errors.Is(err, ErrInvariantViolated))
+	assert.True(t, errors.Is(err, errNegative))
+	var ierr *InvariantError
+	if assert.True(t, errors.As(err, &ierr)) {
+		assert.Equal(t, "c", ierr.State)
+	}
+	assert.Contains(t, err.Error(), "invariant violated entering c: negative balance -5")
+	assert.False(t, c.run)
+	assert.Equal(t, -5, result.Cargo)
+}
+
+func TestAddInvariant_Holds_RunSucceeds(t *testing.T) {
+	m := NewStateMachine()
+	entered := make([]string, 0)
+	m.AddInvariant(nonNegative, func(cargo interface{}, state string) error {
+		entered = append(entered, state)
+		return nil
+	})
+	b := &StateImpl{name: "b", cargo: 2}
+	a := &StateImpl{name: "a", nextState: b, cargo: 1}
+	m.AddStates(a, b)
+
+	_, err := m.Execute(context.Background(), 0, a)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, entered)
+}
+
+func TestAddInvariant_Panics_PanicError(t *testing.T) {
+	m := NewStateMachine()
+	m.AddInvariant(func(cargo interface{}, state string) error { panic("boom") })
+	a := &StateImpl{name: "a"}
+	m.AddState(a)
+
+	_, err := m.Execute(context.Background(), nil, a)
+	var perr *PanicError
+	assert.True(t, errors.As(err, &perr))
+	assert.True(t, errors.Is(err, ErrInvariantViolated))
+}
+
+func TestAddInvariant_CheckedByRandomWalk(t *testing.T) {
+	m := NewStateMachine(WithInvariants(nonNegative))
+	a := &StateImpl{name: "a"}
+	m.AddState(a)
+
+	report := m.RandomWalk(a, WalkConfig{Walks: 1, Cargo: func(*rand.Rand) interface{} { return -1 }})
+	if assert.Len(t, report.Failures, 1) {
+		assert.True(t, errors.Is(report.Failures[0].Err, errNegative))
+	}
+}
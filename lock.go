@@ -0,0 +1,124 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Locker grants the exclusive right to execute a persisted run, so several
+// processes resuming the same snapshots don't execute a run twice at once.
+// Runs are locked by their Snapshot's Run. gustsql has advisory lock adapters
+// for PostgreSQL and MySQL and gustredis one for Redis.
+type Locker interface {
+	// Lock takes the lock of the key without waiting for it, failing with an
+	// error wrapping ErrLocked if it's held elsewhere
+	Lock(ctx context.Context, key string) (Lease, error)
+}
+
+// Lease is a lock taken with a Locker
+type Lease interface {
+	// Unlock releases the lock
+	Unlock() error
+	// Done is closed if the lock is lost before being released, e.g. when
+	// it expired, nil if it can't be lost
+	Done() <-chan struct{}
+}
+
+// SetLocker makes the machine lock persisted runs while executing them: runs
+// taking snapshots with SnapshotRun before their first state, and runs
+// continued with Resume or Continue before starting. A run whose lock is held
+// elsewhere fails with ErrLocked, and one whose lock is lost is aborted with
+// ErrLockLost. nil removes the locker.
+func (sm *StateMachine) SetLocker(locker Locker) {
+	sm.locker = locker
+}
+
+// lockRun locks the run if the machine has a locker
+func (sm *StateMachine) lockRun(r *run) error {
+	if sm.locker == nil || r.lease != nil {
+		return nil
+	}
+	lease, err := sm.locker.Lock(r.ctx, r.idempotencyBase())
+	if err != nil {
+		return fmt.Errorf("locking run: %w", err)
+	}
+
+	sm.runsLock.Lock()
+	r.lease = lease
+	sm.runsLock.Unlock()
+	sm.watchLease(r)
+	return nil
+}
+
+// watchLease aborts the run with ErrLockLost if its lock is lost
+func (sm *StateMachine) watchLease(r *run) {
+	if lost := r.lease.Done(); lost != nil {
+		go func() {
+			select {
+			case <-lost:
+				sm.runsLock.Lock()
+				if r.reason == nil {
+					r.reason = ErrLockLost
+				}
+				sm.runsLock.Unlock()
+				r.cancel()
+			case <-r.ctx.Done():
+			}
+		}()
+	}
+}
+
+// unlock releases the lock of a finished run, failures are reported to the
+// OnObserverError callback, the lock expiring or its session ending eventually
+func (sm *StateMachine) unlock(lease Lease) {
+	if err := lease.Unlock(); err != nil && sm.observerErrorHandler != nil {
+		sm.observerErrorHandler(fmt.Errorf("unlocking run: %w", err))
+	}
+}
+
+// MemoryLocker is a Locker within a single process, for tests and machines
+// that don't run on several nodes
+type MemoryLocker struct {
+	lock *sync.Mutex
+	held map[string]bool
+}
+
+// NewMemoryLocker is a constructor for MemoryLocker
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{lock: &sync.Mutex{}, held: make(map[string]bool)}
+}
+
+// Lock takes the lock of the key
+func (l *MemoryLocker) Lock(ctx context.Context, key string) (Lease, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.held[key] {
+		return nil, fmt.Errorf("%w: %s", ErrLocked, key)
+	}
+	l.held[key] = true
+	return &memoryLease{locker: l, key: key}, nil
+}
+
+// Held reports whether the lock of the key is held
+func (l *MemoryLocker) Held(key string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.held[key]
+}
+
+type memoryLease struct {
+	locker *MemoryLocker
+	key    string
+}
+
+func (l *memoryLease) Unlock() error {
+	l.locker.lock.Lock()
+	defer l.locker.lock.Unlock()
+	delete(l.locker.held, l.key)
+	return nil
+}
+
+func (l *memoryLease) Done() <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,86 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLocker_SnapshotRunLocked(t *testing.T) {
+	locker := NewMemoryLocker()
+	m := NewStateMachine(WithLocker(locker))
+	var last *Snapshot
+	held := false
+	a := NewFuncState("a", func(cargo interface{}) (State, interface{}, error) {
+		held = locker.Held(last.Run)
+		return nil, cargo, nil
+	})
+	m.AddState(a)
+
+	_, err := m.SnapshotRun(context.Background(), "cargo", a, func(snap *Snapshot) error {
+		last = snap
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, held)
+	assert.False(t, locker.Held(last.Run), "lock not released")
+}
+
+func TestSetLocker_ResumeOfLockedRun_Fails(t *testing.T) {
+	locker := NewMemoryLocker()
+	m := NewStateMachine(WithLocker(locker))
+	a := &StateImpl{name: "a"}
+	m.AddState(a)
+
+	snap := &Snapshot{State: "a", Cargo: []byte("null"), Run: "run-1"}
+	lease, _ := locker.Lock(context.Background(), "run-1")
+	result, err := m.Resume(context.Background(), snap, nil)
+	assert.True(t, errors.Is(err, ErrLocked))
+	assert.Equal(t, err, result.Err)
+
+	lease.Unlock()
+	_, err = m.Resume(context.Background(), snap, nil)
+	assert.Nil(t, err)
+}
+
+// lostLease is a lease lost as soon as taken
+type lostLease struct{ lost chan struct{} }
+
+func (l *lostLease) Unlock() error         { return nil }
+func (l *lostLease) Done() <-chan struct{} { return l.lost }
+
+type losingLocker struct{}
+
+func (losingLocker) Lock(ctx context.Context, key string) (Lease, error) {
+	lost := make(chan struct{})
+	close(lost)
+	return &lostLease{lost: lost}, nil
+}
+
+func TestSetLocker_LockLost_RunAborted(t *testing.T) {
+	m := NewStateMachine()
+	m.SetLocker(losingLocker{})
+	a := &ctxFuncState{name: "a", f: func(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+		return nil, cargo, nil
+	}}
+	m.AddState(a)
+
+	_, err := m.SnapshotRun(context.Background(), nil, a, func(snap *Snapshot) error { return nil })
+	assert.True(t, errors.Is(err, ErrLockLost))
+}
+
+func TestSetLocker_PlainRunsNotLocked(t *testing.T) {
+	m := NewStateMachine()
+	m.SetLocker(losingLocker{})
+	a := &StateImpl{name: "a"}
+	m.AddState(a)
+
+	assert.Nil(t, m.Run(nil, a))
+}
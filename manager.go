@@ -0,0 +1,390 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Manager is a small in-process workflow engine: it owns named machines and
+// tracks the instances, runs, started on them by ID
+type Manager struct {
+	lock      *sync.Mutex
+	machines  map[string]*managedMachine
+	instances map[string]*Instance
+	started   int // instances started, for IDs
+
+	store             SnapshotStore // see SetStore
+	storeErrorHandler func(err error)
+
+	supervisors map[string]SupervisorPolicy // by machine, see Supervise
+
+	shutdown bool
+	drain    chan struct{} // closed by Shutdown
+}
+
+type managedMachine struct {
+	sm     *StateMachine
+	start  State
+	decode func(data []byte) (interface{}, error) // of reclaimed cargos
+}
+
+// InstanceStatus is where an instance is in its lifecycle
+type InstanceStatus int
+
+const (
+	// InstanceRunning is an instance whose run hasn't finished
+	InstanceRunning InstanceStatus = iota
+	// InstanceSucceeded is an instance whose run ended without error
+	InstanceSucceeded
+	// InstanceFailed is an instance whose run failed
+	InstanceFailed
+	// InstanceCancelled is an instance whose run was aborted or cancelled
+	InstanceCancelled
+)
+
+func (s InstanceStatus) String() string {
+	switch s {
+	case InstanceRunning:
+		return "running"
+	case InstanceSucceeded:
+		return "succeeded"
+	case InstanceFailed:
+		return "failed"
+	case InstanceCancelled:
+		return "cancelled"
+	}
+	return fmt.Sprintf("InstanceStatus(%d)", int(s))
+}
+
+// Instance is a run of a machine started by a Manager
+type Instance struct {
+	ID      string
+	Machine string // the name the machine was registered with
+	Started time.Time
+
+	seq       int // order started
+	sm        *StateMachine
+	cancel    context.CancelFunc
+	cancelled bool // by Cancel rather than ctx
+	signals   *signals
+	done      chan struct{}
+	manager   *Manager
+	run       string // the run's key in the manager's store
+
+	reloaded *managedMachine // to move to, see Reload
+	handover *handover
+	lock     *sync.Mutex
+	status   InstanceStatus
+	result   *Result
+	steps    []Step
+	recorded int           // states entered when last recorded, retries aren't steps
+	changed  chan struct{} // closed and replaced on every step and once finished
+
+	stopRun          context.CancelFunc // stops the current run, not the instance
+	restartRequested bool               // by a failed sibling, see AllForOne
+	restarts         int
+	restartTimes     []time.Time // of the restarts counted by the policy
+
+	drainErr error // saving the instance stopped by Shutdown
+}
+
+// Step is a state an instance entered
+type Step struct {
+	From string    `json:"from,omitempty"` // empty for the start state
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// NewManager is a constructor for Manager
+func NewManager() *Manager {
+	return &Manager{
+		lock:      &sync.Mutex{},
+		machines:  make(map[string]*managedMachine),
+		instances: make(map[string]*Instance),
+
+		supervisors: make(map[string]SupervisorPolicy),
+		drain:       make(chan struct{}),
+	}
+}
+
+// Register adds a machine under the given name, its instances start in the
+// start state
+func (m *Manager) Register(name string, sm *StateMachine, startState State) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.machines[name]; ok {
+		return fmt.Errorf("%w %s", ErrDuplicateMachine, name)
+	}
+	if !sm.isRegistered(startState) {
+		return fmt.Errorf("%w %v", ErrUnknownStartState, startState)
+	}
+	m.machines[name] = &managedMachine{sm: sm, start: startState}
+	return nil
+}
+
+// Start runs the named machine with the cargo in its own goroutine. The
+// instance runs until it finishes, ctx is done or it's cancelled. It fails
+// with ErrShutdown once the manager is shutting down, see Shutdown.
+func (m *Manager) Start(ctx context.Context, machine string, cargo interface{}) (*Instance, error) {
+	m.lock.Lock()
+	if m.shutdown {
+		m.lock.Unlock()
+		return nil, ErrShutdown
+	}
+	mm, ok := m.machines[machine]
+	if !ok {
+		m.lock.Unlock()
+		return nil, fmt.Errorf("%w %s", ErrUnknownMachine, machine)
+	}
+	inst, ctx := m.newInstance(ctx, machine, mm)
+	m.lock.Unlock()
+
+	go inst.execute(ctx, cargo, mm.start)
+	return inst, nil
+}
+
+// newInstance adds an instance of the machine, the caller holds lock
+func (m *Manager) newInstance(ctx context.Context, machine string, mm *managedMachine) (*Instance, context.Context) {
+	m.started++
+	inst := &Instance{
+		ID:      fmt.Sprintf("%s-%d", machine, m.started),
+		Machine: machine,
+		Started: mm.sm.clock.Now(),
+		seq:     m.started,
+		sm:      mm.sm,
+		done:    make(chan struct{}),
+		lock:    &sync.Mutex{},
+		status:  InstanceRunning,
+		changed: make(chan struct{}),
+		manager: m,
+	}
+	ctx, inst.cancel = context.WithCancel(ctx)
+	ctx, inst.signals = withSignals(ctx)
+	ctx = context.WithValue(ctx, drainKey{}, &drainer{ch: m.drain, save: inst.saveDrained})
+	m.instances[inst.ID] = inst
+	return inst, ctx
+}
+
+// Get returns the instance with the given ID
+func (m *Manager) Get(id string) (*Instance, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	inst, ok := m.instances[id]
+	return inst, ok
+}
+
+// List returns the instances in the order started, finished ones included
+// until removed with Remove
+func (m *Manager) List() []*Instance {
+	m.lock.Lock()
+	instances := make([]*Instance, 0, len(m.instances))
+	for _, inst := range m.instances {
+		instances = append(instances, inst)
+	}
+	m.lock.Unlock()
+
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].seq < instances[j].seq
+	})
+	return instances
+}
+
+// Signal delivers a named signal to the instance, its states receive it with
+// ReceiveSignal
+func (m *Manager) Signal(id, name string, value interface{}) error {
+	inst, err := m.running(id)
+	if err != nil {
+		return err
+	}
+	inst.signals.deliver(name, value)
+	return nil
+}
+
+// Cancel aborts the instance's run, it finishes with InstanceCancelled
+func (m *Manager) Cancel(id string) error {
+	inst, err := m.running(id)
+	if err != nil {
+		return err
+	}
+	inst.lock.Lock()
+	inst.cancelled = true
+	inst.lock.Unlock()
+	inst.cancel()
+	return nil
+}
+
+// Remove forgets a finished instance
+func (m *Manager) Remove(id string) error {
+	if _, err := m.running(id); err == nil {
+		return fmt.Errorf("instance %s is still running", id)
+	} else if _, ok := m.Get(id); !ok {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.instances, id)
+	return nil
+}
+
+// running returns the instance if it's running
+func (m *Manager) running(id string) (*Instance, error) {
+	inst, ok := m.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownInstance, id)
+	}
+	if inst.Status() != InstanceRunning {
+		return nil, fmt.Errorf("%w: instance %s %v", ErrNotRunning, id, inst.Status())
+	}
+	return inst, nil
+}
+
+// exec records the step into the state, and saves a snapshot of it if the
+// manager has a store, then executes it
+func (i *Instance) exec(r *run, state State, cargo interface{}) (State, interface{}, error) {
+	if r.path.entered != i.recorded {
+		if err := i.handOver(r, state, cargo); err != nil {
+			return nil, nil, err
+		}
+		if err := i.save(r, state, cargo); err != nil {
+			return nil, nil, err
+		}
+		i.lock.Lock()
+		i.recorded = r.path.entered
+		step := Step{To: r.sm.StateName(state), At: i.sm.clock.Now()}
+		if n := len(i.steps); n > 0 {
+			step.From = i.steps[n-1].To
+		}
+		i.steps = append(i.steps, step)
+		close(i.changed)
+		i.changed = make(chan struct{})
+		i.lock.Unlock()
+	}
+	return i.sm.execState(r, state, cargo)
+}
+
+func (i *Instance) finish(result *Result, err error) {
+	var status InstanceStatus
+	switch {
+	case err == nil:
+		status = InstanceSucceeded
+	case errors.Is(err, ErrAborted) || errors.Is(err, context.Canceled):
+		status = InstanceCancelled
+	default:
+		status = InstanceFailed
+	}
+
+	i.lock.Lock()
+	cancelled := i.cancelled
+	i.lock.Unlock()
+	// runs interrupted otherwise than by Cancel are left to be reclaimed
+	if i.run != "" && (status != InstanceCancelled || cancelled) {
+		i.manager.deleteSnapshot(i.run)
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.result = result
+	i.status = status
+	i.cancel()
+	close(i.changed)
+	close(i.done)
+}
+
+// Status returns where the instance is in its lifecycle
+func (i *Instance) Status() InstanceStatus {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.status
+}
+
+// CurrentState returns the state the instance is executing, ok is false if it
+// isn't running
+func (i *Instance) CurrentState() (info StateInfo, ok bool) {
+	sm := i.machine()
+	sm.runsLock.RLock()
+	defer sm.runsLock.RUnlock()
+
+	for r := range sm.runs {
+		if r.signals == i.signals && r.state != nil {
+			return r.info(), true
+		}
+	}
+	return info, false
+}
+
+// Done is closed once the instance finished
+func (i *Instance) Done() <-chan struct{} {
+	return i.done
+}
+
+// Result returns the result of the instance's run, ok is false if it's still
+// running
+func (i *Instance) Result() (result *Result, ok bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.result, i.result != nil
+}
+
+// Wait waits for the instance to finish and returns its result and error, or
+// ctx.Err() if ctx is done first
+func (i *Instance) Wait(ctx context.Context) (*Result, error) {
+	select {
+	case <-i.done:
+		result, _ := i.Result()
+		return result, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// History returns the steps the instance took so far
+func (i *Instance) History() []Step {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return append([]Step{}, i.steps...)
+}
+
+// Watch returns a channel receiving the steps the instance takes, starting
+// with those already taken. The channel is closed once the instance finished
+// and all steps were received, or when ctx is done.
+func (i *Instance) Watch(ctx context.Context) <-chan Step {
+	ch := make(chan Step)
+	go func() {
+		defer close(ch)
+		sent := 0
+		for {
+			i.lock.Lock()
+			steps := i.steps[sent:]
+			changed := i.changed
+			finished := i.result != nil
+			i.lock.Unlock()
+
+			for _, step := range steps {
+				select {
+				case ch <- step:
+					sent++
+				case <-ctx.Done():
+					return
+				}
+			}
+			if len(steps) > 0 {
+				continue
+			}
+			if finished {
+				return
+			}
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
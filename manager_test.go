@@ -0,0 +1,158 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newApprovalMachine waits in "approval" for an "approve" signal, then ends
+func newApprovalMachine() (*StateMachine, State) {
+	m := NewStateMachine()
+	approval := &signalState{name: "approval", signal: "approve"}
+	submit := NewFuncState("submit", func(cargo interface{}) (State, interface{}, error) {
+		return approval, cargo, nil
+	})
+	m.AddStates(submit, approval)
+	return m, submit
+}
+
+// signalState waits for the signal and returns its value as the cargo
+type signalState struct {
+	name   string
+	signal string
+}
+
+func (s *signalState) Exec(cargo interface{}) (State, interface{}, error) {
+	panic("ExecContext should be called instead")
+}
+
+func (s *signalState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	value, err := ReceiveSignal(ctx, s.signal)
+	return nil, value, err
+}
+
+func (s *signalState) Name() string {
+	return s.name
+}
+
+func waitForState(t *testing.T, inst *Instance, name string) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if info, ok := inst.CurrentState(); ok && info.Name == name {
+			return
+		}
+	}
+	t.Fatalf("instance never got to %s", name)
+}
+
+func TestManager_StartSignalWait(t *testing.T) {
+	sm, start := newApprovalMachine()
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("approval", sm, start))
+
+	inst, err := mgr.Start(context.Background(), "approval", nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "approval-1", inst.ID)
+	assert.Equal(t, InstanceRunning, inst.Status())
+
+	// signals sent before the state waits are queued
+	assert.Nil(t, mgr.Signal(inst.ID, "approve", "alice"))
+	result, err := inst.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", result.Cargo)
+	assert.Equal(t, InstanceSucceeded, inst.Status())
+
+	got, ok := mgr.Get(inst.ID)
+	assert.True(t, ok)
+	assert.Equal(t, inst, got)
+
+	err = mgr.Signal(inst.ID, "approve", "bob")
+	assert.True(t, errors.Is(err, ErrNotRunning))
+}
+
+func TestManager_CurrentStateAndCancel(t *testing.T) {
+	sm, start := newApprovalMachine()
+	mgr := NewManager()
+	mgr.Register("approval", sm, start)
+
+	first, _ := mgr.Start(context.Background(), "approval", nil)
+	second, _ := mgr.Start(context.Background(), "approval", nil)
+	waitForState(t, first, "approval")
+	waitForState(t, second, "approval")
+
+	assert.Nil(t, mgr.Cancel(first.ID))
+	_, err := first.Wait(context.Background())
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, InstanceCancelled, first.Status())
+	_, ok := first.CurrentState()
+	assert.False(t, ok)
+
+	assert.Equal(t, []*Instance{first, second}, mgr.List())
+	assert.Nil(t, mgr.Remove(first.ID))
+	assert.NotNil(t, mgr.Remove(second.ID)) // still running
+	assert.Equal(t, []*Instance{second}, mgr.List())
+
+	assert.Nil(t, mgr.Signal(second.ID, "approve", 1))
+	second.Wait(context.Background())
+}
+
+func TestManager_Failed(t *testing.T) {
+	a := NewFuncState("a", func(cargo interface{}) (State, interface{}, error) {
+		return nil, nil, errors.New("boom")
+	})
+	sm := NewStateMachine()
+	sm.AddState(a)
+	mgr := NewManager()
+	mgr.Register("m", sm, a)
+
+	inst, _ := mgr.Start(context.Background(), "m", nil)
+	<-inst.Done()
+	assert.Equal(t, InstanceFailed, inst.Status())
+	result, ok := inst.Result()
+	assert.True(t, ok)
+	assert.EqualError(t, result.Err, "state a failed: boom (path: a)")
+}
+
+func TestManager_Errors(t *testing.T) {
+	sm, start := newApprovalMachine()
+	mgr := NewManager()
+
+	assert.True(t, errors.Is(mgr.Register("m", sm, &StateImpl{}), ErrUnknownStartState))
+	assert.Nil(t, mgr.Register("m", sm, start))
+	assert.True(t, errors.Is(mgr.Register("m", sm, start), ErrDuplicateMachine))
+
+	_, err := mgr.Start(context.Background(), "other", nil)
+	assert.True(t, errors.Is(err, ErrUnknownMachine))
+	assert.True(t, errors.Is(mgr.Cancel("m-7"), ErrUnknownInstance))
+	assert.True(t, errors.Is(mgr.Remove("m-7"), ErrUnknownInstance))
+}
+
+func TestReceiveSignal_NotInRun(t *testing.T) {
+	_, err := ReceiveSignal(context.Background(), "x")
+	assert.Equal(t, ErrNoRun, err)
+}
+
+func TestInstance_WatchHistory_StepsOncePerState(t *testing.T) {
+	calls := 0
+	sm, start := newOrderMachine(&calls) // charge is retried
+	mgr := NewManager()
+	mgr.Register("order", sm, start)
+
+	inst, _ := mgr.Start(context.Background(), "order", order{ID: "o1"})
+	steps := make([]Step, 0)
+	for step := range inst.Watch(context.Background()) {
+		steps = append(steps, step)
+	}
+
+	assert.Equal(t, inst.History(), steps)
+	if assert.Len(t, steps, 3) {
+		assert.Equal(t, Step{To: "validate", At: steps[0].At}, steps[0])
+		assert.Equal(t, "validate", steps[1].From)
+		assert.Equal(t, "ship", steps[2].To)
+	}
+}
@@ -0,0 +1,99 @@
+package gust
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown documents the definition: its description, a table of the states
+// with what they do and where they go next, the transitions and a Mermaid
+// diagram. Regenerating it from the machine keeps the docs in sync.
+func (d *Definition) Markdown() string {
+	var b strings.Builder
+
+	name := d.Name
+	if name == "" {
+		name = "State machine"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", name)
+	if d.Version != "" {
+		fmt.Fprintf(&b, "Version %s\n\n", d.Version)
+	}
+	if d.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", d.Description)
+	}
+
+	b.WriteString("## States\n\n")
+	b.WriteString("| State | Description | Next |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, s := range d.States {
+		state := markdownCell(s.Name)
+		if s.Name == d.Start {
+			state += " (start)"
+		}
+		next := d.Successors(s.Name)
+		for i := range next {
+			next[i] = markdownCell(next[i])
+		}
+		nextCell := strings.Join(next, ", ")
+		if nextCell == "" {
+			nextCell = "end"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", state, markdownCell(s.Description), nextCell)
+	}
+
+	if len(d.Transitions) > 0 {
+		b.WriteString("\n## Transitions\n\n")
+		labelled, described, tagged := false, false, false
+		for _, t := range d.Transitions {
+			labelled = labelled || t.Label != ""
+			described = described || t.Description != ""
+			tagged = tagged || len(t.Tags) > 0
+		}
+		header := []string{"From", "To"}
+		if labelled {
+			header = append(header, "On")
+		}
+		if described {
+			header = append(header, "Description")
+		}
+		if tagged {
+			header = append(header, "Tags")
+		}
+		markdownRow(&b, header)
+		separator := make([]string, len(header))
+		for i := range separator {
+			separator[i] = "---"
+		}
+		markdownRow(&b, separator)
+		for _, t := range d.Transitions {
+			row := []string{markdownCell(t.From), markdownCell(t.To)}
+			if labelled {
+				row = append(row, markdownCell(t.Label))
+			}
+			if described {
+				row = append(row, markdownCell(t.Description))
+			}
+			if tagged {
+				row = append(row, markdownCell(strings.Join(t.Tags, ", ")))
+			}
+			markdownRow(&b, row)
+		}
+	}
+
+	b.WriteString("\n## Diagram\n\n```mermaid\n")
+	b.WriteString(d.Mermaid())
+	b.WriteString("```\n")
+	return b.String()
+}
+
+// markdownRow writes a table row of the cells
+func markdownRow(b *strings.Builder, cells []string) {
+	fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
+}
+
+// markdownCell makes the text safe to put in a table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,85 @@
+package gust
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type DescribedState struct { // interface State, HaveName and HaveDescription
+	StateImpl
+	description string
+}
+
+func (s *DescribedState) Description() string {
+	return s.description
+}
+
+func TestMarkdown_FromMachine(t *testing.T) {
+	paid := &DescribedState{StateImpl: StateImpl{name: "paid"}, description: "The order is paid\nfor."}
+	created := &DescribedState{StateImpl: StateImpl{name: "created", nextState: paid}, description: "Waiting for payment | card only"}
+
+	m := NewStateMachine()
+	m.AddStates(created, paid)
+	m.AddTransition(created, paid)
+
+	d := m.Definition()
+	d.Name = "order"
+	d.Version = "2"
+	d.Start = "created"
+	d.Description = "Orders from checkout to payment."
+
+	assert.Equal(t, "# order\n"+
+		"\n"+
+		"Version 2\n"+
+		"\n"+
+		"Orders from checkout to payment.\n"+
+		"\n"+
+		"## States\n"+
+		"\n"+
+		"| State | Description | Next |\n"+
+		"| --- | --- | --- |\n"+
+		"| created (start) | Waiting for payment \\| card only | paid |\n"+
+		"| paid | The order is paid for. | end |\n"+
+		"\n"+
+		"## Transitions\n"+
+		"\n"+
+		"| From | To |\n"+
+		"| --- | --- |\n"+
+		"| created | paid |\n"+
+		"\n"+
+		"## Diagram\n"+
+		"\n"+
+		"```mermaid\n"+
+		"stateDiagram-v2\n"+
+		"    state \"created\" as s0\n"+
+		"    state \"paid\" as s1\n"+
+		"    [*] --> s0\n"+
+		"    s0 --> s1\n"+
+		"```\n", d.Markdown())
+}
+
+func TestMarkdown_NoTransitions_NoTransitionTable(t *testing.T) {
+	d := &Definition{States: []StateDefinition{{Name: "only"}}}
+
+	md := d.Markdown()
+	assert.Contains(t, md, "# State machine\n")
+	assert.Contains(t, md, "| only |  | end |\n")
+	assert.NotContains(t, md, "## Transitions")
+}
+
+func TestMarkdown_TransitionDescriptionsAndTags(t *testing.T) {
+	d := &Definition{
+		States: []StateDefinition{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		Transitions: []TransitionDefinition{
+			{From: "a", To: "b", Description: "Goes on", Tags: []string{"x", "y"}},
+			{From: "b", To: "c"},
+		},
+	}
+
+	md := d.Markdown()
+	assert.Contains(t, md, "| From | To | Description | Tags |\n"+
+		"| --- | --- | --- | --- |\n"+
+		"| a | b | Goes on | x, y |\n"+
+		"| b | c |  |  |\n")
+}
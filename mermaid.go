@@ -0,0 +1,40 @@
+package gust
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mermaid renders the definition as a Mermaid state diagram, which renders in
+// Markdown on GitHub and GitLab among others. Terminal states lead to the end
+// marker [*], labelled with their outcome if it isn't success.
+func (d *Definition) Mermaid() string {
+	var b strings.Builder
+
+	b.WriteString("stateDiagram-v2\n")
+	ids := make(map[string]string, len(d.States))
+	for i, s := range d.States {
+		ids[s.Name] = fmt.Sprintf("s%d", i)
+		fmt.Fprintf(&b, "    state %q as %s\n", s.Name, ids[s.Name])
+	}
+	if d.Start != "" {
+		fmt.Fprintf(&b, "    [*] --> %s\n", ids[d.Start])
+	}
+	for _, t := range d.Transitions {
+		if t.Label != "" {
+			fmt.Fprintf(&b, "    %s --> %s: %s\n", ids[t.From], ids[t.To], t.Label)
+		} else {
+			fmt.Fprintf(&b, "    %s --> %s\n", ids[t.From], ids[t.To])
+		}
+	}
+	for _, s := range d.States {
+		switch {
+		case !s.Terminal:
+		case s.Outcome == "" || s.Outcome == OutcomeSuccess.String():
+			fmt.Fprintf(&b, "    %s --> [*]\n", ids[s.Name])
+		default:
+			fmt.Fprintf(&b, "    %s --> [*]: %s\n", ids[s.Name], s.Outcome)
+		}
+	}
+	return b.String()
+}
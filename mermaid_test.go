@@ -0,0 +1,41 @@
+package gust
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefinition_Mermaid(t *testing.T) {
+	d := &Definition{
+		Start:       "new",
+		States:      []StateDefinition{{Name: "new"}, {Name: "in review"}},
+		Transitions: []TransitionDefinition{{From: "new", To: "in review"}},
+	}
+
+	assert.Equal(t, `stateDiagram-v2
+    state "new" as s0
+    state "in review" as s1
+    [*] --> s0
+    s0 --> s1
+`, d.Mermaid())
+}
+
+func TestDefinition_Mermaid_Terminals(t *testing.T) {
+	d := &Definition{
+		Start:       "new",
+		States:      []StateDefinition{{Name: "new"}, {Name: "paid", Terminal: true}, {Name: "lost", Terminal: true, Outcome: "failure"}},
+		Transitions: []TransitionDefinition{{From: "new", To: "paid"}, {From: "new", To: "lost"}},
+	}
+
+	assert.Equal(t, `stateDiagram-v2
+    state "new" as s0
+    state "paid" as s1
+    state "lost" as s2
+    [*] --> s0
+    s0 --> s1
+    s0 --> s2
+    s1 --> [*]
+    s2 --> [*]: failure
+`, d.Mermaid())
+}
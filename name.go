@@ -0,0 +1,33 @@
+package gust
+
+import "fmt"
+
+// StateName returns the name of the state as given to observers and in the
+// machine's Definition. States without a name, not implementing HaveName or
+// naming themselves "", are named after their type when added, e.g.
+// "*orders.Review", followed by #2, #3 and so on if several states of the
+// type are unnamed. The names are stable as long as states are added in the
+// same order. Unregistered states are named after their type.
+func (sm *StateMachine) StateName(state State) string {
+	if name := stateName(state); name != "" {
+		return name
+	}
+	if name, ok := sm.auto[keyOf(state)]; ok {
+		return name
+	}
+	return displayName(state)
+}
+
+// autoName makes up the name of an unnamed state being added, one not taken
+func (sm *StateMachine) autoName(state State) string {
+	base := displayName(state)
+	name := base
+	for n := 2; ; n++ {
+		if _, ok := sm.names[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s#%d", base, n)
+	}
+	sm.auto[keyOf(state)] = name
+	return name
+}
@@ -0,0 +1,37 @@
+package gust
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateName_UnnamedStates_NamedByTypeInOrder(t *testing.T) {
+	m := NewStateMachine()
+	named := &StateImpl{name: "named"}
+	first, second := &StateNoName{}, &StateNoName{}
+	emptyName := &StateImpl{}
+	m.AddStates(named, first, second, emptyName)
+
+	assert.Equal(t, "named", m.StateName(named))
+	assert.Equal(t, "*gust.StateNoName", m.StateName(first))
+	assert.Equal(t, "*gust.StateNoName#2", m.StateName(second))
+	assert.Equal(t, "*gust.StateImpl", m.StateName(emptyName))
+	assert.Equal(t, "*gust.StateNoName", m.StateName(&StateNoName{})) // unregistered
+
+	s, ok := m.StateByName("*gust.StateNoName#2")
+	assert.True(t, ok)
+	assert.Equal(t, second, s)
+}
+
+func TestStateName_Definition_UniqueNames(t *testing.T) {
+	m := NewStateMachine()
+	first, second := &StateNoName{}, &StateNoName{}
+	m.AddStates(first, second)
+	m.AddTransition(first, second)
+
+	d := m.Definition()
+	assert.Equal(t, []StateDefinition{{Name: "*gust.StateNoName"}, {Name: "*gust.StateNoName#2"}}, d.States)
+	assert.Equal(t, []TransitionDefinition{{From: "*gust.StateNoName", To: "*gust.StateNoName#2"}}, d.Transitions)
+	assert.Nil(t, d.Validate())
+}
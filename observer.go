@@ -0,0 +1,143 @@
+package gust
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ObserverPanicError is reported to the OnObserverError callback when an
+// observer panics during a notification
+type ObserverPanicError struct {
+	Observer Observer
+	Value    interface{} // the value given to panic
+	Stack    []byte      // stack trace of the panicking goroutine
+}
+
+func (e *ObserverPanicError) Error() string {
+	return fmt.Sprintf("observer %T panicked: %v", e.Observer, e.Value)
+}
+
+// OnObserverError sets a callback receiving the errors of misbehaving
+// observers. A panicking observer never crashes the machine, the panic is
+// recovered and reported here as an *ObserverPanicError. Failures releasing
+// run locks, see SetLocker, and keeping dead letters, see SetDeadLetterSink,
+// are reported here too.
+func (sm *StateMachine) OnObserverError(f func(err error)) {
+	sm.observerErrorHandler = f
+}
+
+// notify calls f for the observer, recovering any panic
+func (sm *StateMachine) notify(observer Observer, f func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			if sm.observerErrorHandler != nil {
+				sm.observerErrorHandler(&ObserverPanicError{Observer: observer, Value: v, Stack: debug.Stack()})
+			}
+		}
+	}()
+	f()
+}
+
+// RunObserver when implemented by an observer is also notified when runs
+// start and finish, with the ID of the run, see SetRunIDGenerator, and how
+// long it took
+type RunObserver interface {
+	RunStarted(runID string)
+	RunCompleted(runID string, duration time.Duration)
+	// RunFailed is notified with the error the run returns, aborted runs fail
+	// with an *AbortedError, and runs moved to a reloaded machine, going on
+	// there, with ErrReloaded
+	RunFailed(runID string, duration time.Duration, err error)
+}
+
+func (sm *StateMachine) notifyRunStarted(r *run) {
+	for _, observer := range sm.loadObserverSet().runs {
+		ro := observer.(RunObserver)
+		sm.notify(observer, func() {
+			if co, ok := observer.(CorrelationObserver); ok && r.correlation != "" {
+				co.RunCorrelated(r.id, r.correlation)
+			}
+			ro.RunStarted(r.id)
+		})
+	}
+}
+
+func (sm *StateMachine) notifyRunEnded(r *run, err error) {
+	observers := sm.loadObserverSet().runs
+	if len(observers) == 0 {
+		return
+	}
+	duration := sm.clock.Now().Sub(r.started)
+	for _, observer := range observers {
+		ro := observer.(RunObserver)
+		sm.notify(observer, func() {
+			if err != nil {
+				ro.RunFailed(r.id, duration, err)
+			} else {
+				ro.RunCompleted(r.id, duration)
+			}
+		})
+	}
+}
+
+// RunStatus is a serializable view of where a run is, for pushing live status
+// to UIs or caches
+type RunStatus struct {
+	RunID     string `json:"runId"`
+	Prior     string `json:"prior,omitempty"` // empty for the start state
+	State     string `json:"state"`
+	Label     string `json:"label,omitempty"` // of the transition taken, see AddLabeledTransition
+	Cargo     string `json:"cargo"`           // the cargo's String() if it's a fmt.Stringer, its type otherwise, redacted, see SetRedactor
+	CargoType string `json:"cargoType"`
+	Steps     int    `json:"steps"` // the number of states entered so far
+	// CorrelationID is the run's, see WithCorrelationID
+	CorrelationID string    `json:"correlationId,omitempty"`
+	Started       time.Time `json:"started"`
+	Entered       time.Time `json:"entered"`
+
+	// Description and Tags are those of the transition taken, see
+	// AddDescribedTransition
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// StatusObserver when implemented by an observer is also given the status of
+// the run each time it enters a state, after StateChanged
+type StatusObserver interface {
+	RunStatusChanged(status RunStatus)
+}
+
+func (sm *StateMachine) notifyStatus(r *run, prior State, cargo interface{}) {
+	observers := sm.loadObserverSet().statuses
+	if len(observers) == 0 {
+		return
+	}
+
+	status := RunStatus{RunID: r.id, Cargo: cargoSummary(sm.Redact(cargo)), CargoType: fmt.Sprintf("%T", cargo), Started: r.started, CorrelationID: r.correlation}
+	sm.runsLock.RLock()
+	status.State = sm.StateName(r.state)
+	status.Steps = r.path.entered
+	status.Entered = r.entered
+	sm.runsLock.RUnlock()
+	if prior != nil {
+		status.Prior = sm.StateName(prior)
+		t, _ := sm.TransitionBetween(prior, r.state)
+		status.Label, status.Description, status.Tags = t.Label, t.Description, t.Tags
+	}
+
+	for _, observer := range observers {
+		so := observer.(StatusObserver)
+		sm.notify(observer, func() {
+			so.RunStatusChanged(status)
+		})
+	}
+}
+
+// cargoSummary describes the cargo without serializing it
+func cargoSummary(cargo interface{}) string {
+	if s, ok := cargo.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", cargo)
+}
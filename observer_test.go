@@ -0,0 +1,193 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type PanickingObserver struct{}
+
+func (o *PanickingObserver) StateChanged(priorState string, nextState string) {
+	panic("boom")
+}
+
+func TestObserverPanic_OtherObserversStillNotified(t *testing.T) {
+	b := &StateImpl{
+		name: "stateB",
+	}
+	a := &StateImpl{
+		nextState: b,
+		name:      "stateA",
+	}
+
+	o := NewObserverImpl()
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.RegisterObservers(&PanickingObserver{}, o)
+
+	err := m.Run(nil, a)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, b.run)
+	assert.Len(t, o.states, 2)
+}
+
+func TestObserverPanic_ReportedToErrorCallback(t *testing.T) {
+	a := &StateImpl{
+		name: "stateA",
+	}
+
+	panicker := &PanickingObserver{}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.RegisterObservers(panicker)
+
+	errs := make([]error, 0)
+	m.OnObserverError(func(err error) {
+		errs = append(errs, err)
+	})
+
+	assert.Nil(t, m.Run(nil, a))
+	if !assert.Len(t, errs, 1) {
+		return
+	}
+
+	var panicErr *ObserverPanicError
+	if assert.True(t, errors.As(errs[0], &panicErr)) {
+		assert.Equal(t, panicker, panicErr.Observer)
+		assert.Equal(t, "boom", panicErr.Value)
+		assert.NotEmpty(t, panicErr.Stack)
+		assert.Equal(t, "observer *gust.PanickingObserver panicked: boom", panicErr.Error())
+	}
+}
+
+// runObserver records the run notifications
+type runObserver struct {
+	ObserverImpl
+	events []string
+	err    error
+}
+
+func (o *runObserver) RunStarted(runID string) {
+	o.events = append(o.events, "started "+runID)
+}
+
+func (o *runObserver) RunCompleted(runID string, duration time.Duration) {
+	o.events = append(o.events, "completed "+runID)
+}
+
+func (o *runObserver) RunFailed(runID string, duration time.Duration, err error) {
+	o.events = append(o.events, "failed "+runID)
+	o.err = err
+}
+
+func TestRunObserver_StartedCompletedFailed(t *testing.T) {
+	m := NewStateMachine()
+	failing := &StateImpl{name: "failing", err: errors.New("boom")}
+	ok := &StateImpl{name: "ok"}
+	m.AddStates(ok, failing)
+	o := &runObserver{ObserverImpl: *NewObserverImpl()}
+	m.RegisterObservers(o)
+
+	m.Run(nil, ok)
+	m.Run(nil, failing)
+
+	assert.Equal(t, []string{"started 1", "completed 1", "started 2", "failed 2"}, o.events)
+	assert.True(t, errors.As(o.err, new(*RunError)))
+	assert.Len(t, o.states, 2) // still notified of the states
+}
+
+type statusObserver struct {
+	ObserverImpl
+	statuses []RunStatus
+}
+
+func (o *statusObserver) RunStatusChanged(status RunStatus) {
+	o.statuses = append(o.statuses, status)
+}
+
+type summarized struct{}
+
+func (summarized) String() string { return "order o1" }
+
+func TestStatusObserver_StatusOnEveryState(t *testing.T) {
+	m := NewStateMachine()
+	b := &StateImpl{name: "b", cargo: 5}
+	a := &StateImpl{name: "a", nextState: b, cargo: summarized{}}
+	m.AddStates(a, b)
+	o := &statusObserver{ObserverImpl: *NewObserverImpl()}
+	m.RegisterObservers(o)
+
+	assert.Nil(t, m.Run(nil, a))
+	if assert.Len(t, o.statuses, 2) {
+		assert.Equal(t, "1", o.statuses[0].RunID)
+		assert.Equal(t, "a", o.statuses[0].State)
+		assert.Equal(t, "<nil>", o.statuses[0].Cargo)
+		assert.Equal(t, 1, o.statuses[0].Steps)
+		assert.Equal(t, "a", o.statuses[1].Prior)
+		assert.Equal(t, "b", o.statuses[1].State)
+		assert.Equal(t, "order o1", o.statuses[1].Cargo)
+		assert.Equal(t, 2, o.statuses[1].Steps)
+	}
+}
+
+func TestStatusObserver_TransitionDescriptionAndTags(t *testing.T) {
+	m := NewStateMachine()
+	b := &StateImpl{name: "b"}
+	a := &StateImpl{name: "a", nextState: b}
+	m.AddStates(a, b)
+	m.AddDescribedTransition(a, b, "next", "Moves on to b", "fast")
+	o := &statusObserver{ObserverImpl: *NewObserverImpl()}
+	m.RegisterObservers(o)
+
+	assert.Nil(t, m.Run(nil, a))
+	if assert.Len(t, o.statuses, 2) {
+		assert.Equal(t, "", o.statuses[0].Description)
+		assert.Equal(t, "next", o.statuses[1].Label)
+		assert.Equal(t, "Moves on to b", o.statuses[1].Description)
+		assert.Equal(t, []string{"fast"}, o.statuses[1].Tags)
+	}
+}
+
+// loopState goes back to itself n times
+type loopState struct {
+	n int
+}
+
+func (s *loopState) Exec(cargo interface{}) (State, interface{}, error) {
+	if s.n--; s.n <= 0 {
+		return nil, cargo, nil
+	}
+	return s, cargo, nil
+}
+
+func (s *loopState) Name() string {
+	return "loop"
+}
+
+func TestNotify_NoObservers_NoAllocations(t *testing.T) {
+	m := NewStateMachine()
+	s := &loopState{}
+	m.AddState(s)
+	r := m.startRun(context.Background())
+	defer m.endRun(r)
+
+	assert.Equal(t, 0.0, testing.AllocsPerRun(100, func() {
+		m.NotifyState(s, s)
+		m.notifyStatus(r, s, nil)
+		m.notifyRunEnded(r, nil)
+	}))
+
+	// transitions only allocate to grow the run's path
+	short := testing.AllocsPerRun(10, func() { s.n = 1; m.Run(nil, s) })
+	long := testing.AllocsPerRun(10, func() { s.n = 1001; m.Run(nil, s) })
+	assert.True(t, long-short < 20, "%v allocations for 1000 transitions", long-short)
+}
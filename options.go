@@ -0,0 +1,90 @@
+package gust
+
+import "time"
+
+// Option configures a StateMachine in NewStateMachine
+type Option func(sm *StateMachine)
+
+// WithName sets the machine's Name
+func WithName(name string) Option {
+	return func(sm *StateMachine) {
+		sm.Name = name
+	}
+}
+
+// WithProfilerLabels labels state execution for pprof, like SetProfilerLabels(true)
+func WithProfilerLabels() Option {
+	return func(sm *StateMachine) {
+		sm.SetProfilerLabels(true)
+	}
+}
+
+// WithHistoryLimit bounds the states a run remembers, like SetHistoryLimit
+func WithHistoryLimit(n int) Option {
+	return func(sm *StateMachine) {
+		sm.SetHistoryLimit(n)
+	}
+}
+
+// WithObservers registers observers, like RegisterObservers
+func WithObservers(os ...Observer) Option {
+	return func(sm *StateMachine) {
+		sm.RegisterObservers(os...)
+	}
+}
+
+// WithClock replaces the machine's source of time
+func WithClock(clock Clock) Option {
+	return func(sm *StateMachine) {
+		sm.clock = clock
+	}
+}
+
+// WithMaxTransitions limits the number of transitions a run may take, see MaxTransitions
+func WithMaxTransitions(n int) Option {
+	return func(sm *StateMachine) {
+		sm.MaxTransitions = n
+	}
+}
+
+// WithWatchdog enables the stuck state watchdog, like SetWatchdog
+func WithWatchdog(threshold time.Duration) Option {
+	return func(sm *StateMachine) {
+		sm.SetWatchdog(threshold)
+	}
+}
+
+// WithRateLimit throttles the machine's transitions, like SetRateLimit
+func WithRateLimit(limit RateLimit) Option {
+	return func(sm *StateMachine) {
+		sm.SetRateLimit(limit)
+	}
+}
+
+// WithCoverage tracks the transitions taken by runs, like TrackCoverage(true)
+func WithCoverage() Option {
+	return func(sm *StateMachine) {
+		sm.TrackCoverage(true)
+	}
+}
+
+// WithLocker locks persisted runs, like SetLocker
+func WithLocker(locker Locker) Option {
+	return func(sm *StateMachine) {
+		sm.SetLocker(locker)
+	}
+}
+
+// WithStore saves the machine's runs in the store, like SetStore
+func WithStore(store SnapshotStore) Option {
+	return func(sm *StateMachine) {
+		sm.SetStore(store)
+	}
+}
+
+// WithCodec replaces the codec used for snapshots, like SetCodec
+func WithCodec(codec Codec) Option {
+	return func(sm *StateMachine) {
+		sm.SetCodec(codec)
+	}
+}
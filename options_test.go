@@ -0,0 +1,114 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedClock struct {
+	realClock
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestNewStateMachine_NoOptions_Defaults(t *testing.T) {
+	m := NewStateMachine()
+
+	assert.Equal(t, 0, m.MaxTransitions)
+	assert.Len(t, m.loadObservers(), 0)
+	assert.Equal(t, realClock{}, m.clock)
+}
+
+func TestNewStateMachine_WithOptions_Applied(t *testing.T) {
+	o1 := NewObserverImpl()
+	o2 := NewObserverImpl()
+	clock := fixedClock{now: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	m := NewStateMachine(
+		WithObservers(o1, o2),
+		WithClock(clock),
+		WithMaxTransitions(5),
+		WithWatchdog(time.Minute),
+		WithRateLimit(RateLimit{Rate: 1000, Burst: 10}),
+	)
+
+	assert.Equal(t, []Observer{o1, o2}, m.loadObservers())
+	assert.Equal(t, 5, m.MaxTransitions)
+	assert.Equal(t, time.Minute, m.watchdogThreshold)
+	assert.NotNil(t, m.rateLimit)
+	assert.Equal(t, clock.now, m.clock.Now())
+}
+
+func TestNewStateMachine_WithMaxTransitions_Enforced(t *testing.T) {
+	a := &StateImpl{}
+	a.nextState = a
+
+	m := NewStateMachine(WithMaxTransitions(3))
+	m.AddState(a)
+
+	err := m.Run(nil, a)
+	assert.True(t, errors.Is(err, ErrMaxTransitions))
+}
+
+func TestNewStateMachine_WithClock_UsedForStateEntry(t *testing.T) {
+	clock := fixedClock{now: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)}
+	a := &BlockingState{
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	m := NewStateMachine(WithClock(clock))
+	m.AddState(a)
+
+	done := make(chan error)
+	go func() {
+		done <- m.Run(nil, a)
+	}()
+
+	<-a.entered
+	info, ok := m.CurrentState()
+	assert.True(t, ok)
+	assert.Equal(t, clock.now, info.Entered)
+
+	close(a.release)
+	assert.Nil(t, <-done)
+}
+
+func TestNewStateMachine_WithStore_SavesRuns(t *testing.T) {
+	store := newMemoryStore()
+	m := NewStateMachine(WithName("order"), WithStore(store))
+	var stored []StoredSnapshot
+	a := NewFuncState("a", func(cargo interface{}) (State, interface{}, error) {
+		stored, _ = store.List(context.Background())
+		return nil, cargo, nil
+	})
+	m.AddState(a)
+
+	assert.Nil(t, m.Run("cargo", a))
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, "order", stored[0].Machine)
+		assert.Equal(t, "a", stored[0].Snapshot.State)
+	}
+	assert.Equal(t, 0, store.len()) // deleted once finished
+
+	// aborted runs are left to be resumed
+	b := &ContextStateImpl{name: "b", entered: make(chan struct{})}
+	m.AddState(b)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-b.entered
+		cancel()
+	}()
+	assert.True(t, errors.Is(m.RunContext(ctx, "cargo", b), ErrAborted))
+	stored, _ = store.List(context.Background())
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, "b", stored[0].Snapshot.State)
+	}
+}
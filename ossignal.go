@@ -0,0 +1,97 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifySignals returns a channel receiving sigs, SIGINT and SIGTERM if none,
+// and a function to stop receiving them
+func notifySignals(sigs []os.Signal) (<-chan os.Signal, func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	return ch, func() { signal.Stop(ch) }
+}
+
+// AbortOnSignal aborts the machine's runs when the process receives one of
+// sigs, SIGINT and SIGTERM if none are given, until ctx is done. The runs
+// return an *AbortedError whose reason wraps ErrSignalled, and whose
+// ResumeToken can be persisted with Checkpoint before the process exits.
+func (sm *StateMachine) AbortOnSignal(ctx context.Context, sigs ...os.Signal) {
+	ch, stop := notifySignals(sigs)
+	go func() {
+		defer stop()
+		for {
+			select {
+			case sig := <-ch:
+				sm.Abort(fmt.Errorf("%w %v", ErrSignalled, sig))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Pause cancels the running instances and waits for them to stop, then calls
+// save with a checkpoint of each, taken with Checkpoint, so they can be resumed
+// with Resume once the process restarts. Instances that finished meanwhile
+// aren't saved. It returns the first error saving or ctx.Err() if ctx is done
+// before all instances stopped.
+func (m *Manager) Pause(ctx context.Context, save func(inst *Instance, snap *Snapshot) error) error {
+	paused := make([]*Instance, 0)
+	for _, inst := range m.List() {
+		if inst.Status() == InstanceRunning {
+			inst.cancel()
+			paused = append(paused, inst)
+		}
+	}
+
+	var first error
+	for _, inst := range paused {
+		result, err := inst.Wait(ctx)
+		if result == nil {
+			return err
+		}
+		token, ok := ResumeTokenOf(err)
+		if !ok {
+			continue
+		}
+		snap, err := inst.sm.Checkpoint(token)
+		if err == nil {
+			err = save(inst, snap)
+		}
+		if err != nil && first == nil {
+			first = fmt.Errorf("saving instance %s: %w", inst.ID, err)
+		}
+	}
+	return first
+}
+
+// PauseOnSignal waits for the process to receive one of sigs, SIGINT and
+// SIGTERM if none are given, then pauses the instances with Pause. It returns
+// nil without pausing if ctx is done first. It's meant to be run in its own
+// goroutine, the process exiting once it returns:
+//
+//	go func() {
+//		if err := mgr.PauseOnSignal(ctx, save); err != nil {
+//			log.Print(err)
+//		}
+//		os.Exit(0)
+//	}()
+func (m *Manager) PauseOnSignal(ctx context.Context, save func(inst *Instance, snap *Snapshot) error, sigs ...os.Signal) error {
+	ch, stop := notifySignals(sigs)
+	defer stop()
+
+	select {
+	case <-ch:
+		return m.Pause(ctx, save)
+	case <-ctx.Done():
+		return nil
+	}
+}
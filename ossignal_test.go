@@ -0,0 +1,78 @@
+//go:build !windows
+// +build !windows
+
+package gust
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateMachine_AbortOnSignal_AbortedWithToken(t *testing.T) {
+	sm, start := newApprovalMachine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sm.AbortOnSignal(ctx, syscall.SIGUSR1)
+
+	done := make(chan error)
+	go func() {
+		_, err := sm.Execute(context.Background(), "order", start)
+		done <- err
+	}()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if info, ok := sm.CurrentState(); ok && info.Name == "approval" {
+			break
+		}
+	}
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+
+	err := <-done
+	assert.True(t, errors.Is(err, ErrSignalled))
+	token, ok := ResumeTokenOf(err)
+	if assert.True(t, ok) {
+		assert.Equal(t, "approval", token.State())
+	}
+}
+
+func TestManager_PauseOnSignal_SavesCheckpoints(t *testing.T) {
+	sm, start := newApprovalMachine()
+	mgr := NewManager()
+	mgr.Register("approval", sm, start)
+	inst, _ := mgr.Start(context.Background(), "approval", "order")
+	waitForState(t, inst, "approval")
+
+	saved := make(map[string]*Snapshot)
+	done := make(chan error)
+	go func() {
+		done <- mgr.PauseOnSignal(context.Background(), func(inst *Instance, snap *Snapshot) error {
+			saved[inst.ID] = snap
+			return nil
+		}, syscall.SIGUSR2)
+	}()
+	time.Sleep(10 * time.Millisecond) // for the signal handler to be installed
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+
+	assert.Nil(t, <-done)
+	assert.Equal(t, InstanceCancelled, inst.Status())
+	snap := saved[inst.ID]
+	if !assert.NotNil(t, snap) {
+		return
+	}
+	assert.Equal(t, "approval", snap.State)
+	assert.Equal(t, []string{"submit"}, snap.Path)
+
+	// resuming the checkpoint waits for the approval again
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	result, err := sm.Resume(ctx, snap, nil)
+	assert.True(t, errors.Is(err, ErrAborted))
+	assert.Equal(t, []string{"approval"}, result.Path)
+}
@@ -0,0 +1,107 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ParallelState forks the run into branches, each a run of the same machine
+// from its branch start state given the state's cargo, and joins them before
+// going to Next. The first branch to fail cancels the others, like an errgroup,
+// and the state fails with a *ParallelError.
+type ParallelState struct {
+	name     string
+	branches []State
+
+	Next State
+	// Merge if not nil returns the cargo passed on to Next given the branches'
+	// final cargos in branch order, otherwise Next is given the []interface{}
+	// of the final cargos
+	Merge func(cargo interface{}, results []interface{}) (interface{}, error)
+}
+
+// NewParallel returns a state running the branches concurrently, the branch
+// start states must be registered with the machine
+func NewParallel(name string, branches ...State) *ParallelState {
+	return &ParallelState{name: name, branches: branches}
+}
+
+// ParallelError is returned by a ParallelState whose branches failed, with
+// one error per failed branch. errors.Is and errors.As look into each of them.
+// Branches cancelled because a sibling failed aren't part of it.
+type ParallelError struct {
+	joinedError
+}
+
+// Exec has no run to fork, the branches run only within a machine
+func (s *ParallelState) Exec(cargo interface{}) (State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+// ExecContext runs the branches and waits for all of them to finish
+func (s *ParallelState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	r, ok := ctx.Value(runKey{}).(*run)
+	if !ok {
+		return nil, cargo, ErrNoRun
+	}
+
+	groupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]interface{}, len(s.branches))
+	errs := make([]error, len(s.branches))
+	failed := false
+	lock := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for i, branch := range s.branches {
+		wg.Add(1)
+		go func(i int, branch State) {
+			defer wg.Done()
+			result, err := r.sm.Execute(groupCtx, cargo, branch)
+			results[i] = result.Cargo
+			if err == nil {
+				return
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			// a branch aborted because a sibling failed didn't fail itself
+			if errors.Is(err, ErrAborted) && failed && ctx.Err() == nil {
+				return
+			}
+			errs[i] = fmt.Errorf("branch %s: %w", r.sm.StateName(branch), err)
+			failed = true
+			cancel()
+		}(i, branch)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, cargo, err
+	}
+	var joined []error
+	for _, err := range errs {
+		if err != nil {
+			joined = append(joined, err)
+		}
+	}
+	if len(joined) > 0 {
+		return nil, cargo, &ParallelError{joinedError{joined}}
+	}
+
+	if s.Merge == nil {
+		return s.Next, results, nil
+	}
+	next, err := s.Merge(cargo, results)
+	if err != nil {
+		return nil, cargo, err
+	}
+	return s.Next, next, nil
+}
+
+// Name is the name given to the constructor
+func (s *ParallelState) Name() string {
+	return s.name
+}
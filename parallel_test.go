@@ -0,0 +1,105 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallel_JoinsBranchResults(t *testing.T) {
+	m := NewStateMachine()
+	double := NewFuncState("double", func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo.(int) * 2, nil
+	})
+	square := NewFuncState("square", func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo.(int) * cargo.(int), nil
+	})
+	sum := NewFuncState("sum", func(cargo interface{}) (State, interface{}, error) {
+		total := 0
+		for _, v := range cargo.([]interface{}) {
+			total += v.(int)
+		}
+		return nil, total, nil
+	})
+	fork := NewParallel("fork", double, square)
+	fork.Next = sum
+	m.AddState(double)
+	m.AddState(square)
+	m.AddState(sum)
+	m.AddState(fork)
+
+	result, err := m.Execute(context.Background(), 3, fork)
+	assert.Nil(t, err)
+	assert.Equal(t, 15, result.Cargo)
+}
+
+func TestParallel_Merge(t *testing.T) {
+	m := NewStateMachine()
+	one := NewFuncState("one", func(cargo interface{}) (State, interface{}, error) {
+		return nil, "a", nil
+	})
+	fork := NewParallel("fork", one, one)
+	fork.Merge = func(cargo interface{}, results []interface{}) (interface{}, error) {
+		return cargo.(string) + results[0].(string) + results[1].(string), nil
+	}
+	m.AddState(one)
+	m.AddState(fork)
+
+	result, err := m.Execute(context.Background(), "x", fork)
+	assert.Nil(t, err)
+	assert.Equal(t, "xaa", result.Cargo)
+}
+
+func TestParallel_FirstFailureCancelsSiblings(t *testing.T) {
+	errBoom := errors.New("boom")
+	m := NewStateMachine()
+	fail := NewFuncState("fail", func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo, errBoom
+	})
+	block := &ctxFuncState{name: "block", f: func(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+		select {
+		case <-ctx.Done():
+			return nil, cargo, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil, cargo, nil
+		}
+	}}
+	fork := NewParallel("fork", block, fail)
+	m.AddState(fail)
+	m.AddState(block)
+	m.AddState(fork)
+
+	began := time.Now()
+	_, err := m.Execute(context.Background(), nil, fork)
+	assert.True(t, time.Since(began) < time.Second, "sibling wasn't cancelled")
+	var parallelErr *ParallelError
+	if assert.True(t, errors.As(err, &parallelErr)) {
+		assert.Len(t, parallelErr.Errors, 1)
+	}
+	assert.True(t, errors.Is(err, errBoom))
+	assert.False(t, errors.Is(err, ErrAborted))
+}
+
+func TestParallel_JoinsFailures(t *testing.T) {
+	m := NewStateMachine()
+	a, b := &StateImpl{name: "a"}, &StateImpl{name: "b"}
+	fork := NewParallel("fork", a, b)
+	m.AddState(fork)
+
+	_, err := m.Execute(context.Background(), nil, fork)
+	var parallelErr *ParallelError
+	if assert.True(t, errors.As(err, &parallelErr)) {
+		assert.Len(t, parallelErr.Errors, 2)
+		assert.Contains(t, err.Error(), "branch a: ")
+		assert.Contains(t, err.Error(), "branch b: ")
+	}
+	assert.True(t, errors.Is(err, ErrUnknownStartState))
+}
+
+func TestParallel_OutsideRun(t *testing.T) {
+	_, _, err := NewParallel("fork").Exec(nil)
+	assert.Equal(t, ErrNoRun, err)
+}
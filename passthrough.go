@@ -0,0 +1,37 @@
+package gust
+
+import "fmt"
+
+// PassThroughState is a decision node: entered, it hands its cargo on
+// untouched and the machine takes the guarded transition of highest priority
+// whose guard holds, see AddGuardedTransition and SetTransitionPriority. It's
+// also a place for branches to join before going on. The run fails with
+// ErrAmbiguousTransition if several guards of the highest priority hold, and
+// with ErrDeadEnd if none does.
+type PassThroughState struct {
+	name string
+}
+
+// NewPassThrough returns a pass-through state, route it with
+// AddGuardedTransition
+func NewPassThrough(name string) *PassThroughState {
+	return &PassThroughState{name: name}
+}
+
+// Name returns the state's name
+func (s *PassThroughState) Name() string {
+	return s.name
+}
+
+// Exec passes the cargo on, the next state is resolved from the guards
+func (s *PassThroughState) Exec(cargo interface{}) (State, interface{}, error) {
+	return nil, cargo, nil
+}
+
+// checkPassThrough tells why a pass-through state can't end the run
+func checkPassThrough(state State, name string) error {
+	if _, ok := state.(*PassThroughState); ok {
+		return fmt.Errorf("%w at %s: no guard holds", ErrDeadEnd, name)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassThrough_RoutesByGuards(t *testing.T) {
+	m := NewStateMachine()
+	decide := NewPassThrough("decide")
+	small, large := &StateImpl{name: "small"}, &StateImpl{name: "large"}
+	m.AddStates(decide, small, large)
+	m.AddGuardedTransition(decide, small, "", func(cargo interface{}) bool { return cargo.(int) < 100 })
+	m.AddGuardedTransition(decide, large, "", func(cargo interface{}) bool { return cargo.(int) >= 100 })
+
+	result, err := m.Execute(context.Background(), 500, decide)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"decide", "large"}, result.Path)
+	assert.Equal(t, 500, large.cargoReceived)
+}
+
+func TestPassThrough_JoinsBranches(t *testing.T) {
+	m := NewStateMachine()
+	join := NewPassThrough("join")
+	done := &StateImpl{name: "done"}
+	a := &StateImpl{name: "a", nextState: join, cargo: 1}
+	b := &StateImpl{name: "b", nextState: join, cargo: 2}
+	m.AddStates(a, b, join, done)
+	m.AddTransition(a, join)
+	m.AddTransition(b, join)
+	m.AddGuardedTransition(join, done, "", func(cargo interface{}) bool { return true })
+
+	result, err := m.Execute(context.Background(), nil, b)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b", "join", "done"}, result.Path)
+	assert.Equal(t, 2, done.cargoReceived)
+}
+
+func TestPassThrough_NoGuardHolds_DeadEnd(t *testing.T) {
+	m := NewStateMachine()
+	decide := NewPassThrough("decide")
+	next := &StateImpl{name: "next"}
+	m.AddStates(decide, next)
+	m.AddGuardedTransition(decide, next, "", func(cargo interface{}) bool { return false })
+
+	_, err := m.Execute(context.Background(), nil, decide)
+	assert.True(t, errors.Is(err, ErrDeadEnd))
+	assert.Contains(t, err.Error(), "no guard holds")
+}
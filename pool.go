@@ -0,0 +1,204 @@
+package gust
+
+import (
+	"context"
+	"sync"
+)
+
+// Job is a run queued on a Pool
+type Job struct {
+	Machine    *StateMachine
+	Cargo      interface{}
+	StartState State
+
+	// Context of the run, context.Background() if nil
+	Context context.Context
+	// Done if not nil is called with the outcome once the run finished, from
+	// the worker that ran it, or from the goroutine calling Shutdown for jobs
+	// it didn't start
+	Done func(result *Result, err error)
+}
+
+// Pool executes queued jobs on a fixed number of workers, so processing many
+// runs doesn't spawn a goroutine each. Jobs start in the order submitted,
+// except that a job whose machine is at its limit set with SetMachineLimit
+// lets jobs of other machines go first.
+type Pool struct {
+	lock    *sync.Mutex
+	cond    *sync.Cond
+	queue   []*Job
+	running map[*StateMachine]int
+	limits  map[*StateMachine]int
+	closed  bool
+	workers *sync.WaitGroup
+
+	draining bool
+	drain    chan struct{}               // closed by Shutdown
+	cancels  map[*Job]context.CancelFunc // of the jobs running
+}
+
+// NewPool starts a pool with the given number of workers, at least one
+func NewPool(workers int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{
+		lock:    &sync.Mutex{},
+		queue:   make([]*Job, 0),
+		running: make(map[*StateMachine]int),
+		limits:  make(map[*StateMachine]int),
+		workers: &sync.WaitGroup{},
+		drain:   make(chan struct{}),
+		cancels: make(map[*Job]context.CancelFunc),
+	}
+	p.cond = sync.NewCond(p.lock)
+
+	p.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// SetMachineLimit caps the number of the machine's runs executing at once, 0
+// removes the cap
+func (p *Pool) SetMachineLimit(sm *StateMachine, n int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if n > 0 {
+		p.limits[sm] = n
+	} else {
+		delete(p.limits, sm)
+	}
+	p.cond.Broadcast()
+}
+
+// Submit queues the job, it fails with ErrPoolClosed once Close was called
+func (p *Pool) Submit(job Job) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	p.queue = append(p.queue, &job)
+	p.cond.Signal()
+	return nil
+}
+
+// Queued returns the number of jobs waiting for a worker
+func (p *Pool) Queued() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.queue)
+}
+
+// Close stops accepting jobs and waits for the queued ones to finish
+func (p *Pool) Close() {
+	p.lock.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.lock.Unlock()
+
+	p.workers.Wait()
+}
+
+// Shutdown stops accepting jobs and stops the running ones before the next
+// state they enter, so the process can exit without losing them. Their Done
+// is given an *AbortedError whose reason is ErrShutdown, its ResumeToken can
+// be persisted with Checkpoint and resumed later. Queued jobs aren't started,
+// their Done is called before Shutdown returns with the same error, resuming
+// them in their start state. If ctx is done before the running jobs stopped
+// they're cancelled and ctx.Err() is returned without waiting for them, their
+// Done may then be called after Shutdown returned, with the context's error.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.lock.Lock()
+	p.closed = true
+	if !p.draining {
+		p.draining = true
+		close(p.drain)
+	}
+	queued := p.queue
+	p.queue = make([]*Job, 0)
+	p.cond.Broadcast()
+	p.lock.Unlock()
+
+	for _, job := range queued {
+		if job.Done == nil {
+			continue
+		}
+		token := &ResumeToken{state: job.StartState, name: job.Machine.StateName(job.StartState), cargo: job.Cargo, path: []string{}}
+		if job.Context != nil {
+			token.correlation, _ = CorrelationID(job.Context)
+		}
+		err := &AbortedError{Reason: ErrShutdown, State: token.name, Token: token}
+		job.Done(&Result{Cargo: job.Cargo, Err: err}, err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		p.lock.Lock()
+		for _, cancel := range p.cancels {
+			cancel()
+		}
+		p.lock.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for {
+		job, ok := p.next()
+		if !ok {
+			return
+		}
+
+		ctx := job.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, cancel := context.WithCancel(context.WithValue(ctx, drainKey{}, &drainer{ch: p.drain}))
+		p.lock.Lock()
+		p.cancels[job] = cancel
+		p.lock.Unlock()
+
+		result, err := job.Machine.Execute(ctx, job.Cargo, job.StartState)
+		cancel()
+		if job.Done != nil {
+			job.Done(result, err)
+		}
+
+		p.lock.Lock()
+		delete(p.cancels, job)
+		p.running[job.Machine]--
+		p.cond.Broadcast()
+		p.lock.Unlock()
+	}
+}
+
+// next waits for a job that can run, ok is false once closed and drained
+func (p *Pool) next() (job *Job, ok bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for {
+		for i, j := range p.queue {
+			if limit := p.limits[j.Machine]; limit > 0 && p.running[j.Machine] >= limit {
+				continue
+			}
+			p.queue = append(p.queue[:i], p.queue[i+1:]...)
+			p.running[j.Machine]++
+			return j, true
+		}
+		if p.closed && len(p.queue) == 0 {
+			return nil, false
+		}
+		p.cond.Wait()
+	}
+}
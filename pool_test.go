@@ -0,0 +1,126 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newCountingMachine has a single state recording how many of its runs
+// execute at once
+func newCountingMachine(current, max *int32) (*StateMachine, State) {
+	s := NewFuncState("work", func(cargo interface{}) (State, interface{}, error) {
+		n := atomic.AddInt32(current, 1)
+		for {
+			m := atomic.LoadInt32(max)
+			if n <= m || atomic.CompareAndSwapInt32(max, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(current, -1)
+		return nil, cargo.(int) * 2, nil
+	})
+	m := NewStateMachine()
+	m.AddState(s)
+	return m, s
+}
+
+func TestPool_RunsAllJobsWithinLimits(t *testing.T) {
+	var currentA, maxA, currentAll, maxAll int32
+	a, startA := newCountingMachine(&currentA, &maxA)
+	b, startB := newCountingMachine(&currentAll, &maxAll)
+
+	p := NewPool(3)
+	p.SetMachineLimit(a, 1)
+
+	lock := &sync.Mutex{}
+	results := make(map[int]interface{})
+	done := func(result *Result, err error) {
+		assert.Nil(t, err)
+		lock.Lock()
+		results[result.Cargo.(int)/2] = result.Cargo
+		lock.Unlock()
+	}
+	for i := 0; i < 10; i++ {
+		if i%2 == 0 {
+			assert.Nil(t, p.Submit(Job{Machine: a, StartState: startA, Cargo: i, Done: done}))
+		} else {
+			assert.Nil(t, p.Submit(Job{Machine: b, StartState: startB, Cargo: i, Done: done}))
+		}
+	}
+	p.Close()
+
+	assert.Len(t, results, 10)
+	assert.Equal(t, 18, results[9])
+	assert.Equal(t, int32(1), maxA)
+	assert.True(t, maxAll <= 3)
+	assert.Equal(t, 0, p.Queued())
+}
+
+func TestPool_Closed_SubmitFails(t *testing.T) {
+	p := NewPool(0)
+	p.Close()
+
+	err := p.Submit(Job{})
+	assert.True(t, errors.Is(err, ErrPoolClosed))
+}
+
+func TestPool_Shutdown(t *testing.T) {
+	release := make(chan struct{})
+	sm, build := newReleaseMachine(release)
+	p := NewPool(1)
+
+	results := make(chan error, 2)
+	done := func(result *Result, err error) { results <- err }
+	assert.Nil(t, p.Submit(Job{Machine: sm, StartState: build, Cargo: "v1", Done: done}))
+	for p.Queued() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Nil(t, p.Submit(Job{Machine: sm, StartState: build, Cargo: "v2", Done: done}))
+
+	shutdown := make(chan error)
+	go func() { shutdown <- p.Shutdown(context.Background()) }()
+
+	queued := <-results
+	token, ok := ResumeTokenOf(queued)
+	if assert.True(t, ok) && assert.True(t, errors.Is(queued, ErrShutdown)) {
+		assert.Equal(t, "build", token.State())
+		assert.Equal(t, "v2", token.Cargo())
+	}
+	assert.Equal(t, ErrPoolClosed, p.Submit(Job{Machine: sm, StartState: build}))
+
+	close(release)
+	assert.Nil(t, <-shutdown)
+	running := <-results
+	token, ok = ResumeTokenOf(running)
+	if assert.True(t, ok) && assert.True(t, errors.Is(running, ErrShutdown)) {
+		assert.Equal(t, "ship", token.State())
+		result, err := sm.Continue(context.Background(), token)
+		assert.Nil(t, err)
+		assert.Equal(t, "v1 built shipped", result.Cargo)
+	}
+}
+
+func TestPool_Shutdown_DeadlineCancels(t *testing.T) {
+	sm, build := newReleaseMachine(make(chan struct{}))
+	p := NewPool(1)
+	results := make(chan error, 1)
+	p.Submit(Job{Machine: sm, StartState: build, Cargo: "v1", Done: func(result *Result, err error) { results <- err }})
+	for p.Queued() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := p.Shutdown(ctx)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	err = <-results
+	assert.True(t, errors.Is(err, ErrAborted) || errors.Is(err, context.Canceled), err)
+}
@@ -0,0 +1,10 @@
+package gust
+
+// SetProfilerLabels turns labelling state execution for pprof on or off. When
+// on, the goroutine executing a state is labelled gust_state with the state's
+// name and gust_machine with the machine's Name, so CPU and goroutine profiles
+// show which state is burning time. Labelling allocates on every transition,
+// so it's off by default.
+func (sm *StateMachine) SetProfilerLabels(enabled bool) {
+	sm.profilerLabels = enabled
+}
@@ -0,0 +1,48 @@
+package gust
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// labelState records the pprof labels it's executed with
+type labelState struct {
+	machine, state string
+}
+
+func (s *labelState) Exec(cargo interface{}) (State, interface{}, error) {
+	panic("ExecContext should be called instead")
+}
+
+func (s *labelState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	s.machine, _ = pprof.Label(ctx, "gust_machine")
+	s.state, _ = pprof.Label(ctx, "gust_state")
+	return nil, cargo, nil
+}
+
+func (s *labelState) Name() string {
+	return "labelled"
+}
+
+func TestExec_PprofLabels(t *testing.T) {
+	m := NewStateMachine(WithName("order"), WithProfilerLabels())
+	s := &labelState{}
+	m.AddState(s)
+
+	assert.Nil(t, m.Run(nil, s))
+	assert.Equal(t, "order", s.machine)
+	assert.Equal(t, "labelled", s.state)
+	assert.Equal(t, "order", m.Definition().Name)
+}
+
+func TestExec_ProfilerLabelsOff_NoLabels(t *testing.T) {
+	m := NewStateMachine(WithName("order"))
+	s := &labelState{}
+	m.AddState(s)
+
+	assert.Nil(t, m.Run(nil, s))
+	assert.Equal(t, "", s.state)
+}
@@ -0,0 +1,46 @@
+package gust
+
+import (
+	"context"
+	"time"
+)
+
+// Progress is what a state reported about its own progress
+type Progress struct {
+	Percent float64   // 0 to 100
+	Message string    // free form, e.g. "uploaded 3 of 10 files"
+	Updated time.Time // when it was reported
+}
+
+// ProgressObserver when implemented by an observer is notified whenever a
+// state reports progress with ReportProgress
+type ProgressObserver interface {
+	ProgressReported(info StateInfo)
+}
+
+type runKey struct{}
+
+// ReportProgress lets a ContextState report how far it got, the ctx must be
+// the one given to ExecContext. The progress shows up in CurrentState and is
+// sent to ProgressObservers. It returns false if ctx doesn't belong to a run.
+func ReportProgress(ctx context.Context, percent float64, message string) bool {
+	r, ok := ctx.Value(runKey{}).(*run)
+	if !ok {
+		return false
+	}
+
+	sm := r.sm
+	sm.runsLock.Lock()
+	r.progress = Progress{Percent: percent, Message: message, Updated: sm.clock.Now()}
+	info := r.info()
+	sm.runsLock.Unlock()
+
+	for _, observer := range sm.loadObservers() {
+		if po, ok := observer.(ProgressObserver); ok {
+			sm.notify(observer, func() {
+				po.ProgressReported(info)
+			})
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package gust
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ProgressStateImpl struct { // interface State and ContextState
+	reported chan struct{}
+	release  chan struct{}
+}
+
+func (s *ProgressStateImpl) Exec(cargo interface{}) (State, interface{}, error) {
+	panic("ExecContext should be called instead")
+}
+
+func (s *ProgressStateImpl) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	ReportProgress(ctx, 50, "half way")
+	close(s.reported)
+	<-s.release
+	return nil, cargo, nil
+}
+
+func (s *ProgressStateImpl) Name() string {
+	return "progressing"
+}
+
+type ProgressObserverImpl struct {
+	*ObserverImpl
+	lock     sync.Mutex
+	progress []StateInfo
+}
+
+func (o *ProgressObserverImpl) ProgressReported(info StateInfo) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.progress = append(o.progress, info)
+}
+
+func TestReportProgress_InState_VisibleInCurrentStateAndObservers(t *testing.T) {
+	a := &ProgressStateImpl{
+		reported: make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+
+	o := &ProgressObserverImpl{ObserverImpl: NewObserverImpl()}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.RegisterObservers(o)
+
+	done := make(chan error)
+	go func() {
+		done <- m.Run(nil, a)
+	}()
+
+	<-a.reported
+	info, ok := m.CurrentState()
+	if assert.True(t, ok) {
+		assert.Equal(t, "progressing", info.Name)
+		assert.Equal(t, 50.0, info.Progress.Percent)
+		assert.Equal(t, "half way", info.Progress.Message)
+		assert.False(t, info.Progress.Updated.IsZero())
+	}
+
+	close(a.release)
+	assert.Nil(t, <-done)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if assert.Len(t, o.progress, 1) {
+		assert.Equal(t, "progressing", o.progress[0].Name)
+		assert.Equal(t, "half way", o.progress[0].Progress.Message)
+	}
+}
+
+func TestReportProgress_ContextNotFromRun_ReturnsFalse(t *testing.T) {
+	assert.False(t, ReportProgress(context.Background(), 10, "nothing"))
+}
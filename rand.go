@@ -0,0 +1,68 @@
+package gust
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// NewRand returns a source of randomness seeded with the seed, safe for
+// concurrent use, to make the random behaviors of a machine reproducible, see
+// WithRand, JitterBackoff and WalkConfig
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{lock: &sync.Mutex{}, src: rand.NewSource(seed).(rand.Source64)})
+}
+
+// lockedSource makes a source safe for concurrent use like math/rand's default one
+type lockedSource struct {
+	lock *sync.Mutex
+	src  rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.src.Seed(seed)
+}
+
+// SetRand replaces the machine's source of randomness, used to choose weighted
+// transitions in runs and simulations and by random walks not given one. Runs
+// may use it concurrently, NewRand returns a source that's safe for that. If
+// nil, as by default, math/rand's default source is used.
+func (sm *StateMachine) SetRand(r *rand.Rand) {
+	sm.rand = r
+}
+
+// WithRand replaces the machine's source of randomness, like SetRand
+func WithRand(r *rand.Rand) Option {
+	return func(sm *StateMachine) {
+		sm.SetRand(r)
+	}
+}
+
+// WithSeed makes the machine's random choices reproducible, like
+// SetRand(NewRand(seed))
+func WithSeed(seed int64) Option {
+	return func(sm *StateMachine) {
+		sm.SetRand(NewRand(seed))
+	}
+}
+
+// random returns a random number in [0, 1) from the machine's source
+func (sm *StateMachine) random() float64 {
+	if sm.rand != nil {
+		return sm.rand.Float64()
+	}
+	return rand.Float64()
+}
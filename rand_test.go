@@ -0,0 +1,58 @@
+package gust
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRand_SameSeed_SameNumbers(t *testing.T) {
+	a, b := NewRand(7), NewRand(7)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, a.Int63(), b.Int63())
+	}
+}
+
+func TestNewRand_ConcurrentUse(t *testing.T) {
+	r := NewRand(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Float64()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWithSeed_WeightedChoicesReproducible(t *testing.T) {
+	paths := func() []string {
+		m, checkout, _, _ := weightedMachine(WithSeed(3), WithWeightedRouting())
+		ends := make([]string, 0, 20)
+		for i := 0; i < 20; i++ {
+			result, err := m.Execute(context.Background(), nil, checkout)
+			assert.Nil(t, err)
+			ends = append(ends, result.Path[1])
+		}
+		return ends
+	}
+	assert.Equal(t, paths(), paths())
+}
+
+func TestWithSeed_RandomWalkUsesMachineRand(t *testing.T) {
+	walk := func(seed int64) []string {
+		m, checkout, _, _ := weightedMachine(WithSeed(seed))
+		visits := make([]string, 0)
+		m.RandomWalk(checkout, WalkConfig{Walks: 20, Invariants: []Invariant{func(cargo interface{}, state string) error {
+			visits = append(visits, state)
+			return nil
+		}}})
+		return visits
+	}
+	assert.Equal(t, walk(5), walk(5))
+}
@@ -0,0 +1,88 @@
+package gust
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket: Rate tokens are added per second up to Burst,
+// and each state entry takes one. When the bucket is empty the run waits.
+type RateLimit struct {
+	Rate  float64 // tokens per second
+	Burst int     // bucket size, at least 1
+}
+
+// SetRateLimit throttles how fast the machine may enter states, shared by all
+// runs of the machine. A zero Rate removes the limit.
+func (sm *StateMachine) SetRateLimit(limit RateLimit) {
+	sm.rateLimit = newTokenBucket(limit)
+}
+
+// SetStateRateLimit throttles how fast the given state may be entered, shared
+// by all runs of the machine. A zero Rate removes the limit.
+func (sm *StateMachine) SetStateRateLimit(state State, limit RateLimit) {
+	if sm.stateRateLimits == nil {
+		sm.stateRateLimits = make(map[stateKey]*tokenBucket)
+	}
+	if b := newTokenBucket(limit); b != nil {
+		sm.stateRateLimits[keyOf(state)] = b
+	} else {
+		delete(sm.stateRateLimits, keyOf(state))
+	}
+}
+
+// throttle waits until the state may be entered, it returns false if the run
+// was interrupted while waiting
+func (sm *StateMachine) throttle(r *run, state State) bool {
+	if sm.rateLimit != nil && !sm.sleep(r.ctx, sm.rateLimit.reserve(sm.clock.Now())) {
+		return false
+	}
+	if b, ok := sm.stateRateLimits[keyOf(state)]; ok && !sm.sleep(r.ctx, b.reserve(sm.clock.Now())) {
+		return false
+	}
+	return true
+}
+
+type tokenBucket struct {
+	lock   *sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		lock:   &sync.Mutex{},
+		rate:   limit.Rate,
+		burst:  burst,
+		tokens: burst,
+	}
+}
+
+// reserve takes a token and returns how long to wait before it can be used
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+	}
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,63 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket_BurstThenWaits(t *testing.T) {
+	b := newTokenBucket(RateLimit{Rate: 10, Burst: 2})
+
+	now := time.Now()
+	assert.Equal(t, time.Duration(0), b.reserve(now))
+	assert.Equal(t, time.Duration(0), b.reserve(now))
+	assert.Equal(t, 100*time.Millisecond, b.reserve(now))
+	assert.Equal(t, 150*time.Millisecond, b.reserve(now.Add(50*time.Millisecond)))
+}
+
+func TestTokenBucket_ZeroRate_NoLimit(t *testing.T) {
+	assert.Nil(t, newTokenBucket(RateLimit{}))
+}
+
+func TestRateLimit_Machine_ThrottlesTransitions(t *testing.T) {
+	// A -> A -> A -> A, 4 state entries at 100/s with burst 1 take at least 30ms
+	a := &StateImpl{}
+	a.nextState = a
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.MaxTransitions = 3
+	m.SetRateLimit(RateLimit{Rate: 100, Burst: 1})
+
+	start := time.Now()
+	err := m.Run(nil, a)
+	assert.True(t, errors.Is(err, ErrMaxTransitions))
+	assert.True(t, time.Since(start) >= 30*time.Millisecond)
+}
+
+func TestRateLimit_State_OnlyThrottlesThatState(t *testing.T) {
+	// A -> B, B limited but has a token available
+	b := &StateImpl{}
+	a := &StateImpl{
+		nextState: b,
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.SetStateRateLimit(b, RateLimit{Rate: 0.001, Burst: 1})
+
+	start := time.Now()
+	assert.Nil(t, m.Run(nil, a))
+	assert.True(t, time.Since(start) < time.Second)
+
+	// B's bucket is now empty, a second run waits and gets cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := m.RunContext(ctx, nil, a)
+	assert.True(t, errors.Is(err, ErrAborted))
+}
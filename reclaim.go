@@ -0,0 +1,228 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SnapshotStore persists the snapshots of a Manager's instances where every
+// node running the manager can read them, keyed by the snapshots' Run
+type SnapshotStore interface {
+	// Save stores the snapshot, replacing the one of the same run
+	Save(ctx context.Context, machine string, snap *Snapshot) error
+	// Delete removes the snapshot of the run, once it finished
+	Delete(ctx context.Context, run string) error
+	// List returns the snapshots of the runs that didn't finish
+	List(ctx context.Context) ([]StoredSnapshot, error)
+}
+
+// StoredSnapshot is a snapshot in a SnapshotStore
+type StoredSnapshot struct {
+	Machine  string // the name the machine was registered with
+	Snapshot *Snapshot
+}
+
+// SetStore makes the manager save a snapshot of its instances in the store
+// before each state they execute, so runs interrupted by a crash or a deploy
+// can be reclaimed by any node with Reclaim. Snapshots are deleted once runs
+// finish, unless they were interrupted otherwise than with Cancel. Machines
+// must have a Locker, see SetLocker, for nodes to tell orphaned runs from the
+// ones executing elsewhere.
+func (m *Manager) SetStore(store SnapshotStore) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.store = store
+}
+
+// OnStoreError sets a callback receiving the errors deleting snapshots and
+// reclaiming runs in the background. A snapshot that couldn't be deleted is
+// reclaimed later, its run executing its last state again.
+func (m *Manager) OnStoreError(f func(err error)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.storeErrorHandler = f
+}
+
+// SetCargoDecoder sets how the cargos of the named machine's reclaimed runs
+// are decoded, by default the machine's Codec decodes them into an interface{}
+func (m *Manager) SetCargoDecoder(machine string, decode func(data []byte) (interface{}, error)) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	mm, ok := m.machines[machine]
+	if !ok {
+		return fmt.Errorf("%w %s", ErrUnknownMachine, machine)
+	}
+	mm.decode = decode
+	return nil
+}
+
+// Reclaim resumes the runs in the store no node is executing, their lock being
+// free, as new instances. Snapshots of machines not registered or without a
+// Locker are left alone. The instances run until they finish or are
+// cancelled, ctx only bounds listing the snapshots. It returns the instances
+// started, and the first error listing the snapshots or resuming a run. It
+// does nothing once the manager is shutting down.
+func (m *Manager) Reclaim(ctx context.Context) ([]*Instance, error) {
+	m.lock.Lock()
+	store, shutdown := m.store, m.shutdown
+	m.lock.Unlock()
+	if store == nil || shutdown {
+		return nil, nil
+	}
+
+	stored, err := store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	var reclaimed []*Instance
+	var first error
+	for _, s := range stored {
+		inst, err := m.reclaim(ctx, s)
+		if inst != nil {
+			reclaimed = append(reclaimed, inst)
+		}
+		if err != nil && first == nil {
+			first = fmt.Errorf("reclaiming run %s: %w", s.Snapshot.Run, err)
+		}
+	}
+	return reclaimed, first
+}
+
+// reclaim resumes the stored run if its lock is free
+func (m *Manager) reclaim(ctx context.Context, s StoredSnapshot) (*Instance, error) {
+	m.lock.Lock()
+	mm, ok := m.machines[s.Machine]
+	m.lock.Unlock()
+	if !ok || mm.sm.locker == nil {
+		return nil, nil
+	}
+
+	sm := mm.sm
+	lease, err := sm.locker.Lock(ctx, s.Snapshot.Run)
+	if errors.Is(err, ErrLocked) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// the run may have moved on or finished between listing and locking it
+	current, err := m.stored(ctx, s.Snapshot.Run)
+	if err != nil || current == nil {
+		lease.Unlock()
+		return nil, err
+	}
+	snap, err := sm.Migrate(current)
+	if err != nil {
+		lease.Unlock()
+		return nil, err
+	}
+	state, ok := sm.StateByName(snap.State)
+	if !ok {
+		lease.Unlock()
+		return nil, fmt.Errorf("%w %s", ErrUnknownStartState, snap.State)
+	}
+	decode := mm.decode
+	if decode == nil {
+		decode = sm.decodeCargo
+	}
+	cargo, err := decode(snap.Cargo)
+	if err != nil {
+		lease.Unlock()
+		return nil, fmt.Errorf("decoding cargo: %w", err)
+	}
+
+	m.lock.Lock()
+	if m.shutdown {
+		m.lock.Unlock()
+		lease.Unlock()
+		return nil, nil
+	}
+	inst, runCtx := m.newInstance(context.Background(), s.Machine, mm)
+	m.lock.Unlock()
+	runCtx = context.WithValue(runCtx, resumeKey{}, resumed{base: snap.Run, steps: snap.Steps, lease: lease})
+	go inst.execute(runCtx, cargo, state)
+	return inst, nil
+}
+
+// stored returns the run's snapshot in the store, nil if there's none
+func (m *Manager) stored(ctx context.Context, run string) (*Snapshot, error) {
+	m.lock.Lock()
+	store := m.store
+	m.lock.Unlock()
+
+	stored, err := store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	for _, s := range stored {
+		if s.Snapshot.Run == run {
+			return s.Snapshot, nil
+		}
+	}
+	return nil, nil
+}
+
+// ReclaimEvery calls Reclaim every interval until ctx is done, so orphaned runs
+// are picked up automatically. Errors are sent to the OnStoreError callback.
+func (m *Manager) ReclaimEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.Reclaim(ctx); err != nil {
+			m.storeError(err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// save saves the snapshot of the instance about to execute the state it
+// entered, if the manager has a store
+func (i *Instance) save(r *run, state State, cargo interface{}) error {
+	i.manager.lock.Lock()
+	store := i.manager.store
+	i.manager.lock.Unlock()
+	if store == nil {
+		return nil
+	}
+
+	if err := i.sm.lockRun(r); err != nil {
+		return err
+	}
+	snap, err := i.sm.snapshot(r, state, cargo)
+	if err != nil {
+		return err
+	}
+	if err := store.Save(r.ctx, i.Machine, snap); err != nil {
+		return fmt.Errorf("saving snapshot: %w", err)
+	}
+	i.run = snap.Run
+	return nil
+}
+
+// deleteSnapshot forgets the snapshot of a finished run
+func (m *Manager) deleteSnapshot(run string) {
+	m.lock.Lock()
+	store := m.store
+	m.lock.Unlock()
+	if store == nil {
+		return
+	}
+	if err := store.Delete(context.Background(), run); err != nil {
+		m.storeError(fmt.Errorf("deleting snapshot of run %s: %w", run, err))
+	}
+}
+
+func (m *Manager) storeError(err error) {
+	m.lock.Lock()
+	f := m.storeErrorHandler
+	m.lock.Unlock()
+	if f != nil {
+		f(err)
+	}
+}
@@ -0,0 +1,140 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore is a SnapshotStore shared by the managers of a test
+type memoryStore struct {
+	lock  *sync.Mutex
+	snaps map[string]StoredSnapshot
+	saved int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{lock: &sync.Mutex{}, snaps: make(map[string]StoredSnapshot)}
+}
+
+func (s *memoryStore) Save(ctx context.Context, machine string, snap *Snapshot) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.snaps[snap.Run] = StoredSnapshot{Machine: machine, Snapshot: snap}
+	s.saved++
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, run string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.snaps, run)
+	return nil
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]StoredSnapshot, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stored := make([]StoredSnapshot, 0, len(s.snaps))
+	for _, snap := range s.snaps {
+		stored = append(stored, snap)
+	}
+	return stored, nil
+}
+
+func (s *memoryStore) len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.snaps)
+}
+
+// newNode returns a manager running the approval machine as a node would,
+// sharing the store and locker with the other nodes
+func newNode(store SnapshotStore, locker Locker) *Manager {
+	sm, start := newApprovalMachine()
+	sm.SetLocker(locker)
+	mgr := NewManager()
+	mgr.Register("approval", sm, start)
+	mgr.SetStore(store)
+	return mgr
+}
+
+func TestManager_SetStore_SnapshotsDeletedOnceFinished(t *testing.T) {
+	store := newMemoryStore()
+	mgr := newNode(store, NewMemoryLocker())
+
+	inst, _ := mgr.Start(context.Background(), "approval", "req")
+	waitForState(t, inst, "approval")
+	mgr.Signal(inst.ID, "approve", "ok")
+	_, err := inst.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, store.saved)
+	assert.Equal(t, 0, store.len())
+}
+
+func TestManager_Reclaim_OrphanedRunResumedByAnotherNode(t *testing.T) {
+	store, locker := newMemoryStore(), NewMemoryLocker()
+	a, b := newNode(store, locker), newNode(store, locker)
+
+	ctx, deploy := context.WithCancel(context.Background())
+	inst, _ := a.Start(ctx, "approval", "req")
+	waitForState(t, inst, "approval")
+
+	reclaimed, err := b.Reclaim(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, reclaimed, 0, "reclaimed a run still executing")
+
+	deploy()
+	inst.Wait(context.Background())
+	assert.Equal(t, InstanceCancelled, inst.Status())
+	assert.Equal(t, 1, store.len())
+
+	reclaimed, err = b.Reclaim(context.Background())
+	assert.Nil(t, err)
+	if !assert.Len(t, reclaimed, 1) {
+		return
+	}
+	waitForState(t, reclaimed[0], "approval")
+	assert.Nil(t, b.Signal(reclaimed[0].ID, "approve", "ok"))
+	result, err := reclaimed[0].Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", result.Cargo)
+	assert.Equal(t, 0, store.len())
+}
+
+func TestManager_Cancel_SnapshotDeleted(t *testing.T) {
+	store := newMemoryStore()
+	mgr := newNode(store, NewMemoryLocker())
+
+	inst, _ := mgr.Start(context.Background(), "approval", "req")
+	waitForState(t, inst, "approval")
+	mgr.Cancel(inst.ID)
+	inst.Wait(context.Background())
+	assert.Equal(t, 0, store.len())
+}
+
+type failingStore struct{ *memoryStore }
+
+func (failingStore) Delete(ctx context.Context, run string) error {
+	return errors.New("down")
+}
+
+func TestManager_OnStoreError(t *testing.T) {
+	mgr := newNode(failingStore{newMemoryStore()}, NewMemoryLocker())
+	var reported error
+	mgr.OnStoreError(func(err error) { reported = err })
+
+	inst, _ := mgr.Start(context.Background(), "approval", "req")
+	waitForState(t, inst, "approval")
+	mgr.Cancel(inst.ID)
+	inst.Wait(context.Background())
+	assert.Contains(t, reported.Error(), "deleting snapshot of run")
+}
+
+func TestManager_SetCargoDecoder_UnknownMachine(t *testing.T) {
+	err := NewManager().SetCargoDecoder("nope", nil)
+	assert.True(t, errors.Is(err, ErrUnknownMachine))
+}
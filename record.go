@@ -0,0 +1,140 @@
+package gust
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Record is one Exec call of a recorded run
+type Record struct {
+	State     string          `json:"state"`
+	Cargo     json.RawMessage `json:"cargo"`
+	Next      string          `json:"next,omitempty"` // empty when the run ended
+	NextCargo json.RawMessage `json:"nextCargo,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Retryable bool            `json:"retryable,omitempty"` // whether Error was retryable
+
+	CorrelationID string `json:"correlationId,omitempty"` // the run's, see WithCorrelationID
+}
+
+// RecordRun is like Execute but writes every Exec call of the run, with the
+// cargo going in and out and the state chosen, to w as JSON lines. The cargo
+// must be JSON serializable, it's recorded as redacted by the machine's
+// Redactor. The recording can be replayed with Replay.
+func (sm *StateMachine) RecordRun(ctx context.Context, w io.Writer, cargo interface{}, startState State) (*Result, error) {
+	enc := json.NewEncoder(w)
+	return sm.executeWith(ctx, cargo, startState, func(r *run, state State, cargo interface{}) (State, interface{}, error) {
+		nextState, nextCargo, err := sm.execState(r, state, cargo)
+
+		rec := Record{State: sm.StateName(state), CorrelationID: r.correlation}
+		if nextState != nil {
+			rec.Next = sm.StateName(nextState)
+		}
+		if err != nil {
+			rec.Error = err.Error()
+			rec.Retryable = IsRetryable(err)
+		}
+		var merr error
+		if rec.Cargo, merr = json.Marshal(sm.Redact(cargo)); merr != nil {
+			return nil, nil, fmt.Errorf("recording cargo: %w", merr)
+		}
+		if err == nil {
+			if rec.NextCargo, merr = json.Marshal(sm.Redact(nextCargo)); merr != nil {
+				return nil, nil, fmt.Errorf("recording cargo: %w", merr)
+			}
+		}
+		if werr := enc.Encode(rec); werr != nil {
+			return nil, nil, fmt.Errorf("recording: %w", werr)
+		}
+
+		return nextState, nextCargo, err
+	})
+}
+
+// Replay runs a recording made by RecordRun again without executing any
+// state, each state returns what it returned when recorded. The run goes
+// through the machine as usual, so observers are notified and transitions
+// validated. States are looked up by name and must be named. decode turns
+// recorded cargo back into values, if nil cargo is decoded into generic JSON
+// values (map[string]interface{}, float64 and so on). A recording that doesn't
+// match the machine fails the run with ErrReplayMismatch.
+func (sm *StateMachine) Replay(ctx context.Context, rd io.Reader, decode func(data []byte) (interface{}, error)) (*Result, error) {
+	if decode == nil {
+		decode = decodeJSON
+	}
+
+	records, err := readRecords(rd)
+	if err != nil {
+		return &Result{}, err
+	}
+	if len(records) == 0 {
+		return &Result{}, fmt.Errorf("%w: empty recording", ErrReplayMismatch)
+	}
+
+	startState, ok := sm.StateByName(records[0].State)
+	if !ok {
+		err := fmt.Errorf("%w: no state named %s", ErrReplayMismatch, records[0].State)
+		return &Result{}, err
+	}
+	cargo, err := decode(records[0].Cargo)
+	if err != nil {
+		return &Result{}, fmt.Errorf("decoding cargo: %w", err)
+	}
+
+	ctx = correlated(ctx, records[0].CorrelationID)
+	return sm.executeWith(ctx, cargo, startState, func(r *run, state State, cargo interface{}) (State, interface{}, error) {
+		if len(records) == 0 {
+			return nil, nil, fmt.Errorf("%w: recording ended before %s", ErrReplayMismatch, sm.StateName(state))
+		}
+		rec := records[0]
+		records = records[1:]
+
+		if rec.State != sm.StateName(state) {
+			return nil, nil, fmt.Errorf("%w: recorded %s but in %s", ErrReplayMismatch, rec.State, sm.StateName(state))
+		}
+		if rec.Error != "" {
+			err := errors.New(rec.Error)
+			if rec.Retryable {
+				err = Retryable(err)
+			}
+			return nil, nil, err
+		}
+
+		var nextState State
+		if rec.Next != "" {
+			if nextState, ok = sm.StateByName(rec.Next); !ok {
+				return nil, nil, fmt.Errorf("%w: no state named %s", ErrReplayMismatch, rec.Next)
+			}
+		}
+		nextCargo, err := decode(rec.NextCargo)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decoding cargo: %w", err)
+		}
+		return nextState, nextCargo, nil
+	})
+}
+
+// decodeJSON decodes into generic JSON values
+func decodeJSON(data []byte) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// readRecords reads a recording made by RecordRun
+func readRecords(rd io.Reader) ([]Record, error) {
+	records := make([]Record, 0)
+	dec := json.NewDecoder(rd)
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, fmt.Errorf("reading recording: %w", err)
+		}
+		records = append(records, rec)
+	}
+}
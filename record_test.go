@@ -0,0 +1,114 @@
+package gust
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+// newOrderMachine builds validate -> charge -> ship, where charge fails once
+// with a retryable error, counting how many times states are executed
+func newOrderMachine(calls *int) (*StateMachine, State) {
+	m := NewStateMachine()
+	charged := false
+	states, _ := m.RegisterAll(map[string]ExecFunc{
+		"validate": func(cargo interface{}) (State, interface{}, error) {
+			*calls++
+			next, _ := m.StateByName("charge")
+			return next, cargo, nil
+		},
+		"charge": func(cargo interface{}) (State, interface{}, error) {
+			*calls++
+			if !charged {
+				charged = true
+				return nil, nil, Retryable(errors.New("gateway timeout"))
+			}
+			o := cargo.(order)
+			o.Total += 5
+			next, _ := m.StateByName("ship")
+			return next, o, nil
+		},
+		"ship": func(cargo interface{}) (State, interface{}, error) {
+			*calls++
+			return nil, cargo, nil
+		},
+	})
+	m.SetRetryPolicy(states["charge"], RetryPolicy{MaxAttempts: 2})
+	return m, states["validate"]
+}
+
+func decodeOrder(data []byte) (interface{}, error) {
+	var o order
+	err := json.Unmarshal(data, &o)
+	return o, err
+}
+
+func TestRecordRun_ThenReplay_SameResultWithoutExecuting(t *testing.T) {
+	calls := 0
+	m, start := newOrderMachine(&calls)
+
+	var recording bytes.Buffer
+	recorded, err := m.RecordRun(context.Background(), &recording, order{ID: "o1", Total: 10}, start)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, 4, strings.Count(recording.String(), "\n"))
+
+	o := NewObserverImpl()
+	m.RegisterObservers(o)
+
+	replayed, err := m.Replay(context.Background(), &recording, decodeOrder)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 4, calls) // nothing executed
+	assert.Equal(t, recorded.Path, replayed.Path)
+	assert.Equal(t, order{ID: "o1", Total: 15}, replayed.Cargo)
+	assert.Len(t, o.states, 3)
+}
+
+func TestReplay_GenericDecoding_JSONValues(t *testing.T) {
+	calls := 0
+	m, start := newOrderMachine(&calls)
+
+	var recording bytes.Buffer
+	_, err := m.RecordRun(context.Background(), &recording, order{ID: "o1", Total: 10}, start)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	replayed, err := m.Replay(context.Background(), &recording, nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{"id": "o1", "total": 15.0}, replayed.Cargo)
+}
+
+func TestReplay_RecordingOfOtherMachine_Mismatch(t *testing.T) {
+	recording := `{"state":"validate","cargo":{},"next":"pack","nextCargo":{}}` + "\n"
+
+	calls := 0
+	m, _ := newOrderMachine(&calls)
+
+	_, err := m.Replay(context.Background(), strings.NewReader(recording), nil)
+	assert.True(t, errors.Is(err, ErrReplayMismatch))
+}
+
+func TestReplay_EmptyRecording_Mismatch(t *testing.T) {
+	calls := 0
+	m, _ := newOrderMachine(&calls)
+
+	_, err := m.Replay(context.Background(), strings.NewReader(""), nil)
+	assert.True(t, errors.Is(err, ErrReplayMismatch))
+}
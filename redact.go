@@ -0,0 +1,49 @@
+package gust
+
+// Redactor returns what of a cargo may be seen outside of the machine, e.g. a
+// copy with personal data masked. It mustn't modify the cargo, states are
+// still given it whole.
+type Redactor func(cargo interface{}) interface{}
+
+// SetRedactor sanitizes cargo before it's handed out for observability: the
+// cargo summarized in the RunStatus given to StatusObservers, the cargo in
+// recordings made by RecordRun, and that of finished instances served by a
+// Manager's Handler. With RedactPersisted snapshots and event logs
+// are redacted too. Use Redact to sanitize cargo in your own logs, traces and
+// hooks. Set the redactor before running the machine.
+func (sm *StateMachine) SetRedactor(r Redactor) {
+	sm.redactor = r
+}
+
+// WithRedactor sanitizes cargo handed out for observability, like SetRedactor
+func WithRedactor(r Redactor) Option {
+	return func(sm *StateMachine) {
+		sm.SetRedactor(r)
+	}
+}
+
+// RedactPersisted has the redactor also applied to the cargo of snapshots, be
+// they taken by SnapshotRun, Checkpoint or a Manager's store, and of event
+// logs. Runs resumed from them are given the redacted cargo, so only redact
+// what states can do without or look up again; EncryptedCodec protects cargo
+// that must come back whole.
+func (sm *StateMachine) RedactPersisted(enabled bool) {
+	sm.redactPersisted = enabled
+}
+
+// Redact returns the cargo sanitized by the machine's redactor, the cargo
+// itself if it has none
+func (sm *StateMachine) Redact(cargo interface{}) interface{} {
+	if sm.redactor == nil {
+		return cargo
+	}
+	return sm.redactor(cargo)
+}
+
+// persisted returns the cargo to persist, redacted if RedactPersisted is set
+func (sm *StateMachine) persisted(cargo interface{}) interface{} {
+	if !sm.redactPersisted {
+		return cargo
+	}
+	return sm.Redact(cargo)
+}
@@ -0,0 +1,76 @@
+package gust
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type customer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (c customer) String() string {
+	return c.Name + " <" + c.Email + ">"
+}
+
+func maskEmail(cargo interface{}) interface{} {
+	if c, ok := cargo.(customer); ok {
+		c.Email = "***"
+		return c
+	}
+	return cargo
+}
+
+func TestSetRedactor_StatusAndRecording(t *testing.T) {
+	m := NewStateMachine(WithRedactor(maskEmail))
+	b := &StateImpl{name: "b", cargo: customer{Name: "ann", Email: "ann@example.com"}}
+	a := NewFuncState("a", func(cargo interface{}) (State, interface{}, error) {
+		return b, cargo, nil
+	})
+	m.AddStates(a, b)
+	o := &statusObserver{ObserverImpl: *NewObserverImpl()}
+	m.RegisterObservers(o)
+
+	var buf bytes.Buffer
+	_, err := m.RecordRun(context.Background(), &buf, customer{Name: "bob", Email: "bob@example.com"}, a)
+	assert.Nil(t, err)
+	if assert.Len(t, o.statuses, 2) {
+		assert.Equal(t, "bob <***>", o.statuses[0].Cargo)
+		assert.Equal(t, "bob <***>", o.statuses[1].Cargo)
+	}
+	assert.NotContains(t, buf.String(), "@example.com")
+	assert.Contains(t, buf.String(), `"name":"ann"`)
+	assert.Equal(t, "ann", m.Redact(b.cargo).(customer).Name)
+}
+
+func TestRedactPersisted_Snapshots(t *testing.T) {
+	m := NewStateMachine(WithRedactor(maskEmail))
+	a := &StateImpl{name: "a"}
+	m.AddState(a)
+	cargo := customer{Name: "bob", Email: "bob@example.com"}
+
+	var saved []*Snapshot
+	save := func(snap *Snapshot) error {
+		saved = append(saved, snap)
+		return nil
+	}
+	_, err := m.SnapshotRun(context.Background(), cargo, a, save)
+	assert.Nil(t, err)
+	assert.Contains(t, string(saved[0].Cargo), "bob@example.com") // not persisted redacted by default
+	assert.Equal(t, cargo, a.cargoReceived)
+
+	m.RedactPersisted(true)
+	_, err = m.SnapshotRun(context.Background(), cargo, a, save)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(saved[1].Cargo), "bob@example.com")
+	assert.Equal(t, cargo, a.cargoReceived) // states still get it whole
+}
+
+func TestRedact_NoRedactor_Cargo(t *testing.T) {
+	m := NewStateMachine()
+	assert.Equal(t, 5, m.Redact(5))
+}
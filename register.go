@@ -0,0 +1,65 @@
+package gust
+
+import "sort"
+
+// ExecFunc is the signature of State.Exec, use FuncState to turn one into a state
+type ExecFunc func(cargo interface{}) (nextState State, nextCargo interface{}, err error)
+
+// FuncState is a named state executing a function
+type FuncState struct {
+	name string
+	f    ExecFunc
+}
+
+// NewFuncState returns a state with the given name that executes f
+func NewFuncState(name string, f ExecFunc) *FuncState {
+	return &FuncState{name: name, f: f}
+}
+
+// Exec calls the function
+func (s *FuncState) Exec(cargo interface{}) (State, interface{}, error) {
+	return s.f(cargo)
+}
+
+// Name is the name given to NewFuncState
+func (s *FuncState) Name() string {
+	return s.name
+}
+
+// AddStates adds the states in order, stopping at the first error
+func (sm *StateMachine) AddStates(states ...State) error {
+	for _, state := range states {
+		if err := sm.AddState(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterAll adds a FuncState for every name and function, in name order, and
+// returns the states by name. Functions find the states to transition to with
+// StateByName, or from the returned map.
+func (sm *StateMachine) RegisterAll(funcs map[string]ExecFunc) (map[string]State, error) {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	states := make(map[string]State, len(funcs))
+	for _, name := range names {
+		state := NewFuncState(name, funcs[name])
+		if err := sm.AddState(state); err != nil {
+			return states, err
+		}
+		states[name] = state
+	}
+	return states, nil
+}
+
+// StateByName returns the registered state with the given name, made up by
+// StateName for unnamed states
+func (sm *StateMachine) StateByName(name string) (State, bool) {
+	state, ok := sm.names[name]
+	return state, ok
+}
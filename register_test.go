@@ -0,0 +1,75 @@
+package gust
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddStates_AddsInOrder(t *testing.T) {
+	a, b, c := &StateImpl{}, &StateImpl{}, &StateImpl{}
+
+	m := NewStateMachine()
+	err := m.AddStates(a, b, c)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []State{a, b, c}, m.States)
+}
+
+func TestAddStates_Duplicate_ReturnsError(t *testing.T) {
+	a, b := &StateImpl{}, &StateImpl{}
+
+	m := NewStateMachine()
+	err := m.AddStates(a, b, a)
+	assert.True(t, errors.Is(err, ErrDuplicateState))
+	assert.Len(t, m.States, 2)
+}
+
+func TestRegisterAll_FuncStates_RunByName(t *testing.T) {
+	m := NewStateMachine()
+	visited := make([]string, 0)
+
+	states, err := m.RegisterAll(map[string]ExecFunc{
+		"a": func(cargo interface{}) (State, interface{}, error) {
+			visited = append(visited, "a")
+			next, _ := m.StateByName("b")
+			return next, cargo.(int) + 1, nil
+		},
+		"b": func(cargo interface{}) (State, interface{}, error) {
+			visited = append(visited, "b")
+			next, _ := m.StateByName("c")
+			return next, cargo.(int) + 1, nil
+		},
+		"c": func(cargo interface{}) (State, interface{}, error) {
+			visited = append(visited, "c")
+			assert.Equal(t, 3, cargo)
+			return nil, cargo, nil
+		},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, states, 3)
+	assert.Len(t, m.States, 3)
+	assert.Equal(t, "a", m.States[0].(HaveName).Name()) // registered in name order
+
+	err = m.Run(1, states["a"])
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, visited)
+}
+
+func TestStateByName_Unknown_NotOk(t *testing.T) {
+	m := NewStateMachine()
+	m.AddState(&StateImpl{name: "stateA"})
+
+	_, ok := m.StateByName("stateB")
+	assert.False(t, ok)
+
+	state, ok := m.StateByName("stateA")
+	assert.True(t, ok)
+	assert.Equal(t, "stateA", state.(HaveName).Name())
+}
@@ -0,0 +1,184 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ReloadPolicy is what happens to the running instances of a machine reloaded
+// with Manager.Reload
+type ReloadPolicy int
+
+const (
+	// FinishOnOld lets running instances finish on the machine they started
+	// on, only instances started after the reload run on the new one
+	FinishOnOld ReloadPolicy = iota
+	// MigrateRunning moves running instances to the new machine before the
+	// next state they enter, see Manager.Reload
+	MigrateRunning
+)
+
+func (p ReloadPolicy) String() string {
+	switch p {
+	case FinishOnOld:
+		return "finish on old"
+	case MigrateRunning:
+		return "migrate running"
+	}
+	return fmt.Sprintf("ReloadPolicy(%d)", int(p))
+}
+
+// handover is where an instance moving to a reloaded machine is
+type handover struct {
+	to    *managedMachine
+	snap  *Snapshot // without cargo
+	cargo interface{}
+}
+
+// Reload swaps the named machine for sm with the definition applied, so
+// workflows change without a restart. sm is a machine built like the current
+// one, with the states registered but no transitions, ApplyDefinition
+// validates the definition and declares them. Instances start in the
+// definition's start state, or in the state named like the current start
+// state if it has none. The policy decides what happens to running instances:
+// they finish on the old machine, or move to the new one before the next
+// state they enter, migrated to its Version as with Resume and keeping their
+// cargo as is. Their run on the old machine ends with ErrReloaded. Instances
+// whose state can't be found in the new machine fail.
+func (m *Manager) Reload(machine string, sm *StateMachine, d *Definition, policy ReloadPolicy) error {
+	m.lock.Lock()
+	old, ok := m.machines[machine]
+	m.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("%w %s", ErrUnknownMachine, machine)
+	}
+
+	start, err := sm.ApplyDefinition(d)
+	if err != nil {
+		return err
+	}
+	if start == nil {
+		if start, ok = sm.StateByName(old.sm.StateName(old.start)); !ok {
+			return fmt.Errorf("%w %s", ErrUnknownStartState, old.sm.StateName(old.start))
+		}
+	}
+
+	mm := &managedMachine{sm: sm, start: start, decode: old.decode}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.machines[machine] = mm
+	if policy == MigrateRunning {
+		for _, inst := range m.instances {
+			if inst.Machine != machine {
+				continue
+			}
+			inst.lock.Lock()
+			if inst.status == InstanceRunning {
+				inst.reloaded = mm
+			}
+			inst.lock.Unlock()
+		}
+	}
+	return nil
+}
+
+// execute runs the instance until it finishes, moving it to the machines it's
+// reloaded on
+func (i *Instance) execute(ctx context.Context, cargo interface{}, state State) {
+	sm := i.machine()
+	origin, originCargo := state, cargo
+	for {
+		runCtx, stop := context.WithCancel(ctx)
+		i.lock.Lock()
+		i.stopRun = stop
+		i.lock.Unlock()
+		result, err := sm.executeWith(runCtx, cargo, state, i.exec)
+		stop()
+		i.lock.Lock()
+		h := i.handover
+		i.handover = nil
+		i.lock.Unlock()
+		if h == nil || !errors.Is(err, ErrReloaded) {
+			restart, err := i.restart(ctx, err)
+			if restart {
+				if sm, state, err = i.origin(origin); err == nil {
+					cargo = originCargo
+					ctx = context.WithValue(ctx, resumeKey{}, nil) // a new run, the lease it resumed with is gone
+					continue
+				}
+			}
+			if err != nil && (result == nil || result.Err != err) {
+				result = &Result{Cargo: cargo, Err: err}
+			}
+			i.finish(result, err)
+			return
+		}
+
+		sm = h.to.sm
+		snap, err := sm.Migrate(h.snap)
+		if err == nil {
+			var ok bool
+			if state, ok = sm.StateByName(snap.State); !ok {
+				err = fmt.Errorf("%w %s", ErrUnknownStartState, snap.State)
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("moving to reloaded machine: %w", err)
+			i.finish(&Result{Cargo: h.cargo, Err: err}, err)
+			return
+		}
+		i.lock.Lock()
+		i.sm = sm
+		i.recorded = 0
+		i.lock.Unlock()
+		cargo = h.cargo
+		ctx = resuming(ctx, snap.Run, snap.Steps)
+	}
+}
+
+// origin returns the state the instance started in on the machine it's on,
+// which it was reloaded on if it was
+func (i *Instance) origin(state State) (*StateMachine, State, error) {
+	sm := i.machine()
+	if sm.isRegistered(state) {
+		return sm, state, nil
+	}
+	if named, ok := sm.StateByName(sm.StateName(state)); ok {
+		return sm, named, nil
+	}
+	return sm, nil, fmt.Errorf("restarting: %w %s", ErrUnknownStartState, sm.StateName(state))
+}
+
+// handOver stops the run before the state it entered if the instance is to
+// move to a reloaded machine
+func (i *Instance) handOver(r *run, state State, cargo interface{}) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.reloaded == nil {
+		return nil
+	}
+
+	path := r.path.list()
+	i.handover = &handover{
+		to: i.reloaded,
+		snap: &Snapshot{
+			Version: i.sm.Version,
+			State:   i.sm.StateName(state),
+			Path:    path[:len(path)-1],
+			Taken:   i.sm.clock.Now(),
+			Run:     r.idempotencyBase(),
+			Steps:   r.resumedSteps + r.path.entered - 1,
+		},
+		cargo: cargo,
+	}
+	i.reloaded = nil
+	return Fatal(ErrReloaded)
+}
+
+// machine returns the machine the instance runs on
+func (i *Instance) machine() *StateMachine {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.sm
+}
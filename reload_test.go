@@ -0,0 +1,153 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gateState waits for the signal, then goes to next
+type gateState struct {
+	name   string
+	signal string
+	next   State
+}
+
+func (s *gateState) Exec(cargo interface{}) (State, interface{}, error) {
+	panic("ExecContext should be called instead")
+}
+
+func (s *gateState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	_, err := ReceiveSignal(ctx, s.signal)
+	return s.next, cargo, err
+}
+
+func (s *gateState) Name() string {
+	return s.name
+}
+
+// newReviewMachine waits in submit for "go" then reviews, archiving after the
+// review if archive is set
+func newReviewMachine(archive bool) (*StateMachine, State) {
+	m := NewStateMachine()
+	archived := NewFuncState("archive", func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo, nil
+	})
+	review := NewFuncState("review", func(cargo interface{}) (State, interface{}, error) {
+		if archive {
+			return archived, cargo, nil
+		}
+		return nil, cargo, nil
+	})
+	submit := &gateState{name: "submit", signal: "go", next: review}
+	m.AddStates(submit, review, archived)
+	return m, submit
+}
+
+func archivingDefinition() *Definition {
+	return &Definition{
+		Start:  "submit",
+		States: []StateDefinition{{Name: "submit"}, {Name: "review"}, {Name: "archive"}},
+		Transitions: []TransitionDefinition{
+			{From: "submit", To: "review"},
+			{From: "review", To: "archive"},
+		},
+	}
+}
+
+func startReviewInstance(t *testing.T) (*Manager, *Instance) {
+	old, start := newReviewMachine(false)
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("review", old, start))
+	inst, err := mgr.Start(context.Background(), "review", 1)
+	assert.Nil(t, err)
+	waitForState(t, inst, "submit")
+	return mgr, inst
+}
+
+func TestManager_Reload_MigrateRunning(t *testing.T) {
+	mgr, inst := startReviewInstance(t)
+
+	next, _ := newReviewMachine(true)
+	assert.Nil(t, mgr.Reload("review", next, archivingDefinition(), MigrateRunning))
+	assert.Nil(t, mgr.Signal(inst.ID, "go", nil))
+
+	result, err := inst.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"review", "archive"}, result.Path) // resumed on the new machine
+	assert.Equal(t, 1, result.Cargo)
+	steps := make([]string, 0)
+	for _, step := range inst.History() {
+		steps = append(steps, step.From+"->"+step.To)
+	}
+	assert.Equal(t, []string{"->submit", "submit->review", "review->archive"}, steps)
+}
+
+func TestManager_Reload_FinishOnOld(t *testing.T) {
+	mgr, inst := startReviewInstance(t)
+
+	next, _ := newReviewMachine(true)
+	assert.Nil(t, mgr.Reload("review", next, archivingDefinition(), FinishOnOld))
+	assert.Nil(t, mgr.Signal(inst.ID, "go", nil))
+
+	result, err := inst.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"submit", "review"}, result.Path)
+
+	// new instances start on the new machine
+	inst, err = mgr.Start(context.Background(), "review", 2)
+	assert.Nil(t, err)
+	waitForState(t, inst, "submit")
+	assert.Nil(t, mgr.Signal(inst.ID, "go", nil))
+	result, err = inst.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"submit", "review", "archive"}, result.Path)
+}
+
+func TestManager_Reload_StateMissing_InstanceFails(t *testing.T) {
+	mgr, inst := startReviewInstance(t)
+
+	next := NewStateMachine()
+	submit := &gateState{name: "submit", signal: "go"}
+	next.AddState(submit)
+	assert.Nil(t, mgr.Reload("review", next, &Definition{States: []StateDefinition{{Name: "submit"}}}, MigrateRunning))
+	assert.Nil(t, mgr.Signal(inst.ID, "go", nil))
+
+	_, err := inst.Wait(context.Background())
+	assert.True(t, errors.Is(err, ErrUnknownStartState))
+	assert.Equal(t, InstanceFailed, inst.Status())
+}
+
+func TestManager_Reload_Errors(t *testing.T) {
+	mgr := NewManager()
+	next, _ := newReviewMachine(true)
+	err := mgr.Reload("review", next, archivingDefinition(), FinishOnOld)
+	assert.True(t, errors.Is(err, ErrUnknownMachine))
+
+	old, start := newReviewMachine(false)
+	assert.Nil(t, mgr.Register("review", old, start))
+	err = mgr.Reload("review", next, &Definition{Start: "nowhere"}, FinishOnOld)
+	assert.True(t, errors.Is(err, ErrInvalidDefinition))
+
+	assert.Equal(t, "migrate running", MigrateRunning.String())
+}
+
+func TestManager_Reload_MigrateRunning_NotDeadLettered(t *testing.T) {
+	old, start := newReviewMachine(false)
+	sink := &memDeadLetters{}
+	old.SetDeadLetterSink(sink)
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("review", old, start))
+	inst, _ := mgr.Start(context.Background(), "review", 1)
+	waitForState(t, inst, "submit")
+
+	next, _ := newReviewMachine(true)
+	assert.Nil(t, mgr.Reload("review", next, archivingDefinition(), MigrateRunning))
+	assert.Nil(t, mgr.Signal(inst.ID, "go", nil))
+	_, err := inst.Wait(context.Background())
+
+	assert.Nil(t, err)
+	assert.Empty(t, sink.letters)
+}
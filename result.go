@@ -0,0 +1,69 @@
+package gust
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Result describes a finished run, see Execute
+type Result struct {
+	RunID string      // see SetRunIDGenerator
+	Path  []string    // states entered in order, by name (by type if unnamed), the last ones if SetHistoryLimit is set
+	Cargo interface{} // the last cargo
+	Err   error       // why the run failed, nil on success
+
+	// Outcome is that of the terminal state the run ended in, OutcomeNone if
+	// it failed or didn't end in one
+	Outcome Outcome
+
+	Duration time.Duration // from the run starting to it ending
+	Retries  int           // retries taken over all states, see SetRetryPolicy
+	Timings  []StateTiming // per state entered, in the order first entered
+}
+
+// Visited tells whether the run entered the named state
+func (r *Result) Visited(name string) bool {
+	for _, s := range r.Path {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func newResult(r *run, cargo interface{}, err error) *Result {
+	result := &Result{RunID: r.id, Path: r.path.list(), Cargo: cargo, Err: err, Retries: r.retries, Timings: append([]StateTiming{}, r.timings...)}
+	if !r.started.IsZero() { // simulated runs aren't timed
+		result.Duration = r.sm.clock.Now().Sub(r.started)
+	}
+	if err == nil && r.state != nil {
+		result.Outcome = r.sm.OutcomeOf(r.state)
+	}
+	return result
+}
+
+// Trace formats the run as text, one transition per line followed by how the
+// run ended. The format is stable so it can be kept in golden files:
+//
+//	-> stateA
+//	stateA -> stateB
+//	end
+func (r *Result) Trace() string {
+	var b strings.Builder
+	prior := ""
+	for _, s := range r.Path {
+		if prior == "" {
+			fmt.Fprintf(&b, "-> %s\n", s)
+		} else {
+			fmt.Fprintf(&b, "%s -> %s\n", prior, s)
+		}
+		prior = s
+	}
+	if r.Err != nil {
+		fmt.Fprintf(&b, "failed: %v\n", r.Err)
+	} else {
+		b.WriteString("end\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecute_Success_ResultHasPathAndCargo(t *testing.T) {
+	b := &StateImpl{
+		name:  "stateB",
+		cargo: 3,
+	}
+	a := &StateImpl{
+		nextState: b,
+		name:      "stateA",
+		cargo:     2,
+	}
+
+	m := NewStateMachine()
+	m.AddStates(a, b)
+
+	result, err := m.Execute(context.Background(), 1, a)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"stateA", "stateB"}, result.Path)
+	assert.Equal(t, 3, result.Cargo)
+	assert.Nil(t, result.Err)
+	assert.True(t, result.Visited("stateA"))
+	assert.False(t, result.Visited("stateC"))
+}
+
+func TestExecute_Failure_ResultHasError(t *testing.T) {
+	a := &StateImpl{
+		name: "stateA",
+		err:  errors.New("some error"),
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+
+	result, err := m.Execute(context.Background(), 1, a)
+	assert.Error(t, err)
+	assert.Equal(t, err, result.Err)
+	assert.Equal(t, []string{"stateA"}, result.Path)
+	assert.Equal(t, 1, result.Cargo)
+}
+
+func TestExecute_NoStartState_ResultNotNil(t *testing.T) {
+	m := NewStateMachine()
+
+	result, err := m.Execute(context.Background(), 1, nil)
+	assert.True(t, errors.Is(err, ErrNoStartState))
+	assert.NotNil(t, result)
+	assert.Len(t, result.Path, 0)
+}
+
+func TestResult_Trace_StableFormat(t *testing.T) {
+	result := &Result{Path: []string{"stateA", "stateB", "stateC"}}
+	assert.Equal(t, "-> stateA\nstateA -> stateB\nstateB -> stateC\nend\n", result.Trace())
+
+	result = &Result{Path: []string{"stateA"}, Err: errors.New("some error")}
+	assert.Equal(t, "-> stateA\nfailed: some error\n", result.Trace())
+}
+
+func TestResult_PathsOfConcurrentRuns_NotShared(t *testing.T) {
+	m := NewStateMachine()
+	s := NewFuncState("loop", func(cargo interface{}) (State, interface{}, error) {
+		if n := cargo.(int); n > 1 {
+			next, _ := m.StateByName("loop")
+			return next, n - 1, nil
+		}
+		return nil, 0, nil
+	})
+	m.AddState(s)
+
+	results := make(chan *Result)
+	for i := 1; i <= 20; i++ {
+		go func(n int) {
+			result, _ := m.Execute(context.Background(), n, s)
+			results <- result
+		}(i)
+	}
+	lengths := make(map[int]bool)
+	for i := 0; i < 20; i++ {
+		result := <-results
+		for _, name := range result.Path {
+			assert.Equal(t, "loop", name)
+		}
+		lengths[len(result.Path)] = true
+	}
+	assert.Len(t, lengths, 20) // every run kept its own path
+}
@@ -0,0 +1,108 @@
+package gust
+
+import "errors"
+
+// RetryableError when implemented by an error tells whether the failure is
+// transient and the state may be retried. Use Retryable and Fatal to mark
+// errors without declaring a type.
+type RetryableError interface {
+	error
+	Retryable() bool
+}
+
+// Retryable marks err as transient
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{err: err, retryable: true}
+}
+
+// Fatal marks err as permanent, it's never retried
+func Fatal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{err: err, retryable: false}
+}
+
+// IsRetryable reports whether err, or any error it wraps, is a RetryableError
+// saying it's transient. Errors not classified either way aren't retryable.
+func IsRetryable(err error) bool {
+	var re RetryableError
+	return errors.As(err, &re) && re.Retryable()
+}
+
+// IsFatal reports whether err, or any error it wraps, is a RetryableError
+// saying it's permanent
+func IsFatal(err error) bool {
+	var re RetryableError
+	return errors.As(err, &re) && !re.Retryable()
+}
+
+type classifiedError struct {
+	err       error
+	retryable bool
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+func (e *classifiedError) Retryable() bool {
+	return e.retryable
+}
+
+// RetryPolicy tells how a failing state is retried. The state is executed
+// again with the same cargo.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of executions including the first one,
+	// 1 or less means no retry
+	MaxAttempts int
+
+	// ShouldRetry decides whether an error is worth retrying. If nil, only
+	// errors marked retryable (see IsRetryable) are retried.
+	ShouldRetry func(err error) bool
+
+	// Backoff is how long to wait before each retry, no wait if nil
+	Backoff Backoff
+}
+
+func (p RetryPolicy) shouldRetry(err error) bool {
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(err)
+	}
+	return IsRetryable(err)
+}
+
+// SetRetryPolicy sets the retry policy of a state. Fatal errors are never
+// retried regardless of the policy.
+func (sm *StateMachine) SetRetryPolicy(state State, p RetryPolicy) {
+	if sm.retryPolicies == nil {
+		sm.retryPolicies = make(map[stateKey]RetryPolicy)
+	}
+	sm.retryPolicies[keyOf(state)] = p
+}
+
+// execWithRetry executes the state, retrying according to its policy
+func (sm *StateMachine) execWithRetry(r *run, state State, cargo interface{}) (State, interface{}, error) {
+	p, ok := sm.retryPolicies[keyOf(state)]
+	for attempt := 1; ; attempt++ {
+		r.attempt = attempt
+		nextState, nextCargo, err := sm.exec(r, state, cargo)
+		if err == nil || !ok || attempt >= p.MaxAttempts || IsFatal(err) || !p.shouldRetry(err) {
+			return nextState, nextCargo, err
+		}
+		if p.Backoff != nil && !sm.sleep(r.ctx, p.Backoff.Delay(attempt)) {
+			return nextState, nextCargo, err
+		}
+		if r.ctx.Err() != nil {
+			return nextState, nextCargo, err
+		}
+		r.retries++
+	}
+}
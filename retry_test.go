@@ -0,0 +1,107 @@
+package gust
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type FlakyState struct { // interface State
+	errs  []error // returned in order, then succeeds
+	calls int
+}
+
+func (s *FlakyState) Exec(cargo interface{}) (State, interface{}, error) {
+	s.calls++
+	if s.calls <= len(s.errs) {
+		return nil, nil, s.errs[s.calls-1]
+	}
+	return nil, cargo, nil
+}
+
+func TestRetryable_Classification(t *testing.T) {
+	base := errors.New("timeout")
+
+	assert.True(t, IsRetryable(Retryable(base)))
+	assert.False(t, IsFatal(Retryable(base)))
+	assert.True(t, IsFatal(Fatal(base)))
+	assert.False(t, IsRetryable(Fatal(base)))
+	assert.False(t, IsRetryable(base))
+	assert.False(t, IsFatal(base))
+
+	wrapped := fmt.Errorf("calling api: %w", Retryable(base))
+	assert.True(t, IsRetryable(wrapped))
+	assert.True(t, errors.Is(wrapped, base))
+	assert.Equal(t, "calling api: timeout", wrapped.Error())
+
+	assert.Nil(t, Retryable(nil))
+	assert.Nil(t, Fatal(nil))
+}
+
+func TestRetry_RetryableErrors_RetriedUntilSuccess(t *testing.T) {
+	a := &FlakyState{
+		errs: []error{Retryable(errors.New("e1")), Retryable(errors.New("e2"))},
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.SetRetryPolicy(a, RetryPolicy{MaxAttempts: 3})
+
+	err := m.Run(nil, a)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, a.calls)
+}
+
+func TestRetry_AttemptsExhausted_ReturnsLastError(t *testing.T) {
+	last := Retryable(errors.New("e2"))
+	a := &FlakyState{
+		errs: []error{Retryable(errors.New("e1")), last, Retryable(errors.New("e3"))},
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.SetRetryPolicy(a, RetryPolicy{MaxAttempts: 2})
+
+	err := m.Run(nil, a)
+	assert.True(t, errors.Is(err, last))
+	assert.Equal(t, 2, a.calls)
+}
+
+func TestRetry_FatalOrUnclassifiedError_NotRetried(t *testing.T) {
+	fatal := &FlakyState{
+		errs: []error{Fatal(errors.New("bad input"))},
+	}
+	plain := &FlakyState{
+		errs: []error{errors.New("plain")},
+	}
+
+	m := NewStateMachine()
+	m.AddState(fatal)
+	m.AddState(plain)
+	m.SetRetryPolicy(fatal, RetryPolicy{MaxAttempts: 3, ShouldRetry: func(err error) bool { return true }})
+	m.SetRetryPolicy(plain, RetryPolicy{MaxAttempts: 3})
+
+	assert.Error(t, m.Run(nil, fatal))
+	assert.Equal(t, 1, fatal.calls)
+
+	assert.Error(t, m.Run(nil, plain))
+	assert.Equal(t, 1, plain.calls)
+}
+
+func TestRetry_CustomShouldRetry_RetriesPlainErrors(t *testing.T) {
+	a := &FlakyState{
+		errs: []error{errors.New("plain")},
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.SetRetryPolicy(a, RetryPolicy{
+		MaxAttempts: 2,
+		ShouldRetry: func(err error) bool { return !IsFatal(err) },
+	})
+
+	assert.Nil(t, m.Run(nil, a))
+	assert.Equal(t, 2, a.calls)
+}
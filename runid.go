@@ -0,0 +1,56 @@
+package gust
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// RunIDGenerator makes up the ID of a run, it must return a different ID
+// every time and be safe to call concurrently
+type RunIDGenerator func() string
+
+// SetRunIDGenerator sets how run IDs are made up, e.g. UUIDv7 or a ULID
+// library. Run IDs are given to observers, in Result and in events. By
+// default runs are numbered from 1, which is unique within the machine only.
+// nil restores the default.
+func (sm *StateMachine) SetRunIDGenerator(gen RunIDGenerator) {
+	sm.runIDs = gen
+}
+
+// WithRunIDGenerator sets how run IDs are made up, like SetRunIDGenerator
+func WithRunIDGenerator(gen RunIDGenerator) Option {
+	return func(sm *StateMachine) {
+		sm.SetRunIDGenerator(gen)
+	}
+}
+
+// RunID returns the ID of the run, the ctx must be the one given to
+// ExecContext. ok is false if ctx doesn't belong to a run.
+func RunID(ctx context.Context) (id string, ok bool) {
+	r, ok := ctx.Value(runKey{}).(*run)
+	if !ok {
+		return "", false
+	}
+	return r.id, true
+}
+
+// UUIDv7 is a RunIDGenerator of version 7 UUIDs, as in RFC 9562, which sort
+// by the time they were made up
+func UUIDv7() string {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(fmt.Sprintf("gust: reading random run ID: %v", err))
+	}
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+	copy(b[:6], ms[2:])
+	b[6] = 0x70 | b[6]&0x0f // version
+	b[8] = 0x80 | b[8]&0x3f // variant
+
+	s := hex.EncodeToString(b[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
@@ -0,0 +1,78 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runIDState remembers the ID of the run it executed in
+type runIDState struct {
+	seen string
+}
+
+func (s *runIDState) Exec(cargo interface{}) (State, interface{}, error) {
+	return nil, cargo, nil
+}
+
+func (s *runIDState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	s.seen, _ = RunID(ctx)
+	return nil, cargo, nil
+}
+
+func (s *runIDState) Name() string {
+	return "a"
+}
+
+func TestRunID_Sequential(t *testing.T) {
+	m := NewStateMachine()
+	a := &StateImpl{name: "a"}
+	m.AddStates(a)
+
+	first, _ := m.Execute(context.Background(), nil, a)
+	second, _ := m.Execute(context.Background(), nil, a)
+
+	assert.Equal(t, "1", first.RunID)
+	assert.Equal(t, "2", second.RunID)
+}
+
+func TestSetRunIDGenerator(t *testing.T) {
+	n := 0
+	m := NewStateMachine(WithRunIDGenerator(func() string {
+		n++
+		return fmt.Sprintf("order-%d", n)
+	}))
+	a := &runIDState{}
+	m.AddStates(a)
+	o := &statusObserver{}
+	m.RegisterObservers(o)
+	log := NewMemoryEventLog()
+
+	result, err := m.EventSourcedRun(context.Background(), log, nil, a, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "order-1", result.RunID)
+	assert.Equal(t, "order-1", a.seen)
+	assert.Equal(t, "order-1", o.statuses[0].RunID)
+	events, _ := log.Events()
+	for _, e := range events {
+		assert.Equal(t, "order-1", e.RunID)
+	}
+
+	m.SetRunIDGenerator(nil)
+	result, _ = m.Execute(context.Background(), nil, a)
+	assert.Equal(t, "2", result.RunID)
+
+	_, ok := RunID(context.Background())
+	assert.False(t, ok)
+}
+
+func TestUUIDv7(t *testing.T) {
+	id := UUIDv7()
+
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), id)
+	assert.NotEqual(t, id, UUIDv7())
+}
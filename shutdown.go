@@ -0,0 +1,108 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+)
+
+// drainKey carries the drainer of the runs started with the context
+type drainKey struct{}
+
+// drainer stops runs before the next state they enter once ch is closed, see
+// Manager.Shutdown and Pool.Shutdown
+type drainer struct {
+	ch chan struct{}
+	// save if not nil persists a stopped run, while it still holds its lock
+	save func(r *run, token *ResumeToken)
+}
+
+// drained returns an *AbortedError whose reason is ErrShutdown once the run is
+// draining, the run resuming in the state it's about to enter
+func (r *run) drained(state State, cargo interface{}) error {
+	if r.drain == nil {
+		return nil
+	}
+	select {
+	case <-r.drain.ch:
+	default:
+		return nil
+	}
+
+	token := r.resumeToken(state, cargo)
+	if r.drain.save != nil {
+		r.drain.save(r, token)
+	}
+	return &AbortedError{Reason: ErrShutdown, State: r.sm.StateName(state), Token: token}
+}
+
+// Shutdown stops the manager so the process can exit without losing its
+// instances: it stops starting new ones, Start failing with ErrShutdown, lets
+// the running ones finish the state they're executing, and stops them before
+// the next. Stopped instances end with an *AbortedError whose reason is
+// ErrShutdown, and if the manager has a store their snapshot is saved in it
+// before their lock is released, for the runs to be reclaimed by another
+// node, or this one once restarted. Without a store the error's ResumeToken
+// can be persisted with Checkpoint. Supervised instances aren't restarted. If
+// ctx is done before all instances stopped, the remaining ones are cancelled,
+// left to be reclaimed from the snapshot taken before the state they were
+// executing, and ctx.Err() is returned. Otherwise it returns the first error
+// saving a snapshot.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.lock.Lock()
+	if !m.shutdown {
+		m.shutdown = true
+		close(m.drain)
+	}
+	m.lock.Unlock()
+
+	var first error
+	for _, inst := range m.List() {
+		if result, err := inst.Wait(ctx); result == nil {
+			m.stopRunning()
+			return err
+		}
+		inst.lock.Lock()
+		err := inst.drainErr
+		inst.lock.Unlock()
+		if err != nil && first == nil {
+			first = fmt.Errorf("saving instance %s: %w", inst.ID, err)
+		}
+	}
+	return first
+}
+
+// shuttingDown returns whether Shutdown was called
+func (m *Manager) shuttingDown() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.shutdown
+}
+
+// stopRunning cancels the running instances, leaving their snapshots to be
+// reclaimed
+func (m *Manager) stopRunning() {
+	for _, inst := range m.List() {
+		if inst.Status() == InstanceRunning {
+			inst.cancel()
+		}
+	}
+}
+
+// saveDrained saves the snapshot of the instance stopped by Shutdown in the
+// manager's store, replacing the one taken before the state it last executed
+func (i *Instance) saveDrained(r *run, token *ResumeToken) {
+	i.manager.lock.Lock()
+	store := i.manager.store
+	i.manager.lock.Unlock()
+	if store == nil {
+		return
+	}
+
+	snap, err := r.sm.Checkpoint(token)
+	if err == nil {
+		err = store.Save(r.ctx, i.Machine, snap)
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.drainErr = err
+}
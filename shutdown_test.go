@@ -0,0 +1,168 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newReleaseMachine has a build state waiting for release to be closed or the
+// run's context to be done, then a ship state
+func newReleaseMachine(release chan struct{}) (*StateMachine, State) {
+	ship := NewFuncState("ship", func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo.(string) + " shipped", nil
+	})
+	build := &ctxFuncState{name: "build", f: func(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+		select {
+		case <-release:
+			return ship, cargo.(string) + " built", nil
+		case <-ctx.Done():
+			return nil, cargo, ctx.Err()
+		}
+	}}
+	sm := NewStateMachine()
+	sm.AddStates(build, ship)
+	sm.SetLocker(NewMemoryLocker())
+	return sm, build
+}
+
+func TestManager_Shutdown_DrainsAndPersists(t *testing.T) {
+	store, release := newMemoryStore(), make(chan struct{})
+	sm, build := newReleaseMachine(release)
+	mgr := NewManager()
+	mgr.Register("release", sm, build)
+	mgr.SetStore(store)
+	mgr.Supervise("release", SupervisorPolicy{})
+
+	inst, _ := mgr.Start(context.Background(), "release", "v1")
+	waitForState(t, inst, "build")
+
+	shutdown := make(chan error)
+	go func() { shutdown <- mgr.Shutdown(context.Background()) }()
+	for !mgr.shuttingDown() {
+		time.Sleep(time.Millisecond)
+	}
+	_, err := mgr.Start(context.Background(), "release", "v2")
+	assert.True(t, errors.Is(err, ErrShutdown))
+
+	close(release)
+	assert.Nil(t, <-shutdown)
+	_, err = inst.Wait(context.Background())
+	assert.True(t, errors.Is(err, ErrShutdown))
+	assert.Equal(t, InstanceCancelled, inst.Status())
+	assert.Equal(t, 0, inst.Restarts())
+
+	stored, _ := store.List(context.Background())
+	if !assert.Len(t, stored, 1) {
+		return
+	}
+	assert.Equal(t, "ship", stored[0].Snapshot.State)
+	assert.Equal(t, []string{"build"}, stored[0].Snapshot.Path)
+
+	// another node picks the run up where it stopped
+	sm, build = newReleaseMachine(release)
+	other := NewManager()
+	other.Register("release", sm, build)
+	other.SetStore(store)
+	reclaimed, err := other.Reclaim(context.Background())
+	assert.Nil(t, err)
+	if !assert.Len(t, reclaimed, 1) {
+		return
+	}
+	result, err := reclaimed[0].Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "v1 built shipped", result.Cargo)
+	assert.Equal(t, 0, store.len())
+}
+
+func TestManager_Shutdown_DeadlineCancels(t *testing.T) {
+	store := newMemoryStore()
+	sm, build := newReleaseMachine(make(chan struct{}))
+	mgr := NewManager()
+	mgr.Register("release", sm, build)
+	mgr.SetStore(store)
+
+	inst, _ := mgr.Start(context.Background(), "release", "v1")
+	waitForState(t, inst, "build")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := mgr.Shutdown(ctx)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	inst.Wait(context.Background())
+	assert.Equal(t, InstanceCancelled, inst.Status())
+	stored, _ := store.List(context.Background())
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, "build", stored[0].Snapshot.State, "left to run the state again")
+	}
+}
+
+func TestManager_Shutdown_Reclaim(t *testing.T) {
+	store := newMemoryStore()
+	mgr := newNode(store, NewMemoryLocker())
+	assert.Nil(t, mgr.Shutdown(context.Background()))
+
+	reclaimed, err := mgr.Reclaim(context.Background())
+
+	assert.Nil(t, err)
+	assert.Len(t, reclaimed, 0)
+}
+
+func TestManager_Shutdown_ConcurrentReclaim(t *testing.T) {
+	store, locker, release := newMemoryStore(), NewMemoryLocker(), make(chan struct{})
+	node := func(release chan struct{}) (*Manager, *StateMachine) {
+		sm, build := newReleaseMachine(release)
+		sm.SetLocker(locker)
+		mgr := NewManager()
+		mgr.Register("release", sm, build)
+		mgr.SetStore(store)
+		return mgr, sm
+	}
+	a, smA := node(release)
+	released := make(chan struct{})
+	close(released)
+	b, smB := node(released)
+
+	inst, _ := a.Start(context.Background(), "release", "v1")
+	waitForState(t, inst, "build")
+
+	stop := make(chan struct{})
+	reclaiming := make(chan struct{})
+	go func() {
+		defer close(reclaiming)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				b.Reclaim(context.Background())
+			}
+		}
+	}()
+
+	shutdown := make(chan error)
+	go func() { shutdown <- a.Shutdown(context.Background()) }()
+	for !a.shuttingDown() {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	assert.Nil(t, <-shutdown)
+	close(stop)
+	<-reclaiming
+	b.Reclaim(context.Background())
+
+	results := make([]interface{}, 0)
+	for _, reclaimed := range b.List() {
+		result, err := reclaimed.Wait(context.Background())
+		assert.Nil(t, err)
+		results = append(results, result.Cargo)
+	}
+	assert.Equal(t, []interface{}{"v1 built shipped"}, results)
+	buildsA, _ := smA.Stats().State("build")
+	buildsB, _ := smB.Stats().State("build")
+	assert.Equal(t, 1, buildsA.Entered+buildsB.Entered, "build executed again")
+}
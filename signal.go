@@ -0,0 +1,103 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// signals is the mailbox of named signals delivered to a run
+type signals struct {
+	lock    *sync.Mutex
+	pending map[string][]interface{}
+	changed chan struct{} // closed and replaced whenever a signal arrives
+}
+
+type signalsKey struct{}
+
+func newSignals() *signals {
+	return &signals{
+		lock:    &sync.Mutex{},
+		pending: make(map[string][]interface{}),
+		changed: make(chan struct{}),
+	}
+}
+
+// withSignals gives ctx a mailbox unless it already has one
+func withSignals(ctx context.Context) (context.Context, *signals) {
+	if s, ok := ctx.Value(signalsKey{}).(*signals); ok {
+		return ctx, s
+	}
+	s := newSignals()
+	return context.WithValue(ctx, signalsKey{}, s), s
+}
+
+func (s *signals) deliver(name string, value interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pending[name] = append(s.pending[name], value)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// take dequeues a pending signal, or returns a channel closed once another one
+// arrives if there's none
+func (s *signals) take(name string) (value interface{}, ok bool, changed <-chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if queue := s.pending[name]; len(queue) > 0 {
+		s.pending[name] = queue[1:]
+		return queue[0], true, nil
+	}
+	return nil, false, s.changed
+}
+
+func (s *signals) receive(ctx context.Context, name string) (interface{}, error) {
+	for {
+		value, ok, changed := s.take(name)
+		if ok {
+			return value, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Signal delivers a named signal to the in-flight run with the given ID, to be
+// received with ReceiveSignal or a WaitState. Runs started by Run or Execute
+// are signalled this way, instances of a Manager with Manager.Signal. It fails
+// with ErrUnknownRun if the machine has no such run.
+func (sm *StateMachine) Signal(runID, name string, value interface{}) error {
+	sm.runsLock.RLock()
+	var target *signals
+	for r := range sm.runs {
+		if r.id == runID {
+			target = r.signals
+			break
+		}
+	}
+	sm.runsLock.RUnlock()
+
+	if target == nil {
+		return fmt.Errorf("%w %s", ErrUnknownRun, runID)
+	}
+	target.deliver(name, value)
+	return nil
+}
+
+// ReceiveSignal waits for a signal with the given name delivered to the run,
+// e.g. by StateMachine.Signal or Manager.Signal, and returns its value. Signals delivered before are
+// queued, each is received once. The ctx must be the one given to
+// ExecContext, it returns ctx.Err() once ctx is done and ErrNoRun if ctx
+// doesn't belong to a run.
+func ReceiveSignal(ctx context.Context, name string) (interface{}, error) {
+	s, ok := ctx.Value(signalsKey{}).(*signals)
+	if !ok {
+		return nil, ErrNoRun
+	}
+	return s.receive(ctx, name)
+}
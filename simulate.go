@@ -0,0 +1,97 @@
+package gust
+
+import "fmt"
+
+// defaultSimulationLimit bounds simulations of machines without MaxTransitions,
+// since nothing in a simulation breaks a loop of stubs
+const defaultSimulationLimit = 1000
+
+// Stubs stand in for the Exec of states during Simulate
+type Stubs map[State]ExecFunc
+
+// Simulate walks the machine from the start state without executing any
+// state, and returns the path a run with the given cargo would take. A state
+// with a stub has the stub called instead of Exec. A state without one takes
+// the guarded transition whose guard holds for the cargo if any, see
+// AddGuardedTransition, otherwise a random weighted transition if it has any,
+// see SetTransitionWeight, otherwise follows its only declared transition,
+// ends the run if it has none, and fails with ErrAmbiguousTransition if it has
+// several. The next states are validated as in Run, and the run may only end
+// in a terminal state, so the result has the outcome Run would give it.
+// Observers and hooks aren't notified.
+func (sm *StateMachine) Simulate(cargo interface{}, startState State, stubs Stubs) (*Result, error) {
+	if startState == nil {
+		return &Result{Cargo: cargo, Err: ErrNoStartState}, ErrNoStartState
+	}
+	if !sm.isRegistered(startState) {
+		err := fmt.Errorf("%w %v", ErrUnknownStartState, startState)
+		return &Result{Cargo: cargo, Err: err}, err
+	}
+
+	byKey := make(map[stateKey]ExecFunc, len(stubs))
+	for state, f := range stubs {
+		byKey[keyOf(state)] = f
+	}
+	limit := sm.MaxTransitions
+	if limit <= 0 {
+		limit = defaultSimulationLimit
+	}
+
+	r := &run{sm: sm}
+	fail := func(state State, err error) (*Result, error) {
+		err = newRunError(r, state, err)
+		return newResult(r, cargo, err), err
+	}
+
+	state := startState
+	for transitions := 0; ; transitions++ {
+		r.path.add(sm.StateName(state))
+
+		var nextState State
+		var nextCargo interface{}
+		if f, ok := byKey[keyOf(state)]; ok {
+			var err error
+			if nextState, nextCargo, err = f(cargo); err != nil {
+				return fail(state, err)
+			}
+		} else if guarded, err := sm.guardedNext(state, cargo); err != nil {
+			return fail(state, err)
+		} else if guarded != nil {
+			nextState, nextCargo = guarded, cargo
+		} else if weighted := sm.weightedNext(state, sm.random()); weighted != nil {
+			nextState, nextCargo = weighted, cargo
+		} else {
+			next := sm.AvailableTransitions(state)
+			if !sm.hasTransitions() || len(next) > 1 {
+				return fail(state, fmt.Errorf("%w from %s without a stub", ErrAmbiguousTransition, sm.StateName(state)))
+			}
+			if len(next) == 1 {
+				nextState = next[0]
+			}
+			nextCargo = cargo
+		}
+		cargo = nextCargo
+		if nextState == nil {
+			var err error
+			if nextState, err = sm.guardedNext(state, cargo); err != nil {
+				return fail(state, err)
+			}
+		}
+		if nextState == nil {
+			if err := sm.checkEnd(state); err != nil {
+				return fail(state, err)
+			}
+			r.state = state
+			return newResult(r, cargo, nil), nil
+		}
+
+		if !sm.isRegistered(nextState) {
+			return fail(state, fmt.Errorf("%w %v", ErrUnknownState, nextState))
+		} else if sm.hasTransitions() && !sm.CanTransition(state, nextState) {
+			return fail(state, fmt.Errorf("%w from %v to %v", ErrInvalidTransition, state, nextState))
+		} else if transitions >= limit {
+			return fail(state, fmt.Errorf("%w (%d)", ErrMaxTransitions, limit))
+		}
+		state = nextState
+	}
+}
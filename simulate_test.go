@@ -0,0 +1,119 @@
+package gust
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newDiamond() (*StateMachine, *StateImpl, *StateImpl, *StateImpl, *StateImpl) {
+	//     B
+	//   /   \
+	// A      D
+	//   \   /
+	//     C
+	d := &StateImpl{name: "stateD"}
+	c := &StateImpl{nextState: d, name: "stateC"}
+	b := &StateImpl{nextState: d, name: "stateB"}
+	a := &StateImpl{nextState: c, name: "stateA"}
+
+	m := NewStateMachine()
+	m.AddStates(a, b, c, d)
+	m.AddTransition(a, b)
+	m.AddTransition(a, c)
+	m.AddTransition(b, d)
+	m.AddTransition(c, d)
+	return m, a, b, c, d
+}
+
+func TestSimulate_StubDecidesBranch_NoExecCalled(t *testing.T) {
+	m, a, b, c, d := newDiamond()
+
+	result, err := m.Simulate(5, a, Stubs{
+		a: func(cargo interface{}) (State, interface{}, error) {
+			if cargo.(int) > 3 {
+				return c, cargo, nil
+			}
+			return b, cargo, nil
+		},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"stateA", "stateC", "stateD"}, result.Path)
+	assert.Equal(t, 5, result.Cargo)
+	assert.False(t, a.run)
+	assert.False(t, b.run)
+	assert.False(t, c.run)
+	assert.False(t, d.run)
+}
+
+func TestSimulate_BranchWithoutStub_Ambiguous(t *testing.T) {
+	m, a, _, _, _ := newDiamond()
+
+	result, err := m.Simulate(nil, a, nil)
+	assert.True(t, errors.Is(err, ErrAmbiguousTransition))
+	assert.Equal(t, []string{"stateA"}, result.Path)
+}
+
+func TestSimulate_StubGoesToUndeclared_ReturnsError(t *testing.T) {
+	m, a, _, _, d := newDiamond()
+
+	_, err := m.Simulate(nil, a, Stubs{
+		a: func(cargo interface{}) (State, interface{}, error) {
+			return d, cargo, nil
+		},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+}
+
+func TestSimulate_StubFails_ReturnsError(t *testing.T) {
+	m, a, _, _, _ := newDiamond()
+
+	someErr := errors.New("some error")
+	_, err := m.Simulate(nil, a, Stubs{
+		a: func(cargo interface{}) (State, interface{}, error) {
+			return nil, nil, someErr
+		},
+	})
+	assert.True(t, errors.Is(err, someErr))
+}
+
+func TestSimulate_Loop_StopsAtLimit(t *testing.T) {
+	a := &StateImpl{name: "stateA"}
+
+	m := NewStateMachine(WithMaxTransitions(5))
+	m.AddState(a)
+	m.AddTransition(a, a)
+
+	result, err := m.Simulate(nil, a, nil)
+	assert.True(t, errors.Is(err, ErrMaxTransitions))
+	assert.Len(t, result.Path, 6)
+}
+
+func TestSimulate_EndsAsRunWould(t *testing.T) {
+	m, a, b, c, d := newDiamond()
+	m.MarkOutcome(OutcomeFailure, d)
+	stubs := Stubs{
+		a: func(cargo interface{}) (State, interface{}, error) {
+			return b, cargo, nil
+		},
+	}
+
+	result, err := m.Simulate(nil, a, stubs)
+	assert.Nil(t, err)
+	assert.Equal(t, OutcomeFailure, result.Outcome)
+
+	// ending anywhere but a terminal state is a dead end
+	stubs[c] = func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo, nil
+	}
+	stubs[a] = func(cargo interface{}) (State, interface{}, error) {
+		return c, cargo, nil
+	}
+	result, err = m.Simulate(nil, a, stubs)
+	assert.True(t, errors.Is(err, ErrDeadEnd))
+	assert.Equal(t, []string{"stateA", "stateC"}, result.Path)
+}
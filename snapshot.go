@@ -0,0 +1,177 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Snapshot is where a run is, taken right before a state executes, so the run
+// can be persisted and resumed later with Resume, possibly by a newer version
+// of the machine
+type Snapshot struct {
+	Version string    `json:"version,omitempty"` // the machine's Version when taken
+	State   string    `json:"state"`             // the state about to execute
+	Cargo   []byte    `json:"cargo"`             // the cargo given to it, encoded with the machine's Codec
+	Path    []string  `json:"path,omitempty"`    // the states entered before it
+	Taken   time.Time `json:"taken"`
+
+	// Run and Steps keep the idempotency keys of the resumed run those of the
+	// run the snapshot was taken from, see IdempotencyKey
+	Run   string `json:"run,omitempty"`
+	Steps int    `json:"steps,omitempty"` // the number of states entered before it
+
+	// CorrelationID is the run's, see WithCorrelationID, the resumed run has it
+	// unless given another
+	CorrelationID string `json:"correlationId,omitempty"`
+}
+
+// Migration upgrades a snapshot taken by one version of the machine to the
+// next, e.g. renaming states or changing the shape of the cargo
+type Migration func(snap *Snapshot) error
+
+// migration is a Migration registered with AddMigration
+type migration struct {
+	from, to string
+	migrate  Migration
+}
+
+// WithVersion sets the machine's Version
+func WithVersion(version string) Option {
+	return func(sm *StateMachine) {
+		sm.Version = version
+	}
+}
+
+// AddMigration registers how to upgrade snapshots from one version of the
+// machine to another. Resume chains migrations from the snapshot's version up
+// to the machine's Version.
+func (sm *StateMachine) AddMigration(from, to string, m Migration) {
+	sm.migrations = append(sm.migrations, migration{from: from, to: to, migrate: m})
+}
+
+// RenameState is a Migration for a state that was renamed
+func RenameState(old, new string) Migration {
+	return func(snap *Snapshot) error {
+		if snap.State == old {
+			snap.State = new
+		}
+		for i, name := range snap.Path {
+			if name == old {
+				snap.Path[i] = new
+			}
+		}
+		return nil
+	}
+}
+
+// SnapshotRun is like Execute but calls save with a snapshot right before each
+// state executes, once per state even if retried. The cargo is encoded with
+// the machine's Codec. If save fails, so does the run. The run is locked before
+// its first snapshot if the machine has a Locker.
+func (sm *StateMachine) SnapshotRun(ctx context.Context, cargo interface{}, startState State, save func(snap *Snapshot) error) (*Result, error) {
+	saved := 0 // states entered when last saved, retries don't save again
+	return sm.executeWith(ctx, cargo, startState, func(r *run, state State, cargo interface{}) (State, interface{}, error) {
+		if r.path.entered == saved {
+			return sm.execState(r, state, cargo)
+		}
+		saved = r.path.entered
+		if err := sm.lockRun(r); err != nil {
+			return nil, nil, err
+		}
+		snap, err := sm.snapshot(r, state, cargo)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := save(snap); err != nil {
+			return nil, nil, fmt.Errorf("saving snapshot: %w", err)
+		}
+		return sm.execState(r, state, cargo)
+	})
+}
+
+// snapshot takes the snapshot of the run about to execute the state it entered
+func (sm *StateMachine) snapshot(r *run, state State, cargo interface{}) (*Snapshot, error) {
+	data, err := sm.codec.Marshal(sm.persisted(cargo))
+	if err != nil {
+		return nil, fmt.Errorf("snapshotting cargo: %w", err)
+	}
+	path := r.path.list()
+	return &Snapshot{
+		Version: sm.Version,
+		State:   sm.StateName(state),
+		Cargo:   data,
+		Path:    path[:len(path)-1],
+		Taken:   sm.clock.Now(),
+		Run:     r.idempotencyBase(),
+		Steps:   r.resumedSteps + r.path.entered - 1,
+
+		CorrelationID: r.correlation,
+	}, nil
+}
+
+// Migrate returns a copy of the snapshot upgraded to the machine's Version with
+// the migrations added with AddMigration. It fails with ErrNoMigration when
+// there's no way from the snapshot's version to the machine's.
+func (sm *StateMachine) Migrate(snap *Snapshot) (*Snapshot, error) {
+	s := *snap
+	s.Path = append([]string{}, snap.Path...)
+	s.Cargo = append([]byte{}, snap.Cargo...)
+
+	// each migration is used at most once, so a cycle can't loop forever
+	used := make([]bool, len(sm.migrations))
+	for s.Version != sm.Version {
+		found := false
+		for i, m := range sm.migrations {
+			if used[i] || m.from != s.Version {
+				continue
+			}
+			if err := m.migrate(&s); err != nil {
+				return nil, fmt.Errorf("migrating snapshot from version %s to %s: %w", m.from, m.to, err)
+			}
+			s.Version = m.to
+			used[i] = true
+			found = true
+			break
+		}
+		if !found {
+			return nil, fmt.Errorf("%w from version %q to %q", ErrNoMigration, s.Version, sm.Version)
+		}
+	}
+	return &s, nil
+}
+
+// Resume continues a run from a snapshot, migrated to the machine's Version
+// first. The state the snapshot was taken before is executed again. decode
+// turns the snapshot's cargo back into a value, if nil the machine's Codec
+// decodes it into an interface{}, which with JSONCodec gives generic JSON
+// values like Replay does. The result's path starts at the resumed state.
+func (sm *StateMachine) Resume(ctx context.Context, snap *Snapshot, decode func(data []byte) (interface{}, error)) (*Result, error) {
+	if decode == nil {
+		decode = sm.decodeCargo
+	}
+
+	snap, err := sm.Migrate(snap)
+	if err != nil {
+		return &Result{Err: err}, err
+	}
+	state, ok := sm.StateByName(snap.State)
+	if !ok {
+		err := fmt.Errorf("%w %s", ErrUnknownStartState, snap.State)
+		return &Result{Err: err}, err
+	}
+	cargo, err := decode(snap.Cargo)
+	if err != nil {
+		err = fmt.Errorf("decoding cargo: %w", err)
+		return &Result{Err: err}, err
+	}
+	ctx = correlated(ctx, snap.CorrelationID)
+	return sm.Execute(resuming(ctx, snap.Run, snap.Steps), cargo, state)
+}
+
+// decodeCargo decodes cargo into an interface{} with the machine's codec
+func (sm *StateMachine) decodeCargo(data []byte) (interface{}, error) {
+	var v interface{}
+	err := sm.codec.Unmarshal(data, &v)
+	return v, err
+}
@@ -0,0 +1,150 @@
+package gust
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRun_SnapshotBeforeEachState(t *testing.T) {
+	calls := 0
+	m, start := newOrderMachine(&calls)
+	m.Version = "2"
+
+	snaps := make([]*Snapshot, 0)
+	_, err := m.SnapshotRun(context.Background(), order{ID: "o1", Total: 10}, start, func(snap *Snapshot) error {
+		snaps = append(snaps, snap)
+		return nil
+	})
+	if !assert.Nil(t, err) || !assert.Len(t, snaps, 3) { // charge is retried but saved once
+		return
+	}
+
+	assert.Equal(t, "validate", snaps[0].State)
+	assert.Equal(t, []string{}, snaps[0].Path)
+	assert.Equal(t, "charge", snaps[1].State)
+	assert.Equal(t, "ship", snaps[2].State)
+	assert.Equal(t, []string{"validate", "charge"}, snaps[2].Path)
+	assert.JSONEq(t, `{"id":"o1","total":15}`, string(snaps[2].Cargo))
+	assert.Equal(t, "2", snaps[2].Version)
+}
+
+func TestSnapshotRun_SaveFails_RunFails(t *testing.T) {
+	calls := 0
+	m, start := newOrderMachine(&calls)
+
+	_, err := m.SnapshotRun(context.Background(), order{ID: "o1"}, start, func(snap *Snapshot) error {
+		return errors.New("disk full")
+	})
+	assert.EqualError(t, err, "state validate failed: saving snapshot: disk full (path: validate)")
+	assert.Equal(t, 0, calls)
+}
+
+func TestResume_FromSnapshot_ContinuesRun(t *testing.T) {
+	calls := 0
+	m, _ := newOrderMachine(&calls)
+
+	snap := &Snapshot{State: "charge", Cargo: json.RawMessage(`{"id":"o1","total":10}`), Path: []string{"validate"}}
+	result, err := m.Resume(context.Background(), snap, decodeOrder)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"charge", "ship"}, result.Path)
+	assert.Equal(t, order{ID: "o1", Total: 15}, result.Cargo)
+	assert.Equal(t, 3, calls) // charge twice, ship
+}
+
+func TestResume_OldVersion_Migrated(t *testing.T) {
+	calls := 0
+	m, _ := newOrderMachine(&calls)
+	m.Version = "3"
+
+	// version 1 called charge bill, version 2 renamed amount to total
+	m.AddMigration("2", "3", func(snap *Snapshot) error {
+		var v map[string]interface{}
+		if err := json.Unmarshal(snap.Cargo, &v); err != nil {
+			return err
+		}
+		v["total"] = v["amount"]
+		delete(v, "amount")
+		data, err := json.Marshal(v)
+		snap.Cargo = data
+		return err
+	})
+	m.AddMigration("1", "2", RenameState("bill", "charge"))
+
+	snap := &Snapshot{Version: "1", State: "bill", Cargo: json.RawMessage(`{"id":"o1","amount":10}`), Path: []string{"validate"}}
+	result, err := m.Resume(context.Background(), snap, decodeOrder)
+	assert.Nil(t, err)
+	assert.Equal(t, order{ID: "o1", Total: 15}, result.Cargo)
+
+	assert.Equal(t, "bill", snap.State) // the given snapshot is left alone
+	migrated, err := m.Migrate(snap)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "3", migrated.Version)
+		assert.Equal(t, "charge", migrated.State)
+		assert.JSONEq(t, `{"id":"o1","total":10}`, string(migrated.Cargo))
+	}
+}
+
+func TestResume_NoMigration_Error(t *testing.T) {
+	calls := 0
+	m, _ := newOrderMachine(&calls)
+	m.Version = "2"
+	m.AddMigration("0", "1", RenameState("a", "b"))
+
+	_, err := m.Resume(context.Background(), &Snapshot{Version: "1", State: "charge"}, decodeOrder)
+	assert.True(t, errors.Is(err, ErrNoMigration))
+	assert.EqualError(t, err, `no migration from version "1" to "2"`)
+	assert.Equal(t, 0, calls)
+}
+
+func TestResume_UnknownState_Error(t *testing.T) {
+	calls := 0
+	m, _ := newOrderMachine(&calls)
+
+	_, err := m.Resume(context.Background(), &Snapshot{State: "bill", Cargo: json.RawMessage(`{}`)}, decodeOrder)
+	assert.True(t, errors.Is(err, ErrUnknownStartState))
+}
+
+func TestWithVersion_InDefinition(t *testing.T) {
+	m := NewStateMachine(WithVersion("7"))
+
+	assert.Equal(t, "7", m.Version)
+	assert.Equal(t, "7", m.Definition().Version)
+}
+
+// countStep is an unnamed state counting its executions
+type countStep struct {
+	next  State
+	calls int
+}
+
+func (s *countStep) Exec(cargo interface{}) (State, interface{}, error) {
+	s.calls++
+	return s.next, cargo, nil
+}
+
+func TestResume_UnnamedStatesOfOneType(t *testing.T) {
+	second := &countStep{}
+	first := &countStep{next: second}
+	m := NewStateMachine()
+	m.AddStates(first, second)
+
+	snaps := make([]*Snapshot, 0)
+	_, err := m.SnapshotRun(context.Background(), 1, first, func(snap *Snapshot) error {
+		snaps = append(snaps, snap)
+		return nil
+	})
+	if !assert.Nil(t, err) || !assert.Len(t, snaps, 2) {
+		return
+	}
+	assert.Equal(t, "*gust.countStep#2", snaps[1].State)
+
+	result, err := m.Resume(context.Background(), snaps[1], nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"*gust.countStep#2"}, result.Path)
+	assert.Equal(t, 1, first.calls)
+	assert.Equal(t, 2, second.calls)
+}
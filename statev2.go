@@ -0,0 +1,101 @@
+package gust
+
+import "context"
+
+// StateV2 is a state executed with the run's context and the event it was
+// entered on, deciding where the run goes next. Unlike State it can grow: new
+// information goes into StateEvent and Decision rather than into the signature.
+// Register it with the machine wrapped with NewV2State, and use AsV2 to call
+// states written against State the same way.
+type StateV2 interface {
+	Exec(ctx context.Context, event StateEvent, cargo interface{}) (Decision, error)
+}
+
+// StateEvent is how the run got to the state a StateV2 executes. Fields may be
+// added, don't compare events.
+type StateEvent struct {
+	RunID   string // see SetRunIDGenerator, empty outside of a run
+	Prior   string // name of the state the run came from, empty for the first state
+	Label   string // of the transition taken into the state, see AddLabeledTransition
+	Attempt int    // of the state, from 1, see SetRetryPolicy
+}
+
+// Decision is where a StateV2 has the run go next. Fields may be added, build
+// decisions with field names.
+type Decision struct {
+	Next  State       // nil to end the run, or let guards decide, see AddGuardedTransition
+	Cargo interface{} // given to Next
+}
+
+// V2State is a StateV2 made a State, so machines can run it, see NewV2State
+type V2State struct {
+	name  string
+	state StateV2
+}
+
+// NewV2State wraps a StateV2 in a State with the given name. The states it
+// decides to go to are States too, other V2States included.
+func NewV2State(name string, state StateV2) *V2State {
+	return &V2State{name: name, state: state}
+}
+
+// Name returns the state's name
+func (s *V2State) Name() string {
+	return s.name
+}
+
+// Exec executes the StateV2 outside of a run, with a background context and
+// an empty event
+func (s *V2State) Exec(cargo interface{}) (State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+// ExecContext executes the StateV2 with the run's context and event
+func (s *V2State) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	d, err := s.state.Exec(ctx, stateEventOf(ctx), cargo)
+	return d.Next, d.Cargo, err
+}
+
+// AsV2 returns the state as a StateV2: the StateV2 itself if it's a V2State,
+// otherwise the state executed as it would be by a machine, with ExecContext
+// if it's a ContextState. The event is ignored.
+func AsV2(state State) StateV2 {
+	if s, ok := state.(*V2State); ok {
+		return s.state
+	}
+	return v1State{state}
+}
+
+// v1State is a State made a StateV2
+type v1State struct {
+	state State
+}
+
+func (s v1State) Exec(ctx context.Context, event StateEvent, cargo interface{}) (Decision, error) {
+	var next State
+	var err error
+	if cs, ok := s.state.(ContextState); ok {
+		next, cargo, err = cs.ExecContext(ctx, cargo)
+	} else {
+		next, cargo, err = s.state.Exec(cargo)
+	}
+	return Decision{Next: next, Cargo: cargo}, err
+}
+
+// stateEventOf returns the event the state the run of ctx executes was entered on
+func stateEventOf(ctx context.Context) StateEvent {
+	r, ok := ctx.Value(runKey{}).(*run)
+	if !ok {
+		return StateEvent{}
+	}
+	r.sm.runsLock.RLock()
+	prior, state := r.prior, r.state
+	r.sm.runsLock.RUnlock()
+
+	e := StateEvent{RunID: r.id, Attempt: r.attempt}
+	if prior != nil {
+		e.Prior = r.sm.StateName(prior)
+		e.Label = r.sm.TransitionLabel(prior, state)
+	}
+	return e
+}
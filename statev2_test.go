@@ -0,0 +1,74 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingV2 records the events it's executed on and goes to next
+type recordingV2 struct {
+	next   State
+	events []StateEvent
+	err    error
+}
+
+func (s *recordingV2) Exec(ctx context.Context, event StateEvent, cargo interface{}) (Decision, error) {
+	s.events = append(s.events, event)
+	return Decision{Next: s.next, Cargo: cargo.(int) + 1}, s.err
+}
+
+func TestNewV2State_RunWithEvents(t *testing.T) {
+	m := NewStateMachine()
+	bv2 := &recordingV2{}
+	b := NewV2State("b", bv2)
+	av2 := &recordingV2{next: b}
+	a := NewV2State("a", av2)
+	m.AddStates(a, b)
+	m.AddLabeledTransition(a, b, "go")
+
+	result, err := m.Execute(context.Background(), 1, a)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, result.Path)
+	assert.Equal(t, 3, result.Cargo)
+	assert.Equal(t, []StateEvent{{RunID: "1", Attempt: 1}}, av2.events)
+	assert.Equal(t, []StateEvent{{RunID: "1", Prior: "a", Label: "go", Attempt: 1}}, bv2.events)
+}
+
+func TestNewV2State_Retried_Attempt(t *testing.T) {
+	m := NewStateMachine()
+	v2 := &recordingV2{err: errors.New("flaky")}
+	s := NewV2State("s", v2)
+	m.AddState(s)
+	m.SetRetryPolicy(s, RetryPolicy{MaxAttempts: 2, ShouldRetry: func(error) bool { return true }})
+
+	_, err := m.Execute(context.Background(), 1, s)
+	assert.NotNil(t, err)
+	if assert.Len(t, v2.events, 2) {
+		assert.Equal(t, 2, v2.events[1].Attempt)
+	}
+}
+
+func TestNewV2State_OutsideRun(t *testing.T) {
+	v2 := &recordingV2{}
+	next, cargo, err := NewV2State("s", v2).Exec(1)
+	assert.Nil(t, next)
+	assert.Equal(t, 2, cargo)
+	assert.Nil(t, err)
+	assert.Equal(t, []StateEvent{{}}, v2.events)
+}
+
+func TestAsV2_WrapsState(t *testing.T) {
+	next := &StateImpl{name: "next"}
+	s := &StateImpl{name: "s", nextState: next, cargo: "out"}
+
+	d, err := AsV2(s).Exec(context.Background(), StateEvent{}, "in")
+	assert.Nil(t, err)
+	assert.Equal(t, Decision{Next: next, Cargo: "out"}, d)
+	assert.Equal(t, "in", s.cargoReceived)
+
+	v2 := &recordingV2{}
+	assert.Equal(t, v2, AsV2(NewV2State("v2", v2)))
+}
@@ -0,0 +1,200 @@
+package gust
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Stats are the machine's cumulative counters since it was made or they were
+// reset with ResetStats, for in-process insight without a metrics backend
+type Stats struct {
+	Since time.Time // when counting started
+
+	RunsStarted   int
+	RunsCompleted int // ended without error
+	RunsFailed    int // failed, aborted runs and reloaded runs excepted
+	RunsAborted   int // see Abort
+	RunsReloaded  int // moved to a reloaded machine, see Manager.Reload
+	Transitions   int
+	RunLatency    Histogram // of the runs that ended
+
+	States []StateStats // in the order first entered
+}
+
+// State returns the stats of the named state, false if no run entered it
+func (s *Stats) State(name string) (StateStats, bool) {
+	for _, st := range s.States {
+		if st.State == name {
+			return st, true
+		}
+	}
+	return StateStats{}, false
+}
+
+// StateStats are the counters of a state over all runs
+type StateStats struct {
+	State   string // by name, by type if unnamed
+	Entered int
+	Failed  int // executions failing once retries were exhausted
+	Retries int
+	Latency Histogram // of the executions, retries and their backoff included
+}
+
+// Histogram is a latency distribution in buckets of exponentially growing
+// bounds, see LatencyBounds
+type Histogram struct {
+	Count   int
+	Sum     time.Duration
+	Min     time.Duration
+	Max     time.Duration
+	Buckets []int // Buckets[i] counts latencies up to LatencyBounds[i], the last one those above them all
+}
+
+// LatencyBounds are the upper bounds of the Histogram buckets
+var LatencyBounds = []time.Duration{
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second,
+	10 * time.Second, 30 * time.Second, time.Minute,
+}
+
+// Mean is the average latency, 0 without any
+func (h *Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile estimates the latency below which the fraction q of latencies fall,
+// e.g. 0.99, as the upper bound of the bucket it's in, capped by Max
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q * float64(h.Count)))
+	if rank < 1 {
+		rank = 1
+	}
+	seen := 0
+	for i, n := range h.Buckets {
+		if seen += n; seen < rank {
+			continue
+		}
+		if i < len(LatencyBounds) && LatencyBounds[i] < h.Max {
+			return LatencyBounds[i]
+		}
+		break
+	}
+	return h.Max
+}
+
+func (h *Histogram) add(d time.Duration) {
+	if h.Buckets == nil {
+		h.Buckets = make([]int, len(LatencyBounds)+1)
+	}
+	if h.Count == 0 || d < h.Min {
+		h.Min = d
+	}
+	if d > h.Max {
+		h.Max = d
+	}
+	h.Count++
+	h.Sum += d
+	i := 0
+	for i < len(LatencyBounds) && d > LatencyBounds[i] {
+		i++
+	}
+	h.Buckets[i]++
+}
+
+func (h Histogram) clone() Histogram {
+	h.Buckets = append([]int(nil), h.Buckets...)
+	return h
+}
+
+// stats accumulates the machine's Stats
+type stats struct {
+	lock   *sync.Mutex
+	stats  Stats
+	states map[string]int // index in stats.States by name
+}
+
+func newStats(since time.Time) *stats {
+	return &stats{lock: &sync.Mutex{}, stats: Stats{Since: since}, states: make(map[string]int)}
+}
+
+// Stats returns the machine's counters since it was made or ResetStats was
+// last called
+func (sm *StateMachine) Stats() *Stats {
+	s := sm.stats
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	snapshot := s.stats
+	snapshot.RunLatency = s.stats.RunLatency.clone()
+	snapshot.States = make([]StateStats, len(s.stats.States))
+	for i, st := range s.stats.States {
+		st.Latency = st.Latency.clone()
+		snapshot.States[i] = st
+	}
+	return &snapshot
+}
+
+// ResetStats zeroes the counters returned by Stats
+func (sm *StateMachine) ResetStats() {
+	s := sm.stats
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stats = Stats{Since: sm.clock.Now()}
+	s.states = make(map[string]int)
+}
+
+func (s *stats) runStarted() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stats.RunsStarted++
+}
+
+func (s *stats) runEnded(duration time.Duration, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch {
+	case err == nil:
+		s.stats.RunsCompleted++
+	case errors.Is(err, ErrAborted):
+		s.stats.RunsAborted++
+	case errors.Is(err, ErrReloaded):
+		s.stats.RunsReloaded++
+	default:
+		s.stats.RunsFailed++
+	}
+	s.stats.RunLatency.add(duration)
+}
+
+func (s *stats) stateExecuted(name string, latency time.Duration, retries int, failed bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	i, ok := s.states[name]
+	if !ok {
+		i = len(s.stats.States)
+		s.states[name] = i
+		s.stats.States = append(s.stats.States, StateStats{State: name})
+	}
+	st := &s.stats.States[i]
+	st.Entered++
+	st.Retries += retries
+	if failed {
+		st.Failed++
+	}
+	st.Latency.add(latency)
+}
+
+func (s *stats) transitionTaken() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stats.Transitions++
+}
@@ -0,0 +1,109 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats_CountsRunsAndStates(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := NewStateMachine(WithClock(steppedClock{now: &now}))
+	var charge, ship State
+	ship = NewFuncState("ship", func(cargo interface{}) (State, interface{}, error) {
+		now = now.Add(2 * time.Second)
+		return nil, cargo, nil
+	})
+	charge = NewFuncState("charge", func(cargo interface{}) (State, interface{}, error) {
+		now = now.Add(10 * time.Millisecond)
+		if cargo == "declined" {
+			return nil, cargo, errors.New("card declined")
+		}
+		return ship, cargo, nil
+	})
+	m.AddStates(charge, ship)
+
+	m.Execute(context.Background(), "o1", charge)
+	m.Execute(context.Background(), "declined", charge)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m.Execute(ctx, "o2", charge)
+
+	stats := m.Stats()
+	assert.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), stats.Since)
+	assert.Equal(t, 3, stats.RunsStarted)
+	assert.Equal(t, 1, stats.RunsCompleted)
+	assert.Equal(t, 1, stats.RunsFailed)
+	assert.Equal(t, 1, stats.RunsAborted)
+	assert.Equal(t, 1, stats.Transitions)
+	assert.Equal(t, 3, stats.RunLatency.Count)
+
+	st, ok := stats.State("charge")
+	assert.True(t, ok)
+	assert.Equal(t, 2, st.Entered)
+	assert.Equal(t, 1, st.Failed)
+	assert.Equal(t, 2, st.Latency.Count)
+	assert.Equal(t, 10*time.Millisecond, st.Latency.Mean())
+	assert.Equal(t, 10*time.Millisecond, st.Latency.Quantile(0.99))
+	st, _ = stats.State("ship")
+	assert.Equal(t, 2*time.Second, st.Latency.Max)
+	assert.Equal(t, 1, st.Latency.Buckets[10])
+
+	m.ResetStats()
+	stats = m.Stats()
+	assert.Equal(t, now, stats.Since)
+	assert.Equal(t, 0, stats.RunsStarted)
+	assert.Empty(t, stats.States)
+}
+
+func TestStats_Snapshot(t *testing.T) {
+	m := NewStateMachine()
+	s := &StateImpl{name: "s"}
+	m.AddStates(s)
+	m.Execute(context.Background(), nil, s)
+
+	stats := m.Stats()
+	m.Execute(context.Background(), nil, s)
+
+	assert.Equal(t, 1, stats.States[0].Entered)
+	assert.Equal(t, 1, stats.States[0].Latency.Count)
+}
+
+func TestHistogram_Quantile(t *testing.T) {
+	h := Histogram{}
+	assert.Equal(t, time.Duration(0), h.Quantile(0.5))
+	for i := 0; i < 90; i++ {
+		h.add(3 * time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		h.add(400 * time.Millisecond)
+	}
+	h.add(2 * time.Minute)
+
+	assert.Equal(t, 5*time.Millisecond, h.Quantile(0.5))
+	assert.Equal(t, 500*time.Millisecond, h.Quantile(0.95))
+	assert.Equal(t, 2*time.Minute, h.Quantile(1))
+	assert.Equal(t, 3*time.Millisecond, h.Min)
+}
+
+func TestStats_ReloadedRunNotFailed(t *testing.T) {
+	old, start := newReviewMachine(false)
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("review", old, start))
+	inst, _ := mgr.Start(context.Background(), "review", 1)
+	waitForState(t, inst, "submit")
+
+	next, _ := newReviewMachine(true)
+	assert.Nil(t, mgr.Reload("review", next, archivingDefinition(), MigrateRunning))
+	assert.Nil(t, mgr.Signal(inst.ID, "go", nil))
+	inst.Wait(context.Background())
+
+	stats := old.Stats()
+	assert.Equal(t, 0, stats.RunsFailed)
+	assert.Equal(t, 1, stats.RunsReloaded)
+	review, _ := stats.State("review")
+	assert.Equal(t, 0, review.Failed)
+}
@@ -0,0 +1,37 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SetStore makes the machine's runs save a snapshot in the store right before
+// each state they execute, as with SnapshotRun, under the machine's Name. The
+// snapshot is deleted once the run finishes, unless it was aborted, so runs
+// interrupted by a crash or a deploy can be resumed with Resume, or reclaimed
+// by a Manager sharing the store that registered the machine under its Name.
+// Execute returns the error deleting the snapshot of a run that otherwise
+// succeeded. Runs started by the states, and the instances of a Manager, which
+// saves them in its own store, see Manager.SetStore, aren't saved.
+func (sm *StateMachine) SetStore(store SnapshotStore) {
+	sm.store = store
+}
+
+// executeStored executes a run saving its snapshots in the machine's store
+func (sm *StateMachine) executeStored(ctx context.Context, cargo interface{}, startState State) (*Result, error) {
+	store := sm.store
+	run := ""
+	result, err := sm.SnapshotRun(ctx, cargo, startState, func(snap *Snapshot) error {
+		run = snap.Run
+		return store.Save(ctx, sm.Name, snap)
+	})
+	if run == "" || errors.Is(err, ErrAborted) {
+		return result, err
+	}
+	if derr := store.Delete(context.Background(), run); derr != nil && err == nil {
+		err = fmt.Errorf("deleting snapshot of run %s: %w", run, derr)
+		result.Err = err
+	}
+	return result, err
+}
@@ -0,0 +1,122 @@
+package gust
+
+import (
+	"context"
+	"sync"
+)
+
+// Stream is cargo delivered item by item, so pipeline-style machines don't
+// have to hold a whole dataset in one cargo value. A state returns a Stream
+// as the cargo for the next state, which consumes the items while they're
+// still being produced:
+//
+//	func (s *read) ExecContext(ctx context.Context, cargo interface{}) (gust.State, interface{}, error) {
+//		return s.next, gust.NewStream(ctx, 100, func(ctx context.Context, emit func(interface{}) error) error {
+//			for rows.Next() {
+//				...
+//				if err := emit(row); err != nil {
+//					return err
+//				}
+//			}
+//			return rows.Err()
+//		}), nil
+//	}
+//
+// Producing stops when ctx, normally the run's, is done.
+type Stream struct {
+	items chan interface{}
+	done  chan struct{}
+
+	lock *sync.Mutex
+	err  error
+}
+
+// NewStream starts produce in its own goroutine. emit blocks while buffer
+// items wait to be consumed, and fails once ctx is done.
+func NewStream(ctx context.Context, buffer int, produce func(ctx context.Context, emit func(item interface{}) error) error) *Stream {
+	s := &Stream{
+		items: make(chan interface{}, buffer),
+		done:  make(chan struct{}),
+		lock:  &sync.Mutex{},
+	}
+	emit := func(item interface{}) error {
+		select {
+		case s.items <- item:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	go func() {
+		err := produce(ctx, emit)
+		s.lock.Lock()
+		s.err = err
+		s.lock.Unlock()
+		close(s.items)
+		close(s.done)
+	}()
+	return s
+}
+
+// StreamOf returns a stream of the given items
+func StreamOf(items ...interface{}) *Stream {
+	s := &Stream{
+		items: make(chan interface{}, len(items)),
+		done:  make(chan struct{}),
+		lock:  &sync.Mutex{},
+	}
+	for _, item := range items {
+		s.items <- item
+	}
+	close(s.items)
+	close(s.done)
+	return s
+}
+
+// Next returns the next item, ok is false once the stream ended or ctx is
+// done. Err tells why the stream ended.
+func (s *Stream) Next(ctx context.Context) (item interface{}, ok bool) {
+	select {
+	case item, ok = <-s.items:
+		return item, ok
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// Err returns the error the producer returned, once the stream ended
+func (s *Stream) Err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.err
+}
+
+// Each calls f with every item until the stream ends, f fails or ctx is done.
+// It returns the first error among f's, ctx's and the producer's.
+func (s *Stream) Each(ctx context.Context, f func(item interface{}) error) error {
+	for {
+		item, ok := s.Next(ctx)
+		if !ok {
+			break
+		}
+		if err := f(item); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	<-s.done
+	return s.Err()
+}
+
+// Collect reads the rest of the stream into a slice, see Each
+func (s *Stream) Collect(ctx context.Context) ([]interface{}, error) {
+	items := make([]interface{}, 0)
+	err := s.Each(ctx, func(item interface{}) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
@@ -0,0 +1,90 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ctxFuncState is a named state executing a function with the run's context
+type ctxFuncState struct {
+	name string
+	f    func(ctx context.Context, cargo interface{}) (State, interface{}, error)
+}
+
+func (s *ctxFuncState) Exec(cargo interface{}) (State, interface{}, error) {
+	return s.f(context.Background(), cargo)
+}
+
+func (s *ctxFuncState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	return s.f(ctx, cargo)
+}
+
+func (s *ctxFuncState) Name() string {
+	return s.name
+}
+
+func TestStream_ProducedWhileConsumedByNextState(t *testing.T) {
+	m := NewStateMachine()
+	total := 0
+	sumCtx := &ctxFuncState{name: "sum", f: func(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+		err := cargo.(*Stream).Each(ctx, func(item interface{}) error {
+			total += item.(int)
+			return nil
+		})
+		return nil, total, err
+	}}
+	read := &ctxFuncState{name: "read", f: func(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+		return sumCtx, NewStream(ctx, 1, func(ctx context.Context, emit func(interface{}) error) error {
+			for i := 1; i <= 100; i++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+			}
+			return nil
+		}), nil
+	}}
+	m.AddStates(read, sumCtx)
+
+	result, err := m.Execute(context.Background(), nil, read)
+	assert.Nil(t, err)
+	assert.Equal(t, 5050, result.Cargo)
+}
+
+func TestStream_ProducerFails_Err(t *testing.T) {
+	s := NewStream(context.Background(), 0, func(ctx context.Context, emit func(interface{}) error) error {
+		emit("a")
+		return errors.New("read failed")
+	})
+
+	items, err := s.Collect(context.Background())
+	assert.Equal(t, []interface{}{"a"}, items)
+	assert.EqualError(t, err, "read failed")
+}
+
+func TestStream_ContextDone_ProducerStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	s := NewStream(ctx, 0, func(ctx context.Context, emit func(interface{}) error) error {
+		for {
+			if err := emit(1); err != nil {
+				stopped <- err
+				return err
+			}
+		}
+	})
+	s.Next(ctx)
+	cancel()
+
+	assert.True(t, errors.Is(<-stopped, context.Canceled))
+	_, err := s.Collect(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestStreamOf(t *testing.T) {
+	items, err := StreamOf(1, 2, 3).Collect(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2, 3}, items)
+}
@@ -0,0 +1,82 @@
+package gust
+
+import "context"
+
+// SubMachineState runs another machine as a single state, so workflows can be
+// composed from smaller ones. Entering it runs the inner machine from its
+// start state with the cargo, and the state the inner run ends in selects
+// where the outer run goes next, given the inner run's final cargo: the state
+// set with On for that end state, else the one set with OnOutcome for its
+// outcome, else Next. An inner run failing fails the state with its error.
+// The inner run shares the outer run's context, so it's cancelled with it and
+// keeps its correlation ID, see WithCorrelationID.
+type SubMachineState struct {
+	name  string
+	sm    *StateMachine
+	start State
+
+	byState   map[string]State
+	byOutcome map[Outcome]State
+
+	// Next is where the outer run goes when nothing else was chosen, nil ends
+	// it unless a transition out of the state is chosen otherwise, e.g. by a
+	// guard
+	Next State
+}
+
+// NewSubMachine returns a state running the machine from the start state
+func NewSubMachine(name string, sm *StateMachine, start State) *SubMachineState {
+	return &SubMachineState{
+		name:      name,
+		sm:        sm,
+		start:     start,
+		byState:   make(map[string]State),
+		byOutcome: make(map[Outcome]State),
+	}
+}
+
+// On has the outer run go to next when the inner run ends in the named state
+func (s *SubMachineState) On(endState string, next State) *SubMachineState {
+	s.byState[endState] = next
+	return s
+}
+
+// OnOutcome has the outer run go to next when the inner run ends in a
+// terminal state with the outcome, see MarkOutcome
+func (s *SubMachineState) OnOutcome(outcome Outcome, next State) *SubMachineState {
+	s.byOutcome[outcome] = next
+	return s
+}
+
+// Machine returns the inner machine
+func (s *SubMachineState) Machine() *StateMachine {
+	return s.sm
+}
+
+// Exec runs the inner machine without a context
+func (s *SubMachineState) Exec(cargo interface{}) (State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+// ExecContext runs the inner machine and chooses the next state
+func (s *SubMachineState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	result, err := s.sm.Execute(ctx, cargo, s.start)
+	if err != nil {
+		return nil, cargo, err
+	}
+
+	if len(result.Path) > 0 {
+		if next, ok := s.byState[result.Path[len(result.Path)-1]]; ok {
+			return next, result.Cargo, nil
+		}
+	}
+	if next, ok := s.byOutcome[result.Outcome]; ok && result.Outcome != OutcomeNone {
+		return next, result.Cargo, nil
+	}
+	return s.Next, result.Cargo, nil
+}
+
+// Name is the name given to the constructor
+func (s *SubMachineState) Name() string {
+	return s.name
+}
@@ -0,0 +1,95 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// paymentMachine charges the cargo, approving amounts up to 100
+func paymentMachine() (m *StateMachine, charge State) {
+	m = NewStateMachine()
+	approved := &StateImpl{name: "approved", cargo: "charged"}
+	declined := &StateImpl{name: "declined", cargo: "declined"}
+	charge = NewFuncState("charge", func(cargo interface{}) (State, interface{}, error) {
+		switch amount := cargo.(int); {
+		case amount < 0:
+			return nil, cargo, errors.New("negative amount")
+		case amount > 100:
+			return declined, cargo, nil
+		}
+		return approved, cargo, nil
+	})
+	m.AddStates(charge, approved, declined)
+	m.AddTransition(charge, approved)
+	m.AddTransition(charge, declined)
+	m.MarkTerminal(approved)
+	m.MarkOutcome(OutcomeFailure, declined)
+	return m, charge
+}
+
+func TestSubMachineState_EndStateSelectsTransition(t *testing.T) {
+	inner, charge := paymentMachine()
+	m := NewStateMachine()
+	ship := &StateImpl{name: "ship"}
+	cancel := &StateImpl{name: "cancel"}
+	pay := NewSubMachine("pay", inner, charge).On("approved", ship).On("declined", cancel)
+	m.AddStates(pay, ship, cancel)
+
+	result, err := m.Execute(context.Background(), 10, pay)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pay", "ship"}, result.Path)
+	assert.Equal(t, "charged", ship.cargoReceived)
+
+	result, err = m.Execute(context.Background(), 500, pay)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pay", "cancel"}, result.Path)
+	assert.Equal(t, "declined", cancel.cargoReceived)
+}
+
+func TestSubMachineState_OutcomeAndNext(t *testing.T) {
+	inner, charge := paymentMachine()
+	m := NewStateMachine()
+	ship := &StateImpl{name: "ship"}
+	refund := &StateImpl{name: "refund"}
+	pay := NewSubMachine("pay", inner, charge).OnOutcome(OutcomeFailure, refund)
+	pay.Next = ship
+	m.AddStates(pay, ship, refund)
+
+	result, _ := m.Execute(context.Background(), 500, pay)
+	assert.Equal(t, []string{"pay", "refund"}, result.Path)
+	result, _ = m.Execute(context.Background(), 10, pay)
+	assert.Equal(t, []string{"pay", "ship"}, result.Path)
+	assert.Equal(t, inner, pay.Machine())
+}
+
+func TestSubMachineState_InnerFailure(t *testing.T) {
+	inner, charge := paymentMachine()
+	m := NewStateMachine()
+	pay := NewSubMachine("pay", inner, charge)
+	m.AddStates(pay)
+
+	_, err := m.Execute(context.Background(), -1, pay)
+
+	var runErr *RunError
+	if assert.True(t, errors.As(err, &runErr)) {
+		assert.Equal(t, "pay", runErr.State)
+	}
+	assert.Contains(t, err.Error(), "negative amount")
+}
+
+func TestSubMachineState_SharesContext(t *testing.T) {
+	inner := NewStateMachine()
+	a := &correlatedState{}
+	inner.AddStates(a)
+	m := NewStateMachine()
+	sub := NewSubMachine("sub", inner, a)
+	m.AddStates(sub)
+
+	_, err := m.Execute(WithCorrelationID(context.Background(), "req-42"), nil, sub)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "req-42", a.seen)
+}
@@ -0,0 +1,179 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RestartStrategy is which instances a supervisor restarts when one fails,
+// see SupervisorPolicy
+type RestartStrategy int
+
+const (
+	// OneForOne restarts the failed instance only
+	OneForOne RestartStrategy = iota
+	// AllForOne restarts the failed instance and all other running instances
+	// of the machine, for instances depending on each other
+	AllForOne
+)
+
+func (s RestartStrategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one for one"
+	case AllForOne:
+		return "all for one"
+	}
+	return fmt.Sprintf("RestartStrategy(%d)", int(s))
+}
+
+// SupervisorPolicy restarts the failed instances of a machine, see
+// Manager.Supervise
+type SupervisorPolicy struct {
+	Strategy RestartStrategy
+
+	// MaxRestarts bounds the restarts of an instance within Window, once
+	// exceeded the instance fails for good, 3 if zero
+	MaxRestarts int
+	// Window is how far back restarts count, restarts over the instance's
+	// lifetime count if zero
+	Window time.Duration
+
+	// Backoff is how long to wait before each restart, no wait if nil
+	Backoff Backoff
+	// ShouldRestart decides whether a failure is worth restarting for. If
+	// nil, all failures are but fatal ones, see Fatal.
+	ShouldRestart func(err error) bool
+}
+
+func (p SupervisorPolicy) shouldRestart(err error) bool {
+	if p.ShouldRestart != nil {
+		return p.ShouldRestart(err)
+	}
+	return !IsFatal(err)
+}
+
+func (p SupervisorPolicy) maxRestarts() int {
+	if p.MaxRestarts <= 0 {
+		return 3
+	}
+	return p.MaxRestarts
+}
+
+// Supervise restarts the instances of the named machine whose run fails, so
+// transient infrastructure failures don't need a hand. A restarted instance
+// keeps its ID and history, and runs again from where it started with the
+// cargo it started with, as a new run. Aborted and cancelled instances aren't
+// restarted, except the siblings of a failed instance under AllForOne, and
+// none are once the manager is shutting down.
+func (m *Manager) Supervise(machine string, p SupervisorPolicy) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.machines[machine]; !ok {
+		return fmt.Errorf("%w %s", ErrUnknownMachine, machine)
+	}
+	m.supervisors[machine] = p
+	return nil
+}
+
+// Restarts returns how many times the instance was restarted, see
+// Manager.Supervise
+func (i *Instance) Restarts() int {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.restarts
+}
+
+// restart tells whether the instance's run, failed with err, is run again,
+// after the policy's backoff. err is ctx's error if ctx is done while waiting.
+func (i *Instance) restart(ctx context.Context, err error) (bool, error) {
+	if err == nil || ctx.Err() != nil || i.manager.shuttingDown() {
+		return false, err
+	}
+	i.lock.Lock()
+	sibling := i.restartRequested
+	i.restartRequested = false
+	i.lock.Unlock()
+
+	i.manager.lock.Lock()
+	p, supervised := i.manager.supervisors[i.Machine]
+	i.manager.lock.Unlock()
+	if !supervised {
+		return false, err
+	}
+
+	// siblings stopped to be restarted don't count against their own limit
+	if !sibling || !errors.Is(err, ErrAborted) {
+		if errors.Is(err, ErrAborted) || !p.shouldRestart(err) || !i.countRestart(p) {
+			return false, err
+		}
+		if p.Strategy == AllForOne {
+			i.manager.restartSiblings(i)
+		}
+	}
+
+	i.lock.Lock()
+	i.restarts++
+	attempt := i.restarts
+	run := i.run
+	i.run = ""
+	i.recorded = 0
+	i.lock.Unlock()
+	if run != "" {
+		i.manager.deleteSnapshot(run)
+	}
+
+	if p.Backoff != nil && !i.machine().sleep(ctx, p.Backoff.Delay(attempt)) {
+		return false, ctx.Err()
+	}
+	return true, nil
+}
+
+// countRestart records a restart, false if the policy allows no more
+func (i *Instance) countRestart(p SupervisorPolicy) bool {
+	now := i.machine().clock.Now()
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	recent := i.restartTimes[:0]
+	for _, t := range i.restartTimes {
+		if p.Window <= 0 || now.Sub(t) < p.Window {
+			recent = append(recent, t)
+		}
+	}
+	i.restartTimes = recent
+	if len(i.restartTimes) >= p.maxRestarts() {
+		return false
+	}
+	i.restartTimes = append(i.restartTimes, now)
+	return true
+}
+
+// restartSiblings stops the runs of the other running instances of the
+// machine, to be restarted
+func (m *Manager) restartSiblings(failed *Instance) {
+	m.lock.Lock()
+	siblings := make([]*Instance, 0)
+	for _, inst := range m.instances {
+		if inst != failed && inst.Machine == failed.Machine {
+			siblings = append(siblings, inst)
+		}
+	}
+	m.lock.Unlock()
+
+	for _, inst := range siblings {
+		inst.lock.Lock()
+		stop := inst.stopRun
+		if inst.status != InstanceRunning || stop == nil {
+			stop = nil
+		} else {
+			inst.restartRequested = true
+		}
+		inst.lock.Unlock()
+		if stop != nil {
+			stop()
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFlakyMachine is a machine whose work state fails the first failures times,
+// or waits for the approve signal when given "wait"
+func newFlakyMachine(failures int32) (*StateMachine, State) {
+	m := NewStateMachine()
+	approval := &signalState{name: "approval", signal: "approve"}
+	var runs int32
+	work := NewFuncState("work", func(cargo interface{}) (State, interface{}, error) {
+		if cargo == "wait" {
+			return approval, cargo, nil
+		}
+		if atomic.AddInt32(&runs, 1) <= failures {
+			return nil, cargo, errors.New("connection reset")
+		}
+		return nil, "done", nil
+	})
+	m.AddStates(work, approval)
+	return m, work
+}
+
+func TestManager_Supervise_OneForOne(t *testing.T) {
+	sm, work := newFlakyMachine(2)
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("job", sm, work))
+	assert.Nil(t, mgr.Supervise("job", SupervisorPolicy{Strategy: OneForOne, Backoff: ConstantBackoff(time.Millisecond)}))
+
+	inst, _ := mgr.Start(context.Background(), "job", "go")
+	result, err := inst.Wait(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "done", result.Cargo)
+	assert.Equal(t, 2, inst.Restarts())
+	assert.Equal(t, InstanceSucceeded, inst.Status())
+	assert.Len(t, inst.History(), 3)
+}
+
+func TestManager_Supervise_MaxRestarts(t *testing.T) {
+	sm, work := newFlakyMachine(10)
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("job", sm, work))
+	assert.Nil(t, mgr.Supervise("job", SupervisorPolicy{MaxRestarts: 2}))
+
+	inst, _ := mgr.Start(context.Background(), "job", "go")
+	_, err := inst.Wait(context.Background())
+
+	assert.Contains(t, err.Error(), "connection reset")
+	assert.Equal(t, 2, inst.Restarts())
+	assert.Equal(t, InstanceFailed, inst.Status())
+}
+
+func TestManager_Supervise_Fatal(t *testing.T) {
+	sm := NewStateMachine()
+	fail := &StateImpl{name: "fail", err: Fatal(errors.New("bad input"))}
+	sm.AddStates(fail)
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("job", sm, fail))
+	assert.Nil(t, mgr.Supervise("job", SupervisorPolicy{}))
+
+	inst, _ := mgr.Start(context.Background(), "job", nil)
+	inst.Wait(context.Background())
+
+	assert.Equal(t, 0, inst.Restarts())
+	assert.Equal(t, InstanceFailed, inst.Status())
+}
+
+func TestManager_Supervise_AllForOne(t *testing.T) {
+	sm, work := newFlakyMachine(1)
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("job", sm, work))
+	assert.Nil(t, mgr.Supervise("job", SupervisorPolicy{Strategy: AllForOne}))
+
+	waiting, _ := mgr.Start(context.Background(), "job", "wait")
+	waitForState(t, waiting, "approval")
+	flaky, _ := mgr.Start(context.Background(), "job", "go")
+	_, err := flaky.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, flaky.Restarts())
+
+	for deadline := time.Now().Add(time.Second); waiting.Restarts() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 1, waiting.Restarts())
+	waitForState(t, waiting, "approval")
+	assert.Nil(t, mgr.Signal(waiting.ID, "approve", "approved"))
+	result, err := waiting.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "approved", result.Cargo)
+}
+
+func TestManager_Supervise_CancelNotRestarted(t *testing.T) {
+	sm, work := newFlakyMachine(0)
+	mgr := NewManager()
+	assert.Nil(t, mgr.Register("job", sm, work))
+	assert.Nil(t, mgr.Supervise("job", SupervisorPolicy{}))
+
+	inst, _ := mgr.Start(context.Background(), "job", "wait")
+	waitForState(t, inst, "approval")
+	assert.Nil(t, mgr.Cancel(inst.ID))
+	inst.Wait(context.Background())
+
+	assert.Equal(t, 0, inst.Restarts())
+	assert.Equal(t, InstanceCancelled, inst.Status())
+}
+
+func TestManager_Supervise_UnknownMachine(t *testing.T) {
+	err := NewManager().Supervise("job", SupervisorPolicy{})
+
+	assert.True(t, errors.Is(err, ErrUnknownMachine))
+}
+
+func TestSupervisorPolicy_Window(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	inst := &Instance{sm: NewStateMachine(WithClock(steppedClock{now: &now})), lock: &sync.Mutex{}}
+	p := SupervisorPolicy{MaxRestarts: 1, Window: time.Minute}
+
+	assert.True(t, inst.countRestart(p))
+	assert.False(t, inst.countRestart(p))
+	now = now.Add(time.Minute)
+	assert.True(t, inst.countRestart(p))
+}
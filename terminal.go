@@ -0,0 +1,111 @@
+package gust
+
+import "fmt"
+
+// Terminal when implemented by a state declares whether it's an end state,
+// one a run is meant to finish in, like MarkTerminal
+type Terminal interface {
+	IsTerminal() bool
+}
+
+// HaveOutcome when implemented by a state declares it an end state with the
+// given outcome, like MarkOutcome. OutcomeNone declares nothing.
+type HaveOutcome interface {
+	Outcome() Outcome
+}
+
+// Outcome is how a workflow turned out, told by the terminal state its run
+// ended in, see Result.Outcome
+type Outcome int
+
+const (
+	// OutcomeNone is the outcome of runs that failed, or ended in a state not
+	// declared terminal
+	OutcomeNone Outcome = iota
+	// OutcomeSuccess is the outcome of runs ending in a successful end state
+	OutcomeSuccess
+	// OutcomeFailure is the outcome of runs ending in an end state reached
+	// when the workflow failed, e.g. "payment-declined". The run itself
+	// succeeded, there's no error.
+	OutcomeFailure
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeNone:
+		return "none"
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeFailure:
+		return "failure"
+	}
+	return fmt.Sprintf("Outcome(%d)", int(o))
+}
+
+// MarkTerminal declares the states as end states, successful unless declared
+// otherwise with MarkOutcome. Once a machine has terminal states, a run
+// finishing in any other state, one that returned no next state without an
+// error, fails with ErrDeadEnd, and Validate reports states without
+// transitions that aren't terminal.
+func (sm *StateMachine) MarkTerminal(states ...State) {
+	for _, s := range states {
+		if _, ok := sm.terminals[keyOf(s)]; !ok {
+			sm.MarkOutcome(OutcomeSuccess, s)
+		}
+	}
+}
+
+// MarkOutcome declares the states as end states with the given outcome, like
+// MarkTerminal. OutcomeNone makes them not terminal anymore.
+func (sm *StateMachine) MarkOutcome(outcome Outcome, states ...State) {
+	if sm.terminals == nil {
+		sm.terminals = make(map[stateKey]Outcome)
+	}
+	for _, s := range states {
+		if outcome == OutcomeNone {
+			delete(sm.terminals, keyOf(s))
+		} else {
+			sm.terminals[keyOf(s)] = outcome
+		}
+	}
+}
+
+// IsTerminal tells whether the state is declared an end state
+func (sm *StateMachine) IsTerminal(state State) bool {
+	return sm.OutcomeOf(state) != OutcomeNone
+}
+
+// OutcomeOf returns the outcome of runs ending in the state, OutcomeNone if it
+// isn't terminal
+func (sm *StateMachine) OutcomeOf(state State) Outcome {
+	if outcome, ok := sm.terminals[keyOf(state)]; ok {
+		return outcome
+	}
+	if o, ok := state.(HaveOutcome); ok {
+		return o.Outcome()
+	}
+	if t, ok := state.(Terminal); ok && t.IsTerminal() {
+		return OutcomeSuccess
+	}
+	return OutcomeNone
+}
+
+// markDeclaredTerminal records the outcome the state declares when added
+func (sm *StateMachine) markDeclaredTerminal(state State) {
+	if o, ok := state.(HaveOutcome); ok && o.Outcome() != OutcomeNone {
+		sm.MarkOutcome(o.Outcome(), state)
+	} else if t, ok := state.(Terminal); ok && t.IsTerminal() {
+		sm.MarkTerminal(state)
+	}
+}
+
+// checkEnd tells why the run can't end in the state, if it can't
+func (sm *StateMachine) checkEnd(state State) error {
+	if err := checkPassThrough(state, sm.StateName(state)); err != nil {
+		return err
+	}
+	if len(sm.terminals) > 0 && !sm.IsTerminal(state) {
+		return fmt.Errorf("%w at %s", ErrDeadEnd, sm.StateName(state))
+	}
+	return nil
+}
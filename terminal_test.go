@@ -0,0 +1,144 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// endState is a state declaring itself terminal
+type endState struct {
+	StateImpl
+}
+
+func (s *endState) IsTerminal() bool {
+	return true
+}
+
+func TestMarkTerminal_RunEndingElsewhere_DeadEnd(t *testing.T) {
+	m := NewStateMachine()
+	done := &StateImpl{name: "done"}
+	forgot := &StateImpl{name: "forgot"}
+	m.AddStates(done, forgot)
+	m.MarkTerminal(done)
+
+	assert.Nil(t, m.Run(nil, done))
+	err := m.Run(nil, forgot)
+	assert.True(t, errors.Is(err, ErrDeadEnd))
+	assert.EqualError(t, err, "state forgot failed: dead end at forgot (path: forgot)")
+}
+
+func TestMarkTerminal_NoTerminals_AnyStateEnds(t *testing.T) {
+	m := NewStateMachine()
+	s := &StateImpl{name: "s"}
+	m.AddState(s)
+
+	assert.False(t, m.IsTerminal(s))
+	assert.Nil(t, m.Run(nil, s))
+}
+
+func TestTerminal_DeclaredByState(t *testing.T) {
+	m := NewStateMachine()
+	done := &endState{StateImpl{name: "done"}}
+	forgot := &StateImpl{name: "forgot"}
+	m.AddStates(done, forgot)
+
+	assert.True(t, m.IsTerminal(done))
+	assert.True(t, errors.Is(m.Run(nil, forgot), ErrDeadEnd))
+}
+
+func TestMarkTerminal_Validate(t *testing.T) {
+	m := NewStateMachine()
+	a, done, forgot := &StateImpl{name: "a"}, &StateImpl{name: "done"}, &StateImpl{name: "forgot"}
+	m.AddStates(a, done, forgot)
+	m.AddTransition(a, done)
+	m.AddTransition(a, forgot)
+	m.AddTransition(done, a)
+	m.MarkTerminal(done)
+
+	d := m.Definition()
+	assert.True(t, d.States[1].Terminal)
+	assert.EqualError(t, d.Validate(), "invalid definition: terminal state done has transitions; state forgot has no transitions and isn't terminal")
+
+	other := NewStateMachine()
+	other.AddStates(&StateImpl{name: "a"}, &StateImpl{name: "done"}, &StateImpl{name: "forgot"})
+	d.Transitions = d.Transitions[:2]
+	d.States[2].Terminal = true
+	_, err := other.ApplyDefinition(d)
+	assert.Nil(t, err)
+	done2, _ := other.StateByName("done")
+	assert.True(t, other.IsTerminal(done2))
+}
+
+func TestRandomWalk_DeclaredTerminals(t *testing.T) {
+	m := NewStateMachine()
+	a, done, forgot := &StateImpl{name: "a"}, &StateImpl{name: "done"}, &StateImpl{name: "forgot"}
+	m.AddStates(a, done, forgot)
+	m.AddTransition(a, done)
+	m.AddTransition(a, forgot)
+	m.MarkTerminal(done)
+
+	report := m.RandomWalk(a, WalkConfig{Walks: 20})
+	assert.NotEmpty(t, report.Failures)
+	for _, f := range report.Failures {
+		assert.True(t, errors.Is(f.Err, ErrDeadEnd))
+		assert.Equal(t, []string{"a", "forgot"}, f.Path)
+	}
+}
+
+// declinedState declares itself a failed end
+type declinedState struct {
+	StateImpl
+}
+
+func (s *declinedState) Outcome() Outcome {
+	return OutcomeFailure
+}
+
+func TestMarkOutcome_ResultReportsOutcome(t *testing.T) {
+	m := NewStateMachine()
+	paid := &StateImpl{name: "paid"}
+	declined := &declinedState{StateImpl{name: "declined"}}
+	refunded := &StateImpl{name: "refunded"}
+	failing := &StateImpl{name: "failing", err: errors.New("boom")}
+	m.AddStates(paid, declined, refunded, failing)
+	m.MarkTerminal(paid, declined)
+	m.MarkOutcome(OutcomeFailure, refunded)
+	m.MarkTerminal(refunded)
+
+	result, _ := m.Execute(context.Background(), nil, paid)
+	assert.Equal(t, OutcomeSuccess, result.Outcome)
+	result, _ = m.Execute(context.Background(), nil, declined)
+	assert.Equal(t, OutcomeFailure, result.Outcome)
+	result, _ = m.Execute(context.Background(), nil, refunded)
+	assert.Equal(t, OutcomeFailure, result.Outcome, "MarkTerminal overrode the outcome")
+	result, _ = m.Execute(context.Background(), nil, failing)
+	assert.Equal(t, OutcomeNone, result.Outcome)
+
+	m.MarkOutcome(OutcomeNone, refunded)
+	assert.False(t, m.IsTerminal(refunded))
+	assert.Equal(t, "failure", OutcomeFailure.String())
+}
+
+func TestMarkOutcome_Definition(t *testing.T) {
+	m := NewStateMachine()
+	paid, declined := &StateImpl{name: "paid"}, &StateImpl{name: "declined"}
+	m.AddStates(paid, declined)
+	m.MarkTerminal(paid)
+	m.MarkOutcome(OutcomeFailure, declined)
+
+	d := m.Definition()
+	assert.Equal(t, []StateDefinition{{Name: "paid", Terminal: true}, {Name: "declined", Terminal: true, Outcome: "failure"}}, d.States)
+
+	other := NewStateMachine()
+	other.AddStates(&StateImpl{name: "paid"}, &StateImpl{name: "declined"})
+	_, err := other.ApplyDefinition(d)
+	assert.Nil(t, err)
+	assert.Equal(t, d, other.Definition())
+
+	d.States[0].Outcome = "meh"
+	d.States[1].Terminal = false
+	assert.EqualError(t, d.Validate(), "invalid definition: state paid has unknown outcome meh; state declined has an outcome but isn't terminal; state declined has no transitions and isn't terminal")
+}
@@ -0,0 +1,103 @@
+package gust
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Timeline steps forward and backward through a recorded run, for post-mortem
+// debugging of failed runs. Each position is one Exec call, holding the cargo
+// that went into the state and what came out.
+type Timeline struct {
+	records []Record
+	pos     int
+}
+
+// LoadTimeline reads a recording made by RecordRun, the timeline starts at
+// the first Exec call
+func LoadTimeline(rd io.Reader) (*Timeline, error) {
+	records, err := readRecords(rd)
+	if err != nil {
+		return nil, err
+	}
+	return &Timeline{records: records}, nil
+}
+
+// Len is the number of Exec calls recorded
+func (t *Timeline) Len() int {
+	return len(t.records)
+}
+
+// Position is the index of the current Exec call
+func (t *Timeline) Position() int {
+	return t.pos
+}
+
+// Current returns the current Exec call, ok is false if the recording is empty
+func (t *Timeline) Current() (rec Record, ok bool) {
+	if t.pos >= len(t.records) {
+		return Record{}, false
+	}
+	return t.records[t.pos], true
+}
+
+// Next steps forward, it returns false at the end
+func (t *Timeline) Next() bool {
+	return t.Seek(t.pos + 1)
+}
+
+// Prev steps backward, it returns false at the beginning
+func (t *Timeline) Prev() bool {
+	return t.Seek(t.pos - 1)
+}
+
+// Seek jumps to the i-th Exec call, it returns false if out of range
+func (t *Timeline) Seek(i int) bool {
+	if i < 0 || i >= len(t.records) {
+		return false
+	}
+	t.pos = i
+	return true
+}
+
+// SeekState jumps forward to the next Exec call of the named state, including
+// the current one, it returns false if there's none
+func (t *Timeline) SeekState(name string) bool {
+	for i := t.pos; i < len(t.records); i++ {
+		if t.records[i].State == name {
+			t.pos = i
+			return true
+		}
+	}
+	return false
+}
+
+// SeekFailure jumps forward to the next Exec call that returned an error,
+// including the current one, it returns false if there's none
+func (t *Timeline) SeekFailure() bool {
+	for i := t.pos; i < len(t.records); i++ {
+		if t.records[i].Error != "" {
+			t.pos = i
+			return true
+		}
+	}
+	return false
+}
+
+// Cargo decodes the cargo given to the current state into v
+func (t *Timeline) Cargo(v interface{}) error {
+	rec, ok := t.Current()
+	if !ok {
+		return io.EOF
+	}
+	return json.Unmarshal(rec.Cargo, v)
+}
+
+// NextCargo decodes the cargo the current state returned into v
+func (t *Timeline) NextCargo(v interface{}) error {
+	rec, ok := t.Current()
+	if !ok {
+		return io.EOF
+	}
+	return json.Unmarshal(rec.NextCargo, v)
+}
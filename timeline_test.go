@@ -0,0 +1,87 @@
+package gust
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recordOrderRun(t *testing.T) *Timeline {
+	calls := 0
+	m, start := newOrderMachine(&calls)
+
+	var recording bytes.Buffer
+	_, err := m.RecordRun(context.Background(), &recording, order{ID: "o1", Total: 10}, start)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	timeline, err := LoadTimeline(&recording)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return timeline
+}
+
+func TestTimeline_StepForwardAndBack(t *testing.T) {
+	timeline := recordOrderRun(t)
+
+	// validate, charge (failed), charge, ship
+	assert.Equal(t, 4, timeline.Len())
+	rec, ok := timeline.Current()
+	assert.True(t, ok)
+	assert.Equal(t, "validate", rec.State)
+	assert.False(t, timeline.Prev())
+
+	assert.True(t, timeline.Next())
+	assert.True(t, timeline.Next())
+	rec, _ = timeline.Current()
+	assert.Equal(t, "charge", rec.State)
+	assert.Equal(t, "ship", rec.Next)
+
+	var o order
+	assert.Nil(t, timeline.Cargo(&o))
+	assert.Equal(t, order{ID: "o1", Total: 10}, o)
+	assert.Nil(t, timeline.NextCargo(&o))
+	assert.Equal(t, order{ID: "o1", Total: 15}, o)
+
+	assert.True(t, timeline.Prev())
+	assert.Equal(t, 1, timeline.Position())
+	assert.True(t, timeline.Next())
+	assert.True(t, timeline.Next())
+	assert.False(t, timeline.Next())
+	assert.Equal(t, 3, timeline.Position())
+}
+
+func TestTimeline_SeekFailureAndState(t *testing.T) {
+	timeline := recordOrderRun(t)
+
+	assert.True(t, timeline.SeekFailure())
+	rec, _ := timeline.Current()
+	assert.Equal(t, 1, timeline.Position())
+	assert.Equal(t, "gateway timeout", rec.Error)
+	assert.True(t, rec.Retryable)
+
+	assert.True(t, timeline.SeekState("ship"))
+	assert.Equal(t, 3, timeline.Position())
+	assert.False(t, timeline.SeekState("validate"))
+
+	assert.True(t, timeline.Seek(0))
+	assert.False(t, timeline.Seek(4))
+}
+
+func TestTimeline_Empty(t *testing.T) {
+	timeline, err := LoadTimeline(strings.NewReader(""))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, ok := timeline.Current()
+	assert.False(t, ok)
+	assert.False(t, timeline.Next())
+	assert.Equal(t, io.EOF, timeline.Cargo(&order{}))
+}
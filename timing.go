@@ -0,0 +1,57 @@
+package gust
+
+import "time"
+
+// StateTiming is how long a run spent in a state, over all the times it
+// entered it
+type StateTiming struct {
+	State   string // by name, by type if unnamed
+	Entered int    // times the run entered the state
+
+	// Queued is the time spent before executing, waiting for the rate limit or
+	// a concurrency slot, see SetRateLimit and SetStateConcurrency
+	Queued time.Duration
+	// Duration is the wall-clock time spent executing, retries and their
+	// backoff included
+	Duration time.Duration
+	// Waited is the part of Duration a WaitState spent waiting for its signal
+	// or value
+	Waited  time.Duration
+	Retries int
+}
+
+// Timing returns how long the run spent in the named state, false if it never
+// entered it
+func (r *Result) Timing(name string) (StateTiming, bool) {
+	for _, t := range r.Timings {
+		if t.State == name {
+			return t, true
+		}
+	}
+	return StateTiming{}, false
+}
+
+// timeState adds the time the run spent in the state it just executed, queued
+// from queued to started, then executing until now, to the run's timings and
+// the machine's Stats
+func (sm *StateMachine) timeState(r *run, state State, queued, started time.Time, retries int, failed bool) {
+	name := sm.StateName(state)
+	i := 0
+	for i < len(r.timings) && r.timings[i].State != name {
+		i++
+	}
+	if i == len(r.timings) {
+		r.timings = append(r.timings, StateTiming{State: name})
+	}
+
+	elapsed := sm.clock.Now().Sub(started)
+	t := &r.timings[i]
+	t.Entered++
+	t.Queued += started.Sub(queued)
+	t.Duration += elapsed
+	if _, ok := state.(*WaitState); ok {
+		t.Waited += elapsed
+	}
+	t.Retries += retries
+	sm.stats.stateExecuted(name, elapsed, retries, failed)
+}
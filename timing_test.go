@@ -0,0 +1,98 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// steppedClock only moves when told to
+type steppedClock struct {
+	realClock
+	now *time.Time
+}
+
+func (c steppedClock) Now() time.Time {
+	return *c.now
+}
+
+func TestResult_Timings(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := NewStateMachine(WithClock(steppedClock{now: &now}))
+	signals := make(chan interface{}, 1)
+	var fetch, wait, done State
+	attempts := 0
+	done = NewFuncState("done", func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo, nil
+	})
+	wait = &WaitState{name: "wait", channel: signals, Next: done}
+	fetch = NewFuncState("fetch", func(cargo interface{}) (State, interface{}, error) {
+		now = now.Add(time.Second)
+		if attempts++; attempts < 3 {
+			return nil, cargo, errors.New("unavailable")
+		}
+		now = now.Add(5 * time.Second) // while waiting for the signal
+		signals <- "go"
+		return wait, cargo, nil
+	})
+	m.AddStates(fetch, wait, done)
+	m.SetRetryPolicy(fetch, RetryPolicy{MaxAttempts: 3, ShouldRetry: func(error) bool { return true }})
+
+	result, err := m.Execute(context.Background(), nil, fetch)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 8*time.Second, result.Duration)
+	assert.Equal(t, 2, result.Retries)
+	if assert.Len(t, result.Timings, 3) {
+		assert.Equal(t, StateTiming{State: "fetch", Entered: 1, Duration: 8 * time.Second, Retries: 2}, result.Timings[0])
+		assert.Equal(t, StateTiming{State: "wait", Entered: 1}, result.Timings[1])
+	}
+	timing, ok := result.Timing("done")
+	assert.True(t, ok)
+	assert.Equal(t, 1, timing.Entered)
+	_, ok = result.Timing("missing")
+	assert.False(t, ok)
+}
+
+func TestResult_Timings_Loop(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := NewStateMachine(WithClock(steppedClock{now: &now}))
+	var poll State
+	polls := 0
+	poll = NewFuncState("poll", func(cargo interface{}) (State, interface{}, error) {
+		now = now.Add(time.Second)
+		if polls++; polls < 3 {
+			return poll, cargo, nil
+		}
+		return nil, cargo, nil
+	})
+	m.AddStates(poll)
+
+	result, err := m.Execute(context.Background(), nil, poll)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []StateTiming{{State: "poll", Entered: 3, Duration: 3 * time.Second}}, result.Timings)
+}
+
+func TestResult_Timings_Waited(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := NewStateMachine(WithClock(steppedClock{now: &now}))
+	ch := make(chan interface{}, 1)
+	ch <- "arrived"
+	wait := NewChannelWait("wait", ch)
+	wait.Merge = func(cargo, value interface{}) (interface{}, error) {
+		now = now.Add(time.Minute) // as if the value took a minute to arrive
+		return value, nil
+	}
+	m.AddStates(wait)
+
+	result, err := m.Execute(context.Background(), nil, wait)
+
+	assert.Nil(t, err)
+	timing, _ := result.Timing("wait")
+	assert.Equal(t, time.Minute, timing.Duration)
+	assert.Equal(t, time.Minute, timing.Waited)
+}
@@ -0,0 +1,71 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ResumeToken is where an aborted run was, carried by its *AbortedError. It
+// holds the state and its cargo in memory, so a run interrupted for a short
+// while can be continued with Continue without a snapshot store.
+type ResumeToken struct {
+	state State
+	name  string // the state's, see StateMachine.StateName
+	cargo interface{}
+	path  []string // the states entered before it
+	run   string   // the run's idempotency key base
+	steps int      // the number of states entered before it
+
+	correlation string // see WithCorrelationID
+}
+
+// State returns the name of the state the run resumes in
+func (t *ResumeToken) State() string {
+	return t.name
+}
+
+// Cargo returns the cargo the state is given again
+func (t *ResumeToken) Cargo() interface{} {
+	return t.cargo
+}
+
+// ResumeTokenOf returns the resume token of an aborted run's error
+func ResumeTokenOf(err error) (*ResumeToken, bool) {
+	var aborted *AbortedError
+	if errors.As(err, &aborted) && aborted.Token != nil {
+		return aborted.Token, true
+	}
+	return nil, false
+}
+
+// Continue resumes an aborted run from its token. The state the run was
+// interrupted in is executed again, with the cargo it was given.
+func (sm *StateMachine) Continue(ctx context.Context, token *ResumeToken) (*Result, error) {
+	if token == nil {
+		return &Result{Err: ErrNoStartState}, ErrNoStartState
+	}
+	ctx = correlated(ctx, token.correlation)
+	return sm.Execute(resuming(ctx, token.run, token.steps), token.cargo, token.state)
+}
+
+// Checkpoint turns the token into a Snapshot, with the cargo encoded with the
+// machine's Codec, so an aborted run can be persisted and resumed with Resume,
+// in this process or another
+func (sm *StateMachine) Checkpoint(token *ResumeToken) (*Snapshot, error) {
+	data, err := sm.codec.Marshal(sm.persisted(token.cargo))
+	if err != nil {
+		return nil, fmt.Errorf("snapshotting cargo: %w", err)
+	}
+	return &Snapshot{
+		Version: sm.Version,
+		State:   token.State(),
+		Cargo:   data,
+		Path:    append([]string{}, token.path...),
+		Taken:   sm.clock.Now(),
+		Run:     token.run,
+		Steps:   token.steps,
+
+		CorrelationID: token.correlation,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContinue_AbortedRun_ResumesInterruptedState(t *testing.T) {
+	m := NewStateMachine()
+	sends := 0
+	states, _ := m.RegisterAll(map[string]ExecFunc{
+		"build": func(cargo interface{}) (State, interface{}, error) {
+			next, _ := m.StateByName("send")
+			return next, cargo.(int) + 1, nil
+		},
+		"send": func(cargo interface{}) (State, interface{}, error) {
+			sends++
+			if sends == 1 {
+				m.Abort(errors.New("shutting down"))
+			}
+			return nil, cargo.(int) * 10, nil
+		},
+	})
+
+	err := m.Run(1, states["build"])
+	token, ok := ResumeTokenOf(err)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, "send", token.State())
+	assert.Equal(t, 2, token.Cargo())
+
+	result, err := m.Continue(context.Background(), token)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, result.Cargo)
+	assert.Equal(t, []string{"send"}, result.Path)
+	assert.Equal(t, 2, sends)
+}
+
+func TestContinue_CancelledContext_HasToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m := NewStateMachine()
+	a := &StateImpl{name: "stateA"}
+	m.AddState(a)
+
+	err := m.RunContext(ctx, "cargo", a)
+	assert.True(t, errors.Is(err, context.Canceled))
+	token, ok := ResumeTokenOf(err)
+	if assert.True(t, ok) {
+		assert.Equal(t, "stateA", token.State())
+		assert.Equal(t, "cargo", token.Cargo())
+	}
+}
+
+func TestResumeTokenOf_OtherError_NotOk(t *testing.T) {
+	_, ok := ResumeTokenOf(errors.New("boom"))
+	assert.False(t, ok)
+
+	m := NewStateMachine()
+	_, err := m.Continue(context.Background(), nil)
+	assert.Equal(t, ErrNoStartState, err)
+}
+
+func TestCheckpoint_AbortedWhileExecuting_PathExcludesState(t *testing.T) {
+	sm, start := newApprovalMachine()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := sm.Execute(ctx, "order", start)
+	token, ok := ResumeTokenOf(err)
+	if !assert.True(t, ok) {
+		return
+	}
+	snap, err := sm.Checkpoint(token)
+	assert.Nil(t, err)
+	assert.Equal(t, "approval", snap.State)
+	assert.Equal(t, []string{"submit"}, snap.Path)
+	assert.Equal(t, `"order"`, string(snap.Cargo))
+}
@@ -0,0 +1,142 @@
+package gust
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// TraceObserver prints a line for every run started and finished and every
+// state entered, with a timestamp and the cargo type, for quick debugging:
+//
+//	10:04:05.000 run 1 started
+//	10:04:05.000 run 1 -> validate (main.Order)
+//	10:04:05.002 run 1 validate -[ok]-> charge (main.Order)
+//	10:04:05.310 run 1 completed in 310ms
+//
+// Runs with a correlation ID, see WithCorrelationID, have it after their ID,
+// as in "run 1 [req-42] started". It can be turned off and on with
+// SetEnabled, see also StateMachine.Trace.
+type TraceObserver struct {
+	w    io.Writer
+	lock *sync.Mutex
+	off  bool
+
+	correlations map[string]string // correlation IDs of the runs in flight by run ID
+
+	// Now timestamps run start and end lines, time.Now if nil. State lines
+	// use the time the state was entered.
+	Now func() time.Time
+	// TimeFormat formats timestamps, "15:04:05.000" if empty
+	TimeFormat string
+}
+
+// NewTraceObserver is a constructor for TraceObserver
+func NewTraceObserver(w io.Writer) *TraceObserver {
+	return &TraceObserver{w: w, lock: &sync.Mutex{}, correlations: make(map[string]string)}
+}
+
+// SetEnabled turns printing on or off
+func (o *TraceObserver) SetEnabled(enabled bool) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.off = !enabled
+}
+
+// StateChanged does nothing, states are printed by RunStatusChanged
+func (o *TraceObserver) StateChanged(prior, next string) {}
+
+// RunStatusChanged prints the state entered
+func (o *TraceObserver) RunStatusChanged(status RunStatus) {
+	run := runLabel(status.RunID, status.CorrelationID)
+	if status.Prior == "" {
+		o.printf(status.Entered, "run %s -> %s (%s)", run, status.State, status.CargoType)
+	} else if status.Label != "" {
+		o.printf(status.Entered, "run %s %s -[%s]-> %s (%s)", run, status.Prior, status.Label, status.State, status.CargoType)
+	} else {
+		o.printf(status.Entered, "run %s %s -> %s (%s)", run, status.Prior, status.State, status.CargoType)
+	}
+}
+
+// RunCorrelated remembers the run's correlation ID for its start and end lines
+func (o *TraceObserver) RunCorrelated(runID, correlationID string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.correlations[runID] = correlationID
+}
+
+// RunStarted prints the run started
+func (o *TraceObserver) RunStarted(runID string) {
+	o.printf(o.now(), "run %s started", o.run(runID, false))
+}
+
+// RunCompleted prints the run completed
+func (o *TraceObserver) RunCompleted(runID string, duration time.Duration) {
+	o.printf(o.now(), "run %s completed in %v", o.run(runID, true), duration)
+}
+
+// RunFailed prints the run failed
+func (o *TraceObserver) RunFailed(runID string, duration time.Duration, err error) {
+	o.printf(o.now(), "run %s failed in %v: %v", o.run(runID, true), duration, err)
+}
+
+// run labels the run with its correlation ID, forgetting it once ended
+func (o *TraceObserver) run(runID string, ended bool) string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	correlation := o.correlations[runID]
+	if ended {
+		delete(o.correlations, runID)
+	}
+	return runLabel(runID, correlation)
+}
+
+// runLabel is the run's ID followed by its correlation ID if it has one
+func runLabel(runID, correlationID string) string {
+	if correlationID == "" {
+		return runID
+	}
+	return runID + " [" + correlationID + "]"
+}
+
+func (o *TraceObserver) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+func (o *TraceObserver) printf(at time.Time, format string, args ...interface{}) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.off {
+		return
+	}
+
+	layout := o.TimeFormat
+	if layout == "" {
+		layout = "15:04:05.000"
+	}
+	fmt.Fprintf(o.w, "%s %s\n", at.Format(layout), fmt.Sprintf(format, args...))
+}
+
+// Trace prints the machine's runs to w with a TraceObserver, replacing the
+// one registered by an earlier call. A nil w stops tracing.
+func (sm *StateMachine) Trace(w io.Writer) {
+	sm.observersLock.Lock()
+	defer sm.observersLock.Unlock()
+
+	observers := make([]Observer, 0)
+	for _, o := range sm.loadObservers() {
+		if sm.tracer == nil || o != Observer(sm.tracer) {
+			observers = append(observers, o)
+		}
+	}
+	sm.tracer = nil
+	if w != nil {
+		sm.tracer = NewTraceObserver(w)
+		observers = append(observers, sm.tracer)
+	}
+	sm.storeObservers(observers)
+}
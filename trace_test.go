@@ -0,0 +1,62 @@
+package gust
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceObserver_PrintsRunAndStates(t *testing.T) {
+	m := NewStateMachine(WithClock(fixedClock{now: time.Date(2020, 1, 1, 10, 4, 5, 0, time.UTC)}))
+	b := &StateImpl{name: "b", cargo: order{}}
+	a := &StateImpl{name: "a", nextState: b, cargo: 1}
+	m.AddStates(a, b)
+	m.AddLabeledTransition(a, b, "ok")
+
+	var out bytes.Buffer
+	o := NewTraceObserver(&out)
+	o.Now = func() time.Time { return time.Date(2020, 1, 1, 10, 4, 5, 0, time.UTC) }
+	m.RegisterObservers(o)
+
+	assert.Nil(t, m.Run("cargo", a))
+	assert.Equal(t, `10:04:05.000 run 1 started
+10:04:05.000 run 1 -> a (string)
+10:04:05.000 run 1 a -[ok]-> b (int)
+10:04:05.000 run 1 completed in 0s
+`, out.String())
+}
+
+func TestTraceObserver_Disabled_PrintsNothing(t *testing.T) {
+	var out bytes.Buffer
+	o := NewTraceObserver(&out)
+	o.SetEnabled(false)
+	o.RunFailed("1", time.Second, errors.New("boom"))
+	assert.Equal(t, "", out.String())
+
+	o.SetEnabled(true)
+	o.RunFailed("1", time.Second, errors.New("boom"))
+	assert.Contains(t, out.String(), "run 1 failed in 1s: boom")
+}
+
+func TestStateMachine_Trace_OnOff(t *testing.T) {
+	m := NewStateMachine()
+	a := &StateImpl{name: "a"}
+	m.AddStates(a)
+	other := NewObserverImpl()
+	m.RegisterObservers(other)
+
+	var first, second bytes.Buffer
+	m.Trace(&first)
+	m.Trace(&second) // replaces the first
+	m.Run(nil, a)
+	m.Trace(nil)
+	m.Run(nil, a)
+
+	assert.Equal(t, "", first.String())
+	assert.Contains(t, second.String(), "run 1 -> a")
+	assert.NotContains(t, second.String(), "run 2")
+	assert.Len(t, other.states, 2)
+}
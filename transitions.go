@@ -0,0 +1,125 @@
+package gust
+
+// Transition is a declared move from one state to another
+type Transition struct {
+	From  State
+	To    State
+	Label string // the event the transition is taken on, if declared with AddLabeledTransition
+
+	// Description and Tags document the transition, see AddDescribedTransition
+	Description string
+	Tags        []string
+
+	// Guard if set has the machine take the transition by itself, see
+	// AddGuardedTransition
+	Guard Guard
+	// Priority decides between guarded transitions whose guards hold, the
+	// highest is taken, see SetTransitionPriority
+	Priority int
+	// Weight makes the transition a random choice, see SetTransitionWeight
+	Weight float64
+	// Degraded transitions are taken when the from state fails, see
+	// AddDegradedTransition
+	Degraded bool
+}
+
+// AddTransition declares that the from state may transition to the to state.
+// Once any transition is declared the table is authoritative, and Run returns
+// an error when a state moves somewhere that wasn't declared.
+func (sm *StateMachine) AddTransition(from, to State) {
+	if sm.transitions == nil {
+		sm.transitions = make(map[stateKey][]Transition)
+	}
+	for _, t := range sm.transitions[keyOf(from)] {
+		if sameState(t.To, to) {
+			return // already declared
+		}
+	}
+	sm.transitions[keyOf(from)] = append(sm.transitions[keyOf(from)], Transition{From: from, To: to})
+}
+
+// AddLabeledTransition is like AddTransition but labels the transition with
+// the event it's taken on, e.g. "approved". Observers implementing
+// TransitionObserver are given the label when the transition is taken, and
+// diagrams show it. Labelling a declared transition again replaces its label.
+func (sm *StateMachine) AddLabeledTransition(from, to State, label string) {
+	sm.AddTransition(from, to)
+	ts := sm.transitions[keyOf(from)]
+	for i := range ts {
+		if sameState(ts[i].To, to) {
+			ts[i].Label = label
+		}
+	}
+}
+
+// AddDescribedTransition is like AddLabeledTransition but also describes the
+// transition, e.g. "the customer's card was charged", and tags it, e.g.
+// "billing". The description and tags show up in the Definition, in diagrams
+// and documentation, and in the RunStatus given to StatusObservers.
+// Describing a declared transition again replaces its label, description and
+// tags.
+func (sm *StateMachine) AddDescribedTransition(from, to State, label, description string, tags ...string) {
+	sm.AddTransition(from, to)
+	ts := sm.transitions[keyOf(from)]
+	for i := range ts {
+		if sameState(ts[i].To, to) {
+			ts[i].Label = label
+			ts[i].Description = description
+			ts[i].Tags = append([]string{}, tags...)
+		}
+	}
+}
+
+// TransitionBetween returns the declared transition between the states
+func (sm *StateMachine) TransitionBetween(from, to State) (Transition, bool) {
+	if from == nil {
+		return Transition{}, false
+	}
+	for _, t := range sm.transitions[keyOf(from)] {
+		if sameState(t.To, to) {
+			return t, true
+		}
+	}
+	return Transition{}, false
+}
+
+// TransitionLabel returns the label of the declared transition between the
+// states, empty if it isn't labelled or declared
+func (sm *StateMachine) TransitionLabel(from, to State) string {
+	t, _ := sm.TransitionBetween(from, to)
+	return t.Label
+}
+
+// CanTransition tells whether moving from one state to another is allowed. If
+// no transitions are declared any registered state is a valid target.
+func (sm *StateMachine) CanTransition(from, to State) bool {
+	if !sm.hasTransitions() {
+		return sm.isRegistered(to)
+	}
+	for _, t := range sm.transitions[keyOf(from)] {
+		if sameState(t.To, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// AvailableTransitions returns the states reachable from the given state in
+// declaration order. If no transitions are declared all registered states are
+// returned.
+func (sm *StateMachine) AvailableTransitions(from State) []State {
+	if !sm.hasTransitions() {
+		states := make([]State, len(sm.States))
+		copy(states, sm.States)
+		return states
+	}
+	states := make([]State, 0, len(sm.transitions[keyOf(from)]))
+	for _, t := range sm.transitions[keyOf(from)] {
+		states = append(states, t.To)
+	}
+	return states
+}
+
+func (sm *StateMachine) hasTransitions() bool {
+	return len(sm.transitions) > 0
+}
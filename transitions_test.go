@@ -0,0 +1,150 @@
+package gust
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition_NoTransitionsDeclared_AnyRegisteredState(t *testing.T) {
+	a := &StateImpl{}
+	b := &StateImpl{}
+	c := &StateImpl{}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+
+	assert.True(t, m.CanTransition(a, b))
+	assert.True(t, m.CanTransition(b, a))
+	assert.False(t, m.CanTransition(a, c)) // c not registered
+	assert.Equal(t, []State{a, b}, m.AvailableTransitions(a))
+}
+
+func TestCanTransition_TransitionsDeclared_OnlyDeclared(t *testing.T) {
+	//     B
+	//   /   \
+	// A      D
+	//   \   /
+	//     C
+	a, b, c, d := &StateImpl{}, &StateImpl{}, &StateImpl{}, &StateImpl{}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.AddState(c)
+	m.AddState(d)
+	m.AddTransition(a, b)
+	m.AddTransition(a, c)
+	m.AddTransition(a, c) // duplicate ignored
+	m.AddTransition(b, d)
+	m.AddTransition(c, d)
+
+	assert.True(t, m.CanTransition(a, b))
+	assert.True(t, m.CanTransition(a, c))
+	assert.False(t, m.CanTransition(a, d))
+	assert.False(t, m.CanTransition(d, a))
+
+	assert.Equal(t, []State{b, c}, m.AvailableTransitions(a))
+	assert.Equal(t, []State{d}, m.AvailableTransitions(b))
+	assert.Len(t, m.AvailableTransitions(d), 0)
+}
+
+func TestRun_UndeclaredTransition_ReturnsError(t *testing.T) {
+	// A -> C is taken but only A -> B is declared
+	c := &StateImpl{}
+	b := &StateImpl{}
+	a := &StateImpl{
+		nextState: c,
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.AddState(c)
+	m.AddTransition(a, b)
+
+	err := m.Run(nil, a)
+	assert.Error(t, err)
+	assert.False(t, c.run)
+}
+
+func TestRun_DeclaredTransitions_Works(t *testing.T) {
+	b := &StateImpl{}
+	a := &StateImpl{
+		nextState: b,
+	}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.AddTransition(a, b)
+
+	err := m.Run(nil, a)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, b.run)
+}
+
+// labelObserver records the transitions taken with their labels
+type labelObserver struct {
+	taken []string
+}
+
+func (o *labelObserver) StateChanged(prior, next string) {
+	panic("TransitionTaken should be called instead")
+}
+
+func (o *labelObserver) TransitionTaken(prior, next, label string) {
+	o.taken = append(o.taken, prior+" -"+label+"-> "+next)
+}
+
+func TestAddLabeledTransition_LabelGivenToObservers(t *testing.T) {
+	m := NewStateMachine()
+	review := &StateImpl{name: "review"}
+	fulfilled := &StateImpl{name: "fulfilled"}
+	review.nextState = fulfilled
+	m.AddStates(review, fulfilled)
+	m.AddLabeledTransition(review, fulfilled, "approved")
+	o := &labelObserver{}
+	m.RegisterObservers(o)
+
+	assert.Nil(t, m.Run(nil, review))
+	assert.Equal(t, []string{" --> review", "review -approved-> fulfilled"}, o.taken)
+	assert.Equal(t, "approved", m.TransitionLabel(review, fulfilled))
+	assert.Equal(t, []TransitionDefinition{{From: "review", To: "fulfilled", Label: "approved"}}, m.Definition().Transitions)
+}
+
+func TestAddLabeledTransition_Relabel_ReplacesLabel(t *testing.T) {
+	m := NewStateMachine()
+	a, b := &StateImpl{name: "a"}, &StateImpl{name: "b"}
+	m.AddTransition(a, b)
+	m.AddLabeledTransition(a, b, "go")
+	m.AddLabeledTransition(a, b, "proceed")
+
+	assert.Equal(t, "proceed", m.TransitionLabel(a, b))
+	assert.Len(t, m.AvailableTransitions(a), 1)
+}
+
+func TestAddDescribedTransition_DescriptionAndTags(t *testing.T) {
+	m := NewStateMachine()
+	review := &StateImpl{name: "review"}
+	fulfilled := &StateImpl{name: "fulfilled"}
+	m.AddStates(review, fulfilled)
+	m.AddDescribedTransition(review, fulfilled, "approved", "A reviewer approved the order", "manual", "audit")
+
+	tr, ok := m.TransitionBetween(review, fulfilled)
+	assert.True(t, ok)
+	assert.Equal(t, "approved", tr.Label)
+	assert.Equal(t, "A reviewer approved the order", tr.Description)
+	assert.Equal(t, []string{"manual", "audit"}, tr.Tags)
+	assert.Equal(t, "approved", m.TransitionLabel(review, fulfilled))
+	assert.Equal(t, []TransitionDefinition{{
+		From: "review", To: "fulfilled", Label: "approved",
+		Description: "A reviewer approved the order", Tags: []string{"manual", "audit"},
+	}}, m.Definition().Transitions)
+
+	_, ok = m.TransitionBetween(fulfilled, review)
+	assert.False(t, ok)
+}
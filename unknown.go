@@ -0,0 +1,86 @@
+package gust
+
+import (
+	"fmt"
+	"sync"
+)
+
+// UnknownStatePolicy is what a run does when a state moves to a state that
+// isn't registered, see SetUnknownStatePolicy
+type UnknownStatePolicy int
+
+const (
+	// UnknownStateError fails the run with ErrUnknownState, the default
+	UnknownStateError UnknownStatePolicy = iota
+	// UnknownStateRegister registers the state on the fly and moves to it,
+	// for states made up at runtime, e.g. by plugins
+	UnknownStateRegister
+	// UnknownStateQuarantine moves to the quarantine state instead, given a
+	// *Quarantined cargo, see SetQuarantineState
+	UnknownStateQuarantine
+)
+
+func (p UnknownStatePolicy) String() string {
+	switch p {
+	case UnknownStateError:
+		return "error"
+	case UnknownStateRegister:
+		return "register"
+	case UnknownStateQuarantine:
+		return "quarantine"
+	}
+	return fmt.Sprintf("UnknownStatePolicy(%d)", int(p))
+}
+
+// Quarantined is the cargo of the quarantine state, see SetQuarantineState
+type Quarantined struct {
+	From  string      // name of the state that moved to the unknown state
+	State State       // the unknown state
+	Cargo interface{} // the cargo it was to be given
+}
+
+// SetUnknownStatePolicy sets what runs do when a state moves to a state that
+// isn't registered. States registered on the fly with UnknownStateRegister
+// aren't part of States or the Definition, and if transitions are declared the
+// transition to them must be too. Set the policy before running the machine.
+func (sm *StateMachine) SetUnknownStatePolicy(policy UnknownStatePolicy) {
+	sm.unknownStatePolicy = policy
+	if policy == UnknownStateRegister && sm.dynamic == nil {
+		sm.dynamic = &sync.Map{}
+	}
+}
+
+// SetQuarantineState sets the state runs move to with UnknownStateQuarantine,
+// it must be registered. The transition to it needn't be declared.
+func (sm *StateMachine) SetQuarantineState(state State) error {
+	if !sm.isRegistered(state) {
+		return fmt.Errorf("%w %v", ErrUnknownState, state)
+	}
+	sm.quarantine = state
+	return nil
+}
+
+// WithUnknownStatePolicy sets what runs do when moving to a state that isn't
+// registered, like SetUnknownStatePolicy
+func WithUnknownStatePolicy(policy UnknownStatePolicy) Option {
+	return func(sm *StateMachine) {
+		sm.SetUnknownStatePolicy(policy)
+	}
+}
+
+// routeUnknown applies the unknown state policy to the next state if it isn't
+// registered, quarantined tells whether the run goes to the quarantine state
+func (sm *StateMachine) routeUnknown(state, next State, cargo interface{}) (State, interface{}, bool) {
+	if sm.unknownStatePolicy == UnknownStateError || sm.isRegistered(next) {
+		return next, cargo, false
+	}
+	switch sm.unknownStatePolicy {
+	case UnknownStateRegister:
+		sm.dynamic.LoadOrStore(keyOf(next), next)
+	case UnknownStateQuarantine:
+		if sm.quarantine != nil {
+			return sm.quarantine, &Quarantined{From: sm.StateName(state), State: next, Cargo: cargo}, true
+		}
+	}
+	return next, cargo, false
+}
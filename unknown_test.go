@@ -0,0 +1,74 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownStatePolicy_Error_Default(t *testing.T) {
+	m := NewStateMachine()
+	plugin := &StateImpl{name: "plugin"}
+	a := &StateImpl{name: "a", nextState: plugin}
+	m.AddState(a)
+
+	_, err := m.Execute(context.Background(), nil, a)
+	assert.True(t, errors.Is(err, ErrUnknownState))
+	assert.False(t, plugin.run)
+}
+
+func TestUnknownStatePolicy_Register(t *testing.T) {
+	m := NewStateMachine(WithUnknownStatePolicy(UnknownStateRegister))
+	b := &StateImpl{name: "b"}
+	plugin := &StateImpl{name: "plugin", nextState: b}
+	a := &StateImpl{name: "a", nextState: plugin}
+	m.AddStates(a, b)
+
+	result, err := m.Execute(context.Background(), nil, a)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "plugin", "b"}, result.Path)
+	assert.True(t, m.isRegistered(plugin))
+	assert.Len(t, m.States, 2)
+}
+
+func TestUnknownStatePolicy_Quarantine(t *testing.T) {
+	m := NewStateMachine(WithUnknownStatePolicy(UnknownStateQuarantine))
+	quarantine := &StateImpl{name: "quarantine"}
+	plugin := &StateImpl{name: "plugin"}
+	a := &StateImpl{name: "a", nextState: plugin, cargo: 5}
+	m.AddStates(a, quarantine)
+	m.AddTransition(a, quarantine) // declared or not, the quarantine is reachable
+	assert.True(t, errors.Is(m.SetQuarantineState(plugin), ErrUnknownState))
+	assert.Nil(t, m.SetQuarantineState(quarantine))
+
+	result, err := m.Execute(context.Background(), nil, a)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "quarantine"}, result.Path)
+	assert.Equal(t, &Quarantined{From: "a", State: plugin, Cargo: 5}, quarantine.cargoReceived)
+	assert.False(t, plugin.run)
+}
+
+func TestUnknownStatePolicy_QuarantineUndeclared(t *testing.T) {
+	m := NewStateMachine(WithUnknownStatePolicy(UnknownStateQuarantine))
+	b, quarantine := &StateImpl{name: "b"}, &StateImpl{name: "quarantine"}
+	a := &StateImpl{name: "a", nextState: &StateImpl{name: "plugin"}}
+	m.AddStates(a, b, quarantine)
+	m.AddTransition(a, b)
+	m.SetQuarantineState(quarantine)
+
+	result, err := m.Execute(context.Background(), nil, a)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "quarantine"}, result.Path)
+}
+
+func TestUnknownStatePolicy_QuarantineNotSet_Error(t *testing.T) {
+	m := NewStateMachine(WithUnknownStatePolicy(UnknownStateQuarantine))
+	a := &StateImpl{name: "a", nextState: &StateImpl{name: "plugin"}}
+	m.AddState(a)
+
+	_, err := m.Execute(context.Background(), nil, a)
+	assert.True(t, errors.Is(err, ErrUnknownState))
+	assert.Equal(t, "quarantine", UnknownStateQuarantine.String())
+}
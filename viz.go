@@ -0,0 +1,160 @@
+package gust
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Handler serves a live view of the machine over HTTP, meant to be mounted
+// in a service for debugging, e.g.
+//
+//	http.Handle("/debug/gust", sm.Handler())
+//
+// By default it serves an HTML page of the states and transitions with the
+// states of in-flight runs highlighted. ?format=dot serves the same as a
+// Graphviz digraph with the busy states filled, ?format=json as JSON.
+func (sm *StateMachine) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		d := sm.Definition()
+		runs := sm.Runs()
+
+		switch req.URL.Query().Get("format") {
+		case "", "html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := vizTemplate.Execute(w, newVizPage(d, runs, sm.clock.Now())); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		case "dot":
+			w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+			fmt.Fprint(w, vizDOT(d, runs))
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(newVizJSON(d, runs))
+		default:
+			http.Error(w, "unknown format, use html, dot or json", http.StatusBadRequest)
+		}
+	})
+}
+
+// busy counts the in-flight runs in each state by name, the runs named as in
+// the definition
+func busy(runs []StateInfo) map[string]int {
+	counts := make(map[string]int, len(runs))
+	for _, r := range runs {
+		counts[r.Name]++
+	}
+	return counts
+}
+
+// vizDOT renders the definition with the busy states filled
+func vizDOT(d *Definition, runs []StateInfo) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSuffix(d.DOT(), "}\n"))
+	counts := busy(runs)
+	for _, s := range d.States {
+		if n := counts[s.Name]; n > 0 {
+			fmt.Fprintf(&b, "\t%s [style=filled, fillcolor=gold, xlabel=%q];\n", dotID(s.Name), fmt.Sprintf("%d running", n))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+type vizJSON struct {
+	*Definition
+	Runs []vizRunJSON `json:"runs"`
+}
+
+type vizRunJSON struct {
+	State    string    `json:"state"`
+	Entered  time.Time `json:"entered"`
+	Percent  float64   `json:"percent,omitempty"`
+	Progress string    `json:"progress,omitempty"`
+}
+
+func newVizJSON(d *Definition, runs []StateInfo) vizJSON {
+	v := vizJSON{Definition: d, Runs: make([]vizRunJSON, 0, len(runs))}
+	for _, r := range runs {
+		v.Runs = append(v.Runs, vizRunJSON{
+			State:    r.Name,
+			Entered:  r.Entered,
+			Percent:  r.Progress.Percent,
+			Progress: r.Progress.Message,
+		})
+	}
+	return v
+}
+
+type vizPage struct {
+	States []vizState
+	Runs   []vizRun
+}
+
+type vizState struct {
+	Name    string
+	Start   bool
+	Running int
+	Next    []string
+}
+
+type vizRun struct {
+	State    string
+	For      time.Duration
+	Percent  float64
+	Progress string
+}
+
+func newVizPage(d *Definition, runs []StateInfo, now time.Time) vizPage {
+	counts := busy(runs)
+	page := vizPage{}
+	for _, s := range d.States {
+		page.States = append(page.States, vizState{
+			Name:    s.Name,
+			Start:   s.Name == d.Start,
+			Running: counts[s.Name],
+			Next:    d.Successors(s.Name),
+		})
+	}
+	for _, r := range runs {
+		page.Runs = append(page.Runs, vizRun{
+			State:    r.Name,
+			For:      now.Sub(r.Entered).Truncate(time.Millisecond),
+			Percent:  r.Progress.Percent,
+			Progress: r.Progress.Message,
+		})
+	}
+	return page
+}
+
+var vizTemplate = template.Must(template.New("viz").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gust</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+tr.running { background: gold; }
+</style>
+</head>
+<body>
+<h2>States</h2>
+<table>
+<tr><th>State</th><th>Next</th><th>Running</th></tr>
+{{range .States}}<tr{{if .Running}} class="running"{{end}}><td>{{if .Start}}&rarr; {{end}}{{.Name}}</td><td>{{range $i, $n := .Next}}{{if $i}}, {{end}}{{$n}}{{end}}</td><td>{{if .Running}}{{.Running}}{{end}}</td></tr>
+{{end}}</table>
+<h2>Runs</h2>
+{{if .Runs}}<table>
+<tr><th>State</th><th>For</th><th>Progress</th></tr>
+{{range .Runs}}<tr><td>{{.State}}</td><td>{{.For}}</td><td>{{if .Progress}}{{.Percent}}% {{.Progress}}{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p>Nothing is running.</p>
+{{end}}<p><a href="?format=dot">dot</a> &middot; <a href="?format=json">json</a></p>
+</body>
+</html>
+`))
@@ -0,0 +1,111 @@
+package gust
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runBlocked starts a run A -> B where B blocks, and returns a func finishing it
+func runBlocked(t *testing.T) (*StateMachine, func()) {
+	b := &BlockingState{
+		name:    "stateB",
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	a := &StateImpl{nextState: b, name: "stateA"}
+
+	m := NewStateMachine()
+	m.AddStates(a, b)
+	m.AddTransition(a, b)
+
+	done := make(chan error)
+	go func() {
+		done <- m.Run(nil, a)
+	}()
+	<-b.entered
+
+	return m, func() {
+		close(b.release)
+		assert.Nil(t, <-done)
+	}
+}
+
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestRuns_WhileRunning_ListsRuns(t *testing.T) {
+	m, finish := runBlocked(t)
+
+	runs := m.Runs()
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, "stateB", runs[0].Name)
+	}
+
+	finish()
+	assert.Len(t, m.Runs(), 0)
+}
+
+func TestHandler_HTML_HighlightsRunningState(t *testing.T) {
+	m, finish := runBlocked(t)
+	defer finish()
+
+	rec := get(m.Handler(), "/debug/gust")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `<tr class="running"><td>stateB</td>`)
+	assert.Contains(t, rec.Body.String(), `<tr><td>stateA</td><td>stateB</td>`)
+}
+
+func TestHandler_DOT_FillsRunningState(t *testing.T) {
+	m, finish := runBlocked(t)
+	defer finish()
+
+	rec := get(m.Handler(), "/debug/gust?format=dot")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "digraph gust {\n"+
+		"\tstateA;\n"+
+		"\tstateB;\n"+
+		"\tstateA -> stateB;\n"+
+		"\tstateB [style=filled, fillcolor=gold, xlabel=\"1 running\"];\n"+
+		"}\n", rec.Body.String())
+}
+
+func TestHandler_JSON_ListsRuns(t *testing.T) {
+	m, finish := runBlocked(t)
+	defer finish()
+
+	rec := get(m.Handler(), "/debug/gust?format=json")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var v struct {
+		States []StateDefinition
+		Runs   []struct{ State string }
+	}
+	if assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &v)) {
+		assert.Len(t, v.States, 2)
+		if assert.Len(t, v.Runs, 1) {
+			assert.Equal(t, "stateB", v.Runs[0].State)
+		}
+	}
+}
+
+func TestHandler_NothingRunning(t *testing.T) {
+	m, _, _, _, _ := newDiamond()
+
+	rec := get(m.Handler(), "/")
+	assert.Contains(t, rec.Body.String(), "Nothing is running.")
+}
+
+func TestHandler_UnknownFormat_BadRequest(t *testing.T) {
+	m := NewStateMachine()
+
+	rec := get(m.Handler(), "/?format=svg")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,126 @@
+package gust
+
+import (
+	"context"
+	"time"
+)
+
+// WaitState parks the run until a named signal is delivered to it, with
+// StateMachine.Signal or Manager.Signal, or a value arrives on a channel, then
+// goes to Next. With a Timeout, timed by the machine's Clock, it goes to
+// OnTimeout instead if nothing arrived in time, or fails with ErrWaitTimeout
+// if OnTimeout is nil. A value arriving as the timeout expires is received
+// rather than left behind. If the channel is closed it goes to OnClose, or
+// fails with ErrWaitClosed if OnClose is nil.
+type WaitState struct {
+	name    string
+	signal  string
+	channel <-chan interface{}
+
+	Timeout   time.Duration
+	OnTimeout State
+	OnClose   State
+	Next      State
+
+	// Merge if not nil returns the cargo passed on to Next given the value
+	// received, otherwise the cargo is passed on unchanged
+	Merge func(cargo, value interface{}) (interface{}, error)
+}
+
+// NewSignalWait returns a state waiting for the signal with the given name
+func NewSignalWait(name, signal string) *WaitState {
+	return &WaitState{name: name, signal: signal}
+}
+
+// NewChannelWait returns a state waiting for a value on ch
+func NewChannelWait(name string, ch <-chan interface{}) *WaitState {
+	return &WaitState{name: name, channel: ch}
+}
+
+// Exec waits without a run to receive signals from, so only waits for a
+// channel, ExecContext is used in runs
+func (s *WaitState) Exec(cargo interface{}) (State, interface{}, error) {
+	return s.ExecContext(context.Background(), cargo)
+}
+
+// ExecContext waits for the signal or channel value
+func (s *WaitState) ExecContext(ctx context.Context, cargo interface{}) (State, interface{}, error) {
+	var clock Clock = realClock{}
+	if r, ok := ctx.Value(runKey{}).(*run); ok {
+		clock = r.sm.clock
+	}
+	sigs, ok := ctx.Value(signalsKey{}).(*signals)
+	if s.channel == nil && !ok {
+		return nil, cargo, ErrNoRun
+	}
+
+	var timeout <-chan time.Time
+	if s.Timeout > 0 {
+		timeout = clock.After(s.Timeout)
+	}
+
+	if s.channel != nil {
+		select {
+		case value, ok := <-s.channel:
+			return s.received(cargo, value, ok)
+		case <-timeout:
+			// a value sent as the timeout expired wins
+			select {
+			case value, ok := <-s.channel:
+				return s.received(cargo, value, ok)
+			default:
+				return s.timedOut(cargo)
+			}
+		case <-ctx.Done():
+			return nil, cargo, ctx.Err()
+		}
+	}
+
+	// signals are only dequeued here, so none is lost to the timeout
+	for {
+		value, ok, changed := sigs.take(s.signal)
+		if ok {
+			return s.received(cargo, value, true)
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			if value, ok, _ := sigs.take(s.signal); ok {
+				return s.received(cargo, value, true)
+			}
+			return s.timedOut(cargo)
+		case <-ctx.Done():
+			return nil, cargo, ctx.Err()
+		}
+	}
+}
+
+// received goes to Next with the value, or to OnClose if the channel is closed
+func (s *WaitState) received(cargo, value interface{}, ok bool) (State, interface{}, error) {
+	if !ok {
+		if s.OnClose == nil {
+			return nil, cargo, ErrWaitClosed
+		}
+		return s.OnClose, cargo, nil
+	}
+	if s.Merge == nil {
+		return s.Next, cargo, nil
+	}
+	next, err := s.Merge(cargo, value)
+	if err != nil {
+		return nil, cargo, err
+	}
+	return s.Next, next, nil
+}
+
+func (s *WaitState) timedOut(cargo interface{}) (State, interface{}, error) {
+	if s.OnTimeout == nil {
+		return nil, cargo, ErrWaitTimeout
+	}
+	return s.OnTimeout, cargo, nil
+}
+
+// Name is the name given to the constructor
+func (s *WaitState) Name() string {
+	return s.name
+}
@@ -0,0 +1,161 @@
+package gust
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitState_Signal_MergedAndGoesToNext(t *testing.T) {
+	m := NewStateMachine()
+	wait := NewSignalWait("approval", "approve")
+	done := NewFuncState("done", func(cargo interface{}) (State, interface{}, error) {
+		return nil, cargo, nil
+	})
+	wait.Next = done
+	wait.Merge = func(cargo, value interface{}) (interface{}, error) {
+		return cargo.(string) + " approved by " + value.(string), nil
+	}
+	m.AddStates(wait, done)
+	m.AddTransition(wait, done)
+
+	mgr := NewManager()
+	mgr.Register("approval", m, wait)
+	inst, err := mgr.Start(context.Background(), "approval", "order")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, mgr.Signal(inst.ID, "approve", "alice"))
+
+	result, err := inst.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"approval", "done"}, result.Path)
+	assert.Equal(t, "order approved by alice", result.Cargo)
+}
+
+func TestWaitState_Channel_CargoUnchanged(t *testing.T) {
+	ch := make(chan interface{}, 1)
+	wait := NewChannelWait("wait", ch)
+	ch <- "ignored"
+
+	next, cargo, err := wait.Exec("cargo")
+	assert.Nil(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, "cargo", cargo)
+}
+
+func TestWaitState_Timeout_GoesToOnTimeout(t *testing.T) {
+	wait := NewChannelWait("wait", make(chan interface{}))
+	wait.Timeout = 10 * time.Millisecond
+	wait.OnTimeout = &StateImpl{name: "escalate"}
+
+	next, _, err := wait.Exec(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, wait.OnTimeout, next)
+}
+
+func TestWaitState_TimeoutWithoutOnTimeout_Fails(t *testing.T) {
+	wait := NewSignalWait("wait", "approve")
+	wait.Timeout = 10 * time.Millisecond
+	ctx, _ := withSignals(context.Background())
+
+	_, _, err := wait.ExecContext(ctx, nil)
+	assert.True(t, errors.Is(err, ErrWaitTimeout))
+}
+
+func TestWaitState_SignalOutsideRun_ErrNoRun(t *testing.T) {
+	_, _, err := NewSignalWait("wait", "approve").Exec(nil)
+	assert.True(t, errors.Is(err, ErrNoRun))
+}
+
+// expiredClock times out at once
+type expiredClock struct {
+	realClock
+}
+
+func (expiredClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+func TestWaitState_ChannelClosed_GoesToOnClose(t *testing.T) {
+	ch := make(chan interface{})
+	close(ch)
+	wait := NewChannelWait("wait", ch)
+	wait.Timeout = time.Hour
+
+	_, _, err := wait.Exec(nil)
+	assert.True(t, errors.Is(err, ErrWaitClosed))
+
+	wait.OnClose = &StateImpl{name: "cancelled"}
+	next, _, err := wait.Exec(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, wait.OnClose, next)
+}
+
+func TestWaitState_ValueAsTimeoutExpires_Received(t *testing.T) {
+	m := NewStateMachine(WithClock(expiredClock{}))
+	done, escalate := &StateImpl{name: "done"}, &StateImpl{name: "escalate"}
+	ch := make(chan interface{}, 1)
+	wait := NewChannelWait("wait", ch)
+	wait.Timeout = time.Hour
+	wait.Next, wait.OnTimeout = done, escalate
+	m.AddStates(wait, done, escalate)
+
+	for i := 0; i < 20; i++ {
+		ch <- "go"
+		result, err := m.Execute(context.Background(), nil, wait)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"wait", "done"}, result.Path)
+		assert.Len(t, ch, 0)
+	}
+
+	// with nothing sent, the machine's clock times out
+	result, err := m.Execute(context.Background(), nil, wait)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"wait", "escalate"}, result.Path)
+}
+
+func TestWaitState_SignalAsTimeoutExpires_Received(t *testing.T) {
+	m := NewStateMachine(WithClock(expiredClock{}))
+	done, escalate := &StateImpl{name: "done"}, &StateImpl{name: "escalate"}
+	wait := NewSignalWait("wait", "approve")
+	wait.Timeout = time.Hour
+	wait.Next, wait.OnTimeout = done, escalate
+	m.AddStates(wait, done, escalate)
+	ctx, sigs := withSignals(context.Background())
+	sigs.deliver("approve", "alice")
+
+	result, err := m.Execute(ctx, nil, wait)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"wait", "done"}, result.Path)
+}
+
+func TestStateMachine_Signal_WakesRun(t *testing.T) {
+	m := NewStateMachine(WithRunIDGenerator(func() string { return "r1" }))
+	done := &StateImpl{name: "done"}
+	wait := NewSignalWait("wait", "approve")
+	wait.Next = done
+	m.AddStates(wait, done)
+
+	assert.True(t, errors.Is(m.Signal("r1", "approve", nil), ErrUnknownRun))
+
+	finished := make(chan error, 1)
+	go func() {
+		finished <- m.Run(nil, wait)
+	}()
+	assert.Eventually(t, func() bool {
+		return m.Signal("r1", "approve", nil) == nil
+	}, time.Second, time.Millisecond)
+
+	select {
+	case err := <-finished:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("run not woken by the signal")
+	}
+}
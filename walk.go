@@ -0,0 +1,174 @@
+package gust
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
+)
+
+// Invariant is a condition that must hold whenever a state is entered, it's
+// given the cargo and the state's name and returns an error when violated
+type Invariant func(cargo interface{}, state string) error
+
+// PanicError is a recovered panic
+type PanicError struct {
+	Value interface{} // the value given to panic
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// WalkConfig configures RandomWalk
+type WalkConfig struct {
+	Walks    int                            // number of walks, 100 if zero
+	MaxSteps int                            // transitions per walk, 100 if zero
+	Cargo    func(r *rand.Rand) interface{} // generates the cargo of each walk, nil cargo if not set
+
+	// Exec executes the states along the walk with the cargo, feeding the cargo
+	// they return forward. Their errors and panics are reported, and so are next
+	// states they return that the machine wouldn't accept. The walk still goes
+	// to a random next state.
+	Exec bool
+
+	Invariants []Invariant // checked at every state entered, after the machine's, see AddInvariant
+
+	// Terminals are the states a walk is expected to end in, in addition to
+	// those declared terminal, see MarkTerminal. If there are any, reaching
+	// any other state without transitions is reported as ErrDeadEnd.
+	Terminals []State
+
+	Rand *rand.Rand // source of randomness, the machine's if nil and it has one, see SetRand, otherwise seeded with 1
+}
+
+// WalkFailure is a problem found by RandomWalk
+type WalkFailure struct {
+	Walk int      // which walk, from 0
+	Path []string // states entered in the walk up to the failure
+	Err  error
+}
+
+// WalkReport is what RandomWalk found
+type WalkReport struct {
+	Walks    int // walks taken
+	Steps    int // states entered over all walks
+	Failures []WalkFailure
+}
+
+// RandomWalk performs random walks over the machine's transitions from the
+// start state, each time choosing a successor at random, in proportion to
+// their weights if the state has weighted transitions, see
+// SetTransitionWeight, uniformly otherwise. It reports invariant violations,
+// panics, errors and dead ends. A walk ends at a state without transitions, at
+// the first failure, or after MaxSteps transitions.
+func (sm *StateMachine) RandomWalk(startState State, cfg WalkConfig) *WalkReport {
+	if cfg.Walks <= 0 {
+		cfg.Walks = 100
+	}
+	if cfg.MaxSteps <= 0 {
+		cfg.MaxSteps = 100
+	}
+	rnd := cfg.Rand
+	if rnd == nil {
+		rnd = sm.rand
+	}
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(1))
+	}
+	terminals := make(map[stateKey]bool, len(cfg.Terminals)+len(sm.terminals))
+	for _, t := range cfg.Terminals {
+		terminals[keyOf(t)] = true
+	}
+	for key := range sm.terminals {
+		terminals[key] = true
+	}
+
+	invariants := append(append([]Invariant{}, sm.invariants...), cfg.Invariants...)
+	report := &WalkReport{Failures: make([]WalkFailure, 0)}
+	for walk := 0; walk < cfg.Walks; walk++ {
+		var cargo interface{}
+		if cfg.Cargo != nil {
+			cargo = cfg.Cargo(rnd)
+		}
+
+		path := make([]string, 0)
+		fail := func(err error) {
+			report.Failures = append(report.Failures, WalkFailure{Walk: walk, Path: path, Err: err})
+		}
+
+		report.Walks++
+		state := startState
+		for step := 0; step <= cfg.MaxSteps; step++ {
+			path = append(path, sm.StateName(state))
+			report.Steps++
+
+			if err := checkInvariants(invariants, cargo, sm.StateName(state)); err != nil {
+				fail(err)
+				break
+			}
+			if cfg.Exec {
+				var err error
+				if cargo, err = sm.walkExec(state, cargo); err != nil {
+					fail(err)
+					break
+				}
+			}
+
+			next := sm.AvailableTransitions(state)
+			if len(next) == 0 {
+				if len(terminals) > 0 && !terminals[keyOf(state)] {
+					fail(fmt.Errorf("%w at %s", ErrDeadEnd, sm.StateName(state)))
+				}
+				break
+			}
+			if weighted := sm.weightedNext(state, rnd.Float64()); weighted != nil {
+				state = weighted
+			} else {
+				state = next[rnd.Intn(len(next))]
+			}
+		}
+	}
+	return report
+}
+
+// walkExec executes the state, turning panics into errors and validating the
+// next state it chooses
+func (sm *StateMachine) walkExec(state State, cargo interface{}) (nextCargo interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	r := &run{sm: sm, ctx: context.Background()}
+	nextState, nextCargo, err := sm.exec(r, state, cargo)
+	if err != nil {
+		return cargo, err
+	}
+	if nextState != nil {
+		if !sm.isRegistered(nextState) {
+			return cargo, fmt.Errorf("%w %v", ErrUnknownState, nextState)
+		} else if sm.hasTransitions() && !sm.CanTransition(state, nextState) {
+			return cargo, fmt.Errorf("%w from %v to %v", ErrInvalidTransition, state, nextState)
+		}
+	}
+	return nextCargo, nil
+}
+
+// checkInvariants returns the first violation, panics are reported as a *PanicError
+func checkInvariants(invariants []Invariant, cargo interface{}, state string) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	for _, inv := range invariants {
+		if err := inv(cargo, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package gust
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type PanickingState struct { // interface State
+	name string
+}
+
+func (s *PanickingState) Exec(cargo interface{}) (State, interface{}, error) {
+	if cargo.(int) > 5 {
+		panic("too big")
+	}
+	return nil, cargo, nil
+}
+
+func (s *PanickingState) Name() string {
+	return s.name
+}
+
+func TestRandomWalk_HealthyMachine_NoFailures(t *testing.T) {
+	m, a, _, _, d := newDiamond()
+
+	report := m.RandomWalk(a, WalkConfig{
+		Walks:     50,
+		Terminals: []State{d},
+	})
+
+	assert.Equal(t, 50, report.Walks)
+	assert.Equal(t, 150, report.Steps) // always A, B or C, D
+	assert.Len(t, report.Failures, 0)
+}
+
+func TestRandomWalk_BothBranchesExplored(t *testing.T) {
+	m, a, _, _, _ := newDiamond()
+
+	seen := make(map[string]bool)
+	m.RandomWalk(a, WalkConfig{
+		Walks: 50,
+		Invariants: []Invariant{func(cargo interface{}, state string) error {
+			seen[state] = true
+			return nil
+		}},
+	})
+
+	assert.True(t, seen["stateB"])
+	assert.True(t, seen["stateC"])
+}
+
+func TestRandomWalk_InvariantViolated_Reported(t *testing.T) {
+	m, a, _, _, _ := newDiamond()
+
+	violation := errors.New("never in C")
+	report := m.RandomWalk(a, WalkConfig{
+		Walks: 50,
+		Invariants: []Invariant{func(cargo interface{}, state string) error {
+			if state == "stateC" {
+				return violation
+			}
+			return nil
+		}},
+	})
+
+	if !assert.NotEmpty(t, report.Failures) {
+		return
+	}
+	f := report.Failures[0]
+	assert.Equal(t, violation, f.Err)
+	assert.Equal(t, []string{"stateA", "stateC"}, f.Path)
+}
+
+func TestRandomWalk_DeadEnd_Reported(t *testing.T) {
+	//     B (dead end)
+	//   /
+	// A
+	//   \
+	//     C (terminal)
+	c := &StateImpl{name: "stateC"}
+	b := &StateImpl{name: "stateB"}
+	a := &StateImpl{name: "stateA"}
+
+	m := NewStateMachine()
+	m.AddStates(a, b, c)
+	m.AddTransition(a, b)
+	m.AddTransition(a, c)
+
+	report := m.RandomWalk(a, WalkConfig{Walks: 20, Terminals: []State{c}})
+	if !assert.NotEmpty(t, report.Failures) {
+		return
+	}
+	assert.True(t, errors.Is(report.Failures[0].Err, ErrDeadEnd))
+	assert.Equal(t, []string{"stateA", "stateB"}, report.Failures[0].Path)
+}
+
+func TestRandomWalk_ExecPanicsWithGeneratedCargo_Reported(t *testing.T) {
+	b := &PanickingState{name: "stateB"}
+	a := NewFuncState("stateA", func(cargo interface{}) (State, interface{}, error) {
+		return b, cargo, nil
+	})
+
+	m := NewStateMachine()
+	m.AddStates(a, b)
+	m.AddTransition(a, b)
+
+	report := m.RandomWalk(a, WalkConfig{
+		Walks: 50,
+		Exec:  true,
+		Cargo: func(r *rand.Rand) interface{} {
+			return r.Intn(10)
+		},
+	})
+
+	if !assert.NotEmpty(t, report.Failures) {
+		return
+	}
+	var panicErr *PanicError
+	assert.True(t, errors.As(report.Failures[0].Err, &panicErr))
+	assert.Equal(t, "too big", panicErr.Value)
+	assert.Equal(t, []string{"stateA", "stateB"}, report.Failures[0].Path)
+}
+
+func TestRandomWalk_ExecReturnsUndeclared_Reported(t *testing.T) {
+	c := &StateImpl{name: "stateC"}
+	b := &StateImpl{name: "stateB"}
+	a := &StateImpl{name: "stateA", nextState: c}
+
+	m := NewStateMachine()
+	m.AddStates(a, b, c)
+	m.AddTransition(a, b)
+
+	report := m.RandomWalk(a, WalkConfig{Walks: 1, Exec: true})
+	if !assert.Len(t, report.Failures, 1) {
+		return
+	}
+	assert.True(t, errors.Is(report.Failures[0].Err, ErrInvalidTransition))
+}
+
+func TestRandomWalk_SameSeed_SameWalks(t *testing.T) {
+	m, a, _, _, _ := newDiamond()
+
+	paths := func() []string {
+		visited := make([]string, 0)
+		m.RandomWalk(a, WalkConfig{
+			Walks: 20,
+			Rand:  rand.New(rand.NewSource(42)),
+			Invariants: []Invariant{func(cargo interface{}, state string) error {
+				visited = append(visited, state)
+				return nil
+			}},
+		})
+		return visited
+	}
+
+	assert.Equal(t, paths(), paths())
+}
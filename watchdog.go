@@ -0,0 +1,42 @@
+package gust
+
+import "time"
+
+// StuckObserver when implemented by an observer is notified by the watchdog
+// when a run stays in a state longer than the threshold given to SetWatchdog
+type StuckObserver interface {
+	StateStuck(info StateInfo)
+}
+
+// SetWatchdog makes the machine notify StuckObservers when a run has been in
+// the same state for longer than threshold. The notification is sent once per
+// state entry, from the watchdog's own goroutine. Zero disables the watchdog.
+func (sm *StateMachine) SetWatchdog(threshold time.Duration) {
+	sm.watchdogThreshold = threshold
+}
+
+// armWatchdog restarts the run's watchdog for the state just entered, the
+// caller holds runsLock
+func (sm *StateMachine) armWatchdog(r *run) {
+	if r.watchdog != nil {
+		r.watchdog.Stop()
+	}
+	if sm.watchdogThreshold <= 0 {
+		return
+	}
+
+	info := r.info()
+	r.watchdog = sm.clock.AfterFunc(sm.watchdogThreshold, func() {
+		sm.notifyStuck(info)
+	})
+}
+
+func (sm *StateMachine) notifyStuck(info StateInfo) {
+	for _, observer := range sm.loadObservers() {
+		if so, ok := observer.(StuckObserver); ok {
+			sm.notify(observer, func() {
+				so.StateStuck(info)
+			})
+		}
+	}
+}
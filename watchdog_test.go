@@ -0,0 +1,84 @@
+package gust
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type StuckObserverImpl struct {
+	*ObserverImpl
+	lock  sync.Mutex
+	stuck []StateInfo
+}
+
+func (o *StuckObserverImpl) StateStuck(info StateInfo) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.stuck = append(o.stuck, info)
+}
+
+func (o *StuckObserverImpl) Stuck() []StateInfo {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return append([]StateInfo{}, o.stuck...)
+}
+
+func TestWatchdog_StateOverThreshold_NotifiesOnce(t *testing.T) {
+	// A -> B, where B hangs until released
+	b := &BlockingState{
+		name:    "stateB",
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	a := &StateImpl{
+		nextState: b,
+		name:      "stateA",
+	}
+
+	o := &StuckObserverImpl{ObserverImpl: NewObserverImpl()}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.RegisterObservers(o)
+	m.SetWatchdog(20 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		done <- m.Run(nil, a)
+	}()
+
+	<-b.entered
+	time.Sleep(60 * time.Millisecond)
+	close(b.release)
+	assert.Nil(t, <-done)
+
+	stuck := o.Stuck()
+	if !assert.Len(t, stuck, 1) {
+		return
+	}
+	assert.Equal(t, "stateB", stuck[0].Name)
+	assert.Equal(t, b, stuck[0].State)
+}
+
+func TestWatchdog_FastStates_NoNotification(t *testing.T) {
+	b := &StateImpl{}
+	a := &StateImpl{
+		nextState: b,
+	}
+
+	o := &StuckObserverImpl{ObserverImpl: NewObserverImpl()}
+
+	m := NewStateMachine()
+	m.AddState(a)
+	m.AddState(b)
+	m.RegisterObservers(o)
+	m.SetWatchdog(20 * time.Millisecond)
+
+	assert.Nil(t, m.Run(nil, a))
+	time.Sleep(40 * time.Millisecond)
+	assert.Len(t, o.Stuck(), 0)
+}
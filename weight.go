@@ -0,0 +1,61 @@
+package gust
+
+// SetTransitionWeight weights a declared transition for random choices: a
+// state's weighted transitions are chosen between in proportion to their
+// weights. Simulate and RandomWalk follow weighted transitions from states
+// without a stub, and so do runs when SetWeightedRouting is enabled, which
+// models stochastic processes, e.g. 90% of orders paid and 10% abandoned, to
+// load test downstream systems with a realistic mix of workflows. Weights
+// aren't probabilities, they needn't sum to 1. A weight of 0 or less unweights
+// the transition.
+func (sm *StateMachine) SetTransitionWeight(from, to State, weight float64) {
+	ts := sm.transitions[keyOf(from)]
+	for i := range ts {
+		if sameState(ts[i].To, to) {
+			ts[i].Weight = weight
+		}
+	}
+}
+
+// SetWeightedRouting has runs take a random weighted transition, see
+// SetTransitionWeight, when a state returns no next state and none of its
+// guards hold. Meant for load tests and simulations, disabled by default.
+func (sm *StateMachine) SetWeightedRouting(enabled bool) {
+	sm.weightedRouting = enabled
+}
+
+// WithWeightedRouting has runs take weighted transitions, like
+// SetWeightedRouting(true)
+func WithWeightedRouting() Option {
+	return func(sm *StateMachine) {
+		sm.SetWeightedRouting(true)
+	}
+}
+
+// weightedNext chooses one of the state's weighted transitions in proportion
+// to their weights given x, random in [0, 1), nil if it has none
+func (sm *StateMachine) weightedNext(state State, x float64) State {
+	total := 0.0
+	for _, t := range sm.transitions[keyOf(state)] {
+		if t.Weight > 0 {
+			total += t.Weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	x *= total
+	var last State
+	for _, t := range sm.transitions[keyOf(state)] {
+		if t.Weight <= 0 {
+			continue
+		}
+		if x < t.Weight {
+			return t.To
+		}
+		x -= t.Weight
+		last = t.To
+	}
+	return last // rounding
+}
@@ -0,0 +1,89 @@
+package gust
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func weightedMachine(opts ...Option) (m *StateMachine, checkout, paid, abandoned State) {
+	m = NewStateMachine(opts...)
+	checkout = &StateImpl{name: "checkout"}
+	paid = &StateImpl{name: "paid"}
+	abandoned = &StateImpl{name: "abandoned"}
+	m.AddStates(checkout, paid, abandoned)
+	m.AddTransition(checkout, paid)
+	m.AddTransition(checkout, abandoned)
+	m.SetTransitionWeight(checkout, paid, 9)
+	m.SetTransitionWeight(checkout, abandoned, 1)
+	return m, checkout, paid, abandoned
+}
+
+func TestWeightedNext_InProportion(t *testing.T) {
+	m, checkout, paid, abandoned := weightedMachine()
+
+	assert.Equal(t, paid, m.weightedNext(checkout, 0))
+	assert.Equal(t, paid, m.weightedNext(checkout, 0.89))
+	assert.Equal(t, abandoned, m.weightedNext(checkout, 0.91))
+	assert.Nil(t, m.weightedNext(paid, 0.5))
+}
+
+func TestSetTransitionWeight_Simulate(t *testing.T) {
+	m, checkout, _, _ := weightedMachine()
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		result, err := m.Simulate(nil, checkout, nil)
+		assert.Nil(t, err)
+		counts[result.Path[len(result.Path)-1]]++
+	}
+	assert.InDelta(t, 900, counts["paid"], 100)
+	assert.InDelta(t, 100, counts["abandoned"], 100)
+}
+
+func TestSetTransitionWeight_RandomWalk(t *testing.T) {
+	m, checkout, _, _ := weightedMachine()
+	visits := make(map[string]int)
+	m.RandomWalk(checkout, WalkConfig{
+		Walks: 1000,
+		Rand:  rand.New(rand.NewSource(1)),
+		Invariants: []Invariant{func(cargo interface{}, state string) error {
+			visits[state]++
+			return nil
+		}},
+	})
+	assert.InDelta(t, 900, visits["paid"], 100)
+}
+
+func TestSetWeightedRouting_OptIn(t *testing.T) {
+	m, checkout, _, _ := weightedMachine()
+	result, err := m.Execute(context.Background(), nil, checkout)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"checkout"}, result.Path)
+
+	m, checkout, _, _ = weightedMachine(WithWeightedRouting())
+	result, err = m.Execute(context.Background(), nil, checkout)
+	assert.Nil(t, err)
+	assert.Len(t, result.Path, 2)
+}
+
+func TestSetTransitionWeight_Definition(t *testing.T) {
+	m, _, _, _ := weightedMachine()
+
+	d := m.Definition()
+	assert.Equal(t, []TransitionDefinition{
+		{From: "checkout", To: "paid", Weight: 9},
+		{From: "checkout", To: "abandoned", Weight: 1},
+	}, d.Transitions)
+
+	other := NewStateMachine()
+	checkout := &StateImpl{name: "checkout"}
+	paid := &StateImpl{name: "paid"}
+	other.AddStates(checkout, paid, &StateImpl{name: "abandoned"})
+	_, err := other.ApplyDefinition(d)
+	assert.Nil(t, err)
+	tr, _ := other.TransitionBetween(checkout, paid)
+	assert.Equal(t, 9.0, tr.Weight)
+}